@@ -3,6 +3,7 @@ package v1beta1
 import (
 	"github.com/rabbitmq/cluster-operator/v2/internal/status"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -35,6 +36,134 @@ type RabbitmqClusterStatus struct {
 
 	// DeprecatedFeaturesUsed exposes whether there are deprecated features in-use in a RabbitMQ server.
 	DeprecatedFeaturesUsed []string `json:"deprecatedFeaturesUsed,omitempty"`
+
+	// Addresses advertised to stream clients by each node when spec.stream.perPodServices is set.
+	StreamAdvertisedAddresses []StreamAdvertisedAddress `json:"streamAdvertisedAddresses,omitempty"`
+
+	// Information about the server certificate when TLS is configured with a Secret.
+	TLS *TLSStatus `json:"tls,omitempty"`
+
+	// SHA-256 hash of the definitions referenced in spec.rabbitmq.definitions which were last imported.
+	// When the content of the referenced ConfigMaps or Secrets changes, the operator imports
+	// the definitions through the management API.
+	DefinitionsHash string `json:"definitionsHash,omitempty"`
+
+	// Exports of the definitions configured in spec.backup.definitions.
+	DefinitionsBackup *DefinitionsBackupStatus `json:"definitionsBackup,omitempty"`
+
+	// Warm standby replication configured in spec.replication.
+	Replication *ReplicationStatus `json:"replication,omitempty"`
+
+	// Federation upstreams and shovels declared from spec.links.
+	// Links removed from spec.links are deleted from RabbitMQ.
+	Links []LinkStatus `json:"links,omitempty"`
+
+	// The last change to the rabbitmq.conf, advanced.config and environment configuration of the nodes.
+	Configuration *ConfigurationStatus `json:"configuration,omitempty"`
+
+	// Disruptive changes held back until the next window of spec.maintenanceWindows.
+	Maintenance *MaintenanceStatus `json:"maintenance,omitempty"`
+
+	// Plugins enabled on each node, as listed the last time the operator changed the plugins of the running nodes,
+	// or when the status did not list every node yet, e.g. for new clusters.
+	Plugins []NodePluginsStatus `json:"plugins,omitempty"`
+}
+
+// Observed plugins of a RabbitMQ node.
+type NodePluginsStatus struct {
+	// Name of the Pod running the node.
+	Pod string `json:"pod"`
+	// Plugins explicitly enabled on the node.
+	EnabledPlugins []string `json:"enabledPlugins,omitempty"`
+	// Whether the enabled plugins are the plugins desired by spec.rabbitmq.additionalPlugins and the operator.
+	Converged bool `json:"converged"`
+	// Error of the last attempt to change the plugins of the node.
+	Error string `json:"error,omitempty"`
+}
+
+// Observed state of the changes held back outside of maintenance windows.
+type MaintenanceStatus struct {
+	// Disruptive changes which are applied in the next maintenance window.
+	DeferredActions []DeferredAction `json:"deferredActions,omitempty"`
+	// Start of the next maintenance window.
+	NextWindowStart *metav1.Time `json:"nextWindowStart,omitempty"`
+}
+
+// Observed state of the last configuration change.
+// Changes to some rabbitmq.conf keys, such as log.default.level and disk_free_limit.absolute, are applied
+// on the running nodes with rabbitmqctl. Other changes restart the nodes.
+type ConfigurationStatus struct {
+	// Time the configuration changed.
+	LastChangeTime *metav1.Time `json:"lastChangeTime,omitempty"`
+	// Changed keys which are applied on the running nodes without restarting them.
+	LiveKeys []string `json:"liveKeys,omitempty"`
+	// Whether the live keys have been applied on all nodes.
+	LiveKeysApplied bool `json:"liveKeysApplied,omitempty"`
+	// Changed keys, and names of other changed files such as advanced.config, which required restarting the nodes.
+	// Changes to live keys are applied by the restart as well, and are not listed.
+	RestartKeys []string `json:"restartKeys,omitempty"`
+}
+
+// Observed state of a federation upstream or shovel declared from spec.links.
+type LinkStatus struct {
+	Name        string   `json:"name"`
+	Type        LinkType `json:"type"`
+	VirtualHost string   `json:"virtualHost"`
+	// Whether the federation upstream or shovel is declared with the current URI of the other RabbitmqCluster.
+	Configured bool `json:"configured"`
+	// Reason the link is not configured.
+	Message string `json:"message,omitempty"`
+}
+
+// Observed state of warm standby replication from the upstream.
+type ReplicationStatus struct {
+	// Upstream RabbitmqCluster, as <namespace>/<name>.
+	Upstream string `json:"upstream,omitempty"`
+	// Name of the user this RabbitmqCluster authenticates as on the upstream.
+	// Its credentials are stored in the Secret "<RabbitmqCluster name>-replication-user".
+	Username string `json:"username,omitempty"`
+	// Whether replication is configured on both clusters and the downstream reports replication metrics.
+	Healthy bool `json:"healthy"`
+	// Reason replication is not healthy.
+	Message string `json:"message,omitempty"`
+	// Time of the last message replicated from the upstream.
+	LastReplicatedMessageTime *metav1.Time `json:"lastReplicatedMessageTime,omitempty"`
+	// Time between the last replicated message and the last check, an upper bound of the replication lag.
+	Lag *metav1.Duration `json:"lag,omitempty"`
+	// Time of the last check.
+	LastCheckTime *metav1.Time `json:"lastCheckTime,omitempty"`
+}
+
+// Observed state of the definitions exports.
+type DefinitionsBackupStatus struct {
+	// Time of the last scheduled export. The next export is scheduled after this time.
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+	// Time of the last successful export, whether scheduled or taken before a disruptive operation.
+	LastSuccessfulExportTime *metav1.Time `json:"lastSuccessfulExportTime,omitempty"`
+	// Name of the Secret or ConfigMap, or of the file on the PersistentVolumeClaim, holding the last successful export.
+	LastSuccessfulExport string `json:"lastSuccessfulExport,omitempty"`
+}
+
+// Observed state of the server certificate.
+type TLSStatus struct {
+	// Expiry date of the server certificate.
+	NotAfter *metav1.Time `json:"notAfter,omitempty"`
+	// SHA-256 hash of the certificate and CA certificate observed by the operator. When the hash changes,
+	// the operator clears the PEM cache of every RabbitMQ node so that the rotated certificates are used
+	// for new connections without restarting the nodes.
+	CertificatesHash string `json:"certificatesHash,omitempty"`
+}
+
+// Address advertised to stream clients by a single RabbitMQ node.
+type StreamAdvertisedAddress struct {
+	// Name of the Pod running the node
+	Pod string `json:"pod"`
+	// Host advertised by the node. Empty until the address of the Pod's Service is known.
+	Host string `json:"host,omitempty"`
+	// Port advertised for stream connections
+	Port int32 `json:"port,omitempty"`
+	// Port advertised for stream connections over TLS
+	TLSPort int32 `json:"tlsPort,omitempty"`
 }
 
 // Contains references to resources created with the RabbitmqCluster resource.
@@ -69,6 +198,8 @@ func (clusterStatus *RabbitmqClusterStatus) SetConditions(resources []runtime.Ob
 	var oldClusterAvailableCondition *status.RabbitmqClusterCondition
 	var oldNoWarningsCondition *status.RabbitmqClusterCondition
 	var oldReconcileCondition *status.RabbitmqClusterCondition
+	var oldTLSCertificateExpiringCondition *status.RabbitmqClusterCondition
+	var oldRestartPendingCondition *status.RabbitmqClusterCondition
 
 	for _, condition := range clusterStatus.Conditions {
 		switch condition.Type {
@@ -80,6 +211,10 @@ func (clusterStatus *RabbitmqClusterStatus) SetConditions(resources []runtime.Ob
 			oldNoWarningsCondition = condition.DeepCopy()
 		case status.ReconcileSuccess:
 			oldReconcileCondition = condition.DeepCopy()
+		case status.TLSCertificateExpiring:
+			oldTLSCertificateExpiringCondition = condition.DeepCopy()
+		case status.RestartPending:
+			oldRestartPendingCondition = condition.DeepCopy()
 		}
 	}
 
@@ -100,6 +235,61 @@ func (clusterStatus *RabbitmqClusterStatus) SetConditions(resources []runtime.Ob
 		noWarningsCond,
 		reconciledCondition,
 	}
+	// TLSCertificateExpiring is only set when the certificate is known, see SetTLSCertificateExpiringCondition
+	if oldTLSCertificateExpiringCondition != nil {
+		clusterStatus.Conditions = append(clusterStatus.Conditions, *oldTLSCertificateExpiringCondition)
+	}
+	// RestartPending is only set with spec.restartPolicy Manual, see SetRestartPendingCondition
+	if oldRestartPendingCondition != nil {
+		clusterStatus.Conditions = append(clusterStatus.Conditions, *oldRestartPendingCondition)
+	}
+}
+
+// SetRestartPendingCondition adds or updates the RestartPending condition, or removes it if restarts are not manual
+func (clusterStatus *RabbitmqClusterStatus) SetRestartPendingCondition(manual, pending bool, changedKeys []string) {
+	for i := range clusterStatus.Conditions {
+		if clusterStatus.Conditions[i].Type != status.RestartPending {
+			continue
+		}
+		if !manual {
+			clusterStatus.Conditions = append(clusterStatus.Conditions[:i], clusterStatus.Conditions[i+1:]...)
+			return
+		}
+		clusterStatus.Conditions[i] = status.RestartPendingCondition(pending, changedKeys, ApproveRestartAnnotation, &clusterStatus.Conditions[i])
+		return
+	}
+	if manual {
+		clusterStatus.Conditions = append(clusterStatus.Conditions, status.RestartPendingCondition(pending, changedKeys, ApproveRestartAnnotation, nil))
+	}
+}
+
+// RestartPending returns true if a restart awaits approval
+func (clusterStatus *RabbitmqClusterStatus) RestartPending() bool {
+	for _, condition := range clusterStatus.Conditions {
+		if condition.Type == status.RestartPending {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// SetTLSCertificateExpiringCondition adds, updates or removes the TLSCertificateExpiring condition
+// depending on the expiry date of the server certificate. notAfter is nil when the expiry date is unknown.
+func (clusterStatus *RabbitmqClusterStatus) SetTLSCertificateExpiringCondition(notAfter *metav1.Time) {
+	for i := range clusterStatus.Conditions {
+		if clusterStatus.Conditions[i].Type != status.TLSCertificateExpiring {
+			continue
+		}
+		if notAfter == nil {
+			clusterStatus.Conditions = append(clusterStatus.Conditions[:i], clusterStatus.Conditions[i+1:]...)
+			return
+		}
+		clusterStatus.Conditions[i] = status.TLSCertificateExpiringCondition(notAfter.Time, &clusterStatus.Conditions[i])
+		return
+	}
+	if notAfter != nil {
+		clusterStatus.Conditions = append(clusterStatus.Conditions, status.TLSCertificateExpiringCondition(notAfter.Time, nil))
+	}
 }
 
 func (clusterStatus *RabbitmqClusterStatus) SetCondition(condType status.RabbitmqClusterConditionType,
@@ -82,4 +82,24 @@ var _ = Describe("RabbitmqClusterStatus", func() {
 		Expect(updatedCondition.LastTransitionTime).NotTo(Equal(notExpectedTime))
 		Expect(updatedCondition.LastTransitionTime.Before(&notExpectedTime)).To(BeFalse())
 	})
+	It("adds, updates and removes the RestartPending condition", func() {
+		rmqStatus := RabbitmqClusterStatus{}
+		rmqStatus.SetRestartPendingCondition(true, true, []string{"listeners.tcp.default"})
+		Expect(rmqStatus.Conditions).To(HaveLen(1))
+		Expect(rmqStatus.Conditions[0].Type).To(Equal(status.RestartPending))
+		Expect(rmqStatus.RestartPending()).To(BeTrue())
+
+		sts := &appsv1.StatefulSet{}
+		sts.Spec.Template.Spec.Containers = []corev1.Container{{}}
+		rmqStatus.SetConditions([]runtime.Object{sts, &discoveryv1.EndpointSlice{}})
+		Expect(rmqStatus.RestartPending()).To(BeTrue())
+
+		rmqStatus.SetRestartPendingCondition(true, false, nil)
+		Expect(rmqStatus.RestartPending()).To(BeFalse())
+
+		rmqStatus.SetRestartPendingCondition(false, false, nil)
+		for _, condition := range rmqStatus.Conditions {
+			Expect(condition.Type).NotTo(Equal(status.RestartPending))
+		}
+	})
 })
@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 
@@ -20,6 +21,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	k8sresource "k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 const (
@@ -28,6 +30,9 @@ const (
 	ErlangVersionAnnotation                = "rabbitmq.com/erlang-version"
 	VersionNotAnnotated                    = "VersionNotAnnotated"
 	LegacyStartupProbeAnnotation           = "rabbitmq.com/legacy-startup-probe"
+	// ApproveRestartAnnotation approves the pending restart of a RabbitmqCluster with spec.restartPolicy Manual.
+	// The operator removes it once the restart started, so that later restarts need to be approved again.
+	ApproveRestartAnnotation = "rabbitmq.com/approve-restart"
 )
 
 // +kubebuilder:object:root=true
@@ -81,6 +86,10 @@ type RabbitmqClusterSpec struct {
 	Rabbitmq RabbitmqClusterConfigurationSpec `json:"rabbitmq,omitempty"`
 	// TLS-related configuration for the RabbitMQ cluster.
 	TLS TLSSpec `json:"tls,omitempty"`
+	// Stream-related configuration. Only takes effect when the stream plugin, or a plugin enabling it, is enabled.
+	Stream StreamSpec `json:"stream,omitempty"`
+	// Configuration for exposing the RabbitMQ management UI outside of the cluster.
+	Management ManagementSpec `json:"management,omitempty"`
 	// Provides the ability to override the generated manifest of several child resources.
 	Override RabbitmqClusterOverrideSpec `json:"override,omitempty"`
 	// If unset, or set to false, the cluster will run `rabbitmq-queues rebalance all` whenever the cluster is updated.
@@ -88,6 +97,19 @@ type RabbitmqClusterSpec struct {
 	// Has no effect if the cluster only consists of one node.
 	// For more information, see https://www.rabbitmq.com/rabbitmq-queues.8.html#rebalance
 	SkipPostDeploySteps bool `json:"skipPostDeploySteps,omitempty"`
+	// Whether the nodes are restarted as soon as a configuration change requires it.
+	// With Manual, the operator still updates the configuration, but only restarts the nodes once the restart is approved
+	// by annotating the RabbitmqCluster with rabbitmq.com/approve-restart. Until then, the RestartPending condition lists the changed keys.
+	// Changes which are applied on the running nodes, and changes to the Pod template, such as the image, are not held back.
+	// +optional
+	RestartPolicy RestartPolicy `json:"restartPolicy,omitempty"`
+	// Recurring windows in which the operator applies disruptive changes: rollouts of the Pod template, restarts caused by
+	// configuration changes, expansion of persistent volumes, plugin changes and queue rebalances.
+	// Outside of the windows, these changes are held back and listed in status.maintenance.
+	// Other changes, such as Service annotations, are applied at once. If unset, all changes are applied at once.
+	// +optional
+	// +kubebuilder:validation:MaxItems:=10
+	MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows,omitempty"`
 	// Set to true to automatically enable all feature flags after each upgrade
 	// For more information, see https://www.rabbitmq.com/docs/feature-flags
 	AutoEnableAllFeatureFlags bool `json:"autoEnableAllFeatureFlags,omitempty"`
@@ -110,6 +132,338 @@ type RabbitmqClusterSpec struct {
 	// Secret backend configuration for the RabbitmqCluster.
 	// Enables to fetch default user credentials and certificates from K8s external secret stores.
 	SecretBackend SecretBackend `json:"secretBackend,omitempty"`
+	// Configuration for the default user, whose credentials are stored in the Secret "<RabbitmqCluster name>-default-user".
+	DefaultUser DefaultUserSpec `json:"defaultUser,omitempty"`
+	// Backups taken by the operator.
+	Backup BackupSpec `json:"backup,omitempty"`
+	// Warm standby replication from an upstream RabbitmqCluster. When set, this RabbitmqCluster is a downstream:
+	// the definitions of the upstream are synchronised to it, and the messages of the replicated virtual hosts
+	// are replicated to it, so that it can be promoted if the upstream is lost.
+	// The operator creates the replication user on the upstream, configures both clusters, and enables the
+	// rabbitmq_multi_dc_replication plugin on both, which must be shipped in their image.
+	// +optional
+	Replication *ReplicationSpec `json:"replication,omitempty"`
+	// Federation upstreams and shovels connecting this RabbitmqCluster to other RabbitmqClusters.
+	// The operator builds the URI of the other RabbitmqCluster from its Service, its default user credentials and
+	// the CA certificate of its TLS listeners, enables the rabbitmq_federation or rabbitmq_shovel plugin,
+	// and updates the links when the credentials or the certificate of the other RabbitmqCluster change.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Links []LinkSpec `json:"links,omitempty"`
+	// Authentication and authorisation backends used in addition to the internal database,
+	// which keeps holding the default user.
+	Auth AuthSpec `json:"auth,omitempty"`
+}
+
+// AuthSpec configures the authentication and authorisation backends of RabbitMQ.
+// The operator enables their plugins and sets auth_backends, in which the internal database comes last.
+type AuthSpec struct {
+	// OAuth 2.0 authentication with JSON Web Tokens issued by an OpenID Connect provider.
+	// Clients present the token as password, with the PLAIN or AMQPLAIN mechanism.
+	// +optional
+	OAuth2 *OAuth2Spec `json:"oauth2,omitempty"`
+	// LDAP authentication and authorisation. Clients present their LDAP password with the PLAIN or AMQPLAIN mechanism.
+	// +optional
+	LDAP *LDAPSpec `json:"ldap,omitempty"`
+}
+
+// OAuth2Spec configures the rabbitmq_auth_backend_oauth2 plugin.
+// Tokens are verified with the signing keys if set, or else with the keys published at the JWKS URL,
+// which is discovered from the issuer if not set. Changes to the key material require a restart of the nodes.
+// +kubebuilder:validation:XValidation:rule="has(self.issuerURL) || has(self.jwksURL) || (has(self.signingKeys) && size(self.signingKeys) > 0)",message="one of issuerURL, jwksURL and signingKeys must be set"
+type OAuth2Spec struct {
+	// Audience of the tokens, and prefix of their scopes.
+	// +kubebuilder:validation:MinLength:=1
+	ResourceServerID string `json:"resourceServerID"`
+	// URL of the OpenID Connect provider issuing the tokens.
+	// +kubebuilder:validation:Pattern:="^https://"
+	// +optional
+	IssuerURL string `json:"issuerURL,omitempty"`
+	// URL of the JSON Web Key Set of the provider. Defaults to the one published by the issuer.
+	// +kubebuilder:validation:Pattern:="^https://"
+	// +optional
+	JWKSURL string `json:"jwksURL,omitempty"`
+	// CA certificate the certificates of the issuer and JWKS URL are verified against.
+	// Defaults to the CA certificates of the image.
+	// +optional
+	CACertificate *corev1.SecretKeySelector `json:"caCertificate,omitempty"`
+	// PEM encoded public keys or certificates the tokens are verified with, identified by the key ID of the tokens.
+	// +listType=map
+	// +listMapKey=id
+	// +optional
+	SigningKeys []OAuth2SigningKey `json:"signingKeys,omitempty"`
+	// ID of the signing key tokens without key ID are verified with.
+	// +optional
+	DefaultSigningKey string `json:"defaultSigningKey,omitempty"`
+	// Claims the user name is read from, in order. Defaults to sub and client_id.
+	// +optional
+	PreferredUsernameClaims []string `json:"preferredUsernameClaims,omitempty"`
+	// Claim holding scopes in addition to the scope claim.
+	// +optional
+	AdditionalScopesKey string `json:"additionalScopesKey,omitempty"`
+	// Scopes granted for scopes of the tokens which are not RabbitMQ scopes, such as roles of the provider,
+	// keyed by the scope of the token. Values are space-separated lists of RabbitMQ scopes.
+	// +optional
+	ScopeAliases map[string]string `json:"scopeAliases,omitempty"`
+	// Single sign-on to the management UI through the provider.
+	// +optional
+	Management *OAuth2ManagementSpec `json:"management,omitempty"`
+}
+
+// OAuth2SigningKey references a key tokens are verified with.
+type OAuth2SigningKey struct {
+	// Key ID, as in the kid header of the tokens.
+	// +kubebuilder:validation:Pattern:="^[A-Za-z0-9_-]+$"
+	ID string `json:"id"`
+	// Key of a Secret holding the PEM encoded public key or certificate.
+	SecretKeyRef corev1.SecretKeySelector `json:"secretKeyRef"`
+}
+
+// OAuth2ManagementSpec configures the OAuth 2.0 client of the management UI.
+type OAuth2ManagementSpec struct {
+	// ID of the client registered with the provider.
+	// +kubebuilder:validation:MinLength:=1
+	ClientID string `json:"clientID"`
+	// Key of a Secret holding the client secret, for confidential clients.
+	// It is not stored in the server ConfigMap.
+	// +optional
+	ClientSecret *corev1.SecretKeySelector `json:"clientSecret,omitempty"`
+	// Space-separated scopes the management UI requests.
+	// +optional
+	Scopes string `json:"scopes,omitempty"`
+	// URL of the provider the management UI redirects to. Defaults to the issuer URL.
+	// +optional
+	ProviderURL string `json:"providerURL,omitempty"`
+}
+
+// LDAPSpec configures the rabbitmq_auth_backend_ldap plugin.
+// Users are bound to the directory with their DN, which is built from userDNPattern or, if dnLookup is set,
+// looked up with the bind user. Queries for vhost access, resources and tags can be set in spec.rabbitmq.advancedConfig.
+// +kubebuilder:validation:XValidation:rule="has(self.userDNPattern) || has(self.dnLookup)",message="one of userDNPattern and dnLookup must be set"
+// +kubebuilder:validation:XValidation:rule="!has(self.dnLookup) || has(self.bindDN)",message="dnLookup requires bindDN"
+// +kubebuilder:validation:XValidation:rule="has(self.bindDN) == has(self.bindPassword)",message="bindDN and bindPassword must be set together"
+type LDAPSpec struct {
+	// Host names of the LDAP servers, tried in order.
+	// +kubebuilder:validation:MinItems:=1
+	Servers []string `json:"servers"`
+	// Port of the LDAP servers. Defaults to 389, or to 636 if TLS is enabled without StartTLS.
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=65535
+	// +optional
+	Port *int32 `json:"port,omitempty"`
+	// Connects to the LDAP servers over TLS.
+	// +optional
+	TLS *LDAPTLSSpec `json:"tls,omitempty"`
+	// Pattern of the DN of users, in which ${username} is replaced by the user name, such as cn=${username},ou=People,dc=example,dc=com.
+	// +optional
+	UserDNPattern string `json:"userDNPattern,omitempty"`
+	// Looks up the DN of users by attribute, such as uid, with the bind user.
+	// +optional
+	DNLookup *LDAPDNLookupSpec `json:"dnLookup,omitempty"`
+	// DN of the user bound to look up users and, after authentication, to run authorisation queries.
+	// Authorisation queries run as the authenticated user if not set. Requires bindPassword.
+	// +optional
+	BindDN string `json:"bindDN,omitempty"`
+	// Key of a Secret holding the password of the bind user. It is not stored in the server ConfigMap.
+	// +optional
+	BindPassword *corev1.SecretKeySelector `json:"bindPassword,omitempty"`
+}
+
+// LDAPTLSSpec configures TLS connections to the LDAP servers.
+type LDAPTLSSpec struct {
+	// Upgrades plain connections with StartTLS instead of connecting with LDAPS.
+	// +optional
+	StartTLS bool `json:"startTLS,omitempty"`
+	// CA certificate the certificates of the LDAP servers are verified against.
+	// Defaults to the CA certificates of the image.
+	// +optional
+	CACertificate *corev1.SecretKeySelector `json:"caCertificate,omitempty"`
+}
+
+// LDAPDNLookupSpec configures the lookup of the DN of users.
+type LDAPDNLookupSpec struct {
+	// Attribute holding the user name, such as uid or sAMAccountName.
+	// +kubebuilder:validation:MinLength:=1
+	Attribute string `json:"attribute"`
+	// DN of the subtree users are looked up in.
+	// +kubebuilder:validation:MinLength:=1
+	Base string `json:"base"`
+}
+
+// ReplicationSpec configures a RabbitmqCluster as the downstream of warm standby replication.
+type ReplicationSpec struct {
+	// Upstream RabbitmqCluster to replicate from.
+	Upstream RabbitmqClusterReference `json:"upstream"`
+	// Virtual hosts of the upstream whose messages are replicated. The operator tags them with standby_replication,
+	// and creates them on the upstream if they do not exist.
+	// +kubebuilder:validation:MinItems:=1
+	// +listType:=set
+	VirtualHosts []string `json:"virtualHosts"`
+}
+
+// RabbitmqClusterReference references a RabbitmqCluster in any namespace.
+type RabbitmqClusterReference struct {
+	// Name of the RabbitmqCluster.
+	// +kubebuilder:validation:MinLength:=1
+	Name string `json:"name"`
+	// Namespace of the RabbitmqCluster. Defaults to the namespace of the referencing RabbitmqCluster.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// LinkType is the kind of link between two RabbitmqClusters.
+// +kubebuilder:validation:Enum=Federation;Shovel
+type LinkType string
+
+const (
+	LinkTypeFederation LinkType = "Federation"
+	LinkTypeShovel     LinkType = "Shovel"
+)
+
+// LinkSpec declares a federation upstream or a dynamic shovel connecting this RabbitmqCluster to another one.
+// +kubebuilder:validation:XValidation:rule="self.type == 'Shovel' ? has(self.shovel) : !has(self.shovel)",message="shovel must be set on links of type Shovel only"
+// +kubebuilder:validation:XValidation:rule="self.type == 'Federation' || !has(self.federation)",message="federation can only be set on links of type Federation"
+type LinkSpec struct {
+	// Name of the federation upstream or shovel.
+	// +kubebuilder:validation:MinLength:=1
+	// +kubebuilder:validation:Pattern:="^[-._a-zA-Z0-9]+$"
+	Name string   `json:"name"`
+	Type LinkType `json:"type"`
+	// RabbitmqCluster at the other end of the link.
+	RabbitmqCluster RabbitmqClusterReference `json:"rabbitmqCluster"`
+	// Virtual host of this RabbitmqCluster the federation upstream or shovel is declared in.
+	// +kubebuilder:default:="/"
+	// +optional
+	VirtualHost string `json:"virtualHost,omitempty"`
+	// Virtual host of the other RabbitmqCluster. Defaults to virtualHost.
+	// +optional
+	RemoteVirtualHost string `json:"remoteVirtualHost,omitempty"`
+	// +optional
+	Federation *FederationLinkSpec `json:"federation,omitempty"`
+	// +optional
+	Shovel *ShovelLinkSpec `json:"shovel,omitempty"`
+}
+
+// FederationLinkSpec configures a federation upstream. Exchanges and queues are federated by policies with the
+// federation-upstream or federation-upstream-set key, which are not managed by the operator.
+type FederationLinkSpec struct {
+	// Name of the upstream exchange. Defaults to the name of the federated exchange.
+	// +optional
+	Exchange string `json:"exchange,omitempty"`
+	// Name of the upstream queue. Defaults to the name of the federated queue.
+	// +optional
+	Queue string `json:"queue,omitempty"`
+	// Maximum number of federation links a message can traverse. Defaults to 1.
+	// +kubebuilder:validation:Minimum:=1
+	// +optional
+	MaxHops *int32 `json:"maxHops,omitempty"`
+}
+
+// ShovelDirection is the direction in which a shovel moves messages.
+// +kubebuilder:validation:Enum=Pull;Push
+type ShovelDirection string
+
+const (
+	// ShovelPull moves messages from the other RabbitmqCluster to this one.
+	ShovelPull ShovelDirection = "Pull"
+	// ShovelPush moves messages from this RabbitmqCluster to the other one.
+	ShovelPush ShovelDirection = "Push"
+)
+
+// ShovelLinkSpec configures a dynamic shovel. Its source and destination are relative to the direction.
+// +kubebuilder:validation:XValidation:rule="has(self.sourceQueue) != has(self.sourceExchange)",message="exactly one of sourceQueue and sourceExchange must be set"
+// +kubebuilder:validation:XValidation:rule="!(has(self.destinationQueue) && has(self.destinationExchange))",message="destinationQueue and destinationExchange are mutually exclusive"
+type ShovelLinkSpec struct {
+	// +kubebuilder:default:=Pull
+	// +optional
+	Direction ShovelDirection `json:"direction,omitempty"`
+	// Queue to consume from.
+	// +optional
+	SourceQueue string `json:"sourceQueue,omitempty"`
+	// Exchange to consume from, through a queue declared by the shovel.
+	// +optional
+	SourceExchange string `json:"sourceExchange,omitempty"`
+	// Routing key of the binding of sourceExchange. Defaults to "#".
+	// +optional
+	SourceExchangeKey string `json:"sourceExchangeKey,omitempty"`
+	// Queue to publish to. If neither destinationQueue nor destinationExchange is set, messages are published
+	// with their original exchange and routing key.
+	// +optional
+	DestinationQueue string `json:"destinationQueue,omitempty"`
+	// Exchange to publish to.
+	// +optional
+	DestinationExchange string `json:"destinationExchange,omitempty"`
+	// Routing key messages are published with to destinationExchange. Defaults to their original routing key.
+	// +optional
+	DestinationExchangeKey string `json:"destinationExchangeKey,omitempty"`
+}
+
+// BackupSpec configures backups taken by the operator.
+type BackupSpec struct {
+	// Scheduled exports of the definitions of the cluster, such as virtual hosts, users, policies and queues.
+	// Definitions are also exported before RabbitMQ is upgraded and before all nodes are restarted at the same time.
+	// Exports are not deleted with the RabbitmqCluster.
+	// +optional
+	Definitions *DefinitionsBackupSpec `json:"definitions,omitempty"`
+}
+
+// DefinitionsBackupStorageType is the kind of object definitions exports are stored in.
+// +kubebuilder:validation:Enum=Secret;ConfigMap;PersistentVolumeClaim
+type DefinitionsBackupStorageType string
+
+const (
+	DefinitionsBackupStorageSecret                DefinitionsBackupStorageType = "Secret"
+	DefinitionsBackupStorageConfigMap             DefinitionsBackupStorageType = "ConfigMap"
+	DefinitionsBackupStoragePersistentVolumeClaim DefinitionsBackupStorageType = "PersistentVolumeClaim"
+)
+
+// DefinitionsBackupSpec configures scheduled exports of the definitions through the management API.
+// +kubebuilder:validation:XValidation:rule="self.storageType != 'PersistentVolumeClaim' || has(self.persistentVolumeClaimName)",message="persistentVolumeClaimName must be set when storageType is PersistentVolumeClaim"
+type DefinitionsBackupSpec struct {
+	// Schedule of the exports in cron format, for example "0 3 * * *", or one of @hourly, @daily, @weekly, @monthly and @yearly.
+	// Times are in UTC.
+	// +kubebuilder:validation:MinLength:=1
+	Schedule string `json:"schedule"`
+	// Kind of object the exports are stored in. Secret and ConfigMap store each export in its own object
+	// named "<RabbitmqCluster name>-definitions-<time>" under the key "definitions.json", and are limited to 1MiB.
+	// PersistentVolumeClaim stores each export as "<RabbitmqCluster name>-definitions-<time>.json"
+	// on the claim referenced by persistentVolumeClaimName, written by a Job.
+	// Definitions contain the password hashes of users, so ConfigMaps are only recommended for definitions without users.
+	// +kubebuilder:default:="Secret"
+	StorageType DefinitionsBackupStorageType `json:"storageType,omitempty"`
+	// Name of an existing PersistentVolumeClaim in the namespace of the RabbitmqCluster. Required when storageType is PersistentVolumeClaim.
+	// +optional
+	PersistentVolumeClaimName string `json:"persistentVolumeClaimName,omitempty"`
+	// Number of exports to keep. Older exports are deleted.
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:default:=7
+	Retention int32 `json:"retention,omitempty"`
+}
+
+// DefaultUserSpec configures the default user.
+type DefaultUserSpec struct {
+	// Rotation of the default user credentials.
+	// Credentials can also be rotated once by annotating the RabbitmqCluster with "rabbitmq.com/rotateDefaultUserCredentials".
+	// Rotation is not supported if the credentials are provided by spec.secretBackend,
+	// or set with default_user or default_pass in spec.rabbitmq.additionalConfig.
+	// +optional
+	Rotation *DefaultUserRotationSpec `json:"rotation,omitempty"`
+}
+
+// DefaultUserRotationSpec configures the rotation of the default user credentials.
+// On rotation, the operator creates a new user with the tags and permissions of the current default user
+// and updates the default user Secret, including connection_string and default_user.conf.
+// The previous user keeps working until the grace period is over and is deleted afterwards.
+type DefaultUserRotationSpec struct {
+	// Time between two rotations, for example "720h".
+	// If unset, credentials are only rotated when requested with the annotation.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+	// Time during which the previous credentials stay valid after a rotation.
+	// +kubebuilder:default:="1h"
+	// +optional
+	GracePeriod *metav1.Duration `json:"gracePeriod,omitempty"`
 }
 
 // SecretBackend configures a single secret backend.
@@ -362,10 +716,13 @@ type PersistentVolumeClaim struct {
 }
 
 // TLSSpec allows for the configuration of TLS certificates to be used by RabbitMQ. Also allows for non-TLS traffic to be disabled.
+// +kubebuilder:validation:XValidation:rule="!(has(self.secretName) && has(self.certManager))",message="secretName and certManager are mutually exclusive"
 type TLSSpec struct {
 	// Name of a Secret in the same Namespace as the RabbitmqCluster, containing the server's private key & public certificate for TLS.
 	// The Secret must store these as tls.key and tls.crt, respectively.
 	// This Secret can be created by running `kubectl create secret tls tls-secret --cert=path/to/tls.crt --key=path/to/tls.key`
+	// Rotated certificates are loaded by RabbitMQ without restarting the nodes. The operator notices rotations immediately
+	// if the Secret is labelled with app.kubernetes.io/part-of=rabbitmq, and within 10 minutes otherwise.
 	SecretName string `json:"secretName,omitempty"`
 	// Name of a Secret in the same Namespace as the RabbitmqCluster, containing the Certificate Authority's public certificate for TLS.
 	// The Secret must store this as ca.crt.
@@ -375,6 +732,215 @@ type TLSSpec struct {
 	// When set to true, the RabbitmqCluster disables non-TLS listeners for RabbitMQ, management plugin and for any enabled plugins in the following list: stomp, mqtt, web_stomp, web_mqtt, web_amqp.
 	// Only TLS-enabled clients will be able to connect.
 	DisableNonTLSListeners bool `json:"disableNonTLSListeners,omitempty"`
+	// When set, the operator creates a cert-manager Certificate issuing the server's certificate, instead of reading
+	// it from a user-provided Secret. The Certificate includes the DNS names of every Pod, of the headless Service
+	// and of the client Service, and is updated when the cluster is scaled out.
+	// Requires cert-manager to be installed in the Kubernetes cluster.
+	// +optional
+	CertManager *CertManagerTLSSpec `json:"certManager,omitempty"`
+	// When set to true, Erlang distribution traffic between RabbitMQ nodes, and between CLI tools and nodes, is encrypted
+	// using the server certificate. Peers are verified against the CA certificate, so mutual TLS must be configured.
+	// The certificate must be valid for "<RabbitmqCluster name>-server-<index>.<RabbitmqCluster name>-nodes.<namespace>" for each pod.
+	// Changing this setting on a running cluster restarts all nodes at the same time, since nodes using different
+	// distribution protocols cannot communicate with each other.
+	// +optional
+	InterNode bool `json:"interNode,omitempty"`
+	// Certificates for individual groups of listeners. Listeners without their own certificate use the certificate
+	// and CA configured above.
+	// +optional
+	Listeners *TLSListenersSpec `json:"listeners,omitempty"`
+	// TLS options applied to every TLS listener: AMQP, stream, MQTT, STOMP, management, Prometheus and the web plugins.
+	// Options set in spec.rabbitmq.additionalConfig take precedence.
+	// +optional
+	Options *TLSOptionsSpec `json:"options,omitempty"`
+}
+
+// TLSOptionsSpec restricts the TLS versions and cipher suites accepted by RabbitMQ, and configures client certificate verification.
+type TLSOptionsSpec struct {
+	// TLS versions accepted by the listeners, e.g. ["tlsv1.3"] to only accept TLS 1.3.
+	// +kubebuilder:validation:items:Enum=tlsv1.3;tlsv1.2;tlsv1.1;tlsv1
+	// +kubebuilder:validation:MaxItems:=4
+	// +listType=set
+	// +optional
+	Versions []string `json:"versions,omitempty"`
+	// Cipher suites accepted by the listeners, in order of preference, in OpenSSL format,
+	// e.g. "ECDHE-ECDSA-AES256-GCM-SHA384", or Erlang format, e.g. "TLS_AES_256_GCM_SHA384".
+	// +optional
+	Ciphers []string `json:"ciphers,omitempty"`
+	// When set to true, the order of spec.tls.options.ciphers takes precedence over the client's preferences.
+	// +optional
+	HonorCipherOrder *bool `json:"honorCipherOrder,omitempty"`
+	// Whether the AMQP, stream, MQTT and STOMP listeners request and verify client certificates. By default, they only
+	// verify client certificates when spec.tls.caSecretName is set. The HTTP listeners never verify client certificates,
+	// since the operator connects to the management listener without one.
+	// +kubebuilder:validation:Enum=verify_peer;verify_none
+	// +optional
+	Verify string `json:"verify,omitempty"`
+	// When set to true, clients of the AMQP, stream, MQTT and STOMP listeners without a certificate are rejected.
+	// Requires spec.tls.caSecretName.
+	// +optional
+	FailIfNoPeerCert *bool `json:"failIfNoPeerCert,omitempty"`
+}
+
+// TLSListenersSpec configures certificates for groups of listeners, e.g. a publicly trusted certificate for the
+// management UI while messaging clients use certificates issued by an internal CA.
+type TLSListenersSpec struct {
+	// Certificate for the AMQP 0-9-1 and AMQP 1.0 listener. The stream, MQTT and STOMP listeners share this certificate,
+	// since RabbitMQ configures them with the same TLS options.
+	// +optional
+	AMQP *ListenerTLSSpec `json:"amqp,omitempty"`
+	// Certificate for the management UI and HTTP API. The operator verifies the management listener with this CA
+	// when connecting to the HTTP API.
+	// +optional
+	Management *ListenerTLSSpec `json:"management,omitempty"`
+	// Certificate for the Prometheus metrics endpoint.
+	// +optional
+	Prometheus *ListenerTLSSpec `json:"prometheus,omitempty"`
+	// Certificate for the Web MQTT, Web STOMP and Web AMQP listeners.
+	// +optional
+	Web *ListenerTLSSpec `json:"web,omitempty"`
+}
+
+// ListenerTLSSpec references the Secrets holding the certificate of a group of listeners.
+type ListenerTLSSpec struct {
+	// Name of a Secret in the same Namespace as the RabbitmqCluster, containing the listener's private key & public certificate.
+	// The Secret must store these as tls.key and tls.crt, respectively.
+	// +kubebuilder:validation:MinLength:=1
+	SecretName string `json:"secretName"`
+	// Name of a Secret in the same Namespace as the RabbitmqCluster, containing the Certificate Authority's public certificate
+	// used by the listener. The Secret must store this as ca.crt.
+	// +optional
+	CaSecretName string `json:"caSecretName,omitempty"`
+}
+
+// CertManagerTLSSpec configures the cert-manager Certificate created for the RabbitmqCluster.
+type CertManagerTLSSpec struct {
+	// Issuer or ClusterIssuer signing the server certificate.
+	IssuerRef CertManagerIssuerReference `json:"issuerRef"`
+	// Additional DNS names to include in the certificate, for example the host name of a LoadBalancer.
+	// +kubebuilder:validation:MaxItems:=64
+	// +optional
+	AdditionalDNSNames []string `json:"additionalDNSNames,omitempty"`
+	// Requested lifetime of the certificate. Defaults to the issuer's default, usually 90 days.
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+	// How long before expiry cert-manager renews the certificate.
+	// +optional
+	RenewBefore *metav1.Duration `json:"renewBefore,omitempty"`
+}
+
+// CertManagerIssuerReference references a cert-manager Issuer or ClusterIssuer.
+type CertManagerIssuerReference struct {
+	// Name of the Issuer or ClusterIssuer.
+	// +kubebuilder:validation:MinLength:=1
+	Name string `json:"name"`
+	// Kind of the issuer. Must be one of: Issuer, ClusterIssuer.
+	// An Issuer must be in the same Namespace as the RabbitmqCluster.
+	// +kubebuilder:validation:Enum=Issuer;ClusterIssuer
+	// +kubebuilder:default:="Issuer"
+	// +optional
+	Kind string `json:"kind,omitempty"`
+	// API group of the issuer. Only needs to be set for external issuers.
+	// +kubebuilder:default:="cert-manager.io"
+	// +optional
+	Group string `json:"group,omitempty"`
+}
+
+// StreamSpec allows for the configuration of how stream clients reach individual RabbitMQ nodes.
+type StreamSpec struct {
+	// When set, the operator creates one Service per Pod exposing the stream ports, and configures
+	// stream.advertised_host and stream.advertised_port on each node so that stream clients outside of the
+	// Kubernetes cluster can connect to the node hosting a stream leader or replica.
+	// The advertised addresses are published in status.streamAdvertisedAddresses.
+	// +optional
+	PerPodServices *StreamPerPodServicesSpec `json:"perPodServices,omitempty"`
+}
+
+// StreamPerPodServicesSpec configures the per-Pod Services created for stream clients.
+// +kubebuilder:validation:XValidation:rule="self.type != 'NodePort' || has(self.advertisedHost)",message="advertisedHost must be set when type is NodePort"
+type StreamPerPodServicesSpec struct {
+	// Type of the per-Pod Services. Must be one of: LoadBalancer, NodePort.
+	// +kubebuilder:validation:Enum=LoadBalancer;NodePort
+	// +kubebuilder:default:="LoadBalancer"
+	Type corev1.ServiceType `json:"type,omitempty"`
+	// Host advertised by each node to stream clients. The placeholder {pod} is replaced by the name of the Pod,
+	// for example "{pod}.streams.example.com". Required for NodePort Services.
+	// If unset, the hostname or IP address of the LoadBalancer is advertised.
+	// +kubebuilder:validation:Pattern:="^[A-Za-z0-9.:{}-]+$"
+	// +kubebuilder:validation:MaxLength:=253
+	// +optional
+	AdvertisedHost string `json:"advertisedHost,omitempty"`
+	// Annotations to add to the per-Pod Services. The placeholder {pod} in annotation values is replaced by the name of the Pod.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// ManagementSpec allows for the configuration of how the management UI and HTTP API are exposed.
+type ManagementSpec struct {
+	// Path prefix under which the management UI and HTTP API are served, for example "/rabbitmq".
+	// Sets `management.path_prefix` in rabbitmq.conf and is used as the path of the Ingress or HTTPRoute.
+	// Modifying this property on an existing RabbitmqCluster will trigger a StatefulSet rolling restart.
+	// For more information, see https://www.rabbitmq.com/docs/management#path-prefix
+	// +kubebuilder:validation:Pattern:="^(/[A-Za-z0-9._~-]+)+$"
+	// +kubebuilder:validation:MaxLength:=253
+	// +optional
+	PathPrefix string `json:"pathPrefix,omitempty"`
+	// When set, the operator creates an Ingress or a Gateway API HTTPRoute routing traffic to the management port
+	// of the client Service. The management-tls port is used when TLS is enabled and non-TLS listeners are disabled.
+	// +optional
+	Ingress *ManagementIngressSpec `json:"ingress,omitempty"`
+}
+
+// ManagementIngressType is the kind of resource created to expose the management UI.
+// +kubebuilder:validation:Enum=Ingress;HTTPRoute
+type ManagementIngressType string
+
+const (
+	ManagementIngressTypeIngress   ManagementIngressType = "Ingress"
+	ManagementIngressTypeHTTPRoute ManagementIngressType = "HTTPRoute"
+)
+
+// ManagementIngressSpec configures the Ingress or HTTPRoute that exposes the management UI.
+// +kubebuilder:validation:XValidation:rule="self.type != 'HTTPRoute' || (has(self.parentRefs) && size(self.parentRefs) > 0)",message="parentRefs must be set when type is HTTPRoute"
+// +kubebuilder:validation:XValidation:rule="self.type != 'HTTPRoute' || !has(self.tlsSecretName)",message="tlsSecretName is only supported when type is Ingress; TLS for HTTPRoutes is terminated by the parent Gateway"
+// +kubebuilder:validation:XValidation:rule="self.type != 'HTTPRoute' || !has(self.ingressClassName)",message="ingressClassName is only supported when type is Ingress"
+type ManagementIngressSpec struct {
+	// Kind of resource to create. Ingress creates a networking.k8s.io/v1 Ingress.
+	// HTTPRoute creates a gateway.networking.k8s.io/v1 HTTPRoute and requires the Gateway API CRDs to be installed.
+	// The operator does not watch HTTPRoutes and BackendTLSPolicies, so changes made to them directly are
+	// only reverted on the next reconcile of the RabbitmqCluster.
+	// +kubebuilder:default:="Ingress"
+	Type ManagementIngressType `json:"type,omitempty"`
+	// Host name the management UI is served on. If unset, requests for any host are routed.
+	// +optional
+	Host string `json:"host,omitempty"`
+	// Name of the IngressClass used by the Ingress. Only supported when type is Ingress.
+	// +optional
+	IngressClassName *string `json:"ingressClassName,omitempty"`
+	// Name of a Secret in the same Namespace as the RabbitmqCluster, containing the certificate used
+	// by the Ingress controller to terminate TLS for the host. Only supported when type is Ingress.
+	// +optional
+	TLSSecretName string `json:"tlsSecretName,omitempty"`
+	// Gateways the HTTPRoute attaches to. Required when type is HTTPRoute.
+	// +kubebuilder:validation:MaxItems:=32
+	// +optional
+	ParentRefs []ManagementGatewayParentRef `json:"parentRefs,omitempty"`
+	// Annotations to add to the Ingress or HTTPRoute.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// ManagementGatewayParentRef identifies a Gateway an HTTPRoute attaches to.
+type ManagementGatewayParentRef struct {
+	// Name of the Gateway.
+	// +kubebuilder:validation:MinLength:=1
+	Name string `json:"name"`
+	// Namespace of the Gateway. Defaults to the Namespace of the RabbitmqCluster.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// Name of the Gateway listener to attach to. If unset, the HTTPRoute attaches to all compatible listeners.
+	// +optional
+	SectionName string `json:"sectionName,omitempty"`
 }
 
 // kubebuilder validating tags 'Pattern' and 'MaxLength' must be specified on string type.
@@ -390,11 +956,29 @@ type RabbitmqClusterConfigurationSpec struct {
 	// List of plugins to enable in addition to essential plugins: rabbitmq_management, rabbitmq_prometheus, and rabbitmq_peer_discovery_k8s.
 	// +kubebuilder:validation:MaxItems:=100
 	AdditionalPlugins []Plugin `json:"additionalPlugins,omitempty"`
+	// Plugins which are not part of the image, such as community plugins. Before RabbitMQ starts, the init container
+	// fetch-plugins copies their .ez files into a plugins directory of each node and verifies their checksums.
+	// Enable the plugins by adding their names to additionalPlugins.
+	// Changing pluginSources triggers a StatefulSet rolling restart.
+	// +kubebuilder:validation:MaxItems:=20
+	// +optional
+	PluginSources []PluginSource `json:"pluginSources,omitempty"`
+	// Image of the fetch-plugins init container, which must provide sh, curl and sha256sum.
+	// Defaults to the DEFAULT_PLUGIN_FETCHER_IMAGE of the operator, or curlimages/curl if it is not set.
+	// +optional
+	PluginFetcherImage string `json:"pluginFetcherImage,omitempty"`
 	// Modify to add to the rabbitmq.conf file in addition to default configurations set by the operator.
 	// Modifying this property on an existing RabbitmqCluster will trigger a StatefulSet rolling restart and will cause rabbitmq downtime.
 	// For more information on this config, see https://www.rabbitmq.com/configure.html#config-file
 	// +kubebuilder:validation:MaxLength:=100000
 	AdditionalConfig string `json:"additionalConfig,omitempty"`
+	// rabbitmq.conf fragments read from keys of ConfigMaps and Secrets in the namespace of the RabbitmqCluster, applied in order after additionalConfig.
+	// Keep sensitive settings, such as passwords and URIs with credentials, in Secrets, since additionalConfig is stored in a ConfigMap.
+	// Changes to the content of the referenced keys trigger a StatefulSet rolling restart. The operator notices them immediately
+	// if the ConfigMap or Secret is labelled with app.kubernetes.io/part-of=rabbitmq, and within 10 minutes otherwise.
+	// +kubebuilder:validation:MaxItems:=20
+	// +optional
+	AdditionalConfigFrom []AdditionalConfigSource `json:"additionalConfigFrom,omitempty"`
 	// Specify any rabbitmq advanced.config configurations to apply to the cluster.
 	// For more information on advanced config, see https://www.rabbitmq.com/configure.html#advanced-config-file
 	// +kubebuilder:validation:MaxLength:=100000
@@ -408,6 +992,73 @@ type RabbitmqClusterConfigurationSpec struct {
 	// See also: https://www.erlang.org/doc/apps/erts/inet_cfg.html
 	// +kubebuilder:validation:MaxLength:=2000
 	ErlangInetConfig string `json:"erlangInetConfig,omitempty"`
+	// Definitions, such as virtual hosts, users, policies and queues, imported when RabbitMQ nodes boot.
+	// Changes to the content of the referenced ConfigMaps and Secrets are imported through the management API without restarting the nodes.
+	// For more information, see https://www.rabbitmq.com/docs/definitions#import-on-boot
+	// +optional
+	Definitions *DefinitionsSpec `json:"definitions,omitempty"`
+}
+
+// DefinitionsSpec references the definitions to import.
+type DefinitionsSpec struct {
+	// ConfigMaps and Secrets in the namespace of the RabbitmqCluster containing definitions JSON files.
+	// Every key of the referenced objects is a file and must end with ".json". Keys must be unique across all sources.
+	// +kubebuilder:validation:MinItems:=1
+	Sources []DefinitionsSource `json:"sources"`
+	// Set to true to skip the import at boot if the definitions did not change since the last import.
+	// +optional
+	SkipIfUnchanged bool `json:"skipIfUnchanged,omitempty"`
+}
+
+// PluginSource is the location of the .ez file of a plugin, either a URL or a path in an OCI image or artifact.
+// The name of the file must be the name of the plugin, optionally followed by "-" and its version, such as
+// rabbitmq_delayed_message_exchange-4.1.0.ez.
+// +kubebuilder:validation:XValidation:rule="has(self.url) != has(self.image)",message="exactly one of url or image must be set"
+type PluginSource struct {
+	// Name of the plugin, such as rabbitmq_delayed_message_exchange.
+	Name Plugin `json:"name"`
+	// HTTP or HTTPS URL of the .ez file.
+	// +optional
+	URL string `json:"url,omitempty"`
+	// OCI image or artifact containing the .ez file. It is mounted as an image volume, which requires Kubernetes 1.33 or later.
+	// +optional
+	Image *PluginImageSource `json:"image,omitempty"`
+	// SHA-256 checksum of the .ez file in hexadecimal. Nodes do not start if the file has a different checksum.
+	// +kubebuilder:validation:Pattern:="^[a-f0-9]{64}$"
+	SHA256 string `json:"sha256"`
+}
+
+// PluginImageSource references a .ez file in an OCI image or artifact.
+type PluginImageSource struct {
+	// Image or artifact reference, such as registry.example.com/plugins/delayed-message-exchange:4.1.0.
+	// +kubebuilder:validation:MinLength:=1
+	Reference string `json:"reference"`
+	// Path of the .ez file in the image or artifact, such as plugins/rabbitmq_delayed_message_exchange-4.1.0.ez.
+	// +kubebuilder:validation:MinLength:=1
+	Path string `json:"path"`
+	// Policy for pulling the image or artifact. Defaults to Always for the latest tag, and IfNotPresent otherwise.
+	// +optional
+	PullPolicy corev1.PullPolicy `json:"pullPolicy,omitempty"`
+}
+
+// AdditionalConfigSource references a key of either a ConfigMap or a Secret holding a rabbitmq.conf fragment.
+// The fragment is mounted as a file in the conf.d directory of the nodes, so the reference cannot be optional.
+// +kubebuilder:validation:XValidation:rule="has(self.configMapKeyRef) != has(self.secretKeyRef)",message="exactly one of configMapKeyRef or secretKeyRef must be set"
+// +kubebuilder:validation:XValidation:rule="!(has(self.configMapKeyRef) && has(self.configMapKeyRef.optional) && self.configMapKeyRef.optional) && !(has(self.secretKeyRef) && has(self.secretKeyRef.optional) && self.secretKeyRef.optional)",message="optional references are not supported"
+type AdditionalConfigSource struct {
+	// +optional
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+	// +optional
+	SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+}
+
+// DefinitionsSource references either a ConfigMap or a Secret.
+// +kubebuilder:validation:XValidation:rule="has(self.configMap) != has(self.secret)",message="exactly one of configMap or secret must be set"
+type DefinitionsSource struct {
+	// +optional
+	ConfigMap *corev1.LocalObjectReference `json:"configMap,omitempty"`
+	// +optional
+	Secret *corev1.LocalObjectReference `json:"secret,omitempty"`
 }
 
 // The settings for the persistent storage desired for each Pod in the RabbitmqCluster.
@@ -460,34 +1111,80 @@ func (cluster *RabbitmqCluster) TLSEnabled() bool {
 	return cluster.SecretTLSEnabled() || cluster.VaultTLSEnabled()
 }
 func (cluster *RabbitmqCluster) SecretTLSEnabled() bool {
-	return cluster.Spec.TLS.SecretName != ""
+	return cluster.TLSSecretName() != ""
+}
+
+// InterNodeTLSEnabled returns true if Erlang distribution traffic is encrypted.
+func (cluster *RabbitmqCluster) InterNodeTLSEnabled() bool {
+	return cluster.Spec.TLS.InterNode && cluster.MutualTLSEnabled()
+}
+
+// CertManagerEnabled returns true if the server certificate is issued by cert-manager.
+func (cluster *RabbitmqCluster) CertManagerEnabled() bool {
+	return cluster.Spec.TLS.CertManager != nil
+}
+
+// TLSSecretName returns the name of the Secret containing the server's private key & certificate.
+// When cert-manager issues the certificate, the Secret is owned by the operator's Certificate.
+func (cluster *RabbitmqCluster) TLSSecretName() string {
+	if cluster.CertManagerEnabled() {
+		return cluster.ChildResourceName("tls")
+	}
+	return cluster.Spec.TLS.SecretName
 }
 
 func (cluster *RabbitmqCluster) MutualTLSEnabled() bool {
 	return (cluster.SecretTLSEnabled() && cluster.Spec.TLS.CaSecretName != "") || cluster.VaultTLSEnabled()
 }
 
+// ManagementCaSecretName returns the name of the Secret containing the CA certificate of the management listener.
+func (cluster *RabbitmqCluster) ManagementCaSecretName() string {
+	if listeners := cluster.Spec.TLS.Listeners; listeners != nil && listeners.Management != nil {
+		return listeners.Management.CaSecretName
+	}
+	return cluster.Spec.TLS.CaSecretName
+}
+
 func (cluster *RabbitmqCluster) MemoryLimited() bool {
 	return cluster.Spec.Resources != nil && cluster.Spec.Resources.Limits != nil && !cluster.Spec.Resources.Limits.Memory().IsZero()
 }
 
 func (cluster *RabbitmqCluster) SingleTLSSecret() bool {
-	return cluster.MutualTLSEnabled() && cluster.Spec.TLS.CaSecretName == cluster.Spec.TLS.SecretName
+	return cluster.MutualTLSEnabled() && cluster.Spec.TLS.CaSecretName == cluster.TLSSecretName()
 }
 
 func (cluster *RabbitmqCluster) DisableNonTLSListeners() bool {
 	return cluster.Spec.TLS.DisableNonTLSListeners
 }
 
+func (cluster *RabbitmqCluster) ManagementIngressEnabled() bool {
+	return cluster.Spec.Management.Ingress != nil
+}
+
+// ManagementPathPrefix returns the path prefix of the management UI, or "/" if none is configured.
+func (cluster *RabbitmqCluster) ManagementPathPrefix() string {
+	if cluster.Spec.Management.PathPrefix == "" {
+		return "/"
+	}
+	return cluster.Spec.Management.PathPrefix
+}
+
 func (cluster *RabbitmqCluster) AdditionalPluginEnabled(plugin Plugin) bool {
 	return slices.Contains(cluster.Spec.Rabbitmq.AdditionalPlugins, plugin)
 }
 
-// StreamNeeded returns true when stream or plugins that auto enable stream are turned on
+// StreamNeeded returns true when stream or plugins that auto enable stream are turned on,
+// including the replication plugin enabled by the operator on downstream clusters
 func (cluster *RabbitmqCluster) StreamNeeded() bool {
 	return cluster.AdditionalPluginEnabled("rabbitmq_stream") ||
 		cluster.AdditionalPluginEnabled("rabbitmq_stream_management") ||
-		cluster.AdditionalPluginEnabled("rabbitmq_multi_dc_replication")
+		cluster.AdditionalPluginEnabled("rabbitmq_multi_dc_replication") ||
+		cluster.ReplicationEnabled()
+}
+
+// StreamPerPodServicesEnabled returns true when per-Pod Services for stream clients are requested and stream is needed
+func (cluster *RabbitmqCluster) StreamPerPodServicesEnabled() bool {
+	return cluster.Spec.Stream.PerPodServices != nil && cluster.StreamNeeded()
 }
 
 func (cluster *RabbitmqCluster) VaultEnabled() bool {
@@ -506,6 +1203,103 @@ func (cluster *RabbitmqCluster) VaultDefaultUserSecretEnabled() bool {
 	return cluster.VaultEnabled() && cluster.Spec.SecretBackend.Vault.DefaultUserSecretEnabled()
 }
 
+// DefaultUserRotationSupported returns true if the operator generates the default user credentials and can therefore rotate them.
+func (cluster *RabbitmqCluster) DefaultUserRotationSupported() bool {
+	if cluster.VaultDefaultUserSecretEnabled() || cluster.ExternalSecretEnabled() {
+		return false
+	}
+	for line := range strings.Lines(cluster.Spec.Rabbitmq.AdditionalConfig) {
+		key, _, _ := strings.Cut(line, "=")
+		if key := strings.TrimSpace(key); key == "default_user" || key == "default_pass" {
+			return false
+		}
+	}
+	return true
+}
+
+// DefaultUserRotationInterval returns the time between two rotations of the default user credentials, or 0 if they are not rotated periodically.
+func (cluster *RabbitmqCluster) DefaultUserRotationInterval() time.Duration {
+	if cluster.Spec.DefaultUser.Rotation == nil || cluster.Spec.DefaultUser.Rotation.Interval == nil {
+		return 0
+	}
+	return cluster.Spec.DefaultUser.Rotation.Interval.Duration
+}
+
+// DefaultUserRotationGracePeriod returns the time during which the previous default user credentials stay valid after a rotation.
+func (cluster *RabbitmqCluster) DefaultUserRotationGracePeriod() time.Duration {
+	if cluster.Spec.DefaultUser.Rotation == nil || cluster.Spec.DefaultUser.Rotation.GracePeriod == nil {
+		return time.Hour
+	}
+	return cluster.Spec.DefaultUser.Rotation.GracePeriod.Duration
+}
+
+func (cluster *RabbitmqCluster) ReplicationEnabled() bool {
+	return cluster.Spec.Replication != nil
+}
+
+// ReplicationUpstream returns the namespaced name of the upstream RabbitmqCluster, or an empty one if replication is not configured.
+func (cluster *RabbitmqCluster) ReplicationUpstream() types.NamespacedName {
+	if cluster.Spec.Replication == nil {
+		return types.NamespacedName{}
+	}
+	return cluster.ReferencedRabbitmqCluster(cluster.Spec.Replication.Upstream)
+}
+
+// ReferencedRabbitmqCluster returns the namespaced name of a RabbitmqCluster referenced in the spec,
+// which is in the namespace of this RabbitmqCluster unless specified otherwise.
+func (cluster *RabbitmqCluster) ReferencedRabbitmqCluster(ref RabbitmqClusterReference) types.NamespacedName {
+	name := types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}
+	if name.Namespace == "" {
+		name.Namespace = cluster.Namespace
+	}
+	return name
+}
+
+// LinkEnabled returns true if spec.links has a link of the given type.
+func (cluster *RabbitmqCluster) LinkEnabled(linkType LinkType) bool {
+	return slices.ContainsFunc(cluster.Spec.Links, func(link LinkSpec) bool {
+		return link.Type == linkType
+	})
+}
+
+func (cluster *RabbitmqCluster) OAuth2Enabled() bool {
+	return cluster.Spec.Auth.OAuth2 != nil
+}
+
+// OAuth2ClientSecretEnabled returns true if the management UI authenticates to the OAuth 2.0 provider with a client secret
+func (cluster *RabbitmqCluster) OAuth2ClientSecretEnabled() bool {
+	return cluster.OAuth2Enabled() && cluster.Spec.Auth.OAuth2.Management != nil && cluster.Spec.Auth.OAuth2.Management.ClientSecret != nil
+}
+
+func (cluster *RabbitmqCluster) LDAPEnabled() bool {
+	return cluster.Spec.Auth.LDAP != nil
+}
+
+// LDAPBindPasswordEnabled returns true if the LDAP bind user authenticates with a password
+func (cluster *RabbitmqCluster) LDAPBindPasswordEnabled() bool {
+	return cluster.LDAPEnabled() && cluster.Spec.Auth.LDAP.BindPassword != nil
+}
+
+func (cluster *RabbitmqCluster) DefinitionsBackupEnabled() bool {
+	return cluster.Spec.Backup.Definitions != nil
+}
+
+// DefinitionsBackupStorageType returns the kind of object definitions exports are stored in, Secret by default.
+func (cluster *RabbitmqCluster) DefinitionsBackupStorageType() DefinitionsBackupStorageType {
+	if cluster.Spec.Backup.Definitions == nil || cluster.Spec.Backup.Definitions.StorageType == "" {
+		return DefinitionsBackupStorageSecret
+	}
+	return cluster.Spec.Backup.Definitions.StorageType
+}
+
+// DefinitionsBackupRetention returns the number of definitions exports to keep, 7 by default.
+func (cluster *RabbitmqCluster) DefinitionsBackupRetention() int {
+	if cluster.Spec.Backup.Definitions == nil || cluster.Spec.Backup.Definitions.Retention < 1 {
+		return 7
+	}
+	return int(cluster.Spec.Backup.Definitions.Retention)
+}
+
 func (cluster *RabbitmqCluster) VaultTLSEnabled() bool {
 	return cluster.VaultEnabled() && cluster.Spec.SecretBackend.Vault.TLSEnabled()
 }
@@ -542,6 +1336,48 @@ func (cluster *RabbitmqCluster) DisableDefaultTopologySpreadConstraints() bool {
 	return false
 }
 
+// RestartPolicy is Automatic, the default, or Manual.
+// +kubebuilder:validation:Enum=Automatic;Manual
+type RestartPolicy string
+
+const (
+	RestartPolicyAutomatic RestartPolicy = "Automatic"
+	RestartPolicyManual    RestartPolicy = "Manual"
+)
+
+// MaintenanceWindow is a recurring period in which disruptive changes are applied.
+type MaintenanceWindow struct {
+	// Start of the windows in cron format, for example "0 2 * * sat", or one of @hourly, @daily, @weekly, @monthly and @yearly.
+	// Times are in UTC.
+	// +kubebuilder:validation:MinLength:=1
+	Schedule string `json:"schedule"`
+	// Length of each window, for example "4h".
+	Duration metav1.Duration `json:"duration"`
+}
+
+// DeferredAction is a disruptive change held back until a maintenance window.
+// +kubebuilder:validation:Enum=PodTemplateRollout;ConfigurationRestart;PersistentVolumeExpansion;PluginChange;QueueRebalance
+type DeferredAction string
+
+const (
+	DeferredPodTemplateRollout        DeferredAction = "PodTemplateRollout"
+	DeferredConfigurationRestart      DeferredAction = "ConfigurationRestart"
+	DeferredPersistentVolumeExpansion DeferredAction = "PersistentVolumeExpansion"
+	DeferredPluginChange              DeferredAction = "PluginChange"
+	DeferredQueueRebalance            DeferredAction = "QueueRebalance"
+)
+
+// ManualRestart returns true if restarts caused by configuration changes need to be approved
+func (cluster *RabbitmqCluster) ManualRestart() bool {
+	return cluster.Spec.RestartPolicy == RestartPolicyManual
+}
+
+// RestartApproved returns true if the RabbitmqCluster is annotated with rabbitmq.com/approve-restart
+func (cluster *RabbitmqCluster) RestartApproved() bool {
+	_, ok := cluster.Annotations[ApproveRestartAnnotation]
+	return ok
+}
+
 func (cluster *RabbitmqCluster) GetRabbitMQVersion() string {
 	if cluster.Annotations == nil {
 		return VersionNotAnnotated
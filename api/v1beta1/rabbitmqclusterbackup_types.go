@@ -0,0 +1,114 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	k8sresource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Cluster",type="string",JSONPath=".spec.rabbitmqClusterReference.name"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:resource:shortName={"rmqbackup"},categories=rabbitmq
+// RabbitmqClusterBackup takes a consistent set of VolumeSnapshots of the persistent volumes of a RabbitmqCluster,
+// together with its definitions, its manifest and the credentials needed to restore it with a RabbitmqClusterRestore.
+// The VolumeSnapshots are deleted with the RabbitmqClusterBackup, subject to the deletion policy of their VolumeSnapshotClass.
+type RabbitmqClusterBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RabbitmqClusterBackupSpec   `json:"spec,omitempty"`
+	Status RabbitmqClusterBackupStatus `json:"status,omitempty"`
+}
+
+// BackupQuiesceMode is how writes are stopped while the VolumeSnapshots are taken.
+// +kubebuilder:validation:Enum=None;BlockPublishers;Drain
+type BackupQuiesceMode string
+
+const (
+	// Snapshots are crash consistent. Messages published while the snapshots are taken may be on some volumes only.
+	BackupQuiesceNone BackupQuiesceMode = "None"
+	// Publishers are blocked on all nodes by setting the memory high watermark to 0, and unblocked once all snapshots are taken.
+	// Consumers keep consuming.
+	BackupQuiesceBlockPublishers BackupQuiesceMode = "BlockPublishers"
+	// All nodes are put into maintenance mode with "rabbitmq-upgrade drain", which closes all client connections,
+	// and revived once all snapshots are taken.
+	BackupQuiesceDrain BackupQuiesceMode = "Drain"
+)
+
+// RabbitmqClusterBackupSpec is the desired state of a RabbitmqClusterBackup.
+// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="spec is immutable"
+type RabbitmqClusterBackupSpec struct {
+	// RabbitmqCluster to back up, in the namespace of the RabbitmqClusterBackup. The RabbitmqCluster must use persistent storage.
+	RabbitmqClusterReference corev1.LocalObjectReference `json:"rabbitmqClusterReference"`
+	// Name of the VolumeSnapshotClass used for the VolumeSnapshots. If unset, the default VolumeSnapshotClass is used.
+	// +optional
+	VolumeSnapshotClassName string `json:"volumeSnapshotClassName,omitempty"`
+	// How writes are stopped while the VolumeSnapshots are taken.
+	// +kubebuilder:default:="None"
+	Quiesce BackupQuiesceMode `json:"quiesce,omitempty"`
+}
+
+// BackupPhase is the progress of a RabbitmqClusterBackup or a RabbitmqClusterRestore.
+type BackupPhase string
+
+const (
+	BackupPhaseInProgress BackupPhase = "InProgress"
+	BackupPhaseCompleted  BackupPhase = "Completed"
+	BackupPhaseFailed     BackupPhase = "Failed"
+)
+
+// RabbitmqClusterBackupStatus is the observed state of a RabbitmqClusterBackup.
+type RabbitmqClusterBackupStatus struct {
+	// One of InProgress, Completed and Failed.
+	Phase BackupPhase `json:"phase,omitempty"`
+	// Reason of a failure.
+	Message string `json:"message,omitempty"`
+	// Time the backup started.
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// Time all VolumeSnapshots became ready to use.
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// Name of the Secret holding the definitions, the manifest of the RabbitmqCluster, the Erlang cookie and the default user credentials.
+	SecretName string `json:"secretName,omitempty"`
+	// VolumeSnapshots of the persistent volumes, one per node.
+	VolumeSnapshots []BackupVolumeSnapshot `json:"volumeSnapshots,omitempty"`
+	// True while writes are stopped according to spec.quiesce.
+	Quiesced bool `json:"quiesced,omitempty"`
+}
+
+// BackupVolumeSnapshot is the VolumeSnapshot of the persistent volume of a single node.
+type BackupVolumeSnapshot struct {
+	// Name of the PersistentVolumeClaim the snapshot was taken from.
+	PersistentVolumeClaimName string `json:"persistentVolumeClaimName"`
+	// Name of the VolumeSnapshot.
+	VolumeSnapshotName string `json:"volumeSnapshotName"`
+	// True once the snapshot has been cut. Writes are resumed once all snapshots are cut.
+	Taken bool `json:"taken,omitempty"`
+	// True once the snapshot can be used to provision volumes.
+	ReadyToUse bool `json:"readyToUse,omitempty"`
+	// Minimum size of a volume restored from the snapshot.
+	RestoreSize *k8sresource.Quantity `json:"restoreSize,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RabbitmqClusterBackupList contains a list of RabbitmqClusterBackups.
+type RabbitmqClusterBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RabbitmqClusterBackup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RabbitmqClusterBackup{}, &RabbitmqClusterBackupList{})
+}
@@ -0,0 +1,66 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RestoredFromAnnotation is set on RabbitmqClusters created by a RabbitmqClusterRestore, with the name of the RabbitmqClusterRestore
+const RestoredFromAnnotation = "rabbitmq.com/restoredFrom"
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Backup",type="string",JSONPath=".spec.backupReference.name"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:resource:shortName={"rmqrestore"},categories=rabbitmq
+// RabbitmqClusterRestore creates a RabbitmqCluster from a completed RabbitmqClusterBackup in the same namespace.
+// The RabbitmqCluster has the name of the backed up RabbitmqCluster, so that the node names stored on the volumes match,
+// and its PersistentVolumeClaims are provisioned from the VolumeSnapshots of the backup.
+// The Erlang cookie and the default user credentials of the backed up RabbitmqCluster are restored as well.
+// A RabbitmqCluster with that name, or its PersistentVolumeClaims, must not exist.
+type RabbitmqClusterRestore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RabbitmqClusterRestoreSpec   `json:"spec,omitempty"`
+	Status RabbitmqClusterRestoreStatus `json:"status,omitempty"`
+}
+
+// RabbitmqClusterRestoreSpec is the desired state of a RabbitmqClusterRestore.
+// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="spec is immutable"
+type RabbitmqClusterRestoreSpec struct {
+	// RabbitmqClusterBackup to restore, in the namespace of the RabbitmqClusterRestore.
+	BackupReference corev1.LocalObjectReference `json:"backupReference"`
+}
+
+// RabbitmqClusterRestoreStatus is the observed state of a RabbitmqClusterRestore.
+type RabbitmqClusterRestoreStatus struct {
+	// One of InProgress, Completed and Failed.
+	Phase BackupPhase `json:"phase,omitempty"`
+	// Reason of a failure, or what the restore is waiting for.
+	Message string `json:"message,omitempty"`
+	// Name of the restored RabbitmqCluster.
+	RabbitmqClusterName string `json:"rabbitmqClusterName,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RabbitmqClusterRestoreList contains a list of RabbitmqClusterRestores.
+type RabbitmqClusterRestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RabbitmqClusterRestore `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RabbitmqClusterRestore{}, &RabbitmqClusterRestoreList{})
+}
@@ -28,15 +28,577 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdditionalConfigSource) DeepCopyInto(out *AdditionalConfigSource) {
+	*out = *in
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(v1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecretKeyRef != nil {
+		in, out := &in.SecretKeyRef, &out.SecretKeyRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdditionalConfigSource.
+func (in *AdditionalConfigSource) DeepCopy() *AdditionalConfigSource {
+	if in == nil {
+		return nil
+	}
+	out := new(AdditionalConfigSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuthSpec) DeepCopyInto(out *AuthSpec) {
+	*out = *in
+	if in.OAuth2 != nil {
+		in, out := &in.OAuth2, &out.OAuth2
+		*out = new(OAuth2Spec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LDAP != nil {
+		in, out := &in.LDAP, &out.LDAP
+		*out = new(LDAPSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthSpec.
+func (in *AuthSpec) DeepCopy() *AuthSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AuthSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupSpec) DeepCopyInto(out *BackupSpec) {
+	*out = *in
+	if in.Definitions != nil {
+		in, out := &in.Definitions, &out.Definitions
+		*out = new(DefinitionsBackupSpec)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupSpec.
+func (in *BackupSpec) DeepCopy() *BackupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupVolumeSnapshot) DeepCopyInto(out *BackupVolumeSnapshot) {
+	*out = *in
+	if in.RestoreSize != nil {
+		in, out := &in.RestoreSize, &out.RestoreSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupVolumeSnapshot.
+func (in *BackupVolumeSnapshot) DeepCopy() *BackupVolumeSnapshot {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupVolumeSnapshot)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertManagerIssuerReference) DeepCopyInto(out *CertManagerIssuerReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertManagerIssuerReference.
+func (in *CertManagerIssuerReference) DeepCopy() *CertManagerIssuerReference {
+	if in == nil {
+		return nil
+	}
+	out := new(CertManagerIssuerReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertManagerTLSSpec) DeepCopyInto(out *CertManagerTLSSpec) {
+	*out = *in
+	out.IssuerRef = in.IssuerRef
+	if in.AdditionalDNSNames != nil {
+		in, out := &in.AdditionalDNSNames, &out.AdditionalDNSNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.RenewBefore != nil {
+		in, out := &in.RenewBefore, &out.RenewBefore
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertManagerTLSSpec.
+func (in *CertManagerTLSSpec) DeepCopy() *CertManagerTLSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CertManagerTLSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigurationStatus) DeepCopyInto(out *ConfigurationStatus) {
+	*out = *in
+	if in.LastChangeTime != nil {
+		in, out := &in.LastChangeTime, &out.LastChangeTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LiveKeys != nil {
+		in, out := &in.LiveKeys, &out.LiveKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RestartKeys != nil {
+		in, out := &in.RestartKeys, &out.RestartKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigurationStatus.
+func (in *ConfigurationStatus) DeepCopy() *ConfigurationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigurationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DefaultUserRotationSpec) DeepCopyInto(out *DefaultUserRotationSpec) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.GracePeriod != nil {
+		in, out := &in.GracePeriod, &out.GracePeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DefaultUserRotationSpec.
+func (in *DefaultUserRotationSpec) DeepCopy() *DefaultUserRotationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DefaultUserRotationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DefaultUserSpec) DeepCopyInto(out *DefaultUserSpec) {
+	*out = *in
+	if in.Rotation != nil {
+		in, out := &in.Rotation, &out.Rotation
+		*out = new(DefaultUserRotationSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DefaultUserSpec.
+func (in *DefaultUserSpec) DeepCopy() *DefaultUserSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DefaultUserSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DefinitionsBackupSpec) DeepCopyInto(out *DefinitionsBackupSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DefinitionsBackupSpec.
+func (in *DefinitionsBackupSpec) DeepCopy() *DefinitionsBackupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DefinitionsBackupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DefinitionsBackupStatus) DeepCopyInto(out *DefinitionsBackupStatus) {
+	*out = *in
+	if in.LastScheduleTime != nil {
+		in, out := &in.LastScheduleTime, &out.LastScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccessfulExportTime != nil {
+		in, out := &in.LastSuccessfulExportTime, &out.LastSuccessfulExportTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DefinitionsBackupStatus.
+func (in *DefinitionsBackupStatus) DeepCopy() *DefinitionsBackupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DefinitionsBackupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DefinitionsSource) DeepCopyInto(out *DefinitionsSource) {
+	*out = *in
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.Secret != nil {
+		in, out := &in.Secret, &out.Secret
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DefinitionsSource.
+func (in *DefinitionsSource) DeepCopy() *DefinitionsSource {
+	if in == nil {
+		return nil
+	}
+	out := new(DefinitionsSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DefinitionsSpec) DeepCopyInto(out *DefinitionsSpec) {
+	*out = *in
+	if in.Sources != nil {
+		in, out := &in.Sources, &out.Sources
+		*out = make([]DefinitionsSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DefinitionsSpec.
+func (in *DefinitionsSpec) DeepCopy() *DefinitionsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DefinitionsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EmbeddedLabelsAnnotations) DeepCopyInto(out *EmbeddedLabelsAnnotations) {
 	*out = *in
-	if in.Labels != nil {
-		in, out := &in.Labels, &out.Labels
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EmbeddedLabelsAnnotations.
+func (in *EmbeddedLabelsAnnotations) DeepCopy() *EmbeddedLabelsAnnotations {
+	if in == nil {
+		return nil
+	}
+	out := new(EmbeddedLabelsAnnotations)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EmbeddedObjectMeta) DeepCopyInto(out *EmbeddedObjectMeta) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EmbeddedObjectMeta.
+func (in *EmbeddedObjectMeta) DeepCopy() *EmbeddedObjectMeta {
+	if in == nil {
+		return nil
+	}
+	out := new(EmbeddedObjectMeta)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationLinkSpec) DeepCopyInto(out *FederationLinkSpec) {
+	*out = *in
+	if in.MaxHops != nil {
+		in, out := &in.MaxHops, &out.MaxHops
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationLinkSpec.
+func (in *FederationLinkSpec) DeepCopy() *FederationLinkSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationLinkSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LDAPDNLookupSpec) DeepCopyInto(out *LDAPDNLookupSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LDAPDNLookupSpec.
+func (in *LDAPDNLookupSpec) DeepCopy() *LDAPDNLookupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LDAPDNLookupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LDAPSpec) DeepCopyInto(out *LDAPSpec) {
+	*out = *in
+	if in.Servers != nil {
+		in, out := &in.Servers, &out.Servers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Port != nil {
+		in, out := &in.Port, &out.Port
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(LDAPTLSSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DNLookup != nil {
+		in, out := &in.DNLookup, &out.DNLookup
+		*out = new(LDAPDNLookupSpec)
+		**out = **in
+	}
+	if in.BindPassword != nil {
+		in, out := &in.BindPassword, &out.BindPassword
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LDAPSpec.
+func (in *LDAPSpec) DeepCopy() *LDAPSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LDAPSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LDAPTLSSpec) DeepCopyInto(out *LDAPTLSSpec) {
+	*out = *in
+	if in.CACertificate != nil {
+		in, out := &in.CACertificate, &out.CACertificate
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LDAPTLSSpec.
+func (in *LDAPTLSSpec) DeepCopy() *LDAPTLSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LDAPTLSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LinkSpec) DeepCopyInto(out *LinkSpec) {
+	*out = *in
+	out.RabbitmqCluster = in.RabbitmqCluster
+	if in.Federation != nil {
+		in, out := &in.Federation, &out.Federation
+		*out = new(FederationLinkSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Shovel != nil {
+		in, out := &in.Shovel, &out.Shovel
+		*out = new(ShovelLinkSpec)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LinkSpec.
+func (in *LinkSpec) DeepCopy() *LinkSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LinkSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LinkStatus) DeepCopyInto(out *LinkStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LinkStatus.
+func (in *LinkStatus) DeepCopy() *LinkStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LinkStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ListenerTLSSpec) DeepCopyInto(out *ListenerTLSSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ListenerTLSSpec.
+func (in *ListenerTLSSpec) DeepCopy() *ListenerTLSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ListenerTLSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceStatus) DeepCopyInto(out *MaintenanceStatus) {
+	*out = *in
+	if in.DeferredActions != nil {
+		in, out := &in.DeferredActions, &out.DeferredActions
+		*out = make([]DeferredAction, len(*in))
+		copy(*out, *in)
+	}
+	if in.NextWindowStart != nil {
+		in, out := &in.NextWindowStart, &out.NextWindowStart
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceStatus.
+func (in *MaintenanceStatus) DeepCopy() *MaintenanceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
+	*out = *in
+	out.Duration = in.Duration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindow.
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagementGatewayParentRef) DeepCopyInto(out *ManagementGatewayParentRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagementGatewayParentRef.
+func (in *ManagementGatewayParentRef) DeepCopy() *ManagementGatewayParentRef {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagementGatewayParentRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagementIngressSpec) DeepCopyInto(out *ManagementIngressSpec) {
+	*out = *in
+	if in.IngressClassName != nil {
+		in, out := &in.IngressClassName, &out.IngressClassName
+		*out = new(string)
+		**out = **in
+	}
+	if in.ParentRefs != nil {
+		in, out := &in.ParentRefs, &out.ParentRefs
+		*out = make([]ManagementGatewayParentRef, len(*in))
+		copy(*out, *in)
 	}
 	if in.Annotations != nil {
 		in, out := &in.Annotations, &out.Annotations
@@ -47,41 +609,132 @@ func (in *EmbeddedLabelsAnnotations) DeepCopyInto(out *EmbeddedLabelsAnnotations
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EmbeddedLabelsAnnotations.
-func (in *EmbeddedLabelsAnnotations) DeepCopy() *EmbeddedLabelsAnnotations {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagementIngressSpec.
+func (in *ManagementIngressSpec) DeepCopy() *ManagementIngressSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(EmbeddedLabelsAnnotations)
+	out := new(ManagementIngressSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *EmbeddedObjectMeta) DeepCopyInto(out *EmbeddedObjectMeta) {
+func (in *ManagementSpec) DeepCopyInto(out *ManagementSpec) {
 	*out = *in
-	if in.Labels != nil {
-		in, out := &in.Labels, &out.Labels
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
+	if in.Ingress != nil {
+		in, out := &in.Ingress, &out.Ingress
+		*out = new(ManagementIngressSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagementSpec.
+func (in *ManagementSpec) DeepCopy() *ManagementSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagementSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodePluginsStatus) DeepCopyInto(out *NodePluginsStatus) {
+	*out = *in
+	if in.EnabledPlugins != nil {
+		in, out := &in.EnabledPlugins, &out.EnabledPlugins
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodePluginsStatus.
+func (in *NodePluginsStatus) DeepCopy() *NodePluginsStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodePluginsStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OAuth2ManagementSpec) DeepCopyInto(out *OAuth2ManagementSpec) {
+	*out = *in
+	if in.ClientSecret != nil {
+		in, out := &in.ClientSecret, &out.ClientSecret
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OAuth2ManagementSpec.
+func (in *OAuth2ManagementSpec) DeepCopy() *OAuth2ManagementSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OAuth2ManagementSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OAuth2SigningKey) DeepCopyInto(out *OAuth2SigningKey) {
+	*out = *in
+	in.SecretKeyRef.DeepCopyInto(&out.SecretKeyRef)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OAuth2SigningKey.
+func (in *OAuth2SigningKey) DeepCopy() *OAuth2SigningKey {
+	if in == nil {
+		return nil
+	}
+	out := new(OAuth2SigningKey)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OAuth2Spec) DeepCopyInto(out *OAuth2Spec) {
+	*out = *in
+	if in.CACertificate != nil {
+		in, out := &in.CACertificate, &out.CACertificate
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SigningKeys != nil {
+		in, out := &in.SigningKeys, &out.SigningKeys
+		*out = make([]OAuth2SigningKey, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	if in.Annotations != nil {
-		in, out := &in.Annotations, &out.Annotations
+	if in.PreferredUsernameClaims != nil {
+		in, out := &in.PreferredUsernameClaims, &out.PreferredUsernameClaims
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ScopeAliases != nil {
+		in, out := &in.ScopeAliases, &out.ScopeAliases
 		*out = make(map[string]string, len(*in))
 		for key, val := range *in {
 			(*out)[key] = val
 		}
 	}
+	if in.Management != nil {
+		in, out := &in.Management, &out.Management
+		*out = new(OAuth2ManagementSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EmbeddedObjectMeta.
-func (in *EmbeddedObjectMeta) DeepCopy() *EmbeddedObjectMeta {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OAuth2Spec.
+func (in *OAuth2Spec) DeepCopy() *OAuth2Spec {
 	if in == nil {
 		return nil
 	}
-	out := new(EmbeddedObjectMeta)
+	out := new(OAuth2Spec)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -104,6 +757,41 @@ func (in *PersistentVolumeClaim) DeepCopy() *PersistentVolumeClaim {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PluginImageSource) DeepCopyInto(out *PluginImageSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PluginImageSource.
+func (in *PluginImageSource) DeepCopy() *PluginImageSource {
+	if in == nil {
+		return nil
+	}
+	out := new(PluginImageSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PluginSource) DeepCopyInto(out *PluginSource) {
+	*out = *in
+	if in.Image != nil {
+		in, out := &in.Image, &out.Image
+		*out = new(PluginImageSource)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PluginSource.
+func (in *PluginSource) DeepCopy() *PluginSource {
+	if in == nil {
+		return nil
+	}
+	out := new(PluginSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PodTemplateSpec) DeepCopyInto(out *PodTemplateSpec) {
 	*out = *in
@@ -156,6 +844,111 @@ func (in *RabbitmqCluster) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitmqClusterBackup) DeepCopyInto(out *RabbitmqClusterBackup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitmqClusterBackup.
+func (in *RabbitmqClusterBackup) DeepCopy() *RabbitmqClusterBackup {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitmqClusterBackup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RabbitmqClusterBackup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitmqClusterBackupList) DeepCopyInto(out *RabbitmqClusterBackupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]RabbitmqClusterBackup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitmqClusterBackupList.
+func (in *RabbitmqClusterBackupList) DeepCopy() *RabbitmqClusterBackupList {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitmqClusterBackupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RabbitmqClusterBackupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitmqClusterBackupSpec) DeepCopyInto(out *RabbitmqClusterBackupSpec) {
+	*out = *in
+	out.RabbitmqClusterReference = in.RabbitmqClusterReference
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitmqClusterBackupSpec.
+func (in *RabbitmqClusterBackupSpec) DeepCopy() *RabbitmqClusterBackupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitmqClusterBackupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitmqClusterBackupStatus) DeepCopyInto(out *RabbitmqClusterBackupStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.VolumeSnapshots != nil {
+		in, out := &in.VolumeSnapshots, &out.VolumeSnapshots
+		*out = make([]BackupVolumeSnapshot, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitmqClusterBackupStatus.
+func (in *RabbitmqClusterBackupStatus) DeepCopy() *RabbitmqClusterBackupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitmqClusterBackupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RabbitmqClusterConfigurationSpec) DeepCopyInto(out *RabbitmqClusterConfigurationSpec) {
 	*out = *in
@@ -164,6 +957,25 @@ func (in *RabbitmqClusterConfigurationSpec) DeepCopyInto(out *RabbitmqClusterCon
 		*out = make([]Plugin, len(*in))
 		copy(*out, *in)
 	}
+	if in.PluginSources != nil {
+		in, out := &in.PluginSources, &out.PluginSources
+		*out = make([]PluginSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AdditionalConfigFrom != nil {
+		in, out := &in.AdditionalConfigFrom, &out.AdditionalConfigFrom
+		*out = make([]AdditionalConfigSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Definitions != nil {
+		in, out := &in.Definitions, &out.Definitions
+		*out = new(DefinitionsSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitmqClusterConfigurationSpec.
@@ -308,6 +1120,111 @@ func (in *RabbitmqClusterPersistenceSpec) DeepCopy() *RabbitmqClusterPersistence
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitmqClusterReference) DeepCopyInto(out *RabbitmqClusterReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitmqClusterReference.
+func (in *RabbitmqClusterReference) DeepCopy() *RabbitmqClusterReference {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitmqClusterReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitmqClusterRestore) DeepCopyInto(out *RabbitmqClusterRestore) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitmqClusterRestore.
+func (in *RabbitmqClusterRestore) DeepCopy() *RabbitmqClusterRestore {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitmqClusterRestore)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RabbitmqClusterRestore) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitmqClusterRestoreList) DeepCopyInto(out *RabbitmqClusterRestoreList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]RabbitmqClusterRestore, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitmqClusterRestoreList.
+func (in *RabbitmqClusterRestoreList) DeepCopy() *RabbitmqClusterRestoreList {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitmqClusterRestoreList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RabbitmqClusterRestoreList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitmqClusterRestoreSpec) DeepCopyInto(out *RabbitmqClusterRestoreSpec) {
+	*out = *in
+	out.BackupReference = in.BackupReference
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitmqClusterRestoreSpec.
+func (in *RabbitmqClusterRestoreSpec) DeepCopy() *RabbitmqClusterRestoreSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitmqClusterRestoreSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitmqClusterRestoreStatus) DeepCopyInto(out *RabbitmqClusterRestoreStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitmqClusterRestoreStatus.
+func (in *RabbitmqClusterRestoreStatus) DeepCopy() *RabbitmqClusterRestoreStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitmqClusterRestoreStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RabbitmqClusterSecretReference) DeepCopyInto(out *RabbitmqClusterSecretReference) {
 	*out = *in
@@ -412,8 +1329,15 @@ func (in *RabbitmqClusterSpec) DeepCopyInto(out *RabbitmqClusterSpec) {
 		}
 	}
 	in.Rabbitmq.DeepCopyInto(&out.Rabbitmq)
-	out.TLS = in.TLS
+	in.TLS.DeepCopyInto(&out.TLS)
+	in.Stream.DeepCopyInto(&out.Stream)
+	in.Management.DeepCopyInto(&out.Management)
 	in.Override.DeepCopyInto(&out.Override)
+	if in.MaintenanceWindows != nil {
+		in, out := &in.MaintenanceWindows, &out.MaintenanceWindows
+		*out = make([]MaintenanceWindow, len(*in))
+		copy(*out, *in)
+	}
 	if in.TerminationGracePeriodSeconds != nil {
 		in, out := &in.TerminationGracePeriodSeconds, &out.TerminationGracePeriodSeconds
 		*out = new(int64)
@@ -425,6 +1349,21 @@ func (in *RabbitmqClusterSpec) DeepCopyInto(out *RabbitmqClusterSpec) {
 		**out = **in
 	}
 	in.SecretBackend.DeepCopyInto(&out.SecretBackend)
+	in.DefaultUser.DeepCopyInto(&out.DefaultUser)
+	in.Backup.DeepCopyInto(&out.Backup)
+	if in.Replication != nil {
+		in, out := &in.Replication, &out.Replication
+		*out = new(ReplicationSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Links != nil {
+		in, out := &in.Links, &out.Links
+		*out = make([]LinkSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.Auth.DeepCopyInto(&out.Auth)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitmqClusterSpec.
@@ -462,6 +1401,48 @@ func (in *RabbitmqClusterStatus) DeepCopyInto(out *RabbitmqClusterStatus) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.StreamAdvertisedAddresses != nil {
+		in, out := &in.StreamAdvertisedAddresses, &out.StreamAdvertisedAddresses
+		*out = make([]StreamAdvertisedAddress, len(*in))
+		copy(*out, *in)
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(TLSStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DefinitionsBackup != nil {
+		in, out := &in.DefinitionsBackup, &out.DefinitionsBackup
+		*out = new(DefinitionsBackupStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Replication != nil {
+		in, out := &in.Replication, &out.Replication
+		*out = new(ReplicationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Links != nil {
+		in, out := &in.Links, &out.Links
+		*out = make([]LinkStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Configuration != nil {
+		in, out := &in.Configuration, &out.Configuration
+		*out = new(ConfigurationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Maintenance != nil {
+		in, out := &in.Maintenance, &out.Maintenance
+		*out = new(MaintenanceStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Plugins != nil {
+		in, out := &in.Plugins, &out.Plugins
+		*out = make([]NodePluginsStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitmqClusterStatus.
@@ -474,6 +1455,55 @@ func (in *RabbitmqClusterStatus) DeepCopy() *RabbitmqClusterStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicationSpec) DeepCopyInto(out *ReplicationSpec) {
+	*out = *in
+	out.Upstream = in.Upstream
+	if in.VirtualHosts != nil {
+		in, out := &in.VirtualHosts, &out.VirtualHosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationSpec.
+func (in *ReplicationSpec) DeepCopy() *ReplicationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicationStatus) DeepCopyInto(out *ReplicationStatus) {
+	*out = *in
+	if in.LastReplicatedMessageTime != nil {
+		in, out := &in.LastReplicatedMessageTime, &out.LastReplicatedMessageTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Lag != nil {
+		in, out := &in.Lag, &out.Lag
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.LastCheckTime != nil {
+		in, out := &in.LastCheckTime, &out.LastCheckTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationStatus.
+func (in *ReplicationStatus) DeepCopy() *ReplicationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecretBackend) DeepCopyInto(out *SecretBackend) {
 	*out = *in
@@ -520,6 +1550,21 @@ func (in *Service) DeepCopy() *Service {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ShovelLinkSpec) DeepCopyInto(out *ShovelLinkSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShovelLinkSpec.
+func (in *ShovelLinkSpec) DeepCopy() *ShovelLinkSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ShovelLinkSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StatefulSet) DeepCopyInto(out *StatefulSet) {
 	*out = *in
@@ -592,9 +1637,151 @@ func (in *StatefulSetSpec) DeepCopy() *StatefulSetSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StreamAdvertisedAddress) DeepCopyInto(out *StreamAdvertisedAddress) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StreamAdvertisedAddress.
+func (in *StreamAdvertisedAddress) DeepCopy() *StreamAdvertisedAddress {
+	if in == nil {
+		return nil
+	}
+	out := new(StreamAdvertisedAddress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StreamPerPodServicesSpec) DeepCopyInto(out *StreamPerPodServicesSpec) {
+	*out = *in
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StreamPerPodServicesSpec.
+func (in *StreamPerPodServicesSpec) DeepCopy() *StreamPerPodServicesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(StreamPerPodServicesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StreamSpec) DeepCopyInto(out *StreamSpec) {
+	*out = *in
+	if in.PerPodServices != nil {
+		in, out := &in.PerPodServices, &out.PerPodServices
+		*out = new(StreamPerPodServicesSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StreamSpec.
+func (in *StreamSpec) DeepCopy() *StreamSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(StreamSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TLSListenersSpec) DeepCopyInto(out *TLSListenersSpec) {
+	*out = *in
+	if in.AMQP != nil {
+		in, out := &in.AMQP, &out.AMQP
+		*out = new(ListenerTLSSpec)
+		**out = **in
+	}
+	if in.Management != nil {
+		in, out := &in.Management, &out.Management
+		*out = new(ListenerTLSSpec)
+		**out = **in
+	}
+	if in.Prometheus != nil {
+		in, out := &in.Prometheus, &out.Prometheus
+		*out = new(ListenerTLSSpec)
+		**out = **in
+	}
+	if in.Web != nil {
+		in, out := &in.Web, &out.Web
+		*out = new(ListenerTLSSpec)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLSListenersSpec.
+func (in *TLSListenersSpec) DeepCopy() *TLSListenersSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TLSListenersSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TLSOptionsSpec) DeepCopyInto(out *TLSOptionsSpec) {
+	*out = *in
+	if in.Versions != nil {
+		in, out := &in.Versions, &out.Versions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Ciphers != nil {
+		in, out := &in.Ciphers, &out.Ciphers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.HonorCipherOrder != nil {
+		in, out := &in.HonorCipherOrder, &out.HonorCipherOrder
+		*out = new(bool)
+		**out = **in
+	}
+	if in.FailIfNoPeerCert != nil {
+		in, out := &in.FailIfNoPeerCert, &out.FailIfNoPeerCert
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLSOptionsSpec.
+func (in *TLSOptionsSpec) DeepCopy() *TLSOptionsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TLSOptionsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TLSSpec) DeepCopyInto(out *TLSSpec) {
 	*out = *in
+	if in.CertManager != nil {
+		in, out := &in.CertManager, &out.CertManager
+		*out = new(CertManagerTLSSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Listeners != nil {
+		in, out := &in.Listeners, &out.Listeners
+		*out = new(TLSListenersSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Options != nil {
+		in, out := &in.Options, &out.Options
+		*out = new(TLSOptionsSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLSSpec.
@@ -607,6 +1794,25 @@ func (in *TLSSpec) DeepCopy() *TLSSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TLSStatus) DeepCopyInto(out *TLSStatus) {
+	*out = *in
+	if in.NotAfter != nil {
+		in, out := &in.NotAfter, &out.NotAfter
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLSStatus.
+func (in *TLSStatus) DeepCopy() *TLSStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TLSStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VaultSpec) DeepCopyInto(out *VaultSpec) {
 	*out = *in
@@ -11,6 +11,7 @@ package main
 import (
 	"crypto/fips140"
 	"crypto/tls"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -29,6 +30,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 
 	"k8s.io/klog/v2"
@@ -47,6 +49,7 @@ import (
 	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
 	controllers "github.com/rabbitmq/cluster-operator/v2/internal/controller"
 	"github.com/rabbitmq/cluster-operator/v2/internal/rabbitmqclient"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
 	webhookv1beta1 "github.com/rabbitmq/cluster-operator/v2/internal/webhook/v1beta1"
 	// +kubebuilder:scaffold:imports
 )
@@ -66,18 +69,21 @@ func init() {
 
 func main() {
 	var (
-		metricsAddr             string
-		metricsCertPath         string
-		metricsCertName         string
-		metricsCertKey          string
-		probeAddr               string
-		secureMetrics           bool
-		enableHTTP2             bool
-		defaultRabbitmqImage    = "rabbitmq:4.3.4-management"
-		controlRabbitmqImage    = false
-		defaultUserUpdaterImage = "ghcr.io/rabbitmq/default-user-credential-updater:1.0.14"
-		defaultImagePullSecrets = ""
-		tlsOpts                 []func(*tls.Config)
+		metricsAddr               string
+		metricsCertPath           string
+		metricsCertName           string
+		metricsCertKey            string
+		probeAddr                 string
+		secureMetrics             bool
+		enableHTTP2               bool
+		defaultRabbitmqImage      = "rabbitmq:4.3.4-management"
+		controlRabbitmqImage      = false
+		podExecFallback           = false
+		podExecDisabled           = false
+		defaultUserUpdaterImage   = "ghcr.io/rabbitmq/default-user-credential-updater:1.0.14"
+		defaultPluginFetcherImage = resource.DefaultPluginFetcherImage
+		defaultImagePullSecrets   = ""
+		tlsOpts                   []func(*tls.Config)
 	)
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to. "+
@@ -134,6 +140,10 @@ func main() {
 		defaultUserUpdaterImage = configuredDefaultUserUpdaterImage
 	}
 
+	if configuredDefaultPluginFetcherImage, ok := os.LookupEnv("DEFAULT_PLUGIN_FETCHER_IMAGE"); ok {
+		defaultPluginFetcherImage = configuredDefaultPluginFetcherImage
+	}
+
 	// EXPERIMENTAL: If the environment variable CONTROL_RABBITMQ_IMAGE is set to `true`, the operator will
 	// automatically set the default image tags. (DEFAULT_RABBITMQ_IMAGE and DEFAULT_USER_UPDATER_IMAGE)
 	// No safety checks!
@@ -145,6 +155,31 @@ func main() {
 		}
 	}
 
+	// If the environment variable ENABLE_POD_EXEC_FALLBACK is set to `true`, the operator execs into RabbitMQ pods
+	// for routine operations, such as enabling feature flags, when the management API fails to run them.
+	if configuredPodExecFallback, ok := os.LookupEnv("ENABLE_POD_EXEC_FALLBACK"); ok {
+		var err error
+		if podExecFallback, err = strconv.ParseBool(configuredPodExecFallback); err != nil {
+			log.Error(err, "unable to start manager")
+			os.Exit(1)
+		}
+	}
+
+	// If the environment variable DISABLE_POD_EXEC is set to `true`, the operator does not exec into RabbitMQ pods
+	// to reconcile RabbitmqClusters: plugin and runtime configuration changes restart the nodes instead.
+	// Features which cannot work without pod exec still use it, see docs/pod-exec.md.
+	if configuredPodExecDisabled, ok := os.LookupEnv("DISABLE_POD_EXEC"); ok {
+		var err error
+		if podExecDisabled, err = strconv.ParseBool(configuredPodExecDisabled); err != nil {
+			log.Error(err, "unable to start manager")
+			os.Exit(1)
+		}
+	}
+	if podExecDisabled && podExecFallback {
+		log.Error(errors.New("DISABLE_POD_EXEC and ENABLE_POD_EXEC_FALLBACK must not both be set to true"), "unable to start manager")
+		os.Exit(1)
+	}
+
 	if configuredDefaultImagePullSecrets, ok := os.LookupEnv("DEFAULT_IMAGE_PULL_SECRETS"); ok {
 		defaultImagePullSecrets = configuredDefaultImagePullSecrets
 	}
@@ -214,6 +249,7 @@ func main() {
 		&discoveryv1.EndpointSlice{}:       {Label: rmqSelector},
 		&rbacv1.Role{}:                     {Label: rmqSelector},
 		&rbacv1.RoleBinding{}:              {Label: rmqSelector},
+		&networkingv1.Ingress{}:            {Label: rmqSelector},
 	}
 
 	if leaseDuration := getEnvInDuration("LEASE_DURATION"); leaseDuration != 0 {
@@ -260,6 +296,8 @@ func main() {
 		ClusterConfig:           clusterConfig,
 		Clientset:               kubernetes.NewForConfigOrDie(clusterConfig),
 		PodExecutor:             controllers.NewPodExecutor(),
+		PodExecFallback:         podExecFallback,
+		PodExecDisabled:         podExecDisabled,
 		DefaultRabbitmqImage:    defaultRabbitmqImage,
 		DefaultUserUpdaterImage: defaultUserUpdaterImage,
 		DefaultImagePullSecrets: defaultImagePullSecrets,
@@ -269,6 +307,29 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err = (&controllers.RabbitmqClusterBackupReconciler{
+		Client:        mgr.GetClient(),
+		APIReader:     mgr.GetAPIReader(),
+		Scheme:        mgr.GetScheme(),
+		Recorder:      mgr.GetEventRecorderFor("rabbitmqclusterbackup-controller"),
+		ClusterConfig: clusterConfig,
+		Clientset:     kubernetes.NewForConfigOrDie(clusterConfig),
+		PodExecutor:   controllers.NewPodExecutor(),
+	}).SetupWithManager(mgr); err != nil {
+		log.Error(err, "unable to create controller", "controller", "rabbitmqclusterbackup")
+		os.Exit(1)
+	}
+
+	if err = (&controllers.RabbitmqClusterRestoreReconciler{
+		Client:    mgr.GetClient(),
+		APIReader: mgr.GetAPIReader(),
+		Scheme:    mgr.GetScheme(),
+		Recorder:  mgr.GetEventRecorderFor("rabbitmqclusterrestore-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		log.Error(err, "unable to create controller", "controller", "rabbitmqclusterrestore")
+		os.Exit(1)
+	}
+
 	if _, disabled := os.LookupEnv("DISABLE_DEPRECATED_FEATURES_CHECK"); !disabled {
 		deprecatedFeaturesCheckInterval := 5 * time.Minute
 		if envInterval := getEnvInDuration("DEPRECATED_FEATURES_CHECK_INTERVAL"); envInterval != 0 {
@@ -287,9 +348,10 @@ func main() {
 	}
 	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
 		if err := webhookv1beta1.SetupRabbitmqClusterWebhookWithManager(mgr, webhookv1beta1.RabbitmqClusterCustomDefaulter{
-			DefaultRabbitmqImage:    defaultRabbitmqImage,
-			DefaultImagePullSecrets: defaultImagePullSecrets,
-			DefaultUserUpdaterImage: defaultUserUpdaterImage,
+			DefaultRabbitmqImage:      defaultRabbitmqImage,
+			DefaultImagePullSecrets:   defaultImagePullSecrets,
+			DefaultUserUpdaterImage:   defaultUserUpdaterImage,
+			DefaultPluginFetcherImage: defaultPluginFetcherImage,
 		}); err != nil {
 			log.Error(err, "unable to create webhook", "webhook", "RabbitmqCluster")
 			os.Exit(1)
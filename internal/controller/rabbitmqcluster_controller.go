@@ -40,12 +40,16 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -70,6 +74,8 @@ type RabbitmqClusterReconciler struct {
 	ClusterConfig           *rest.Config
 	Clientset               *kubernetes.Clientset
 	PodExecutor             PodExecutor
+	PodExecFallback         bool
+	PodExecDisabled         bool
 	RabbitmqClientFactory   rabbitmqclient.RabbitmqClientFactory
 	DefaultRabbitmqImage    string
 	DefaultUserUpdaterImage string
@@ -77,13 +83,18 @@ type RabbitmqClusterReconciler struct {
 	ControlRabbitmqImage    bool
 }
 
+// pods/exec is required for the operations the management API cannot run. Setting plugins and runtime configuration
+// on running nodes and checking deletion markers exec into Pods unless PodExecDisabled is set, and enabling feature flags
+// and rebalancing queues only with PodExecFallback. Applying stream advertised addresses, reloading rotated TLS certificates,
+// inspecting standby replication and quiescing nodes for backups always exec into Pods, see docs/pod-exec.md.
+
 // the rbac rule requires an empty row at the end to render
 // +kubebuilder:rbac:groups="",resources=pods/exec,verbs=create
-// +kubebuilder:rbac:groups="",resources=pods,verbs=update;get;list;watch
-// +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update
+// +kubebuilder:rbac:groups="",resources=pods,verbs=update;get;list;watch;delete
+// +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;delete
 // +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;delete
-// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update
-// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;delete
 // +kubebuilder:rbac:groups=rabbitmq.com,resources=rabbitmqclusters,verbs=get;list;watch;create;update
 // +kubebuilder:rbac:groups=rabbitmq.com,resources=rabbitmqclusters/status,verbs=get;update
 // +kubebuilder:rbac:groups=rabbitmq.com,resources=rabbitmqclusters/finalizers,verbs=update
@@ -94,6 +105,10 @@ type RabbitmqClusterReconciler struct {
 // +kubebuilder:rbac:groups="discovery.k8s.io",resources=endpointslices,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=endpoints,verbs=get;watch;list
 // +kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=rolebindings,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups="networking.k8s.io",resources=ingresses,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups="gateway.networking.k8s.io",resources=httproutes;backendtlspolicies,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups="cert-manager.io",resources=certificates,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups="batch",resources=jobs,verbs=get;list;create
 
 func (r *RabbitmqClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := ctrl.LoggerFrom(ctx)
@@ -110,7 +125,8 @@ func (r *RabbitmqClusterReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 	// Check if the resource has been marked for deletion
 	if !rabbitmqCluster.DeletionTimestamp.IsZero() {
 		logger.Info("Deleting")
-		return ctrl.Result{}, r.prepareForDeletion(ctx, rabbitmqCluster)
+		requeueAfter, err := r.prepareForDeletion(ctx, rabbitmqCluster)
+		return ctrl.Result{RequeueAfter: requeueAfter}, err
 	}
 
 	// exit if pause reconciliation label is set to true
@@ -143,10 +159,17 @@ func (r *RabbitmqClusterReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 	tlsErr := r.reconcileTLS(ctx, rabbitmqCluster)
 	if errors.Is(tlsErr, errDisableNonTLSConfig) {
 		return ctrl.Result{}, nil
+	} else if errors.Is(tlsErr, errCertificateNotReady) {
+		logger.Info(tlsErr.Error())
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
 	} else if tlsErr != nil {
 		return ctrl.Result{}, tlsErr
 	}
 
+	if err := r.rotateErlangCookieIfAnnotated(ctx, rabbitmqCluster); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// if the secret already exists, ensure it has the labels necessary for being in the controller's cache
 	// otherwise, our attempt to create it will fail (CreateOrUpdate only checks for the existence of the resource in the cache)
 	defaultUserSecret := &corev1.Secret{}
@@ -179,9 +202,35 @@ func (r *RabbitmqClusterReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 	}
 	logger.V(1).Info("RabbitmqCluster", "spec", string(instanceSpec))
 
+	downstreams, err := r.replicationDownstreams(ctx, rabbitmqCluster)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if len(downstreams) > 0 && rabbitmqCluster.ReplicationEnabled() {
+		r.Recorder.Event(rabbitmqCluster, corev1.EventTypeWarning, "ChainedReplication",
+			fmt.Sprintf("RabbitmqCluster %s replicates from this cluster, which is itself a downstream; replication cannot be chained", downstreams[0].Name))
+	}
+
 	resourceBuilder := resource.RabbitmqResourceBuilder{
-		Instance: rabbitmqCluster,
-		Scheme:   r.Scheme,
+		Instance:                     rabbitmqCluster,
+		Scheme:                       r.Scheme,
+		ReplicationUpstream:          len(downstreams) > 0,
+		RestartOnRuntimeConfigChange: r.PodExecDisabled,
+	}
+	if rabbitmqCluster.SecretTLSEnabled() {
+		// exposed to clients in the default user Secret
+		if resourceBuilder.TLSCACertificate, err = r.tlsCACertificate(ctx, rabbitmqCluster); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	if len(rabbitmqCluster.Spec.Rabbitmq.AdditionalConfigFrom) > 0 {
+		fragments, err := resource.ReadAdditionalConfigFrom(ctx, r.APIReader, rabbitmqCluster)
+		if err != nil {
+			r.setReconcileSuccess(ctx, rabbitmqCluster, corev1.ConditionFalse, "FailedAdditionalConfigFrom", err.Error())
+			return ctrl.Result{}, err
+		}
+		// restarts the nodes through the server ConfigMap when the content changes
+		resourceBuilder.AdditionalConfigFromHash = resource.AdditionalConfigFromHash(fragments)
 	}
 
 	if !resource.ShouldCreatePeerDiscoveryRBAC(rabbitmqCluster) {
@@ -199,7 +248,23 @@ func (r *RabbitmqClusterReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		}
 	}
 
+	if err := r.deleteStaleManagementIngress(ctx, rabbitmqCluster); err != nil {
+		logger.Error(err, "Failed to delete management Ingress or HTTPRoute")
+		return ctrl.Result{}, err
+	}
+
+	if err := r.reconcileStreamAdvertisedAddresses(ctx, rabbitmqCluster); err != nil {
+		r.setReconcileSuccess(ctx, rabbitmqCluster, corev1.ConditionFalse, "FailedReconcileStreamAddresses", err.Error())
+		return ctrl.Result{}, err
+	}
+
+	if resourceBuilder.AuthSecretsHash, err = r.reconcileAuthSecrets(ctx, rabbitmqCluster); err != nil {
+		r.setReconcileSuccess(ctx, rabbitmqCluster, corev1.ConditionFalse, "FailedAuthConfiguration", err.Error())
+		return ctrl.Result{}, err
+	}
+
 	builders := resourceBuilder.ResourceBuilders()
+	maintenanceWindowOpen, _ := maintenanceWindow(rabbitmqCluster, time.Now())
 
 	for _, builder := range builders {
 		obj, err := builder.Build()
@@ -222,6 +287,13 @@ func (r *RabbitmqClusterReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 				if err := builder.Update(sts); err != nil {
 					return ctrl.Result{}, err
 				}
+				if maintenanceWindowOpen {
+					if rabbitmqImageChanged(current, sts) {
+						r.exportDefinitionsBeforeDisruption(ctx, rabbitmqCluster, current, "upgrading RabbitMQ")
+					} else if persistenceExpansionRequired(current, sts) {
+						r.exportDefinitionsBeforeDisruption(ctx, rabbitmqCluster, current, "expanding persistent volumes")
+					}
+				}
 				if ScaleToZero(current, sts) {
 					err := r.saveReplicasBeforeZero(ctx, rabbitmqCluster, current)
 					if err != nil {
@@ -242,18 +314,31 @@ func (r *RabbitmqClusterReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 				}
 			}
 
-			// The PVCs for the StatefulSet may require expanding
-			if err = r.reconcilePVC(ctx, rabbitmqCluster, sts); err != nil {
-				r.setReconcileSuccess(ctx, rabbitmqCluster, corev1.ConditionFalse, "FailedReconcilePVC", err.Error())
-				return ctrl.Result{}, err
+			// The PVCs for the StatefulSet may require expanding, which deletes the StatefulSet.
+			// Outside of maintenance windows, the expansion is held back together with the Pod template.
+			if maintenanceWindowOpen || current == nil || !persistenceExpansionRequired(current, sts) {
+				if err = r.reconcilePVC(ctx, rabbitmqCluster, sts); err != nil {
+					r.setReconcileSuccess(ctx, rabbitmqCluster, corev1.ConditionFalse, "FailedReconcilePVC", err.Error())
+					return ctrl.Result{}, err
+				}
 			}
 
 		}
 		var operationResult controllerutil.OperationResult
+		var heldActions map[rabbitmqv1beta1.DeferredAction]bool
 		err = clientretry.RetryOnConflict(clientretry.DefaultRetry, func() error {
 			var apiError error
 			operationResult, apiError = controllerutil.CreateOrUpdate(ctx, r.Client, obj, func() error {
-				return builder.Update(obj)
+				sts, ok := obj.(*appsv1.StatefulSet)
+				if !ok || sts.ResourceVersion == "" || maintenanceWindowOpen {
+					return builder.Update(obj)
+				}
+				previous := sts.DeepCopy()
+				if err := builder.Update(sts); err != nil {
+					return err
+				}
+				heldActions = holdDisruptiveChanges(sts, previous)
+				return nil
 			})
 			return apiError
 		})
@@ -270,12 +355,32 @@ func (r *RabbitmqClusterReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		if err = r.annotateIfNeeded(ctx, logger, builder, operationResult, rabbitmqCluster); err != nil {
 			return ctrl.Result{}, err
 		}
+
+		if err = r.recordConfigurationChange(ctx, builder, operationResult, rabbitmqCluster); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		if builder.UpdateMayRequireStsRecreate() {
+			if heldActions == nil {
+				heldActions = map[rabbitmqv1beta1.DeferredAction]bool{
+					rabbitmqv1beta1.DeferredPodTemplateRollout:        false,
+					rabbitmqv1beta1.DeferredPersistentVolumeExpansion: false,
+				}
+			}
+			if err = r.updateDeferredActions(ctx, rabbitmqCluster, heldActions); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
 	}
 
 	if requeueAfter, err := r.restartStatefulSetIfNeeded(ctx, logger, rabbitmqCluster); err != nil || requeueAfter > 0 {
 		return ctrl.Result{RequeueAfter: requeueAfter}, err
 	}
 
+	if requeueAfter, err := r.reconcileFullRestart(ctx, rabbitmqCluster); err != nil || requeueAfter > 0 {
+		return ctrl.Result{RequeueAfter: requeueAfter}, err
+	}
+
 	if err := r.reconcileStatus(ctx, rabbitmqCluster); err != nil {
 		return ctrl.Result{}, err
 	}
@@ -303,12 +408,53 @@ func (r *RabbitmqClusterReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		return ctrl.Result{RequeueAfter: requeueAfter}, err
 	}
 
+	definitionsRequeueAfter, err := r.reconcileDefinitions(ctx, rabbitmqCluster)
+	if err != nil {
+		r.setReconcileSuccess(ctx, rabbitmqCluster, corev1.ConditionFalse, "FailedDefinitionsImport", err.Error())
+		return ctrl.Result{}, err
+	}
+
+	backupRequeueAfter, err := r.reconcileDefinitionsBackup(ctx, rabbitmqCluster)
+	if err != nil {
+		r.setReconcileSuccess(ctx, rabbitmqCluster, corev1.ConditionFalse, "FailedDefinitionsExport", err.Error())
+		return ctrl.Result{}, err
+	}
+
+	// Rotations are scheduled far ahead, so the next one does not hold back ReconcileSuccess
+	rotationRequeueAfter, err := r.reconcileDefaultUserRotation(ctx, rabbitmqCluster)
+	if err != nil {
+		r.setReconcileSuccess(ctx, rabbitmqCluster, corev1.ConditionFalse, "FailedDefaultUserRotation", err.Error())
+		return ctrl.Result{}, err
+	}
+
+	replicationRequeueAfter, err := r.reconcileReplication(ctx, rabbitmqCluster)
+	if err != nil {
+		r.setReconcileSuccess(ctx, rabbitmqCluster, corev1.ConditionFalse, "FailedReplicationConfiguration", err.Error())
+		return ctrl.Result{}, err
+	}
+
+	linksRequeueAfter, err := r.reconcileLinks(ctx, rabbitmqCluster)
+	if err != nil {
+		r.setReconcileSuccess(ctx, rabbitmqCluster, corev1.ConditionFalse, "FailedLinksConfiguration", err.Error())
+		return ctrl.Result{}, err
+	}
+
 	// Set ReconcileSuccess to true and update observedGeneration after all reconciliation steps have finished with no error
 	r.setReconcileSuccess(ctx, rabbitmqCluster, corev1.ConditionTrue, "Success", "Finish reconciling")
 
 	logger.Info("Finished reconciling")
 
-	return ctrl.Result{}, nil
+	result := ctrl.Result{RequeueAfter: earliest(rotationRequeueAfter, definitionsRequeueAfter, backupRequeueAfter, replicationRequeueAfter, linksRequeueAfter)}
+	if rabbitmqCluster.SecretTLSEnabled() {
+		// Re-evaluate the certificate expiry and detect rotations of TLS Secrets which are not watched
+		result.RequeueAfter = earliest(result.RequeueAfter, tlsCertificateRecheckInterval)
+	}
+	if len(rabbitmqCluster.Spec.Rabbitmq.AdditionalConfigFrom) > 0 {
+		result.RequeueAfter = earliest(result.RequeueAfter, additionalConfigFromRecheckInterval)
+	}
+	// Apply the deferred actions once the next maintenance window opens
+	result.RequeueAfter = earliest(result.RequeueAfter, untilNextMaintenanceWindow(rabbitmqCluster))
+	return result, nil
 }
 
 func (r *RabbitmqClusterReconciler) getRabbitmqCluster(ctx context.Context, namespacedName types.NamespacedName) (*rabbitmqv1beta1.RabbitmqCluster, error) {
@@ -446,6 +592,22 @@ func (r *RabbitmqClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Owns(&rbacv1.RoleBinding{}).
 		Owns(&corev1.ServiceAccount{}).
 		Owns(&corev1.Secret{}).
+		Owns(&networkingv1.Ingress{}).
+		// TLS and CA Secrets are not owned by the RabbitmqCluster. Only Secrets labelled with
+		// app.kubernetes.io/part-of=rabbitmq are cached, such as Secrets issued by cert-manager.
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.rabbitmqClustersForTLSSecret)).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.rabbitmqClustersForDefinitions)).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.rabbitmqClustersForDefinitions)).
+		// Upstreams of warm standby replication are configured when a downstream references them
+		Watches(&rabbitmqv1beta1.RabbitmqCluster{}, handler.EnqueueRequestsFromMapFunc(r.rabbitmqClustersForReplication),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		// Links are declared again when the other RabbitmqCluster changes or its credentials or certificates rotate
+		Watches(&rabbitmqv1beta1.RabbitmqCluster{}, handler.EnqueueRequestsFromMapFunc(r.rabbitmqClustersForLinks),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.rabbitmqClustersForLinks)).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.rabbitmqClustersForAuthSecrets)).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.rabbitmqClustersForAdditionalConfig)).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.rabbitmqClustersForAdditionalConfig)).
 		Complete(r)
 }
 
@@ -0,0 +1,341 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/rabbitmqclient"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	k8sresource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// VolumeSnapshots are not watched, since their CRD may not be installed, so their progress is checked periodically
+const volumeSnapshotRecheckInterval = 10 * time.Second
+
+// Commands run on every node to stop and resume writes, by spec.quiesce.
+// set_vm_memory_high_watermark does not change the application environment, so the configured watermark is restored from there.
+var (
+	quiesceCommands = map[rabbitmqv1beta1.BackupQuiesceMode]string{
+		rabbitmqv1beta1.BackupQuiesceBlockPublishers: "rabbitmqctl set_vm_memory_high_watermark 0",
+		rabbitmqv1beta1.BackupQuiesceDrain:           "rabbitmq-upgrade drain",
+	}
+	resumeCommands = map[rabbitmqv1beta1.BackupQuiesceMode]string{
+		rabbitmqv1beta1.BackupQuiesceBlockPublishers: "rabbitmqctl eval '{ok, Watermark} = application:get_env(rabbit, vm_memory_high_watermark), vm_memory_monitor:set_vm_memory_high_watermark(Watermark).'",
+		rabbitmqv1beta1.BackupQuiesceDrain:           "rabbitmq-upgrade revive",
+	}
+)
+
+// RabbitmqClusterBackupReconciler reconciles a RabbitmqClusterBackup object
+type RabbitmqClusterBackupReconciler struct {
+	client.Client
+	APIReader             client.Reader
+	Scheme                *runtime.Scheme
+	Recorder              record.EventRecorder
+	ClusterConfig         *rest.Config
+	Clientset             *kubernetes.Clientset
+	PodExecutor           PodExecutor
+	RabbitmqClientFactory rabbitmqclient.RabbitmqClientFactory
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *RabbitmqClusterBackupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.RabbitmqClientFactory == nil {
+		r.RabbitmqClientFactory = &rabbitmqclient.DefaultRabbitmqClientFactory{}
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&rabbitmqv1beta1.RabbitmqClusterBackup{}).
+		Complete(r)
+}
+
+// +kubebuilder:rbac:groups=rabbitmq.com,resources=rabbitmqclusterbackups,verbs=get;list;watch
+// +kubebuilder:rbac:groups=rabbitmq.com,resources=rabbitmqclusterbackups/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=rabbitmq.com,resources=rabbitmqclusterbackups/finalizers,verbs=update
+// +kubebuilder:rbac:groups="snapshot.storage.k8s.io",resources=volumesnapshots,verbs=get;create
+
+// Reconcile takes the VolumeSnapshots of a RabbitmqClusterBackup once, stopping writes in between as set in spec.quiesce,
+// and tracks them until they are ready to use
+func (r *RabbitmqClusterBackupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	backup := &rabbitmqv1beta1.RabbitmqClusterBackup{}
+	if err := r.Get(ctx, req.NamespacedName, backup); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !backup.DeletionTimestamp.IsZero() || backup.Status.Phase == rabbitmqv1beta1.BackupPhaseCompleted || backup.Status.Phase == rabbitmqv1beta1.BackupPhaseFailed {
+		return ctrl.Result{}, nil
+	}
+
+	rmq := &rabbitmqv1beta1.RabbitmqCluster{}
+	if err := r.Get(ctx, types.NamespacedName{Name: backup.Spec.RabbitmqClusterReference.Name, Namespace: backup.Namespace}, rmq); err != nil {
+		if k8serrors.IsNotFound(err) {
+			// there are no nodes left to resume writes on
+			backup.Status.Quiesced = false
+			return ctrl.Result{}, r.failBackup(ctx, backup, rmq, fmt.Sprintf("RabbitmqCluster %s not found", backup.Spec.RabbitmqClusterReference.Name))
+		}
+		return ctrl.Result{}, err
+	}
+
+	if backup.Status.Phase == "" {
+		if rmq.Spec.Persistence.Storage == nil || rmq.Spec.Persistence.Storage.IsZero() {
+			return ctrl.Result{}, r.failBackup(ctx, backup, rmq, fmt.Sprintf("RabbitmqCluster %s does not use persistent storage", rmq.Name))
+		}
+		if err := r.updateBackupStatus(ctx, backup, func(status *rabbitmqv1beta1.RabbitmqClusterBackupStatus) {
+			status.Phase = rabbitmqv1beta1.BackupPhaseInProgress
+			status.StartTime = &metav1.Time{Time: time.Now()}
+		}); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if len(backup.Status.VolumeSnapshots) == 0 {
+		return r.takeVolumeSnapshots(ctx, backup, rmq)
+	}
+	return r.checkVolumeSnapshots(ctx, backup, rmq)
+}
+
+// takeVolumeSnapshots stores what is needed besides the volumes to restore the RabbitmqCluster,
+// stops writes, and creates a VolumeSnapshot of the PersistentVolumeClaim of every node
+func (r *RabbitmqClusterBackupReconciler) takeVolumeSnapshots(ctx context.Context, backup *rabbitmqv1beta1.RabbitmqClusterBackup, rmq *rabbitmqv1beta1.RabbitmqCluster) (ctrl.Result, error) {
+	logger := ctrl.LoggerFrom(ctx)
+
+	sts := &appsv1.StatefulSet{}
+	if err := r.Get(ctx, types.NamespacedName{Name: rmq.ChildResourceName("server"), Namespace: rmq.Namespace}, sts); client.IgnoreNotFound(err) != nil {
+		return ctrl.Result{}, err
+	}
+	if !allReplicasReadyAndUpdated(sts) {
+		logger.V(1).Info("not all replicas ready yet; requeuing request to back up RabbitmqCluster")
+		return ctrl.Result{RequeueAfter: 15 * time.Second}, nil
+	}
+
+	secretName, err := r.storeBackupSecret(ctx, backup, rmq)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if err := r.quiesce(ctx, backup, rmq); err != nil {
+		return ctrl.Result{}, r.failBackup(ctx, backup, rmq, err.Error())
+	}
+
+	var snapshots []rabbitmqv1beta1.BackupVolumeSnapshot
+	for i := 0; i < int(*sts.Spec.Replicas); i++ {
+		snapshot, err := resource.BackupVolumeSnapshot(backup, rmq.PVCName(i), i)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := controllerutil.SetControllerReference(backup, snapshot, r.Scheme); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed setting controller reference: %w", err)
+		}
+		if err := r.Create(ctx, snapshot); err != nil && !k8serrors.IsAlreadyExists(err) {
+			if meta.IsNoMatchError(err) {
+				return ctrl.Result{}, r.failBackup(ctx, backup, rmq, "VolumeSnapshots are not supported by the Kubernetes cluster")
+			}
+			return ctrl.Result{}, fmt.Errorf("failed to create VolumeSnapshot %s: %w", snapshot.GetName(), err)
+		}
+		snapshots = append(snapshots, rabbitmqv1beta1.BackupVolumeSnapshot{
+			PersistentVolumeClaimName: rmq.PVCName(i),
+			VolumeSnapshotName:        snapshot.GetName(),
+		})
+	}
+	logger.Info("created VolumeSnapshots", "count", len(snapshots))
+
+	if err := r.updateBackupStatus(ctx, backup, func(status *rabbitmqv1beta1.RabbitmqClusterBackupStatus) {
+		status.SecretName = secretName
+		status.VolumeSnapshots = snapshots
+	}); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: volumeSnapshotRecheckInterval}, nil
+}
+
+// checkVolumeSnapshots records the progress of the VolumeSnapshots. Writes are resumed as soon as all snapshots are taken,
+// and the backup completes once all of them are ready to use.
+func (r *RabbitmqClusterBackupReconciler) checkVolumeSnapshots(ctx context.Context, backup *rabbitmqv1beta1.RabbitmqClusterBackup, rmq *rabbitmqv1beta1.RabbitmqCluster) (ctrl.Result, error) {
+	snapshots := make([]rabbitmqv1beta1.BackupVolumeSnapshot, len(backup.Status.VolumeSnapshots))
+	allTaken, allReady := true, true
+	for i, recorded := range backup.Status.VolumeSnapshots {
+		snapshot := &unstructured.Unstructured{}
+		snapshot.SetGroupVersionKind(resource.VolumeSnapshotGVK)
+		if err := r.Get(ctx, types.NamespacedName{Name: recorded.VolumeSnapshotName, Namespace: backup.Namespace}, snapshot); err != nil {
+			if k8serrors.IsNotFound(err) {
+				return ctrl.Result{}, r.failBackup(ctx, backup, rmq, fmt.Sprintf("VolumeSnapshot %s was deleted", recorded.VolumeSnapshotName))
+			}
+			return ctrl.Result{}, err
+		}
+		if message, found, _ := unstructured.NestedString(snapshot.Object, "status", "error", "message"); found {
+			return ctrl.Result{}, r.failBackup(ctx, backup, rmq, fmt.Sprintf("VolumeSnapshot %s failed: %s", recorded.VolumeSnapshotName, message))
+		}
+
+		snapshots[i] = recorded
+		_, snapshots[i].Taken, _ = unstructured.NestedString(snapshot.Object, "status", "creationTime")
+		snapshots[i].ReadyToUse, _, _ = unstructured.NestedBool(snapshot.Object, "status", "readyToUse")
+		if restoreSize, found, _ := unstructured.NestedString(snapshot.Object, "status", "restoreSize"); found {
+			if quantity, err := k8sresource.ParseQuantity(restoreSize); err == nil {
+				snapshots[i].RestoreSize = &quantity
+			}
+		}
+		allTaken = allTaken && snapshots[i].Taken
+		allReady = allReady && snapshots[i].ReadyToUse
+	}
+
+	if allTaken && backup.Status.Quiesced {
+		if err := r.resume(ctx, backup, rmq); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if err := r.updateBackupStatus(ctx, backup, func(status *rabbitmqv1beta1.RabbitmqClusterBackupStatus) {
+		status.VolumeSnapshots = snapshots
+		status.Quiesced = backup.Status.Quiesced && !allTaken
+		if allReady {
+			status.Phase = rabbitmqv1beta1.BackupPhaseCompleted
+			status.CompletionTime = &metav1.Time{Time: time.Now()}
+		}
+	}); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if !allReady {
+		return ctrl.Result{RequeueAfter: volumeSnapshotRecheckInterval}, nil
+	}
+	r.Recorder.Event(backup, corev1.EventTypeNormal, "BackupCompleted", fmt.Sprintf("all %d VolumeSnapshots are ready to use", len(snapshots)))
+	return ctrl.Result{}, nil
+}
+
+// storeBackupSecret creates the Secret owned by the backup holding the definitions, the manifest of the RabbitmqCluster,
+// the Erlang cookie and the default user credentials, unless it already exists. The default user credentials are left out
+// when they are not stored in a Secret, e.g. when they come from Vault.
+func (r *RabbitmqClusterBackupReconciler) storeBackupSecret(ctx context.Context, backup *rabbitmqv1beta1.RabbitmqClusterBackup, rmq *rabbitmqv1beta1.RabbitmqCluster) (string, error) {
+	existing := &corev1.Secret{}
+	if err := r.APIReader.Get(ctx, types.NamespacedName{Name: backup.Name, Namespace: backup.Namespace}, existing); err == nil {
+		return existing.Name, nil
+	} else if !k8serrors.IsNotFound(err) {
+		return "", err
+	}
+
+	rabbitClient, err := r.RabbitmqClientFactory.GetClientForService(ctx, r.APIReader, rmq)
+	if err != nil {
+		return "", fmt.Errorf("failed to create RabbitMQ client: %w", err)
+	}
+	definitions, err := rabbitClient.ListDefinitions()
+	if err != nil {
+		return "", fmt.Errorf("failed to export definitions: %w", err)
+	}
+	definitionsData, err := json.Marshal(definitions)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal definitions: %w", err)
+	}
+	clusterData, err := resource.BackedUpRabbitmqCluster(rmq)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal RabbitmqCluster: %w", err)
+	}
+
+	cookie := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: rmq.ChildResourceName("erlang-cookie"), Namespace: rmq.Namespace}, cookie); err != nil {
+		return "", fmt.Errorf("failed to get Erlang cookie: %w", err)
+	}
+	data := map[string][]byte{
+		resource.DefinitionsBackupKey:     definitionsData,
+		resource.BackupRabbitmqClusterKey: clusterData,
+		resource.BackupErlangCookieKey:    cookie.Data[resource.ErlangCookieKey],
+	}
+
+	defaultUser := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: rmq.ChildResourceName(resource.DefaultUserSecretName), Namespace: rmq.Namespace}, defaultUser); err == nil {
+		if data[resource.BackupDefaultUserKey], err = json.Marshal(defaultUser.Data); err != nil {
+			return "", fmt.Errorf("failed to marshal default user: %w", err)
+		}
+	} else if !k8serrors.IsNotFound(err) {
+		return "", err
+	}
+
+	secret := resource.BackupSecret(backup, data)
+	if err := controllerutil.SetControllerReference(backup, secret, r.Scheme); err != nil {
+		return "", fmt.Errorf("failed setting controller reference: %w", err)
+	}
+	if err := r.Create(ctx, secret); err != nil {
+		return "", fmt.Errorf("failed to create Secret %s: %w", secret.Name, err)
+	}
+	return secret.Name, nil
+}
+
+// quiesce stops writes on all nodes. Quiesced is recorded first, so that writes are resumed even if stopping them fails on some nodes.
+func (r *RabbitmqClusterBackupReconciler) quiesce(ctx context.Context, backup *rabbitmqv1beta1.RabbitmqClusterBackup, rmq *rabbitmqv1beta1.RabbitmqCluster) error {
+	cmd, ok := quiesceCommands[backup.Spec.Quiesce]
+	if !ok {
+		return nil
+	}
+	if err := r.updateBackupStatus(ctx, backup, func(status *rabbitmqv1beta1.RabbitmqClusterBackupStatus) {
+		status.Quiesced = true
+	}); err != nil {
+		return err
+	}
+	return r.execOnAllNodes(ctx, rmq, cmd)
+}
+
+// resume undoes quiesce on all nodes
+func (r *RabbitmqClusterBackupReconciler) resume(ctx context.Context, backup *rabbitmqv1beta1.RabbitmqClusterBackup, rmq *rabbitmqv1beta1.RabbitmqCluster) error {
+	cmd, ok := resumeCommands[backup.Spec.Quiesce]
+	if !ok {
+		return nil
+	}
+	if err := r.execOnAllNodes(ctx, rmq, cmd); err != nil {
+		r.Recorder.Event(backup, corev1.EventTypeWarning, "FailedResume", err.Error())
+		return err
+	}
+	return nil
+}
+
+// execOnAllNodes runs a command in every node. Quiescing nodes has no management API equivalent, so backups which quiesce
+// require the pods/exec permission.
+func (r *RabbitmqClusterBackupReconciler) execOnAllNodes(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster, cmd string) error {
+	logger := ctrl.LoggerFrom(ctx)
+	for i := int32(0); i < *rmq.Spec.Replicas; i++ {
+		podName := fmt.Sprintf("%s-%d", rmq.ChildResourceName("server"), i)
+		stdout, stderr, err := r.PodExecutor.Exec(r.Clientset, r.ClusterConfig, rmq.Namespace, podName, "rabbitmq", "sh", "-c", cmd)
+		if err != nil {
+			logger.Error(err, "failed to run command on pod", "pod", podName, "command", cmd, "stdout", stdout, "stderr", stderr)
+			return fmt.Errorf("failed to run %q on pod %s: %w", cmd, podName, err)
+		}
+	}
+	return nil
+}
+
+// failBackup resumes writes if they were stopped, and marks the backup as failed.
+// If writes cannot be resumed, the error is returned so that resuming them is retried.
+func (r *RabbitmqClusterBackupReconciler) failBackup(ctx context.Context, backup *rabbitmqv1beta1.RabbitmqClusterBackup, rmq *rabbitmqv1beta1.RabbitmqCluster, message string) error {
+	if backup.Status.Quiesced {
+		if err := r.resume(ctx, backup, rmq); err != nil {
+			return err
+		}
+	}
+	r.Recorder.Event(backup, corev1.EventTypeWarning, "BackupFailed", message)
+	return r.updateBackupStatus(ctx, backup, func(status *rabbitmqv1beta1.RabbitmqClusterBackupStatus) {
+		status.Phase = rabbitmqv1beta1.BackupPhaseFailed
+		status.Message = message
+		status.Quiesced = false
+	})
+}
+
+func (r *RabbitmqClusterBackupReconciler) updateBackupStatus(ctx context.Context, backup *rabbitmqv1beta1.RabbitmqClusterBackup, update func(*rabbitmqv1beta1.RabbitmqClusterBackupStatus)) error {
+	patch := client.MergeFrom(backup.DeepCopy())
+	update(&backup.Status)
+	if err := r.Status().Patch(ctx, backup, patch); err != nil {
+		return fmt.Errorf("failed to update RabbitmqClusterBackup status: %w", err)
+	}
+	return nil
+}
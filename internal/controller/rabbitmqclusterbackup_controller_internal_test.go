@@ -0,0 +1,244 @@
+package controllers
+
+import (
+	"fmt"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8sresource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("RabbitmqClusterBackupReconciler", func() {
+	var (
+		cluster    *rabbitmqv1beta1.RabbitmqCluster
+		backup     *rabbitmqv1beta1.RabbitmqClusterBackup
+		executor   *recordingPodExecutor
+		fakeClient client.Client
+		reconciler *RabbitmqClusterBackupReconciler
+	)
+
+	BeforeEach(func() {
+		storage := k8sresource.MustParse("10Gi")
+		cluster = &rabbitmqv1beta1.RabbitmqCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "rabbit", Namespace: "default"},
+			Spec: rabbitmqv1beta1.RabbitmqClusterSpec{
+				Replicas:    new(int32(3)),
+				Persistence: rabbitmqv1beta1.RabbitmqClusterPersistenceSpec{Storage: &storage},
+			},
+		}
+		backup = &rabbitmqv1beta1.RabbitmqClusterBackup{
+			ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "default"},
+			Spec: rabbitmqv1beta1.RabbitmqClusterBackupSpec{
+				RabbitmqClusterReference: corev1.LocalObjectReference{Name: "rabbit"},
+				Quiesce:                  rabbitmqv1beta1.BackupQuiesceDrain,
+			},
+		}
+		executor = &recordingPodExecutor{}
+	})
+
+	JustBeforeEach(func() {
+		scheme := backupTestScheme()
+		sts := &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "rabbit-server", Namespace: "default"},
+			Spec:       appsv1.StatefulSetSpec{Replicas: new(int32(3))},
+			Status:     appsv1.StatefulSetStatus{ReadyReplicas: 3},
+		}
+		cookie := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "rabbit-erlang-cookie", Namespace: "default"},
+			Data:       map[string][]byte{resource.ErlangCookieKey: []byte("cookie")},
+		}
+		defaultUser := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "rabbit-default-user", Namespace: "default"},
+			Data:       map[string][]byte{"username": []byte("user"), "password": []byte("pass")},
+		}
+		fakeClient = fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(cluster, backup, sts, cookie, defaultUser).
+			WithStatusSubresource(backup).
+			Build()
+		reconciler = &RabbitmqClusterBackupReconciler{
+			Client:                fakeClient,
+			APIReader:             fakeClient,
+			Scheme:                scheme,
+			Recorder:              record.NewFakeRecorder(10),
+			PodExecutor:           executor,
+			RabbitmqClientFactory: &staticRabbitmqClientFactory{client: &definitionsBackupRabbitmqClient{}},
+		}
+	})
+
+	reconcile := func(ctx SpecContext) ctrl.Result {
+		result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "nightly", Namespace: "default"}})
+		Expect(err).NotTo(HaveOccurred())
+		return result
+	}
+
+	getBackup := func(ctx SpecContext) *rabbitmqv1beta1.RabbitmqClusterBackup {
+		updated := &rabbitmqv1beta1.RabbitmqClusterBackup{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "nightly", Namespace: "default"}, updated)).To(Succeed())
+		return updated
+	}
+
+	setSnapshotStatus := func(ctx SpecContext, name string, status map[string]any) {
+		snapshot := &unstructured.Unstructured{}
+		snapshot.SetGroupVersionKind(resource.VolumeSnapshotGVK)
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, snapshot)).To(Succeed())
+		Expect(unstructured.SetNestedField(snapshot.Object, status, "status")).To(Succeed())
+		Expect(fakeClient.Update(ctx, snapshot)).To(Succeed())
+	}
+
+	It("drains all nodes and snapshots their volumes", func(ctx SpecContext) {
+		Expect(reconcile(ctx).RequeueAfter).To(Equal(volumeSnapshotRecheckInterval))
+
+		Expect(executor.commands).To(Equal([]string{
+			"rabbit-server-0: rabbitmq-upgrade drain",
+			"rabbit-server-1: rabbitmq-upgrade drain",
+			"rabbit-server-2: rabbitmq-upgrade drain",
+		}))
+
+		status := getBackup(ctx).Status
+		Expect(status.Phase).To(Equal(rabbitmqv1beta1.BackupPhaseInProgress))
+		Expect(status.StartTime).NotTo(BeNil())
+		Expect(status.Quiesced).To(BeTrue())
+		Expect(status.SecretName).To(Equal("nightly"))
+		Expect(status.VolumeSnapshots).To(HaveLen(3))
+		Expect(status.VolumeSnapshots[2]).To(Equal(rabbitmqv1beta1.BackupVolumeSnapshot{
+			PersistentVolumeClaimName: "persistence-rabbit-server-2",
+			VolumeSnapshotName:        "nightly-server-2",
+		}))
+
+		snapshot := &unstructured.Unstructured{}
+		snapshot.SetGroupVersionKind(resource.VolumeSnapshotGVK)
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "nightly-server-0", Namespace: "default"}, snapshot)).To(Succeed())
+		Expect(snapshot.GetOwnerReferences()).To(ConsistOf(HaveField("Name", "nightly")))
+
+		secret := &corev1.Secret{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "nightly", Namespace: "default"}, secret)).To(Succeed())
+		Expect(secret.OwnerReferences).To(ConsistOf(HaveField("Name", "nightly")))
+		Expect(secret.Data).To(HaveKeyWithValue(resource.BackupErlangCookieKey, []byte("cookie")))
+		Expect(secret.Data).To(HaveKeyWithValue(resource.DefinitionsBackupKey, ContainSubstring(`"rabbit_version":"4.2.0"`)))
+		Expect(secret.Data).To(HaveKeyWithValue(resource.BackupRabbitmqClusterKey, ContainSubstring(`"name":"rabbit"`)))
+		Expect(secret.Data).To(HaveKeyWithValue(resource.BackupDefaultUserKey, ContainSubstring(`"username"`)))
+	})
+
+	It("revives the nodes once all snapshots are taken, and completes once they are ready", func(ctx SpecContext) {
+		reconcile(ctx)
+		executor.commands = nil
+
+		setSnapshotStatus(ctx, "nightly-server-0", map[string]any{"creationTime": "2026-10-18T00:00:00Z"})
+		reconcile(ctx)
+		Expect(executor.commands).To(BeEmpty())
+		Expect(getBackup(ctx).Status.Quiesced).To(BeTrue())
+
+		for i := range 3 {
+			setSnapshotStatus(ctx, fmt.Sprintf("nightly-server-%d", i), map[string]any{"creationTime": "2026-10-18T00:00:00Z", "readyToUse": false})
+		}
+		Expect(reconcile(ctx).RequeueAfter).To(Equal(volumeSnapshotRecheckInterval))
+		Expect(executor.commands).To(HaveLen(3))
+		Expect(executor.commands).To(HaveEach(HaveSuffix("rabbitmq-upgrade revive")))
+		status := getBackup(ctx).Status
+		Expect(status.Quiesced).To(BeFalse())
+		Expect(status.Phase).To(Equal(rabbitmqv1beta1.BackupPhaseInProgress))
+		Expect(status.VolumeSnapshots).To(HaveEach(HaveField("Taken", BeTrue())))
+
+		for i := range 3 {
+			setSnapshotStatus(ctx, fmt.Sprintf("nightly-server-%d", i), map[string]any{"creationTime": "2026-10-18T00:00:00Z", "readyToUse": true, "restoreSize": "10Gi"})
+		}
+		Expect(reconcile(ctx).RequeueAfter).To(BeZero())
+		Expect(executor.commands).To(HaveLen(3))
+		status = getBackup(ctx).Status
+		Expect(status.Phase).To(Equal(rabbitmqv1beta1.BackupPhaseCompleted))
+		Expect(status.CompletionTime).NotTo(BeNil())
+		Expect(status.VolumeSnapshots).To(HaveEach(HaveField("RestoreSize", HaveValue(Equal(k8sresource.MustParse("10Gi"))))))
+	})
+
+	It("revives the nodes and fails when a snapshot fails", func(ctx SpecContext) {
+		reconcile(ctx)
+		executor.commands = nil
+
+		setSnapshotStatus(ctx, "nightly-server-1", map[string]any{"error": map[string]any{"message": "no space left"}})
+		Expect(reconcile(ctx).RequeueAfter).To(BeZero())
+		Expect(executor.commands).To(HaveEach(HaveSuffix("rabbitmq-upgrade revive")))
+		status := getBackup(ctx).Status
+		Expect(status.Phase).To(Equal(rabbitmqv1beta1.BackupPhaseFailed))
+		Expect(status.Message).To(Equal("VolumeSnapshot nightly-server-1 failed: no space left"))
+		Expect(status.Quiesced).To(BeFalse())
+	})
+
+	When("publishers are blocked", func() {
+		BeforeEach(func() {
+			backup.Spec.Quiesce = rabbitmqv1beta1.BackupQuiesceBlockPublishers
+		})
+
+		It("sets the memory high watermark to 0 on all nodes", func(ctx SpecContext) {
+			reconcile(ctx)
+			Expect(executor.commands).To(HaveLen(3))
+			Expect(executor.commands).To(HaveEach(HaveSuffix("rabbitmqctl set_vm_memory_high_watermark 0")))
+		})
+	})
+
+	When("writes are not stopped", func() {
+		BeforeEach(func() {
+			backup.Spec.Quiesce = rabbitmqv1beta1.BackupQuiesceNone
+		})
+
+		It("only snapshots the volumes", func(ctx SpecContext) {
+			reconcile(ctx)
+			Expect(executor.commands).To(BeEmpty())
+			Expect(getBackup(ctx).Status.Quiesced).To(BeFalse())
+			Expect(getBackup(ctx).Status.VolumeSnapshots).To(HaveLen(3))
+		})
+	})
+
+	When("the RabbitmqCluster does not use persistent storage", func() {
+		BeforeEach(func() {
+			cluster.Spec.Persistence.Storage = new(k8sresource.MustParse("0"))
+		})
+
+		It("fails", func(ctx SpecContext) {
+			reconcile(ctx)
+			status := getBackup(ctx).Status
+			Expect(status.Phase).To(Equal(rabbitmqv1beta1.BackupPhaseFailed))
+			Expect(status.Message).To(Equal("RabbitmqCluster rabbit does not use persistent storage"))
+			Expect(executor.commands).To(BeEmpty())
+		})
+	})
+})
+
+// backupTestScheme knows VolumeSnapshots as unstructured objects, since their types are not vendored
+func backupTestScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+	Expect(rabbitmqv1beta1.AddToScheme(scheme)).To(Succeed())
+	scheme.AddKnownTypeWithName(resource.VolumeSnapshotGVK, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(resource.VolumeSnapshotGVK.GroupVersion().WithKind("VolumeSnapshotList"), &unstructured.UnstructuredList{})
+	return scheme
+}
+
+// recordingPodExecutor records the commands run on each pod
+type recordingPodExecutor struct {
+	mu       sync.Mutex
+	commands []string
+}
+
+func (e *recordingPodExecutor) Exec(_ *kubernetes.Clientset, _ *rest.Config, _, podName, _ string, command ...string) (string, string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.commands = append(e.commands, podName+": "+command[len(command)-1])
+	return "", "", nil
+}
@@ -0,0 +1,205 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/metadata"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// RabbitmqClusterRestoreReconciler reconciles a RabbitmqClusterRestore object
+type RabbitmqClusterRestoreReconciler struct {
+	client.Client
+	APIReader client.Reader
+	Scheme    *runtime.Scheme
+	Recorder  record.EventRecorder
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *RabbitmqClusterRestoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&rabbitmqv1beta1.RabbitmqClusterRestore{}).
+		Complete(r)
+}
+
+// +kubebuilder:rbac:groups=rabbitmq.com,resources=rabbitmqclusterrestores,verbs=get;list;watch
+// +kubebuilder:rbac:groups=rabbitmq.com,resources=rabbitmqclusterrestores/status,verbs=get;update;patch
+
+// Reconcile creates the Secrets and PersistentVolumeClaims of the backed up RabbitmqCluster, and then the RabbitmqCluster itself,
+// so that its StatefulSet finds volumes provisioned from the VolumeSnapshots of the backup instead of provisioning empty ones
+func (r *RabbitmqClusterRestoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := ctrl.LoggerFrom(ctx)
+
+	restore := &rabbitmqv1beta1.RabbitmqClusterRestore{}
+	if err := r.Get(ctx, req.NamespacedName, restore); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !restore.DeletionTimestamp.IsZero() || restore.Status.Phase == rabbitmqv1beta1.BackupPhaseCompleted || restore.Status.Phase == rabbitmqv1beta1.BackupPhaseFailed {
+		return ctrl.Result{}, nil
+	}
+
+	backupName := restore.Spec.BackupReference.Name
+	backup := &rabbitmqv1beta1.RabbitmqClusterBackup{}
+	if err := r.Get(ctx, types.NamespacedName{Name: backupName, Namespace: restore.Namespace}, backup); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return ctrl.Result{}, r.failRestore(ctx, restore, fmt.Sprintf("RabbitmqClusterBackup %s not found", backupName))
+		}
+		return ctrl.Result{}, err
+	}
+	switch backup.Status.Phase {
+	case rabbitmqv1beta1.BackupPhaseCompleted:
+	case rabbitmqv1beta1.BackupPhaseFailed:
+		return ctrl.Result{}, r.failRestore(ctx, restore, fmt.Sprintf("RabbitmqClusterBackup %s failed", backupName))
+	default:
+		logger.V(1).Info("backup not completed yet; requeuing request to restore it", "backup", backupName)
+		return ctrl.Result{RequeueAfter: 15 * time.Second}, r.updateRestoreStatus(ctx, restore, func(status *rabbitmqv1beta1.RabbitmqClusterRestoreStatus) {
+			status.Phase = rabbitmqv1beta1.BackupPhaseInProgress
+			status.Message = fmt.Sprintf("waiting for RabbitmqClusterBackup %s to complete", backupName)
+		})
+	}
+
+	backupSecret := &corev1.Secret{}
+	if err := r.APIReader.Get(ctx, types.NamespacedName{Name: backup.Status.SecretName, Namespace: restore.Namespace}, backupSecret); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return ctrl.Result{}, r.failRestore(ctx, restore, fmt.Sprintf("Secret %s of RabbitmqClusterBackup %s not found", backup.Status.SecretName, backupName))
+		}
+		return ctrl.Result{}, err
+	}
+	rmq := &rabbitmqv1beta1.RabbitmqCluster{}
+	if err := json.Unmarshal(backupSecret.Data[resource.BackupRabbitmqClusterKey], rmq); err != nil {
+		return ctrl.Result{}, r.failRestore(ctx, restore, fmt.Sprintf("invalid RabbitmqCluster in Secret %s: %s", backupSecret.Name, err))
+	}
+	// node names are derived from the name of the RabbitmqCluster, and are stored on the volumes
+	rmq.Namespace = restore.Namespace
+	rmq.Spec.Replicas = new(int32(len(backup.Status.VolumeSnapshots)))
+	if rmq.Annotations == nil {
+		rmq.Annotations = map[string]string{}
+	}
+	rmq.Annotations[rabbitmqv1beta1.RestoredFromAnnotation] = restore.Name
+
+	existing := &rabbitmqv1beta1.RabbitmqCluster{}
+	if err := r.Get(ctx, types.NamespacedName{Name: rmq.Name, Namespace: rmq.Namespace}, existing); err == nil {
+		if existing.Annotations[rabbitmqv1beta1.RestoredFromAnnotation] != restore.Name {
+			return ctrl.Result{}, r.failRestore(ctx, restore, fmt.Sprintf("RabbitmqCluster %s already exists", rmq.Name))
+		}
+		return ctrl.Result{}, r.completeRestore(ctx, restore, rmq.Name)
+	} else if !k8serrors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+
+	for i, snapshot := range backup.Status.VolumeSnapshots {
+		pvc := resource.RestoredPersistentVolumeClaim(rmq, i, snapshot)
+		if err := r.Create(ctx, pvc); err != nil {
+			if !k8serrors.IsAlreadyExists(err) {
+				return ctrl.Result{}, fmt.Errorf("failed to create PersistentVolumeClaim %s: %w", pvc.Name, err)
+			}
+			// created by a previous attempt of this restore
+			current := &corev1.PersistentVolumeClaim{}
+			if err := r.APIReader.Get(ctx, client.ObjectKeyFromObject(pvc), current); err != nil {
+				return ctrl.Result{}, err
+			}
+			if !reflect.DeepEqual(current.Spec.DataSource, pvc.Spec.DataSource) {
+				return ctrl.Result{}, r.failRestore(ctx, restore, fmt.Sprintf("PersistentVolumeClaim %s already exists", pvc.Name))
+			}
+		}
+	}
+
+	secrets, err := restoredSecrets(rmq, backupSecret)
+	if err != nil {
+		return ctrl.Result{}, r.failRestore(ctx, restore, fmt.Sprintf("invalid Secret %s: %s", backupSecret.Name, err))
+	}
+	for _, secret := range secrets {
+		if err := r.Create(ctx, secret); err != nil {
+			if !k8serrors.IsAlreadyExists(err) {
+				return ctrl.Result{}, fmt.Errorf("failed to create Secret %s: %w", secret.Name, err)
+			}
+			current := &corev1.Secret{}
+			if err := r.APIReader.Get(ctx, client.ObjectKeyFromObject(secret), current); err != nil {
+				return ctrl.Result{}, err
+			}
+			if !reflect.DeepEqual(current.Data, secret.Data) {
+				return ctrl.Result{}, r.failRestore(ctx, restore, fmt.Sprintf("Secret %s already exists", secret.Name))
+			}
+		}
+	}
+
+	if err := r.Create(ctx, rmq); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to create RabbitmqCluster %s: %w", rmq.Name, err)
+	}
+	logger.Info("restored RabbitmqCluster", "rabbitmqcluster", rmq.Name, "backup", backupName)
+	r.Recorder.Event(restore, corev1.EventTypeNormal, "RestoreCompleted", fmt.Sprintf("created RabbitmqCluster %s from %d VolumeSnapshots", rmq.Name, len(backup.Status.VolumeSnapshots)))
+	return ctrl.Result{}, r.completeRestore(ctx, restore, rmq.Name)
+}
+
+// restoredSecrets returns the Erlang cookie Secret and, if it was backed up, the default user Secret of the restored RabbitmqCluster.
+// They are labelled like the Secrets the operator creates, and adopted by the RabbitmqCluster once it is reconciled.
+func restoredSecrets(rmq *rabbitmqv1beta1.RabbitmqCluster, backupSecret *corev1.Secret) ([]*corev1.Secret, error) {
+	cookie, ok := backupSecret.Data[resource.BackupErlangCookieKey]
+	if !ok {
+		return nil, fmt.Errorf("missing key %s", resource.BackupErlangCookieKey)
+	}
+	secrets := []*corev1.Secret{{
+		ObjectMeta: metadataForRestoredSecret(rmq, rmq.ChildResourceName("erlang-cookie")),
+		Type:       corev1.SecretTypeOpaque,
+		Data:       map[string][]byte{resource.ErlangCookieKey: cookie},
+	}}
+
+	if defaultUser, ok := backupSecret.Data[resource.BackupDefaultUserKey]; ok {
+		data := map[string][]byte{}
+		if err := json.Unmarshal(defaultUser, &data); err != nil {
+			return nil, fmt.Errorf("invalid key %s: %w", resource.BackupDefaultUserKey, err)
+		}
+		secrets = append(secrets, &corev1.Secret{
+			ObjectMeta: metadataForRestoredSecret(rmq, rmq.ChildResourceName(resource.DefaultUserSecretName)),
+			Type:       corev1.SecretTypeOpaque,
+			Data:       data,
+		})
+	}
+	return secrets, nil
+}
+
+func metadataForRestoredSecret(rmq *rabbitmqv1beta1.RabbitmqCluster, name string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      name,
+		Namespace: rmq.Namespace,
+		Labels:    metadata.GetLabels(rmq.Name, rmq.Labels),
+	}
+}
+
+func (r *RabbitmqClusterRestoreReconciler) completeRestore(ctx context.Context, restore *rabbitmqv1beta1.RabbitmqClusterRestore, rmqName string) error {
+	return r.updateRestoreStatus(ctx, restore, func(status *rabbitmqv1beta1.RabbitmqClusterRestoreStatus) {
+		status.Phase = rabbitmqv1beta1.BackupPhaseCompleted
+		status.Message = ""
+		status.RabbitmqClusterName = rmqName
+	})
+}
+
+func (r *RabbitmqClusterRestoreReconciler) failRestore(ctx context.Context, restore *rabbitmqv1beta1.RabbitmqClusterRestore, message string) error {
+	r.Recorder.Event(restore, corev1.EventTypeWarning, "RestoreFailed", message)
+	return r.updateRestoreStatus(ctx, restore, func(status *rabbitmqv1beta1.RabbitmqClusterRestoreStatus) {
+		status.Phase = rabbitmqv1beta1.BackupPhaseFailed
+		status.Message = message
+	})
+}
+
+func (r *RabbitmqClusterRestoreReconciler) updateRestoreStatus(ctx context.Context, restore *rabbitmqv1beta1.RabbitmqClusterRestore, update func(*rabbitmqv1beta1.RabbitmqClusterRestoreStatus)) error {
+	patch := client.MergeFrom(restore.DeepCopy())
+	update(&restore.Status)
+	if err := r.Status().Patch(ctx, restore, patch); err != nil {
+		return fmt.Errorf("failed to update RabbitmqClusterRestore status: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	corev1 "k8s.io/api/core/v1"
+	k8sresource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("RabbitmqClusterRestoreReconciler", func() {
+	var (
+		backup     *rabbitmqv1beta1.RabbitmqClusterBackup
+		restore    *rabbitmqv1beta1.RabbitmqClusterRestore
+		objects    []client.Object
+		fakeClient client.Client
+		reconciler *RabbitmqClusterRestoreReconciler
+	)
+
+	BeforeEach(func() {
+		backup = &rabbitmqv1beta1.RabbitmqClusterBackup{
+			ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "default"},
+			Spec: rabbitmqv1beta1.RabbitmqClusterBackupSpec{
+				RabbitmqClusterReference: corev1.LocalObjectReference{Name: "rabbit"},
+			},
+			Status: rabbitmqv1beta1.RabbitmqClusterBackupStatus{
+				Phase:      rabbitmqv1beta1.BackupPhaseCompleted,
+				SecretName: "nightly",
+				VolumeSnapshots: []rabbitmqv1beta1.BackupVolumeSnapshot{
+					{PersistentVolumeClaimName: "persistence-rabbit-server-0", VolumeSnapshotName: "nightly-server-0", ReadyToUse: true},
+					{PersistentVolumeClaimName: "persistence-rabbit-server-1", VolumeSnapshotName: "nightly-server-1", ReadyToUse: true},
+				},
+			},
+		}
+		restore = &rabbitmqv1beta1.RabbitmqClusterRestore{
+			ObjectMeta: metav1.ObjectMeta{Name: "recover", Namespace: "default"},
+			Spec: rabbitmqv1beta1.RabbitmqClusterRestoreSpec{
+				BackupReference: corev1.LocalObjectReference{Name: "nightly"},
+			},
+		}
+		objects = nil
+	})
+
+	JustBeforeEach(func() {
+		storage := k8sresource.MustParse("10Gi")
+		backedUp, err := resource.BackedUpRabbitmqCluster(&rabbitmqv1beta1.RabbitmqCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "rabbit", Namespace: "default", Labels: map[string]string{"team": "messaging"}},
+			Spec: rabbitmqv1beta1.RabbitmqClusterSpec{
+				Replicas:    new(int32(3)),
+				Persistence: rabbitmqv1beta1.RabbitmqClusterPersistenceSpec{Storage: &storage},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		backupSecret := resource.BackupSecret(backup, map[string][]byte{
+			resource.BackupRabbitmqClusterKey: backedUp,
+			resource.BackupErlangCookieKey:    []byte("cookie"),
+			resource.BackupDefaultUserKey:     []byte(`{"username":"dXNlcg==","password":"cGFzcw=="}`),
+		})
+
+		scheme := backupTestScheme()
+		fakeClient = fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(append(objects, backup, backupSecret, restore)...).
+			WithStatusSubresource(backup, restore).
+			Build()
+		reconciler = &RabbitmqClusterRestoreReconciler{
+			Client:    fakeClient,
+			APIReader: fakeClient,
+			Scheme:    scheme,
+			Recorder:  record.NewFakeRecorder(10),
+		}
+	})
+
+	reconcile := func(ctx SpecContext) ctrl.Result {
+		result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "recover", Namespace: "default"}})
+		Expect(err).NotTo(HaveOccurred())
+		return result
+	}
+
+	getRestore := func(ctx SpecContext) *rabbitmqv1beta1.RabbitmqClusterRestore {
+		updated := &rabbitmqv1beta1.RabbitmqClusterRestore{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "recover", Namespace: "default"}, updated)).To(Succeed())
+		return updated
+	}
+
+	It("creates the volumes and Secrets before the RabbitmqCluster", func(ctx SpecContext) {
+		reconcile(ctx)
+
+		status := getRestore(ctx).Status
+		Expect(status.Phase).To(Equal(rabbitmqv1beta1.BackupPhaseCompleted))
+		Expect(status.RabbitmqClusterName).To(Equal("rabbit"))
+
+		rmq := &rabbitmqv1beta1.RabbitmqCluster{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "rabbit", Namespace: "default"}, rmq)).To(Succeed())
+		Expect(rmq.Spec.Replicas).To(Equal(new(int32(2))))
+		Expect(rmq.Labels).To(HaveKeyWithValue("team", "messaging"))
+		Expect(rmq.Annotations).To(HaveKeyWithValue(rabbitmqv1beta1.RestoredFromAnnotation, "recover"))
+
+		pvcs := &corev1.PersistentVolumeClaimList{}
+		Expect(fakeClient.List(ctx, pvcs)).To(Succeed())
+		Expect(pvcs.Items).To(ConsistOf(
+			And(HaveField("Name", "persistence-rabbit-server-0"), HaveField("Spec.DataSource.Name", "nightly-server-0")),
+			And(HaveField("Name", "persistence-rabbit-server-1"), HaveField("Spec.DataSource.Name", "nightly-server-1")),
+		))
+
+		cookie := &corev1.Secret{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "rabbit-erlang-cookie", Namespace: "default"}, cookie)).To(Succeed())
+		Expect(cookie.Data).To(Equal(map[string][]byte{resource.ErlangCookieKey: []byte("cookie")}))
+		Expect(cookie.Labels).To(HaveKeyWithValue("app.kubernetes.io/part-of", "rabbitmq"))
+		defaultUser := &corev1.Secret{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "rabbit-default-user", Namespace: "default"}, defaultUser)).To(Succeed())
+		Expect(defaultUser.Data).To(Equal(map[string][]byte{"username": []byte("user"), "password": []byte("pass")}))
+	})
+
+	When("the backup is in progress", func() {
+		BeforeEach(func() {
+			backup.Status.Phase = rabbitmqv1beta1.BackupPhaseInProgress
+		})
+
+		It("waits for it", func(ctx SpecContext) {
+			Expect(reconcile(ctx).RequeueAfter).To(BeNumerically(">", 0))
+			status := getRestore(ctx).Status
+			Expect(status.Phase).To(Equal(rabbitmqv1beta1.BackupPhaseInProgress))
+			Expect(status.Message).To(Equal("waiting for RabbitmqClusterBackup nightly to complete"))
+		})
+	})
+
+	When("the RabbitmqCluster exists", func() {
+		BeforeEach(func() {
+			objects = []client.Object{&rabbitmqv1beta1.RabbitmqCluster{ObjectMeta: metav1.ObjectMeta{Name: "rabbit", Namespace: "default"}}}
+		})
+
+		It("fails", func(ctx SpecContext) {
+			reconcile(ctx)
+			status := getRestore(ctx).Status
+			Expect(status.Phase).To(Equal(rabbitmqv1beta1.BackupPhaseFailed))
+			Expect(status.Message).To(Equal("RabbitmqCluster rabbit already exists"))
+		})
+	})
+
+	When("a PersistentVolumeClaim of the RabbitmqCluster exists", func() {
+		BeforeEach(func() {
+			objects = []client.Object{&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "persistence-rabbit-server-1", Namespace: "default"}}}
+		})
+
+		It("fails without creating the RabbitmqCluster", func(ctx SpecContext) {
+			reconcile(ctx)
+			status := getRestore(ctx).Status
+			Expect(status.Phase).To(Equal(rabbitmqv1beta1.BackupPhaseFailed))
+			Expect(status.Message).To(Equal("PersistentVolumeClaim persistence-rabbit-server-1 already exists"))
+
+			clusters := &rabbitmqv1beta1.RabbitmqClusterList{}
+			Expect(fakeClient.List(ctx, clusters)).To(Succeed())
+			Expect(clusters.Items).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// additionalConfigFromRecheckInterval is how often the content referenced in spec.rabbitmq.additionalConfigFrom is read again,
+// which picks up changes to ConfigMaps and Secrets which are not labelled with app.kubernetes.io/part-of=rabbitmq, and therefore not watched
+const additionalConfigFromRecheckInterval = 10 * time.Minute
+
+// rabbitmqClustersForAdditionalConfig maps a ConfigMap or Secret to the RabbitmqClusters in its namespace referencing it
+// in spec.rabbitmq.additionalConfigFrom, so that changes to its content restart the nodes
+func (r *RabbitmqClusterReconciler) rabbitmqClustersForAdditionalConfig(ctx context.Context, obj client.Object) []reconcile.Request {
+	clusters := &rabbitmqv1beta1.RabbitmqClusterList{}
+	if err := r.List(ctx, clusters, client.InNamespace(obj.GetNamespace())); err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "failed to list RabbitmqClusters for additionalConfigFrom", "name", obj.GetName())
+		return nil
+	}
+	_, isSecret := obj.(*corev1.Secret)
+	var requests []reconcile.Request
+	for _, cluster := range clusters.Items {
+		for _, source := range cluster.Spec.Rabbitmq.AdditionalConfigFrom {
+			if (isSecret && source.SecretKeyRef != nil && source.SecretKeyRef.Name == obj.GetName()) ||
+				(!isSecret && source.ConfigMapKeyRef != nil && source.ConfigMapKeyRef.Name == obj.GetName()) {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace},
+				})
+				break
+			}
+		}
+	}
+	return requests
+}
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("rabbitmqClustersForAdditionalConfig", func() {
+	var reconciler *RabbitmqClusterReconciler
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(rabbitmqv1beta1.AddToScheme(scheme)).To(Succeed())
+		rmq := &rabbitmqv1beta1.RabbitmqCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "rabbit", Namespace: "default"},
+			Spec: rabbitmqv1beta1.RabbitmqClusterSpec{
+				Rabbitmq: rabbitmqv1beta1.RabbitmqClusterConfigurationSpec{
+					AdditionalConfigFrom: []rabbitmqv1beta1.AdditionalConfigSource{
+						{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "tuning"},
+							Key:                  "rabbitmq.conf",
+						}},
+						{SecretKeyRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "shovels"},
+							Key:                  "shovels.conf",
+						}},
+					},
+				},
+			},
+		}
+		reconciler = &RabbitmqClusterReconciler{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(rmq).Build()}
+	})
+
+	It("maps referenced ConfigMaps and Secrets to the RabbitmqCluster", func(ctx SpecContext) {
+		request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "rabbit", Namespace: "default"}}
+		Expect(reconciler.rabbitmqClustersForAdditionalConfig(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "tuning", Namespace: "default"},
+		})).To(ConsistOf(request))
+		Expect(reconciler.rabbitmqClustersForAdditionalConfig(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "shovels", Namespace: "default"},
+		})).To(ConsistOf(request))
+	})
+
+	It("ignores objects of another kind or namespace", func(ctx SpecContext) {
+		Expect(reconciler.rabbitmqClustersForAdditionalConfig(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "tuning", Namespace: "default"},
+		})).To(BeEmpty())
+		Expect(reconciler.rabbitmqClustersForAdditionalConfig(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "tuning", Namespace: "other"},
+		})).To(BeEmpty())
+	})
+})
@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// authSecret is a Secret rendering a credential of spec.auth into the conf.d directory of the nodes
+type authSecret struct {
+	name        string
+	description string
+	// selector references the credential, or is nil if it is not set
+	selector *corev1.SecretKeySelector
+	build    func(*rabbitmqv1beta1.RabbitmqCluster, string) (*corev1.Secret, error)
+}
+
+func authSecrets(rmq *rabbitmqv1beta1.RabbitmqCluster) []authSecret {
+	secrets := []authSecret{
+		{name: resource.OAuth2SecretName, description: "OAuth 2.0 client secret", build: resource.OAuth2Secret},
+		{name: resource.LDAPSecretName, description: "LDAP bind password", build: resource.LDAPSecret},
+	}
+	if rmq.OAuth2ClientSecretEnabled() {
+		secrets[0].selector = rmq.Spec.Auth.OAuth2.Management.ClientSecret
+	}
+	if rmq.LDAPBindPasswordEnabled() {
+		secrets[1].selector = rmq.Spec.Auth.LDAP.BindPassword
+	}
+	return secrets
+}
+
+// reconcileAuthSecrets renders the credentials of spec.auth, such as the client secret of the management UI
+// and the LDAP bind password, into Secrets mounted in the conf.d directory of the nodes, so that they are not
+// stored in the server ConfigMap. Secrets of credentials which are not set are deleted.
+// It runs before the StatefulSet is created, whose Pods cannot start without these Secrets.
+// It returns the hash of the rendered Secrets, which restarts the nodes through the server ConfigMap when a credential changes.
+func (r *RabbitmqClusterReconciler) reconcileAuthSecrets(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster) (string, error) {
+	var confs [][]byte
+	for _, authSecret := range authSecrets(rmq) {
+		desired, err := r.reconcileAuthSecret(ctx, rmq, authSecret)
+		if err != nil {
+			return "", err
+		}
+		if desired != nil {
+			for _, conf := range desired.Data {
+				confs = append(confs, conf)
+			}
+		}
+	}
+	return resource.AuthSecretsHash(confs), nil
+}
+
+// reconcileAuthSecret returns the desired Secret, or nil if the credential is not set
+func (r *RabbitmqClusterReconciler) reconcileAuthSecret(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster, authSecret authSecret) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{Name: rmq.ChildResourceName(authSecret.name), Namespace: rmq.Namespace}
+	if authSecret.selector == nil {
+		if err := r.Get(ctx, key, secret); err != nil {
+			return nil, client.IgnoreNotFound(err)
+		}
+		if err := r.Delete(ctx, secret); client.IgnoreNotFound(err) != nil {
+			return nil, fmt.Errorf("failed to delete %s Secret: %w", authSecret.description, err)
+		}
+		return nil, nil
+	}
+
+	selector := authSecret.selector
+	source := &corev1.Secret{}
+	if err := r.APIReader.Get(ctx, types.NamespacedName{Name: selector.Name, Namespace: rmq.Namespace}, source); err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", authSecret.description, err)
+	}
+	value, ok := source.Data[selector.Key]
+	if !ok {
+		return nil, fmt.Errorf("secret %s does not have the key %s of the %s", selector.Name, selector.Key, authSecret.description)
+	}
+
+	desired, err := authSecret.build(rmq, string(value))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s in secret %s: %w", authSecret.description, selector.Name, err)
+	}
+	secret.ObjectMeta = desired.ObjectMeta
+	operationResult, err := controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		secret.Labels = desired.Labels
+		secret.Type = desired.Type
+		secret.Data = desired.Data
+		return controllerutil.SetControllerReference(rmq, secret, r.Scheme)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile %s Secret: %w", authSecret.description, err)
+	}
+	if operationResult == controllerutil.OperationResultUpdated {
+		// conf.d is read at boot
+		msg := fmt.Sprintf("%s changed; the nodes will be restarted to apply it", authSecret.description)
+		ctrl.LoggerFrom(ctx).Info(msg)
+		r.Recorder.Event(rmq, corev1.EventTypeNormal, "AuthSecretUpdated", msg)
+	}
+	return desired, nil
+}
+
+// rabbitmqClustersForAuthSecrets maps a Secret to the RabbitmqClusters in its namespace referencing it in spec.auth
+func (r *RabbitmqClusterReconciler) rabbitmqClustersForAuthSecrets(ctx context.Context, obj client.Object) []reconcile.Request {
+	clusters := &rabbitmqv1beta1.RabbitmqClusterList{}
+	if err := r.List(ctx, clusters, client.InNamespace(obj.GetNamespace())); err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "failed to list RabbitmqClusters for spec.auth Secret", "secret", obj.GetName())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, cluster := range clusters.Items {
+		for _, authSecret := range authSecrets(&cluster) {
+			if authSecret.selector != nil && authSecret.selector.Name == obj.GetName() {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace},
+				})
+				break
+			}
+		}
+	}
+	return requests
+}
@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("reconcileAuthSecrets", func() {
+	var (
+		rmq          *rabbitmqv1beta1.RabbitmqCluster
+		clientSecret *corev1.Secret
+		fakeClient   client.Client
+		reconciler   *RabbitmqClusterReconciler
+		oauth2Key    = types.NamespacedName{Name: "rabbit-oauth2", Namespace: "default"}
+	)
+
+	BeforeEach(func() {
+		rmq = &rabbitmqv1beta1.RabbitmqCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "rabbit", Namespace: "default", UID: "some-uid"},
+			Spec: rabbitmqv1beta1.RabbitmqClusterSpec{
+				Auth: rabbitmqv1beta1.AuthSpec{OAuth2: &rabbitmqv1beta1.OAuth2Spec{
+					ResourceServerID: "rabbitmq",
+					IssuerURL:        "https://idp.example.com",
+					Management: &rabbitmqv1beta1.OAuth2ManagementSpec{
+						ClientID: "rabbitmq-management",
+						ClientSecret: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "idp-client"},
+							Key:                  "client-secret",
+						},
+					},
+				}},
+			},
+		}
+		clientSecret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "idp-client", Namespace: "default"},
+			Data:       map[string][]byte{"client-secret": []byte("s3cr3t")},
+		}
+	})
+
+	JustBeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		Expect(rabbitmqv1beta1.AddToScheme(scheme)).To(Succeed())
+		fakeClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(rmq, clientSecret).Build()
+		reconciler = &RabbitmqClusterReconciler{
+			Client:    fakeClient,
+			APIReader: fakeClient,
+			Scheme:    scheme,
+			Recorder:  record.NewFakeRecorder(10),
+		}
+	})
+
+	It("creates the OAuth 2.0 Secret owned by the RabbitmqCluster", func(ctx SpecContext) {
+		_, err := reconciler.reconcileAuthSecrets(ctx, rmq)
+		Expect(err).NotTo(HaveOccurred())
+
+		secret := &corev1.Secret{}
+		Expect(fakeClient.Get(ctx, oauth2Key, secret)).To(Succeed())
+		Expect(secret.Data).To(HaveKeyWithValue("oauth2.conf", []byte("management.oauth_client_secret = s3cr3t\n")))
+		Expect(secret.OwnerReferences).To(ConsistOf(HaveField("Name", "rabbit")))
+	})
+
+	It("updates the OAuth 2.0 Secret when the client secret changes", func(ctx SpecContext) {
+		_, err := reconciler.reconcileAuthSecrets(ctx, rmq)
+		Expect(err).NotTo(HaveOccurred())
+		clientSecret.Data["client-secret"] = []byte("rotated")
+		Expect(fakeClient.Update(ctx, clientSecret)).To(Succeed())
+
+		_, err = reconciler.reconcileAuthSecrets(ctx, rmq)
+		Expect(err).NotTo(HaveOccurred())
+		secret := &corev1.Secret{}
+		Expect(fakeClient.Get(ctx, oauth2Key, secret)).To(Succeed())
+		Expect(secret.Data).To(HaveKeyWithValue("oauth2.conf", []byte("management.oauth_client_secret = rotated\n")))
+		Expect(reconciler.Recorder.(*record.FakeRecorder).Events).To(Receive(ContainSubstring("OAuth 2.0 client secret changed")))
+	})
+
+	It("fails when the client secret does not have the key", func(ctx SpecContext) {
+		rmq.Spec.Auth.OAuth2.Management.ClientSecret.Key = "missing"
+		_, err := reconciler.reconcileAuthSecrets(ctx, rmq)
+		Expect(err).To(MatchError(ContainSubstring("does not have the key missing of the OAuth 2.0 client secret")))
+	})
+
+	It("returns a hash which changes with the client secret", func(ctx SpecContext) {
+		hash, err := reconciler.reconcileAuthSecrets(ctx, rmq)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hash).NotTo(BeEmpty())
+
+		clientSecret.Data["client-secret"] = []byte("rotated")
+		Expect(fakeClient.Update(ctx, clientSecret)).To(Succeed())
+		rotatedHash, err := reconciler.reconcileAuthSecrets(ctx, rmq)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rotatedHash).NotTo(Equal(hash))
+
+		rmq.Spec.Auth.OAuth2.Management.ClientSecret = nil
+		Expect(reconciler.reconcileAuthSecrets(ctx, rmq)).To(BeEmpty())
+	})
+
+	It("fails when the client secret spans several lines", func(ctx SpecContext) {
+		clientSecret.Data["client-secret"] = []byte("s3cr3t\nauth_backends.1 = anonymous")
+		Expect(fakeClient.Update(ctx, clientSecret)).To(Succeed())
+
+		_, err := reconciler.reconcileAuthSecrets(ctx, rmq)
+		Expect(err).To(MatchError(ContainSubstring("invalid OAuth 2.0 client secret in secret idp-client")))
+		Expect(k8serrors.IsNotFound(fakeClient.Get(ctx, oauth2Key, &corev1.Secret{}))).To(BeTrue())
+	})
+
+	It("deletes the OAuth 2.0 Secret when the client secret is removed", func(ctx SpecContext) {
+		_, err := reconciler.reconcileAuthSecrets(ctx, rmq)
+		Expect(err).NotTo(HaveOccurred())
+		rmq.Spec.Auth.OAuth2.Management.ClientSecret = nil
+
+		_, err = reconciler.reconcileAuthSecrets(ctx, rmq)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(k8serrors.IsNotFound(fakeClient.Get(ctx, oauth2Key, &corev1.Secret{}))).To(BeTrue())
+	})
+
+	It("creates the LDAP Secret with the bind password", func(ctx SpecContext) {
+		rmq.Spec.Auth.LDAP = &rabbitmqv1beta1.LDAPSpec{
+			Servers:       []string{"ldap.example.com"},
+			UserDNPattern: "cn=${username},ou=People,dc=example,dc=com",
+			BindDN:        "cn=rabbitmq,dc=example,dc=com",
+			BindPassword: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "idp-client"},
+				Key:                  "bind-password",
+			},
+		}
+		clientSecret.Data["bind-password"] = []byte("p4ss")
+		Expect(fakeClient.Update(ctx, clientSecret)).To(Succeed())
+
+		_, err := reconciler.reconcileAuthSecrets(ctx, rmq)
+		Expect(err).NotTo(HaveOccurred())
+		secret := &corev1.Secret{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "rabbit-ldap", Namespace: "default"}, secret)).To(Succeed())
+		Expect(secret.Data).To(HaveKeyWithValue("ldap.conf", []byte("auth_ldap.other_bind.password = p4ss\n")))
+		Expect(fakeClient.Get(ctx, oauth2Key, &corev1.Secret{})).To(Succeed())
+	})
+
+	It("maps the client secret to the RabbitmqClusters using it", func(ctx SpecContext) {
+		Expect(reconciler.rabbitmqClustersForAuthSecrets(ctx, clientSecret)).To(ConsistOf(
+			reconcile.Request{NamespacedName: types.NamespacedName{Name: "rabbit", Namespace: "default"}}))
+		Expect(reconciler.rabbitmqClustersForAuthSecrets(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"},
+		})).To(BeEmpty())
+	})
+})
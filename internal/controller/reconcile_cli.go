@@ -2,13 +2,20 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
+	rabbithole "github.com/michaelklishin/rabbit-hole/v3"
 	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/rabbitmqclient"
 	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -42,10 +49,47 @@ func (r *RabbitmqClusterReconciler) runRabbitmqCLICommandsIfAnnotated(ctx contex
 		return 2 * time.Second, nil
 	}
 
-	if pluginsConfig.Annotations != nil && pluginsConfig.Annotations[pluginsUpdateAnnotation] != "" {
+	// Plugin changes and queue rebalances are held back outside of maintenance windows
+	maintenanceWindowOpen, _ := maintenanceWindow(rmq, time.Now())
+	pluginsChanged := pluginsConfig.Annotations[pluginsUpdateAnnotation] != ""
+	if pluginsChanged && maintenanceWindowOpen {
 		if err = r.runSetPluginsCommand(ctx, rmq, pluginsConfig); err != nil {
 			return 0, err
 		}
+	} else if !r.PodExecDisabled && pluginsStatusIncomplete(rmq) {
+		// e.g. new or scaled out clusters, whose plugins have not been changed on the running nodes
+		if err = r.reportEnabledPlugins(ctx, rmq, pluginsConfig); err != nil {
+			return 0, err
+		}
+	}
+
+	serverConf, err := r.configMap(ctx, rmq, rmq.ChildResourceName(resource.ServerConfigMapName))
+	if client.IgnoreNotFound(err) != nil {
+		return 0, err
+	}
+	if err == nil && serverConf.Annotations[runtimeConfigUpdateAnnotation] != "" {
+		if err = r.runApplyRuntimeConfigCommands(ctx, rmq, serverConf); err != nil {
+			return 0, err
+		}
+	}
+
+	if rmq.StreamPerPodServicesEnabled() {
+		streamAdvertisedConfig, err := r.configMap(ctx, rmq, rmq.ChildResourceName(resource.StreamAdvertisedConfigMapName))
+		if client.IgnoreNotFound(err) != nil {
+			return 0, err
+		}
+		if err == nil && streamAdvertisedConfig.Annotations[streamAdvertisedUpdateAnnotation] != "" {
+			if err = r.runSetStreamAdvertisedAddressesCommand(ctx, rmq, streamAdvertisedConfig); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	// If the TLS certificates have been rotated, make RabbitMQ reload them
+	if rmq.Annotations != nil && rmq.Annotations[tlsCertificatesUpdateAnnotation] != "" {
+		if requeueAfter, err := r.runClearPEMCacheCommand(ctx, rmq); err != nil || requeueAfter > 0 {
+			return requeueAfter, err
+		}
 	}
 
 	// If RabbitMQ cluster is newly created, enable all feature flags since some are disabled by default
@@ -56,23 +100,24 @@ func (r *RabbitmqClusterReconciler) runRabbitmqCLICommandsIfAnnotated(ctx contex
 	}
 
 	// If the cluster has been marked as needing it, run rabbitmq-queues rebalance all
-	if rmq.Annotations != nil && rmq.Annotations[queueRebalanceAnnotation] != "" {
+	rebalanceNeeded := rmq.Annotations[queueRebalanceAnnotation] != ""
+	if rebalanceNeeded && maintenanceWindowOpen {
 		if err := r.runQueueRebalanceCommand(ctx, rmq); err != nil {
 			return 0, err
 		}
 	}
 
-	return 0, nil
+	return 0, r.updateDeferredActions(ctx, rmq, map[rabbitmqv1beta1.DeferredAction]bool{
+		rabbitmqv1beta1.DeferredPluginChange:   pluginsChanged && !maintenanceWindowOpen,
+		rabbitmqv1beta1.DeferredQueueRebalance: rebalanceNeeded && !maintenanceWindowOpen,
+	})
 }
 
 func (r *RabbitmqClusterReconciler) runEnableFeatureFlagsCommand(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster, sts *appsv1.StatefulSet) error {
 	logger := ctrl.LoggerFrom(ctx)
 	podName := fmt.Sprintf("%s-0", rmq.ChildResourceName("server"))
-	cmd := "rabbitmqctl enable_feature_flag all"
-	stdout, stderr, err := r.exec(rmq.Namespace, podName, "rabbitmq", "bash", "-c", cmd)
-	if err != nil {
+	if err := r.runThroughManagementAPI(ctx, rmq, podName, enableAllFeatureFlags, "rabbitmqctl enable_feature_flag all"); err != nil {
 		msg := "failed to enable all feature flags on pod"
-		logger.Error(err, msg, "pod", podName, "command", cmd, "stdout", stdout, "stderr", stderr)
 		r.Recorder.Event(rmq, corev1.EventTypeWarning, "FailedReconcile", fmt.Sprintf("%s %s", msg, podName))
 		return fmt.Errorf("%s %s: %w", msg, podName, err)
 	}
@@ -80,37 +125,203 @@ func (r *RabbitmqClusterReconciler) runEnableFeatureFlagsCommand(ctx context.Con
 	return r.deleteAnnotation(ctx, sts, stsCreateAnnotation)
 }
 
+// enableAllFeatureFlags enables what 'rabbitmqctl enable_feature_flag all' enables: all disabled feature flags which are not experimental
+func enableAllFeatureFlags(rabbitClient rabbitmqclient.RabbitmqClient) error {
+	featureFlags, err := rabbitClient.ListFeatureFlags()
+	if err != nil {
+		return fmt.Errorf("failed to list feature flags: %w", err)
+	}
+	for _, featureFlag := range featureFlags {
+		if featureFlag.State != rabbithole.StateDisabled || featureFlag.Stability == rabbithole.StabilityExperimental {
+			continue
+		}
+		if _, err := rabbitClient.EnableFeatureFlag(featureFlag.Name); err != nil {
+			return fmt.Errorf("failed to enable feature flag %s: %w", featureFlag.Name, err)
+		}
+	}
+	return nil
+}
+
+// runThroughManagementAPI runs an operation through the management API of a node.
+// If the API call fails and the operator runs with pod exec fallback, it runs the equivalent CLI command in the pod instead.
+func (r *RabbitmqClusterReconciler) runThroughManagementAPI(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster, podName string, operation func(rabbitmqclient.RabbitmqClient) error, cmd string) error {
+	logger := ctrl.LoggerFrom(ctx)
+	rabbitClient, err := r.RabbitmqClientFactory.GetClientForPod(ctx, r.APIReader, rmq, podName)
+	if err == nil {
+		err = operation(rabbitClient)
+	}
+	if err == nil {
+		return nil
+	}
+	if !r.PodExecFallback {
+		logger.Error(err, "management API call failed", "pod", podName)
+		return err
+	}
+
+	logger.Info("management API call failed; running the command in the pod instead", "pod", podName, "command", cmd, "error", err.Error())
+	stdout, stderr, err := r.exec(rmq.Namespace, podName, "rabbitmq", "sh", "-c", cmd)
+	if err != nil {
+		logger.Error(err, "failed to run command in pod", "pod", podName, "command", cmd, "stdout", stdout, "stderr", stderr)
+		return err
+	}
+	return nil
+}
+
+// maxParallelPluginCommands bounds the number of nodes the operator changes the plugins of at the same time
+const maxParallelPluginCommands = 5
+
+// listEnabledPluginsCommand lists the plugins enabled in the enabled_plugins file. Plugins only enabled as
+// dependencies of other plugins, such as rabbitmq_management_agent, are not listed, so that the list can be
+// compared with the desired plugins.
+const listEnabledPluginsCommand = "rabbitmq-plugins list --explicitly-enabled --minimal --silent"
+
 // There are 2 paths how plugins are set:
 // 1. When StatefulSet is (re)started, the up-to-date plugins list (ConfigMap copied by the init container) is read by RabbitMQ nodes during node start up.
 // 2. When the plugins ConfigMap is changed, 'rabbitmq-plugins set' updates the plugins on every node (without the need to re-start the nodes).
-// This method implements the 2nd path.
+// This method implements the 2nd path. Nodes are updated in parallel, and a failure on one node does not stop the others.
+// The plugins enabled on each node are reported in status.plugins, which reportEnabledPlugins also fills when no plugins change.
+// The management API cannot set plugins, so the 1st path is taken instead when pod exec is disabled.
 func (r *RabbitmqClusterReconciler) runSetPluginsCommand(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster, configMap *corev1.ConfigMap) error {
 	logger := ctrl.LoggerFrom(ctx)
-	plugins := resource.NewRabbitmqPlugins(rmq.Spec.Rabbitmq.AdditionalPlugins)
-	for i := int32(0); i < *rmq.Spec.Replicas; i++ {
-		podName := fmt.Sprintf("%s-%d", rmq.ChildResourceName("server"), i)
-		cmd := fmt.Sprintf("rabbitmq-plugins set %s", plugins.AsString(" "))
-		stdout, stderr, err := r.exec(rmq.Namespace, podName, "rabbitmq", "sh", "-c", cmd)
-		if err != nil {
-			msg := "failed to set plugins on pod"
-			logger.Error(err, msg, "pod", podName, "command", cmd, "stdout", stdout, "stderr", stderr)
-			r.Recorder.Event(rmq, corev1.EventTypeWarning, "FailedReconcile", fmt.Sprintf("%s %s", msg, podName))
-			return fmt.Errorf("%s %s: %w", msg, podName, err)
-		}
+	if r.PodExecDisabled {
+		return r.restartToSetPlugins(ctx, rmq, configMap)
+	}
+	plugins := resource.RabbitmqPluginsFromConfigMap(configMap)
+	cmd := fmt.Sprintf("rabbitmq-plugins set %s", plugins.AsString(" "))
+
+	nodes, errs := forEachNodePlugins(rmq, func(podName string) (rabbitmqv1beta1.NodePluginsStatus, error) {
+		return r.setPluginsOnPod(ctx, rmq, podName, cmd, plugins.DesiredPlugins())
+	})
+	if err := r.updatePluginsStatus(ctx, rmq, nodes); err != nil {
+		errs = append(errs, err)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
 	}
 	logger.Info("successfully set plugins")
 	return r.deleteAnnotation(ctx, configMap, pluginsUpdateAnnotation)
 }
 
-func (r *RabbitmqClusterReconciler) runQueueRebalanceCommand(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster) error {
+// reportEnabledPlugins lists the plugins enabled on every node in status.plugins, without changing them
+func (r *RabbitmqClusterReconciler) reportEnabledPlugins(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster, configMap *corev1.ConfigMap) error {
+	plugins := resource.RabbitmqPluginsFromConfigMap(configMap)
+	nodes, _ := forEachNodePlugins(rmq, func(podName string) (rabbitmqv1beta1.NodePluginsStatus, error) {
+		return r.listPluginsOnPod(ctx, rmq, podName, plugins.DesiredPlugins()), nil
+	})
+	return r.updatePluginsStatus(ctx, rmq, nodes)
+}
+
+// pluginsStatusIncomplete returns true if status.plugins does not list the enabled plugins of every node
+func pluginsStatusIncomplete(rmq *rabbitmqv1beta1.RabbitmqCluster) bool {
+	if len(rmq.Status.Plugins) != int(*rmq.Spec.Replicas) {
+		return true
+	}
+	return slices.ContainsFunc(rmq.Status.Plugins, func(node rabbitmqv1beta1.NodePluginsStatus) bool {
+		return node.EnabledPlugins == nil
+	})
+}
+
+// forEachNodePlugins runs fn for every node, at most maxParallelPluginCommands at the same time.
+// A failure on one node does not stop the others.
+func forEachNodePlugins(rmq *rabbitmqv1beta1.RabbitmqCluster, fn func(podName string) (rabbitmqv1beta1.NodePluginsStatus, error)) ([]rabbitmqv1beta1.NodePluginsStatus, []error) {
+	nodes := make([]rabbitmqv1beta1.NodePluginsStatus, *rmq.Spec.Replicas)
+	errs := make([]error, len(nodes))
+	workers := make(chan struct{}, maxParallelPluginCommands)
+	var wg sync.WaitGroup
+	for i := range nodes {
+		wg.Go(func() {
+			workers <- struct{}{}
+			defer func() { <-workers }()
+			nodes[i], errs[i] = fn(fmt.Sprintf("%s-%d", rmq.ChildResourceName("server"), i))
+		})
+	}
+	wg.Wait()
+	return nodes, errs
+}
+
+func (r *RabbitmqClusterReconciler) updatePluginsStatus(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster, nodes []rabbitmqv1beta1.NodePluginsStatus) error {
+	if equality.Semantic.DeepEqual(nodes, rmq.Status.Plugins) {
+		return nil
+	}
+	patch := client.MergeFrom(rmq.DeepCopy())
+	rmq.Status.Plugins = nodes
+	if err := r.Status().Patch(ctx, rmq, patch); err != nil {
+		return fmt.Errorf("failed to update status of plugins: %w", err)
+	}
+	return nil
+}
+
+// restartToSetPlugins marks the server-conf ConfigMap as updated, so that restartStatefulSetIfNeeded restarts the nodes,
+// which read the updated plugins on start up. The restart honours spec.restartPolicy and maintenance windows like configuration changes.
+func (r *RabbitmqClusterReconciler) restartToSetPlugins(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster, configMap *corev1.ConfigMap) error {
 	logger := ctrl.LoggerFrom(ctx)
-	podName := fmt.Sprintf("%s-0", rmq.ChildResourceName("server"))
-	cmd := "rabbitmq-queues rebalance all"
+	serverConfName := rmq.ChildResourceName(resource.ServerConfigMapName)
+	if err := r.updateAnnotation(ctx, &corev1.ConfigMap{}, rmq.Namespace, serverConfName, serverConfAnnotation, time.Now().Format(time.RFC3339)); err != nil {
+		msg := "failed to annotate " + serverConfName
+		logger.Error(err, msg)
+		r.Recorder.Event(rmq, corev1.EventTypeWarning, "FailedUpdate", msg)
+		return err
+	}
+	r.Recorder.Event(rmq, corev1.EventTypeNormal, "PluginsChanged", "plugins are set by restarting the nodes, as pod exec is disabled")
+
+	// the plugins of the nodes are not observed without pod exec
+	if rmq.Status.Plugins != nil {
+		patch := client.MergeFrom(rmq.DeepCopy())
+		rmq.Status.Plugins = nil
+		if err := r.Status().Patch(ctx, rmq, patch); err != nil {
+			return fmt.Errorf("failed to update status of plugins: %w", err)
+		}
+	}
+	logger.Info("marked nodes for restart to set plugins")
+	return r.deleteAnnotation(ctx, configMap, pluginsUpdateAnnotation)
+}
+
+// setPluginsOnPod sets the plugins of a node, and lists the plugins enabled afterwards
+func (r *RabbitmqClusterReconciler) setPluginsOnPod(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster, podName, cmd string, desiredPlugins []string) (rabbitmqv1beta1.NodePluginsStatus, error) {
+	logger := ctrl.LoggerFrom(ctx)
+	var setErr error
 	stdout, stderr, err := r.exec(rmq.Namespace, podName, "rabbitmq", "sh", "-c", cmd)
 	if err != nil {
-		msg := "failed to run queue rebalance on pod"
+		msg := "failed to set plugins on pod"
 		logger.Error(err, msg, "pod", podName, "command", cmd, "stdout", stdout, "stderr", stderr)
 		r.Recorder.Event(rmq, corev1.EventTypeWarning, "FailedReconcile", fmt.Sprintf("%s %s", msg, podName))
+		setErr = fmt.Errorf("%s %s: %w", msg, podName, err)
+	}
+
+	node := r.listPluginsOnPod(ctx, rmq, podName, desiredPlugins)
+	if setErr != nil {
+		node.Error = setErr.Error()
+	}
+	return node, setErr
+}
+
+// listPluginsOnPod lists the plugins explicitly enabled on a node
+func (r *RabbitmqClusterReconciler) listPluginsOnPod(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster, podName string, desiredPlugins []string) rabbitmqv1beta1.NodePluginsStatus {
+	logger := ctrl.LoggerFrom(ctx)
+	node := rabbitmqv1beta1.NodePluginsStatus{Pod: podName}
+	stdout, stderr, err := r.exec(rmq.Namespace, podName, "rabbitmq", "sh", "-c", listEnabledPluginsCommand)
+	if err != nil {
+		// the plugins of the node are unknown, for example because the node is down
+		logger.Error(err, "failed to list plugins on pod", "pod", podName, "command", listEnabledPluginsCommand, "stdout", stdout, "stderr", stderr)
+		return node
+	}
+	node.EnabledPlugins = strings.Fields(stdout)
+	slices.Sort(node.EnabledPlugins)
+	desired := slices.Sorted(slices.Values(desiredPlugins))
+	node.Converged = slices.Equal(node.EnabledPlugins, desired)
+	return node
+}
+
+func (r *RabbitmqClusterReconciler) runQueueRebalanceCommand(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster) error {
+	logger := ctrl.LoggerFrom(ctx)
+	podName := fmt.Sprintf("%s-0", rmq.ChildResourceName("server"))
+	rebalanceQueues := func(rabbitClient rabbitmqclient.RabbitmqClient) error {
+		_, err := rabbitClient.RebalanceQueues()
+		return err
+	}
+	if err := r.runThroughManagementAPI(ctx, rmq, podName, rebalanceQueues, "rabbitmq-queues rebalance all"); err != nil {
+		msg := "failed to run queue rebalance on pod"
+		r.Recorder.Event(rmq, corev1.EventTypeWarning, "FailedReconcile", fmt.Sprintf("%s %s", msg, podName))
 		return fmt.Errorf("%s %s: %w", msg, podName, err)
 	}
 	logger.Info("successfully rebalanced queues")
@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	rabbithole "github.com/michaelklishin/rabbit-hole/v3"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/rabbitmqclient"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Routine operations through the management API", func() {
+	var (
+		cluster     *rabbitmqv1beta1.RabbitmqCluster
+		sts         *appsv1.StatefulSet
+		fakeClient  client.Client
+		executor    *recordingPodExecutor
+		rabbitAdmin *managementAPIRabbitmqClient
+		reconciler  *RabbitmqClusterReconciler
+	)
+
+	BeforeEach(func() {
+		cluster = &rabbitmqv1beta1.RabbitmqCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "rabbit",
+				Namespace:   "default",
+				Annotations: map[string]string{queueRebalanceAnnotation: "2026-10-18T10:00:00Z"},
+			},
+		}
+		sts = &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{
+			Name:        cluster.ChildResourceName("server"),
+			Namespace:   cluster.Namespace,
+			Annotations: map[string]string{stsCreateAnnotation: "2026-10-18T10:00:00Z"},
+		}}
+		scheme := runtime.NewScheme()
+		Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(rabbitmqv1beta1.AddToScheme(scheme)).To(Succeed())
+		fakeClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster, sts).Build()
+		executor = &recordingPodExecutor{}
+		rabbitAdmin = &managementAPIRabbitmqClient{featureFlags: []rabbithole.FeatureFlag{
+			{Name: "khepri_db", State: rabbithole.StateDisabled, Stability: rabbithole.StabilityStable},
+			{Name: "rabbitmq_4.0.0", State: rabbithole.StateEnabled, Stability: rabbithole.StabilityStable},
+			{Name: "rabbitmq_4.2.0", State: rabbithole.StateDisabled, Stability: rabbithole.StabilityExperimental},
+		}}
+		reconciler = &RabbitmqClusterReconciler{
+			Client:                fakeClient,
+			Scheme:                scheme,
+			Recorder:              record.NewFakeRecorder(10),
+			PodExecutor:           executor,
+			RabbitmqClientFactory: &staticRabbitmqClientFactory{client: rabbitAdmin},
+		}
+	})
+
+	It("enables the disabled feature flags which are not experimental", func(ctx SpecContext) {
+		Expect(reconciler.runEnableFeatureFlagsCommand(ctx, cluster, sts)).To(Succeed())
+		Expect(rabbitAdmin.requests).To(Equal([]string{"PUT /api/feature-flags/khepri_db/enable"}))
+		Expect(executor.commands).To(BeEmpty())
+		Expect(sts.Annotations).NotTo(HaveKey(stsCreateAnnotation))
+	})
+
+	It("rebalances the queues", func(ctx SpecContext) {
+		Expect(reconciler.runQueueRebalanceCommand(ctx, cluster)).To(Succeed())
+		Expect(rabbitAdmin.requests).To(Equal([]string{"POST /api/rebalance/queues"}))
+		Expect(executor.commands).To(BeEmpty())
+		Expect(cluster.Annotations).NotTo(HaveKey(queueRebalanceAnnotation))
+	})
+
+	When("the management API fails", func() {
+		BeforeEach(func() {
+			rabbitAdmin.err = errors.New("connection refused")
+		})
+
+		It("keeps the annotations to retry without pod exec fallback", func(ctx SpecContext) {
+			Expect(reconciler.runEnableFeatureFlagsCommand(ctx, cluster, sts)).To(MatchError(ContainSubstring("connection refused")))
+			Expect(reconciler.runQueueRebalanceCommand(ctx, cluster)).To(MatchError(ContainSubstring("connection refused")))
+			Expect(executor.commands).To(BeEmpty())
+			Expect(sts.Annotations).To(HaveKey(stsCreateAnnotation))
+			Expect(cluster.Annotations).To(HaveKey(queueRebalanceAnnotation))
+		})
+
+		It("runs the commands in the pod with pod exec fallback", func(ctx SpecContext) {
+			reconciler.PodExecFallback = true
+			Expect(reconciler.runEnableFeatureFlagsCommand(ctx, cluster, sts)).To(Succeed())
+			Expect(reconciler.runQueueRebalanceCommand(ctx, cluster)).To(Succeed())
+			Expect(executor.commands).To(Equal([]string{
+				"rabbit-server-0: rabbitmqctl enable_feature_flag all",
+				"rabbit-server-0: rabbitmq-queues rebalance all",
+			}))
+			Expect(sts.Annotations).NotTo(HaveKey(stsCreateAnnotation))
+			Expect(cluster.Annotations).NotTo(HaveKey(queueRebalanceAnnotation))
+		})
+	})
+})
+
+// managementAPIRabbitmqClient lists the given feature flags and records the requests changing RabbitMQ, or fails them all with err
+type managementAPIRabbitmqClient struct {
+	rabbitmqclient.RabbitmqClient
+	featureFlags []rabbithole.FeatureFlag
+	err          error
+
+	mu       sync.Mutex
+	requests []string
+}
+
+func (c *managementAPIRabbitmqClient) record(request string) (*http.Response, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = append(c.requests, request)
+	return &http.Response{StatusCode: http.StatusNoContent}, nil
+}
+
+func (c *managementAPIRabbitmqClient) ListFeatureFlags() ([]rabbithole.FeatureFlag, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.featureFlags, nil
+}
+
+func (c *managementAPIRabbitmqClient) EnableFeatureFlag(featureFlagName string) (*http.Response, error) {
+	return c.record(fmt.Sprintf("PUT /api/feature-flags/%s/enable", featureFlagName))
+}
+
+func (c *managementAPIRabbitmqClient) RebalanceQueues() (*http.Response, error) {
+	return c.record("POST /api/rebalance/queues")
+}
@@ -52,8 +52,7 @@ var _ = Describe("Reconcile CLI", func() {
 					Expect(client.Get(ctx, types.NamespacedName{Namespace: cluster.Namespace, Name: cluster.ChildResourceName("server")}, sts)).To(Succeed())
 					return sts.ObjectMeta.Annotations
 				}, 5).ShouldNot(HaveKey("rabbitmq.com/createdAt"))
-				Expect(fakeExecutor.ExecutedCommands()).To(ContainElement(command{"bash", "-c",
-					"rabbitmqctl enable_feature_flag all"}))
+				Expect(fakeRabbitmqFactory.Requests()).To(ContainElement("PUT /api/feature-flags/khepri_db/enable"))
 			})
 		})
 	})
@@ -129,8 +128,8 @@ var _ = Describe("Reconcile CLI", func() {
 					Eventually(k.Object(rmq)).Within(time.Second * 5).WithPolling(time.Second).Should(HaveField("ObjectMeta.Annotations", HaveKey("rabbitmq.com/queueRebalanceNeededAt")))
 
 					// by not running the commands
-					Expect(fakeExecutor.ExecutedCommands()).NotTo(ContainElement(command{"sh", "-c", "rabbitmq-queues rebalance all"}))
-					Expect(fakeExecutor.ExecutedCommands()).NotTo(ContainElement(command{"bash", "-c", "rabbitmqctl enable_feature_flag all"}))
+					Expect(fakeRabbitmqFactory.Requests()).NotTo(ContainElement("POST /api/rebalance/queues"))
+					Expect(fakeRabbitmqFactory.Requests()).NotTo(ContainElement("PUT /api/feature-flags/khepri_db/enable"))
 					_, err := time.Parse(time.RFC3339, rmq.Annotations["rabbitmq.com/queueRebalanceNeededAt"])
 					Expect(err).NotTo(HaveOccurred(), "Annotation rabbitmq.com/queueRebalanceNeededAt was not a valid RFC3339 timestamp")
 				})
@@ -147,9 +146,9 @@ var _ = Describe("Reconcile CLI", func() {
 					rmq.Namespace = defaultNamespace
 					Eventually(k.Object(rmq)).Within(time.Second * 5).WithPolling(time.Second).ShouldNot(HaveField("ObjectMeta.Annotations", HaveKey("rabbitmq.com/queueRebalanceNeededAt")))
 
-					// by executing the commands
-					Expect(fakeExecutor.ExecutedCommands()).To(ContainElement(command{"sh", "-c", "rabbitmq-queues rebalance all"}))
-					Expect(fakeExecutor.ExecutedCommands()).To(ContainElement(command{"bash", "-c", "rabbitmqctl enable_feature_flag all"}))
+					// by calling the management API
+					Expect(fakeRabbitmqFactory.Requests()).To(ContainElement("POST /api/rebalance/queues"))
+					Expect(fakeRabbitmqFactory.Requests()).To(ContainElement("PUT /api/feature-flags/khepri_db/enable"))
 				})
 			})
 		})
@@ -177,38 +176,38 @@ var _ = Describe("Reconcile CLI", func() {
 			})
 
 			When("disabled", func() {
-				It("doesn't call enable_feature_flag CLI", func() {
+				It("doesn't enable feature flags", func() {
 					// BeforeEach updated the STS from zero replicas ready to all ready
 					// Initial rabbitmqcluster create command has reconciles pending to have
-					// all replicas ready to execute commands. We have to reset the "registry"
-					// of commands.
-					fakeExecutor.ResetExecutedCommands()
+					// all replicas ready to call the management API. We have to reset the "registry"
+					// of requests.
+					fakeRabbitmqFactory.ResetRequests()
 
 					Eventually(k.Update(cluster, func() {
 						if cluster != nil {
 							cluster.Spec.AutoEnableAllFeatureFlags = false
 						}
 					})).Should(Succeed())
-					Consistently(fakeExecutor.ExecutedCommands).Within(time.Second * 5).WithPolling(time.Second).
-						ShouldNot(ContainElement(command{"bash", "-c", "rabbitmqctl enable_feature_flag all"}))
+					Consistently(fakeRabbitmqFactory.Requests).Within(time.Second * 5).WithPolling(time.Second).
+						ShouldNot(ContainElement("PUT /api/feature-flags/khepri_db/enable"))
 				})
 			})
 
 			When("enabled", func() {
-				It("calls enable_feature_flag CLI", func() {
+				It("enables all feature flags", func() {
 					// BeforeEach updated the STS from zero replicas ready to all ready
 					// Initial rabbitmqcluster create command has reconciles pending to have
-					// all replicas ready to execute commands. We have to reset the "registry"
-					// of commands.
-					fakeExecutor.ResetExecutedCommands()
+					// all replicas ready to call the management API. We have to reset the "registry"
+					// of requests.
+					fakeRabbitmqFactory.ResetRequests()
 
 					Eventually(k.Update(cluster, func() {
 						if cluster != nil {
 							cluster.Spec.AutoEnableAllFeatureFlags = true
 						}
 					})).Should(Succeed())
-					Eventually(fakeExecutor.ExecutedCommands).Within(time.Second * 5).WithPolling(time.Second).
-						Should(ContainElement(command{"bash", "-c", "rabbitmqctl enable_feature_flag all"}))
+					Eventually(fakeRabbitmqFactory.Requests).Within(time.Second * 5).WithPolling(time.Second).
+						Should(ContainElement("PUT /api/feature-flags/khepri_db/enable"))
 				})
 			})
 		})
@@ -266,7 +265,7 @@ var _ = Describe("Reconcile CLI", func() {
 						Expect(err).ToNot(HaveOccurred())
 						return rmq.ObjectMeta.Annotations
 					}, 5).ShouldNot(HaveKey("rabbitmq.com/queueRebalanceNeededAt"))
-					Expect(fakeExecutor.ExecutedCommands()).NotTo(ContainElement(command{"sh", "-c", "rabbitmq-queues rebalance all"}))
+					Expect(fakeRabbitmqFactory.Requests()).NotTo(ContainElement("POST /api/rebalance/queues"))
 				})
 			})
 		})
@@ -325,7 +324,7 @@ var _ = Describe("Reconcile CLI", func() {
 						Expect(err).ToNot(HaveOccurred())
 						return rmq.ObjectMeta.Annotations
 					}, 5).ShouldNot(HaveKey("rabbitmq.com/queueRebalanceNeededAt"))
-					Expect(fakeExecutor.ExecutedCommands()).NotTo(ContainElement(command{"sh", "-c", "rabbitmq-queues rebalance all"}))
+					Expect(fakeRabbitmqFactory.Requests()).NotTo(ContainElement("POST /api/rebalance/queues"))
 				})
 			})
 		})
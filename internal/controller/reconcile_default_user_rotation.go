@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	rabbithole "github.com/michaelklishin/rabbit-hole/v3"
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/rabbitmqclient"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// rotateDefaultUserAnnotation is set by users on the RabbitmqCluster to rotate the default user credentials once
+	rotateDefaultUserAnnotation = "rabbitmq.com/rotateDefaultUserCredentials"
+	// set on the default user Secret by the operator
+	defaultUserRotatedAtAnnotation = "rabbitmq.com/credentialsRotatedAt"
+	previousDefaultUserAnnotation  = "rabbitmq.com/previousDefaultUser"
+)
+
+// reconcileDefaultUserRotation rotates the default user credentials when spec.defaultUser.rotation.interval elapsed
+// or when requested with an annotation.
+// Since a user has a single password in RabbitMQ, a new user with the tags and permissions of the current default user
+// is created and written to the default user Secret. The previous user is deleted once the grace period is over,
+// so that clients have time to pick up the new credentials.
+// It returns the time after which the rotation needs to be reconciled again, or 0 if there is nothing scheduled.
+func (r *RabbitmqClusterReconciler) reconcileDefaultUserRotation(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster) (time.Duration, error) {
+	logger := ctrl.LoggerFrom(ctx)
+	_, requested := rmq.Annotations[rotateDefaultUserAnnotation]
+
+	if !rmq.DefaultUserRotationSupported() {
+		if !requested {
+			return 0, nil
+		}
+		msg := "default user credentials cannot be rotated since they are not generated by the operator"
+		logger.Info(msg)
+		r.Recorder.Event(rmq, corev1.EventTypeWarning, "DefaultUserRotationNotSupported", msg)
+		return 0, r.deleteAnnotation(ctx, rmq, rotateDefaultUserAnnotation)
+	}
+
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: rmq.ChildResourceName(resource.DefaultUserSecretName), Namespace: rmq.Namespace}, secret); err != nil {
+		return 0, client.IgnoreNotFound(err)
+	}
+	previousUsername := secret.Annotations[previousDefaultUserAnnotation]
+	if !requested && previousUsername == "" && rmq.DefaultUserRotationInterval() == 0 {
+		return 0, nil
+	}
+
+	sts, err := r.statefulSet(ctx, rmq)
+	if err != nil {
+		return 0, client.IgnoreNotFound(err)
+	}
+	if !allReplicasReadyAndUpdated(sts) {
+		logger.V(1).Info("not all replicas ready yet; requeuing request to rotate default user credentials")
+		return 15 * time.Second, nil
+	}
+
+	rotatedAt := secret.CreationTimestamp.Time
+	if t, err := time.Parse(time.RFC3339, secret.Annotations[defaultUserRotatedAtAnnotation]); err == nil {
+		rotatedAt = t
+	}
+
+	rabbitClient, err := r.RabbitmqClientFactory.GetClientForService(ctx, r.APIReader, rmq)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create RabbitMQ client: %w", err)
+	}
+
+	if previousUsername != "" {
+		// the next rotation waits for the end of the grace period of the current one
+		if remaining := time.Until(rotatedAt.Add(rmq.DefaultUserRotationGracePeriod())); remaining > 0 {
+			return remaining, nil
+		}
+		if _, err := rabbitClient.DeleteUser(previousUsername); err != nil {
+			return 0, fmt.Errorf("failed to delete previous default user %s: %w", previousUsername, err)
+		}
+		delete(secret.Annotations, previousDefaultUserAnnotation)
+		if err := r.Update(ctx, secret); err != nil {
+			return 0, fmt.Errorf("failed to update default user Secret: %w", err)
+		}
+		logger.Info("deleted previous default user", "user", previousUsername)
+	}
+
+	if !requested {
+		interval := rmq.DefaultUserRotationInterval()
+		if interval == 0 {
+			return 0, nil
+		}
+		if remaining := time.Until(rotatedAt.Add(interval)); remaining > 0 {
+			return remaining, nil
+		}
+	}
+
+	if err := r.rotateDefaultUser(ctx, rmq, secret, rabbitClient); err != nil {
+		r.Recorder.Event(rmq, corev1.EventTypeWarning, "FailedDefaultUserRotation", err.Error())
+		return 0, err
+	}
+	if requested {
+		if err := r.deleteAnnotation(ctx, rmq, rotateDefaultUserAnnotation); err != nil {
+			return 0, err
+		}
+	}
+	return rmq.DefaultUserRotationGracePeriod(), nil
+}
+
+func (r *RabbitmqClusterReconciler) rotateDefaultUser(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster, secret *corev1.Secret, rabbitClient rabbitmqclient.RabbitmqClient) error {
+	logger := ctrl.LoggerFrom(ctx)
+	currentUsername := string(secret.Data["username"])
+
+	currentUser, err := rabbitClient.GetUser(currentUsername)
+	if err != nil {
+		return fmt.Errorf("failed to get default user %s: %w", currentUsername, err)
+	}
+	permissions, err := rabbitClient.ListPermissionsOf(currentUsername)
+	if err != nil {
+		return fmt.Errorf("failed to list permissions of default user %s: %w", currentUsername, err)
+	}
+	topicPermissions, err := rabbitClient.ListTopicPermissionsOf(currentUsername)
+	if err != nil {
+		return fmt.Errorf("failed to list topic permissions of default user %s: %w", currentUsername, err)
+	}
+
+	username, password, err := resource.GenerateDefaultUserCredentials()
+	if err != nil {
+		return fmt.Errorf("failed to generate default user credentials: %w", err)
+	}
+	if _, err := rabbitClient.PutUser(username, rabbithole.UserSettings{Name: username, Tags: currentUser.Tags, Password: password}); err != nil {
+		return fmt.Errorf("failed to create user %s: %w", username, err)
+	}
+	for _, p := range permissions {
+		if _, err := rabbitClient.UpdatePermissionsIn(p.Vhost, username, rabbithole.Permissions{Configure: p.Configure, Write: p.Write, Read: p.Read}); err != nil {
+			return fmt.Errorf("failed to set permissions of user %s in vhost %s: %w", username, p.Vhost, err)
+		}
+	}
+	for _, p := range topicPermissions {
+		if _, err := rabbitClient.UpdateTopicPermissionsIn(p.Vhost, username, rabbithole.TopicPermissions{Exchange: p.Exchange, Write: p.Write, Read: p.Read}); err != nil {
+			return fmt.Errorf("failed to set topic permissions of user %s in vhost %s: %w", username, p.Vhost, err)
+		}
+	}
+
+	builder := resource.RabbitmqResourceBuilder{Instance: rmq, Scheme: r.Scheme}
+	if err := builder.DefaultUserSecret().SetCredentials(secret, username, password); err != nil {
+		return err
+	}
+	if secret.Annotations == nil {
+		secret.Annotations = make(map[string]string)
+	}
+	secret.Annotations[defaultUserRotatedAtAnnotation] = time.Now().Format(time.RFC3339)
+	secret.Annotations[previousDefaultUserAnnotation] = currentUsername
+	if err := r.Update(ctx, secret); err != nil {
+		// nobody knows the password of the new user
+		if _, deleteErr := rabbitClient.DeleteUser(username); deleteErr != nil {
+			logger.Error(deleteErr, "failed to delete unused user", "user", username)
+		}
+		return fmt.Errorf("failed to update default user Secret: %w", err)
+	}
+
+	logger.Info("rotated default user credentials", "user", username, "previousUser", currentUsername)
+	r.Recorder.Event(rmq, corev1.EventTypeNormal, "DefaultUserRotated",
+		fmt.Sprintf("Rotated default user credentials; user %s is deleted in %s", currentUsername, rmq.DefaultUserRotationGracePeriod()))
+	return nil
+}
@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	rabbithole "github.com/michaelklishin/rabbit-hole/v3"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/rabbitmqclient"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("reconcileDefaultUserRotation", func() {
+	var (
+		cluster      *rabbitmqv1beta1.RabbitmqCluster
+		secret       *corev1.Secret
+		fakeClient   client.Client
+		rabbitClient *userRotationRabbitmqClient
+		reconciler   *RabbitmqClusterReconciler
+	)
+
+	BeforeEach(func() {
+		cluster = &rabbitmqv1beta1.RabbitmqCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "rabbit", Namespace: "default"},
+			Spec: rabbitmqv1beta1.RabbitmqClusterSpec{
+				Replicas: new(int32(1)),
+				DefaultUser: rabbitmqv1beta1.DefaultUserSpec{
+					Rotation: &rabbitmqv1beta1.DefaultUserRotationSpec{
+						Interval: &metav1.Duration{Duration: 24 * time.Hour},
+					},
+				},
+			},
+		}
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "rabbit-default-user",
+				Namespace:   "default",
+				Annotations: map[string]string{defaultUserRotatedAtAnnotation: time.Now().Add(-25 * time.Hour).Format(time.RFC3339)},
+			},
+			Data: map[string][]byte{
+				"username": []byte("old-user"),
+				"password": []byte("old-password"),
+				"host":     []byte("rabbit.default.svc"),
+				"port":     []byte("5672"),
+			},
+		}
+		rabbitClient = &userRotationRabbitmqClient{
+			users: map[string]rabbithole.UserSettings{
+				"old-user": {Name: "old-user", Tags: rabbithole.UserTags{"administrator"}},
+			},
+			permissions: map[string][]rabbithole.PermissionInfo{
+				"old-user": {{User: "old-user", Vhost: "/", Configure: ".*", Write: ".*", Read: ".*"}},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(rabbitmqv1beta1.AddToScheme(scheme)).To(Succeed())
+		sts := &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "rabbit-server", Namespace: "default"},
+			Spec:       appsv1.StatefulSetSpec{Replicas: new(int32(1))},
+			Status:     appsv1.StatefulSetStatus{ReadyReplicas: 1},
+		}
+		fakeClient = fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(cluster, secret, sts).
+			WithStatusSubresource(sts).
+			Build()
+		reconciler = &RabbitmqClusterReconciler{
+			Client:                fakeClient,
+			APIReader:             fakeClient,
+			Scheme:                scheme,
+			Recorder:              record.NewFakeRecorder(10),
+			RabbitmqClientFactory: &staticRabbitmqClientFactory{client: rabbitClient},
+		}
+	})
+
+	getSecret := func(ctx context.Context) *corev1.Secret {
+		updated := &corev1.Secret{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "rabbit-default-user", Namespace: "default"}, updated)).To(Succeed())
+		return updated
+	}
+
+	It("creates a new user and keeps the previous one during the grace period", func(ctx SpecContext) {
+		requeueAfter, err := reconciler.reconcileDefaultUserRotation(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requeueAfter).To(Equal(time.Hour))
+
+		updated := getSecret(ctx)
+		username := string(updated.Data["username"])
+		Expect(username).To(HavePrefix("default_user_"))
+		Expect(string(updated.Data["password"])).NotTo(Equal("old-password"))
+		Expect(string(updated.Data["connection_string"])).To(ContainSubstring(username))
+		Expect(updated.Annotations).To(HaveKeyWithValue(previousDefaultUserAnnotation, "old-user"))
+
+		Expect(rabbitClient.users).To(HaveKey("old-user"))
+		Expect(rabbitClient.users).To(HaveKeyWithValue(username, MatchFields(IgnoreExtras, Fields{
+			"Tags":     Equal(rabbithole.UserTags{"administrator"}),
+			"Password": Equal(string(updated.Data["password"])),
+		})))
+		Expect(rabbitClient.permissions[username]).To(ConsistOf(rabbithole.PermissionInfo{User: username, Vhost: "/", Configure: ".*", Write: ".*", Read: ".*"}))
+	})
+
+	When("the grace period is over", func() {
+		BeforeEach(func() {
+			secret.Annotations[defaultUserRotatedAtAnnotation] = time.Now().Add(-2 * time.Hour).Format(time.RFC3339)
+			secret.Annotations[previousDefaultUserAnnotation] = "previous-user"
+			rabbitClient.users["previous-user"] = rabbithole.UserSettings{Name: "previous-user"}
+		})
+
+		It("deletes the previous user", func(ctx SpecContext) {
+			requeueAfter, err := reconciler.reconcileDefaultUserRotation(ctx, cluster)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requeueAfter).To(BeNumerically("~", 22*time.Hour, time.Minute))
+
+			Expect(rabbitClient.users).NotTo(HaveKey("previous-user"))
+			Expect(getSecret(ctx).Annotations).NotTo(HaveKey(previousDefaultUserAnnotation))
+			Expect(string(getSecret(ctx).Data["username"])).To(Equal("old-user"))
+		})
+	})
+
+	When("rotation is requested with the annotation", func() {
+		BeforeEach(func() {
+			cluster.Spec.DefaultUser.Rotation = nil
+			cluster.Annotations = map[string]string{rotateDefaultUserAnnotation: "true"}
+			secret.Annotations = nil
+		})
+
+		It("rotates the credentials once", func(ctx SpecContext) {
+			_, err := reconciler.reconcileDefaultUserRotation(ctx, cluster)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(getSecret(ctx).Data["username"])).NotTo(Equal("old-user"))
+
+			updated := &rabbitmqv1beta1.RabbitmqCluster{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "rabbit", Namespace: "default"}, updated)).To(Succeed())
+			Expect(updated.Annotations).NotTo(HaveKey(rotateDefaultUserAnnotation))
+		})
+	})
+
+	When("the credentials are set in additionalConfig", func() {
+		BeforeEach(func() {
+			cluster.Spec.Rabbitmq.AdditionalConfig = "default_user = old-user\ndefault_pass = old-password"
+		})
+
+		It("does not rotate them", func(ctx SpecContext) {
+			requeueAfter, err := reconciler.reconcileDefaultUserRotation(ctx, cluster)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requeueAfter).To(BeZero())
+			Expect(string(getSecret(ctx).Data["username"])).To(Equal("old-user"))
+		})
+	})
+})
+
+// staticRabbitmqClientFactory returns the same client for every Pod and Service
+type staticRabbitmqClientFactory struct {
+	client rabbitmqclient.RabbitmqClient
+}
+
+func (f *staticRabbitmqClientFactory) GetClientForPod(_ context.Context, _ client.Reader, _ *rabbitmqv1beta1.RabbitmqCluster, _ string) (rabbitmqclient.RabbitmqClient, error) {
+	return f.client, nil
+}
+
+func (f *staticRabbitmqClientFactory) GetClientForService(_ context.Context, _ client.Reader, _ *rabbitmqv1beta1.RabbitmqCluster) (rabbitmqclient.RabbitmqClient, error) {
+	return f.client, nil
+}
+
+// userRotationRabbitmqClient keeps users and permissions in memory
+type userRotationRabbitmqClient struct {
+	rabbitmqclient.RabbitmqClient
+	users       map[string]rabbithole.UserSettings
+	permissions map[string][]rabbithole.PermissionInfo
+}
+
+func (c *userRotationRabbitmqClient) GetUser(username string) (*rabbithole.UserInfo, error) {
+	user, ok := c.users[username]
+	if !ok {
+		return nil, rabbithole.ErrorResponse{StatusCode: http.StatusNotFound}
+	}
+	return &rabbithole.UserInfo{Name: user.Name, Tags: user.Tags}, nil
+}
+
+func (c *userRotationRabbitmqClient) PutUser(username string, info rabbithole.UserSettings) (*http.Response, error) {
+	c.users[username] = info
+	return nil, nil
+}
+
+func (c *userRotationRabbitmqClient) DeleteUser(username string) (*http.Response, error) {
+	delete(c.users, username)
+	return nil, nil
+}
+
+func (c *userRotationRabbitmqClient) ListPermissionsOf(username string) ([]rabbithole.PermissionInfo, error) {
+	return c.permissions[username], nil
+}
+
+func (c *userRotationRabbitmqClient) UpdatePermissionsIn(vhost, username string, p rabbithole.Permissions) (*http.Response, error) {
+	c.permissions[username] = append(c.permissions[username], rabbithole.PermissionInfo{User: username, Vhost: vhost, Configure: p.Configure, Write: p.Write, Read: p.Read})
+	return nil, nil
+}
+
+func (c *userRotationRabbitmqClient) ListTopicPermissionsOf(_ string) ([]rabbithole.TopicPermissionInfo, error) {
+	return nil, nil
+}
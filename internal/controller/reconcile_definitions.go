@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	rabbithole "github.com/michaelklishin/rabbit-hole/v3"
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// ConfigMaps and Secrets with definitions which are not labelled with app.kubernetes.io/part-of=rabbitmq are not watched,
+// so changes to their content are only noticed periodically
+const definitionsRecheckInterval = 5 * time.Minute
+
+// reconcileDefinitions imports the definitions referenced in spec.rabbitmq.definitions through the management API
+// when the content of the referenced ConfigMaps or Secrets changed, so that nodes do not need to be restarted.
+// Nodes import the definitions mounted in their Pod when they boot. Therefore, the definitions found when
+// spec.rabbitmq.definitions is first observed are only recorded in the status.
+func (r *RabbitmqClusterReconciler) reconcileDefinitions(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster) (time.Duration, error) {
+	logger := ctrl.LoggerFrom(ctx)
+	if rmq.Spec.Rabbitmq.Definitions == nil {
+		return 0, r.setDefinitionsHash(ctx, rmq, "")
+	}
+
+	files, err := resource.ReadDefinitions(ctx, r.APIReader, rmq)
+	if err != nil {
+		r.Recorder.Event(rmq, corev1.EventTypeWarning, "InvalidDefinitions", err.Error())
+		return 0, err
+	}
+	hash := resource.DefinitionsHash(files)
+	if hash == rmq.Status.DefinitionsHash {
+		return definitionsRecheckInterval, nil
+	}
+
+	if rmq.Status.DefinitionsHash != "" {
+		sts, err := r.statefulSet(ctx, rmq)
+		if err != nil {
+			return 0, err
+		}
+		if !allReplicasReadyAndUpdated(sts) {
+			logger.V(1).Info("not all replicas ready yet; requeuing request to import definitions")
+			return 15 * time.Second, nil
+		}
+
+		rabbitClient, err := r.RabbitmqClientFactory.GetClientForService(ctx, r.APIReader, rmq)
+		if err != nil {
+			return 0, fmt.Errorf("failed to create RabbitMQ client: %w", err)
+		}
+		for _, name := range slices.Sorted(maps.Keys(files)) {
+			definitions := &rabbithole.ExportedDefinitions{}
+			if err := json.Unmarshal(files[name], definitions); err != nil {
+				return 0, fmt.Errorf("failed to parse definitions file %s: %w", name, err)
+			}
+			// these lists are not omitted when empty, and RabbitMQ rejects null
+			if definitions.Policies == nil {
+				definitions.Policies = &[]rabbithole.PolicyDefinition{}
+			}
+			if definitions.Queues == nil {
+				definitions.Queues = &[]rabbithole.QueueInfo{}
+			}
+			if definitions.Exchanges == nil {
+				definitions.Exchanges = &[]rabbithole.ExchangeInfo{}
+			}
+			if definitions.Bindings == nil {
+				definitions.Bindings = &[]rabbithole.BindingInfo{}
+			}
+			if _, err := rabbitClient.UploadDefinitions(definitions); err != nil {
+				msg := fmt.Sprintf("failed to import definitions file %s", name)
+				r.Recorder.Event(rmq, corev1.EventTypeWarning, "FailedReconcile", msg)
+				return 0, fmt.Errorf("%s: %w", msg, err)
+			}
+		}
+		logger.Info("successfully imported definitions")
+		r.Recorder.Event(rmq, corev1.EventTypeNormal, "DefinitionsImported", "Imported changed definitions")
+	}
+
+	return definitionsRecheckInterval, r.setDefinitionsHash(ctx, rmq, hash)
+}
+
+func (r *RabbitmqClusterReconciler) setDefinitionsHash(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster, hash string) error {
+	if rmq.Status.DefinitionsHash == hash {
+		return nil
+	}
+	patch := client.MergeFrom(rmq.DeepCopy())
+	rmq.Status.DefinitionsHash = hash
+	return r.Status().Patch(ctx, rmq, patch)
+}
+
+// rabbitmqClustersForDefinitions maps a ConfigMap or Secret to the RabbitmqClusters in its Namespace importing definitions from it.
+func (r *RabbitmqClusterReconciler) rabbitmqClustersForDefinitions(ctx context.Context, obj client.Object) []reconcile.Request {
+	clusters := &rabbitmqv1beta1.RabbitmqClusterList{}
+	if err := r.List(ctx, clusters, client.InNamespace(obj.GetNamespace())); err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "failed to list RabbitmqClusters for definitions", "name", obj.GetName())
+		return nil
+	}
+	_, isSecret := obj.(*corev1.Secret)
+	var requests []reconcile.Request
+	for _, cluster := range clusters.Items {
+		if cluster.Spec.Rabbitmq.Definitions == nil {
+			continue
+		}
+		for _, source := range cluster.Spec.Rabbitmq.Definitions.Sources {
+			if (isSecret && source.Secret != nil && source.Secret.Name == obj.GetName()) ||
+				(!isSecret && source.ConfigMap != nil && source.ConfigMap.Name == obj.GetName()) {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace},
+				})
+				break
+			}
+		}
+	}
+	return requests
+}
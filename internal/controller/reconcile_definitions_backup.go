@@ -0,0 +1,274 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/cron"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// Jobs writing exports to a PersistentVolumeClaim are not watched, so their completion is checked periodically
+const definitionsBackupJobRecheckInterval = 30 * time.Second
+
+// reconcileDefinitionsBackup exports the definitions through the management API on the schedule set in spec.backup.definitions.
+// It returns the time until the next scheduled export.
+func (r *RabbitmqClusterReconciler) reconcileDefinitionsBackup(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster) (time.Duration, error) {
+	logger := ctrl.LoggerFrom(ctx)
+	if !rmq.DefinitionsBackupEnabled() {
+		return 0, nil
+	}
+
+	var jobRecheck time.Duration
+	if rmq.DefinitionsBackupStorageType() == rabbitmqv1beta1.DefinitionsBackupStoragePersistentVolumeClaim {
+		active, err := r.recordDefinitionsBackupJobs(ctx, rmq)
+		if err != nil {
+			return 0, err
+		}
+		if active {
+			jobRecheck = definitionsBackupJobRecheckInterval
+		}
+	}
+
+	schedule, err := cron.Parse(rmq.Spec.Backup.Definitions.Schedule)
+	if err != nil {
+		return 0, fmt.Errorf("invalid schedule in spec.backup.definitions: %w", err)
+	}
+	lastScheduleTime := rmq.CreationTimestamp.Time
+	if rmq.Status.DefinitionsBackup != nil && rmq.Status.DefinitionsBackup.LastScheduleTime != nil {
+		lastScheduleTime = rmq.Status.DefinitionsBackup.LastScheduleTime.Time
+	}
+	now := time.Now()
+	if next := schedule.Next(lastScheduleTime); now.Before(next) {
+		return earliest(next.Sub(now), jobRecheck), nil
+	}
+
+	sts, err := r.statefulSet(ctx, rmq)
+	if err != nil {
+		return 0, err
+	}
+	if !allReplicasReadyAndUpdated(sts) {
+		logger.V(1).Info("not all replicas ready yet; requeuing request to export definitions")
+		return 15 * time.Second, nil
+	}
+
+	if err := r.exportDefinitions(ctx, rmq, now); err != nil {
+		r.Recorder.Event(rmq, corev1.EventTypeWarning, "FailedDefinitionsExport", err.Error())
+		return 0, err
+	}
+	if err := r.updateDefinitionsBackupStatus(ctx, rmq, func(status *rabbitmqv1beta1.DefinitionsBackupStatus) {
+		status.LastScheduleTime = &metav1.Time{Time: now}
+	}); err != nil {
+		return 0, err
+	}
+
+	if rmq.DefinitionsBackupStorageType() == rabbitmqv1beta1.DefinitionsBackupStoragePersistentVolumeClaim {
+		jobRecheck = definitionsBackupJobRecheckInterval
+	}
+	return earliest(schedule.Next(now).Sub(now), jobRecheck), nil
+}
+
+// exportDefinitionsBeforeDisruption exports the definitions before an operation which stops RabbitMQ nodes,
+// so that they can be restored if the operation fails. A failed export does not block the operation,
+// because the operation may be what brings an unavailable cluster back.
+func (r *RabbitmqClusterReconciler) exportDefinitionsBeforeDisruption(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster, sts *appsv1.StatefulSet, operation string) {
+	if !rmq.DefinitionsBackupEnabled() {
+		return
+	}
+	logger := ctrl.LoggerFrom(ctx)
+	// the StatefulSet may already be updated, so only readiness is checked
+	if sts.Spec.Replicas == nil || sts.Status.ReadyReplicas != *sts.Spec.Replicas {
+		msg := fmt.Sprintf("Skipped export of definitions before %s because not all replicas are ready", operation)
+		logger.Info(msg)
+		r.Recorder.Event(rmq, corev1.EventTypeWarning, "FailedDefinitionsExport", msg)
+		return
+	}
+	if err := r.exportDefinitions(ctx, rmq, time.Now()); err != nil {
+		msg := fmt.Sprintf("Failed to export definitions before %s: %s", operation, err)
+		logger.Error(err, msg)
+		r.Recorder.Event(rmq, corev1.EventTypeWarning, "FailedDefinitionsExport", msg)
+	}
+}
+
+// exportDefinitions stores the definitions in a new Secret or ConfigMap and deletes the oldest exports beyond the retention.
+// For PersistentVolumeClaims, the export is handed to a Job, and recorded in the status once the Job completed.
+func (r *RabbitmqClusterReconciler) exportDefinitions(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster, now time.Time) error {
+	logger := ctrl.LoggerFrom(ctx)
+	rabbitClient, err := r.RabbitmqClientFactory.GetClientForService(ctx, r.APIReader, rmq)
+	if err != nil {
+		return fmt.Errorf("failed to create RabbitMQ client: %w", err)
+	}
+	definitions, err := rabbitClient.ListDefinitions()
+	if err != nil {
+		return fmt.Errorf("failed to export definitions: %w", err)
+	}
+	data, err := json.Marshal(definitions)
+	if err != nil {
+		return fmt.Errorf("failed to marshal definitions: %w", err)
+	}
+
+	name := resource.DefinitionsBackupName(rmq, now)
+	switch rmq.DefinitionsBackupStorageType() {
+	case rabbitmqv1beta1.DefinitionsBackupStoragePersistentVolumeClaim:
+		// the Secret exists before the Job, so the Pod of the Job does not start without the export
+		secret := resource.DefinitionsBackupSecret(rmq, name, data)
+		if err := r.Create(ctx, secret); err != nil {
+			return fmt.Errorf("failed to create Secret %s: %w", name, err)
+		}
+		job := resource.DefinitionsBackupJob(rmq, name)
+		if err := r.Create(ctx, job); err != nil {
+			if deleteErr := r.Delete(ctx, secret); client.IgnoreNotFound(deleteErr) != nil {
+				logger.Error(deleteErr, "failed to delete definitions export", "secret", name)
+			}
+			return fmt.Errorf("failed to create Job %s: %w", name, err)
+		}
+		// the Secret is deleted with the Job
+		if err := controllerutil.SetOwnerReference(job, secret, r.Scheme); err != nil {
+			return fmt.Errorf("failed setting owner reference: %w", err)
+		}
+		if err := r.Update(ctx, secret); err != nil {
+			return fmt.Errorf("failed to set owner of Secret %s: %w", name, err)
+		}
+		logger.Info("created Job to write definitions export", "job", name)
+		return nil
+	case rabbitmqv1beta1.DefinitionsBackupStorageConfigMap:
+		if err := r.Create(ctx, resource.DefinitionsBackupConfigMap(rmq, name, data)); err != nil {
+			return fmt.Errorf("failed to create ConfigMap %s: %w", name, err)
+		}
+		if err := r.deleteExpiredDefinitionsBackups(ctx, rmq, &corev1.ConfigMapList{}); err != nil {
+			return err
+		}
+	default:
+		if err := r.Create(ctx, resource.DefinitionsBackupSecret(rmq, name, data)); err != nil {
+			return fmt.Errorf("failed to create Secret %s: %w", name, err)
+		}
+		if err := r.deleteExpiredDefinitionsBackups(ctx, rmq, &corev1.SecretList{}); err != nil {
+			return err
+		}
+	}
+
+	logger.Info("exported definitions", "name", name)
+	r.Recorder.Event(rmq, corev1.EventTypeNormal, "DefinitionsExported", fmt.Sprintf("Exported definitions to %s %s", rmq.DefinitionsBackupStorageType(), name))
+	return r.updateDefinitionsBackupStatus(ctx, rmq, func(status *rabbitmqv1beta1.DefinitionsBackupStatus) {
+		status.LastSuccessfulExportTime = &metav1.Time{Time: now}
+		status.LastSuccessfulExport = name
+	})
+}
+
+// deleteExpiredDefinitionsBackups deletes the oldest Secrets or ConfigMaps holding exports beyond the retention
+func (r *RabbitmqClusterReconciler) deleteExpiredDefinitionsBackups(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster, list client.ObjectList) error {
+	// exports are not owned by the RabbitmqCluster, and a recent export may not be in the cache yet
+	if err := r.APIReader.List(ctx, list, client.InNamespace(rmq.Namespace), client.MatchingLabels(resource.DefinitionsBackupLabels(rmq.Name))); err != nil {
+		return fmt.Errorf("failed to list definitions exports: %w", err)
+	}
+	var exports []client.Object
+	switch l := list.(type) {
+	case *corev1.SecretList:
+		for i := range l.Items {
+			// Secrets owned by Jobs are handed over to PersistentVolumeClaims
+			if len(l.Items[i].OwnerReferences) == 0 {
+				exports = append(exports, &l.Items[i])
+			}
+		}
+	case *corev1.ConfigMapList:
+		for i := range l.Items {
+			exports = append(exports, &l.Items[i])
+		}
+	}
+
+	// names end with the time of the export
+	slices.SortFunc(exports, func(a, b client.Object) int {
+		return strings.Compare(b.GetName(), a.GetName())
+	})
+	retention := rmq.DefinitionsBackupRetention()
+	if len(exports) <= retention {
+		return nil
+	}
+	for _, export := range exports[retention:] {
+		if err := r.Delete(ctx, export); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete definitions export %s: %w", export.GetName(), err)
+		}
+	}
+	return nil
+}
+
+// recordDefinitionsBackupJobs records the latest export written to a PersistentVolumeClaim in the status.
+// It returns true if a Job is still running.
+func (r *RabbitmqClusterReconciler) recordDefinitionsBackupJobs(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster) (bool, error) {
+	jobs := &batchv1.JobList{}
+	if err := r.APIReader.List(ctx, jobs, client.InNamespace(rmq.Namespace), client.MatchingLabels(resource.DefinitionsBackupLabels(rmq.Name))); err != nil {
+		return false, fmt.Errorf("failed to list definitions export Jobs: %w", err)
+	}
+
+	var active bool
+	var latest *batchv1.Job
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		switch {
+		case jobConditionTrue(job, batchv1.JobComplete):
+			if latest == nil || job.Name > latest.Name {
+				latest = job
+			}
+		case !jobConditionTrue(job, batchv1.JobFailed):
+			active = true
+		}
+	}
+	if latest == nil {
+		return active, nil
+	}
+
+	exportTime := latest.CreationTimestamp
+	if rmq.Status.DefinitionsBackup != nil && rmq.Status.DefinitionsBackup.LastSuccessfulExportTime != nil &&
+		!rmq.Status.DefinitionsBackup.LastSuccessfulExportTime.Before(&exportTime) {
+		return active, nil
+	}
+	r.Recorder.Event(rmq, corev1.EventTypeNormal, "DefinitionsExported", fmt.Sprintf("Exported definitions to PersistentVolumeClaim %s as %s.json",
+		rmq.Spec.Backup.Definitions.PersistentVolumeClaimName, latest.Name))
+	return active, r.updateDefinitionsBackupStatus(ctx, rmq, func(status *rabbitmqv1beta1.DefinitionsBackupStatus) {
+		status.LastSuccessfulExportTime = &exportTime
+		status.LastSuccessfulExport = latest.Name + ".json"
+	})
+}
+
+func jobConditionTrue(job *batchv1.Job, conditionType batchv1.JobConditionType) bool {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == conditionType && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RabbitmqClusterReconciler) updateDefinitionsBackupStatus(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster, update func(*rabbitmqv1beta1.DefinitionsBackupStatus)) error {
+	patch := client.MergeFrom(rmq.DeepCopy())
+	if rmq.Status.DefinitionsBackup == nil {
+		rmq.Status.DefinitionsBackup = &rabbitmqv1beta1.DefinitionsBackupStatus{}
+	}
+	update(rmq.Status.DefinitionsBackup)
+	return r.Status().Patch(ctx, rmq, patch)
+}
+
+// rabbitmqImageChanged returns true if the desired StatefulSet runs a different RabbitMQ image than the current one
+func rabbitmqImageChanged(current, desired *appsv1.StatefulSet) bool {
+	image := func(sts *appsv1.StatefulSet) string {
+		for _, container := range sts.Spec.Template.Spec.Containers {
+			if container.Name == "rabbitmq" {
+				return container.Image
+			}
+		}
+		return ""
+	}
+	return image(current) != image(desired)
+}
@@ -0,0 +1,227 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	rabbithole "github.com/michaelklishin/rabbit-hole/v3"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/rabbitmqclient"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+var _ = Describe("reconcileDefinitionsBackup", func() {
+	var (
+		cluster    *rabbitmqv1beta1.RabbitmqCluster
+		objects    []client.Object
+		created    []string
+		fakeClient client.Client
+		reconciler *RabbitmqClusterReconciler
+	)
+
+	BeforeEach(func() {
+		cluster = &rabbitmqv1beta1.RabbitmqCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "rabbit",
+				Namespace:         "default",
+				CreationTimestamp: metav1.Time{Time: time.Now().Add(-25 * time.Hour)},
+			},
+			Spec: rabbitmqv1beta1.RabbitmqClusterSpec{
+				Replicas: new(int32(1)),
+				Image:    "rabbitmq:4.2",
+				Backup: rabbitmqv1beta1.BackupSpec{
+					Definitions: &rabbitmqv1beta1.DefinitionsBackupSpec{Schedule: "@daily", Retention: 2},
+				},
+			},
+		}
+		objects = nil
+		created = nil
+	})
+
+	JustBeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(batchv1.AddToScheme(scheme)).To(Succeed())
+		Expect(rabbitmqv1beta1.AddToScheme(scheme)).To(Succeed())
+		sts := &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "rabbit-server", Namespace: "default"},
+			Spec:       appsv1.StatefulSetSpec{Replicas: new(int32(1))},
+			Status:     appsv1.StatefulSetStatus{ReadyReplicas: 1},
+		}
+		fakeClient = fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(append(objects, cluster, sts)...).
+			WithStatusSubresource(cluster, sts, &batchv1.Job{}).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+					kind, err := c.GroupVersionKindFor(obj)
+					if err != nil {
+						return err
+					}
+					created = append(created, kind.Kind)
+					return c.Create(ctx, obj, opts...)
+				},
+			}).
+			Build()
+		reconciler = &RabbitmqClusterReconciler{
+			Client:                fakeClient,
+			APIReader:             fakeClient,
+			Scheme:                scheme,
+			Recorder:              record.NewFakeRecorder(10),
+			RabbitmqClientFactory: &staticRabbitmqClientFactory{client: &definitionsBackupRabbitmqClient{}},
+		}
+	})
+
+	getCluster := func(ctx SpecContext) *rabbitmqv1beta1.RabbitmqCluster {
+		updated := &rabbitmqv1beta1.RabbitmqCluster{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "rabbit", Namespace: "default"}, updated)).To(Succeed())
+		return updated
+	}
+
+	backupSecret := func(name string) *corev1.Secret {
+		return resource.DefinitionsBackupSecret(cluster, name, []byte(`{}`))
+	}
+
+	When("an export is due", func() {
+		BeforeEach(func() {
+			objects = []client.Object{
+				backupSecret("rabbit-definitions-20260101-000000"),
+				backupSecret("rabbit-definitions-20260102-000000"),
+			}
+		})
+
+		It("stores the definitions in a Secret and deletes the oldest exports", func(ctx SpecContext) {
+			requeueAfter, err := reconciler.reconcileDefinitionsBackup(ctx, cluster)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requeueAfter).To(BeNumerically("<=", 24*time.Hour))
+
+			status := getCluster(ctx).Status.DefinitionsBackup
+			Expect(status).NotTo(BeNil())
+			Expect(status.LastScheduleTime).NotTo(BeNil())
+			Expect(status.LastSuccessfulExportTime).NotTo(BeNil())
+			Expect(status.LastSuccessfulExport).To(HavePrefix("rabbit-definitions-"))
+
+			secrets := &corev1.SecretList{}
+			Expect(fakeClient.List(ctx, secrets)).To(Succeed())
+			Expect(secrets.Items).To(ConsistOf(
+				HaveField("Name", "rabbit-definitions-20260102-000000"),
+				And(
+					HaveField("Name", status.LastSuccessfulExport),
+					HaveField("Data", HaveKeyWithValue("definitions.json", ContainSubstring(`"rabbit_version":"4.2.0"`))),
+				),
+			))
+		})
+	})
+
+	When("the next export is not due yet", func() {
+		BeforeEach(func() {
+			cluster.Status.DefinitionsBackup = &rabbitmqv1beta1.DefinitionsBackupStatus{
+				LastScheduleTime: &metav1.Time{Time: time.Now()},
+			}
+		})
+
+		It("waits for the next scheduled time", func(ctx SpecContext) {
+			requeueAfter, err := reconciler.reconcileDefinitionsBackup(ctx, cluster)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requeueAfter).To(BeNumerically(">", 0))
+			Expect(requeueAfter).To(BeNumerically("<=", 24*time.Hour))
+
+			secrets := &corev1.SecretList{}
+			Expect(fakeClient.List(ctx, secrets)).To(Succeed())
+			Expect(secrets.Items).To(BeEmpty())
+		})
+	})
+
+	When("exports are stored on a PersistentVolumeClaim", func() {
+		BeforeEach(func() {
+			cluster.Spec.Backup.Definitions.StorageType = rabbitmqv1beta1.DefinitionsBackupStoragePersistentVolumeClaim
+			cluster.Spec.Backup.Definitions.PersistentVolumeClaimName = "backups"
+		})
+
+		It("hands the export over to a Job", func(ctx SpecContext) {
+			requeueAfter, err := reconciler.reconcileDefinitionsBackup(ctx, cluster)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requeueAfter).To(Equal(definitionsBackupJobRecheckInterval))
+
+			jobs := &batchv1.JobList{}
+			Expect(fakeClient.List(ctx, jobs)).To(Succeed())
+			Expect(jobs.Items).To(HaveLen(1))
+			secret := &corev1.Secret{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: jobs.Items[0].Name, Namespace: "default"}, secret)).To(Succeed())
+			Expect(secret.OwnerReferences).To(ConsistOf(HaveField("Name", jobs.Items[0].Name)))
+			Expect(created).To(Equal([]string{"Secret", "Job"}))
+			Expect(getCluster(ctx).Status.DefinitionsBackup.LastSuccessfulExportTime).To(BeNil())
+		})
+
+		When("a Job completed", func() {
+			BeforeEach(func() {
+				cluster.Status.DefinitionsBackup = &rabbitmqv1beta1.DefinitionsBackupStatus{
+					LastScheduleTime: &metav1.Time{Time: time.Now()},
+				}
+				job := resource.DefinitionsBackupJob(cluster, "rabbit-definitions-20260102-000000")
+				job.CreationTimestamp = metav1.Time{Time: time.Now().Add(-time.Minute)}
+				job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+				objects = []client.Object{job}
+			})
+
+			It("records the export in the status", func(ctx SpecContext) {
+				_, err := reconciler.reconcileDefinitionsBackup(ctx, cluster)
+				Expect(err).NotTo(HaveOccurred())
+
+				status := getCluster(ctx).Status.DefinitionsBackup
+				Expect(status.LastSuccessfulExport).To(Equal("rabbit-definitions-20260102-000000.json"))
+				Expect(status.LastSuccessfulExportTime).NotTo(BeNil())
+			})
+		})
+	})
+
+	Context("before disruptive operations", func() {
+		It("exports the definitions", func(ctx SpecContext) {
+			sts := &appsv1.StatefulSet{
+				Spec:   appsv1.StatefulSetSpec{Replicas: new(int32(1))},
+				Status: appsv1.StatefulSetStatus{ReadyReplicas: 1},
+			}
+			reconciler.exportDefinitionsBeforeDisruption(ctx, cluster, sts, "upgrading RabbitMQ")
+
+			secrets := &corev1.SecretList{}
+			Expect(fakeClient.List(ctx, secrets)).To(Succeed())
+			Expect(secrets.Items).To(HaveLen(1))
+			Expect(getCluster(ctx).Status.DefinitionsBackup.LastScheduleTime).To(BeNil())
+		})
+
+		It("detects upgrades", func() {
+			current := &appsv1.StatefulSet{Spec: appsv1.StatefulSetSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "rabbitmq", Image: "rabbitmq:4.1"}},
+			}}}}
+			desired := current.DeepCopy()
+			Expect(rabbitmqImageChanged(current, desired)).To(BeFalse())
+			desired.Spec.Template.Spec.Containers[0].Image = "rabbitmq:4.2"
+			Expect(rabbitmqImageChanged(current, desired)).To(BeTrue())
+		})
+	})
+})
+
+// definitionsBackupRabbitmqClient exports fixed definitions
+type definitionsBackupRabbitmqClient struct {
+	rabbitmqclient.RabbitmqClient
+}
+
+func (c *definitionsBackupRabbitmqClient) ListDefinitions() (*rabbithole.ExportedDefinitions, error) {
+	return &rabbithole.ExportedDefinitions{
+		RabbitVersion: "4.2.0",
+		Vhosts:        &[]rabbithole.VhostInfo{{Name: "/"}},
+	}, nil
+}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"net/http"
+
+	rabbithole "github.com/michaelklishin/rabbit-hole/v3"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/rabbitmqclient"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("reconcileDefinitions", func() {
+	var (
+		cluster      *rabbitmqv1beta1.RabbitmqCluster
+		configMap    *corev1.ConfigMap
+		fakeClient   client.Client
+		rabbitClient *definitionsRabbitmqClient
+		reconciler   *RabbitmqClusterReconciler
+	)
+
+	BeforeEach(func() {
+		cluster = &rabbitmqv1beta1.RabbitmqCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "rabbit", Namespace: "default"},
+			Spec: rabbitmqv1beta1.RabbitmqClusterSpec{
+				Replicas: new(int32(1)),
+				Rabbitmq: rabbitmqv1beta1.RabbitmqClusterConfigurationSpec{
+					Definitions: &rabbitmqv1beta1.DefinitionsSpec{
+						Sources: []rabbitmqv1beta1.DefinitionsSource{{ConfigMap: &corev1.LocalObjectReference{Name: "definitions"}}},
+					},
+				},
+			},
+		}
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "definitions", Namespace: "default"},
+			Data:       map[string]string{"vhosts.json": `{"vhosts": [{"name": "orders"}]}`},
+		}
+		rabbitClient = &definitionsRabbitmqClient{}
+	})
+
+	JustBeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(rabbitmqv1beta1.AddToScheme(scheme)).To(Succeed())
+		sts := &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "rabbit-server", Namespace: "default"},
+			Spec:       appsv1.StatefulSetSpec{Replicas: new(int32(1))},
+			Status:     appsv1.StatefulSetStatus{ReadyReplicas: 1},
+		}
+		fakeClient = fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(cluster, configMap, sts).
+			WithStatusSubresource(cluster, sts).
+			Build()
+		reconciler = &RabbitmqClusterReconciler{
+			Client:                fakeClient,
+			APIReader:             fakeClient,
+			Scheme:                scheme,
+			Recorder:              record.NewFakeRecorder(10),
+			RabbitmqClientFactory: &staticRabbitmqClientFactory{client: rabbitClient},
+		}
+	})
+
+	It("records the definitions imported at boot", func(ctx SpecContext) {
+		requeueAfter, err := reconciler.reconcileDefinitions(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requeueAfter).To(Equal(definitionsRecheckInterval))
+		Expect(rabbitClient.uploaded).To(BeEmpty())
+
+		updated := &rabbitmqv1beta1.RabbitmqCluster{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "rabbit", Namespace: "default"}, updated)).To(Succeed())
+		Expect(updated.Status.DefinitionsHash).To(Equal(resource.DefinitionsHash(map[string][]byte{
+			"vhosts.json": []byte(`{"vhosts": [{"name": "orders"}]}`),
+		})))
+	})
+
+	When("the definitions changed", func() {
+		BeforeEach(func() {
+			cluster.Status.DefinitionsHash = "previous"
+		})
+
+		It("imports them through the management API", func(ctx SpecContext) {
+			_, err := reconciler.reconcileDefinitions(ctx, cluster)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rabbitClient.uploaded).To(HaveLen(1))
+			Expect(*rabbitClient.uploaded[0].Vhosts).To(ConsistOf(HaveField("Name", "orders")))
+			Expect(rabbitClient.uploaded[0].Queues).NotTo(BeNil())
+
+			updated := &rabbitmqv1beta1.RabbitmqCluster{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "rabbit", Namespace: "default"}, updated)).To(Succeed())
+			Expect(updated.Status.DefinitionsHash).NotTo(Equal("previous"))
+		})
+	})
+
+	When("the definitions are not valid", func() {
+		BeforeEach(func() {
+			configMap.Data["vhosts.json"] = "not json"
+		})
+
+		It("returns an error", func(ctx SpecContext) {
+			_, err := reconciler.reconcileDefinitions(ctx, cluster)
+			Expect(err).To(MatchError(ContainSubstring("does not contain a JSON object")))
+		})
+	})
+})
+
+// definitionsRabbitmqClient records the uploaded definitions
+type definitionsRabbitmqClient struct {
+	rabbitmqclient.RabbitmqClient
+	uploaded []*rabbithole.ExportedDefinitions
+}
+
+func (c *definitionsRabbitmqClient) UploadDefinitions(definitions *rabbithole.ExportedDefinitions) (*http.Response, error) {
+	c.uploaded = append(c.uploaded, definitions)
+	return nil, nil
+}
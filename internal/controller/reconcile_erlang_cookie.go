@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientretry "k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// rotateErlangCookieAnnotation is set by users on the RabbitmqCluster to request a new Erlang cookie
+const rotateErlangCookieAnnotation = "rabbitmq.com/rotateErlangCookie"
+
+// rotateErlangCookieIfAnnotated writes a new Erlang cookie and marks the StatefulSet for a full restart,
+// since nodes using different cookies cannot communicate with each other.
+// The StatefulSet is updated first, so that no Pod is restarted with the new cookie before all of them are.
+// The restart itself is done by reconcileFullRestart once the other child resources are reconciled.
+func (r *RabbitmqClusterReconciler) rotateErlangCookieIfAnnotated(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster) error {
+	if _, ok := rmq.Annotations[rotateErlangCookieAnnotation]; !ok {
+		return nil
+	}
+	logger := ctrl.LoggerFrom(ctx)
+
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: rmq.ChildResourceName("erlang-cookie"), Namespace: rmq.Namespace}, secret); err != nil {
+		// a missing Secret is created with a new cookie anyway
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		return r.deleteAnnotation(ctx, rmq, rotateErlangCookieAnnotation)
+	}
+
+	cookie, err := resource.GenerateErlangCookie()
+	if err != nil {
+		return fmt.Errorf("failed to generate Erlang cookie: %w", err)
+	}
+
+	if err := clientretry.RetryOnConflict(clientretry.DefaultRetry, func() error {
+		sts, err := r.statefulSet(ctx, rmq)
+		if err != nil {
+			return err
+		}
+		if sts.Annotations == nil {
+			sts.Annotations = make(map[string]string)
+		}
+		sts.Annotations[resource.FullRestartAnnotation] = time.Now().Format(time.RFC3339)
+		sts.Spec.UpdateStrategy = appsv1.StatefulSetUpdateStrategy{Type: appsv1.OnDeleteStatefulSetStrategyType}
+		if sts.Spec.Template.Annotations == nil {
+			sts.Spec.Template.Annotations = make(map[string]string)
+		}
+		sts.Spec.Template.Annotations[resource.ErlangCookieHashAnnotation] = resource.ErlangCookieHash([]byte(cookie))
+		return r.Update(ctx, sts)
+	}); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to mark StatefulSet for restart: %w", err)
+	}
+
+	if secret.Data == nil {
+		secret.Data = make(map[string][]byte)
+	}
+	secret.Data[resource.ErlangCookieKey] = []byte(cookie)
+	if err := r.Update(ctx, secret); err != nil {
+		return fmt.Errorf("failed to update Erlang cookie Secret: %w", err)
+	}
+
+	logger.Info("rotated Erlang cookie; restarting all nodes")
+	r.Recorder.Event(rmq, corev1.EventTypeNormal, "ErlangCookieRotated", "Rotated Erlang cookie; restarting all nodes")
+	return r.deleteAnnotation(ctx, rmq, rotateErlangCookieAnnotation)
+}
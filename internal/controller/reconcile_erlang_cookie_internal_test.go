@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("rotateErlangCookieIfAnnotated", func() {
+	var (
+		cluster    *rabbitmqv1beta1.RabbitmqCluster
+		objects    []client.Object
+		fakeClient client.Client
+		reconciler *RabbitmqClusterReconciler
+	)
+
+	BeforeEach(func() {
+		cluster = &rabbitmqv1beta1.RabbitmqCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "rabbit",
+				Namespace:   "default",
+				Annotations: map[string]string{rotateErlangCookieAnnotation: "true"},
+			},
+		}
+		objects = []client.Object{
+			cluster,
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "rabbit-erlang-cookie", Namespace: "default"},
+				Data:       map[string][]byte{resource.ErlangCookieKey: []byte("old-cookie")},
+			},
+			&appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "rabbit-server", Namespace: "default"},
+				Spec: appsv1.StatefulSetSpec{
+					UpdateStrategy: appsv1.StatefulSetUpdateStrategy{Type: appsv1.RollingUpdateStatefulSetStrategyType},
+				},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(rabbitmqv1beta1.AddToScheme(scheme)).To(Succeed())
+		fakeClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+		reconciler = &RabbitmqClusterReconciler{
+			Client:   fakeClient,
+			Scheme:   scheme,
+			Recorder: record.NewFakeRecorder(10),
+		}
+	})
+
+	It("writes a new cookie and restarts all nodes with it", func(ctx SpecContext) {
+		Expect(reconciler.rotateErlangCookieIfAnnotated(ctx, cluster)).To(Succeed())
+
+		secret := &corev1.Secret{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "rabbit-erlang-cookie", Namespace: "default"}, secret)).To(Succeed())
+		cookie := secret.Data[resource.ErlangCookieKey]
+		Expect(string(cookie)).NotTo(Equal("old-cookie"))
+		Expect(cookie).NotTo(BeEmpty())
+
+		sts := &appsv1.StatefulSet{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "rabbit-server", Namespace: "default"}, sts)).To(Succeed())
+		Expect(sts.Annotations).To(HaveKey(resource.FullRestartAnnotation))
+		Expect(sts.Spec.UpdateStrategy.Type).To(Equal(appsv1.OnDeleteStatefulSetStrategyType))
+		Expect(sts.Spec.Template.Annotations).To(HaveKeyWithValue(resource.ErlangCookieHashAnnotation, resource.ErlangCookieHash(cookie)))
+
+		updated := &rabbitmqv1beta1.RabbitmqCluster{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "rabbit", Namespace: "default"}, updated)).To(Succeed())
+		Expect(updated.Annotations).NotTo(HaveKey(rotateErlangCookieAnnotation))
+	})
+
+	When("the cluster is not annotated", func() {
+		BeforeEach(func() {
+			cluster.Annotations = nil
+		})
+
+		It("keeps the current cookie", func(ctx SpecContext) {
+			Expect(reconciler.rotateErlangCookieIfAnnotated(ctx, cluster)).To(Succeed())
+
+			secret := &corev1.Secret{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "rabbit-erlang-cookie", Namespace: "default"}, secret)).To(Succeed())
+			Expect(secret.Data).To(HaveKeyWithValue(resource.ErlangCookieKey, []byte("old-cookie")))
+
+			sts := &appsv1.StatefulSet{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "rabbit-server", Namespace: "default"}, sts)).To(Succeed())
+			Expect(sts.Annotations).NotTo(HaveKey(resource.FullRestartAnnotation))
+		})
+	})
+})
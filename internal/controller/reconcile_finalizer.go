@@ -3,12 +3,11 @@ package controllers
 import (
 	"context"
 	"fmt"
-	"strings"
 	"time"
 
 	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
-	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	clientretry "k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -47,31 +46,47 @@ func (r *RabbitmqClusterReconciler) removeFinalizer(ctx context.Context, rabbitm
 	return nil
 }
 
-func (r *RabbitmqClusterReconciler) prepareForDeletion(ctx context.Context, rabbitmqCluster *rabbitmqv1beta1.RabbitmqCluster) error {
+// prepareForDeletion marks all Pods for deletion, so that their preStop hooks do not wait for quorum queues and streams
+// to have enough online replicas, which is impossible when all nodes stop. The finalizer is removed once the marker has propagated.
+func (r *RabbitmqClusterReconciler) prepareForDeletion(ctx context.Context, rabbitmqCluster *rabbitmqv1beta1.RabbitmqCluster) (time.Duration, error) {
 	if controllerutil.ContainsFinalizer(rabbitmqCluster, deletionFinalizer) {
+		var pods []corev1.Pod
 		_ = clientretry.RetryOnConflict(clientretry.DefaultRetry, func() error {
-			return r.addRabbitmqDeletionLabel(ctx, rabbitmqCluster)
+			var err error
+			pods, err = r.addRabbitmqDeletionLabel(ctx, rabbitmqCluster)
+			return err
 		})
 
-		// wait for up to 3 seconds for the labels to propagate
-		timeout := time.Now().Add(3 * time.Second)
-		for time.Now().Before(timeout) && !r.checkIfLabelPropagated(ctx, rabbitmqCluster) {
-			time.Sleep(200 * time.Millisecond)
+		if r.PodExecDisabled {
+			for i := range pods {
+				if !r.deletionMarkerPropagated(ctx, &pods[i]) {
+					ctrl.LoggerFrom(ctx).V(1).Info("waiting for Pod labels to propagate before deleting RabbitMQ nodes")
+					return 5 * time.Second, nil
+				}
+			}
+		} else {
+			// wait for up to 3 seconds for the labels to propagate
+			timeout := time.Now().Add(3 * time.Second)
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: rabbitmqCluster.ChildResourceName("server") + "-0", Namespace: rabbitmqCluster.Namespace}}
+			for time.Now().Before(timeout) && !r.deletionMarkerPropagated(ctx, pod) {
+				time.Sleep(200 * time.Millisecond)
+			}
 		}
 
 		if err := r.removeFinalizer(ctx, rabbitmqCluster); err != nil {
 			ctrl.LoggerFrom(ctx).Error(err, "Failed to remove finalizer for deletion")
-			return err
+			return 0, err
 		}
 	}
-	return nil
+	return 0, nil
 }
 
-func (r *RabbitmqClusterReconciler) addRabbitmqDeletionLabel(ctx context.Context, rabbitmqCluster *rabbitmqv1beta1.RabbitmqCluster) error {
+// addRabbitmqDeletionLabel marks all Pods of the RabbitmqCluster for deletion and returns them
+func (r *RabbitmqClusterReconciler) addRabbitmqDeletionLabel(ctx context.Context, rabbitmqCluster *rabbitmqv1beta1.RabbitmqCluster) ([]corev1.Pod, error) {
 	pods := &corev1.PodList{}
 	selector, err := labels.Parse(fmt.Sprintf("app.kubernetes.io/name=%s", rabbitmqCluster.Name))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	listOptions := client.ListOptions{
 		LabelSelector: selector,
@@ -79,28 +94,18 @@ func (r *RabbitmqClusterReconciler) addRabbitmqDeletionLabel(ctx context.Context
 	}
 
 	if err := r.List(ctx, pods, &listOptions); err != nil {
-		return err
+		return nil, err
 	}
 
 	for i := range pods.Items {
 		pod := &pods.Items[i]
-		pod.Labels[resource.DeletionMarker] = "true"
+		if !markForDeletion(pod) {
+			continue
+		}
 		if err := r.Update(ctx, pod); client.IgnoreNotFound(err) != nil {
-			return fmt.Errorf("cannot Update Pod %s in Namespace %s: %w", pod.Name, pod.Namespace, err)
+			return nil, fmt.Errorf("cannot Update Pod %s in Namespace %s: %w", pod.Name, pod.Namespace, err)
 		}
 	}
 
-	return nil
-}
-
-func (r *RabbitmqClusterReconciler) checkIfLabelPropagated(ctx context.Context, rabbitmqCluster *rabbitmqv1beta1.RabbitmqCluster) bool {
-	logger := ctrl.LoggerFrom(ctx)
-	podName := fmt.Sprintf("%s-0", rabbitmqCluster.ChildResourceName("server"))
-	cmd := "cat /etc/pod-info/skipPreStopChecks"
-	stdout, _, err := r.exec(rabbitmqCluster.Namespace, podName, "rabbitmq", "sh", "-c", cmd)
-	if err != nil {
-		logger.Info("Failed to check for deletion label propagation, deleting anyway", "pod", podName, "command", cmd, "stdout", stdout)
-		return true
-	}
-	return strings.HasPrefix(stdout, "true")
+	return pods.Items, nil
 }
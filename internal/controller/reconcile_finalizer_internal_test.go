@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("prepareForDeletion", func() {
+	var (
+		cluster    *rabbitmqv1beta1.RabbitmqCluster
+		fakeClient client.Client
+		executor   *deletionMarkerPodExecutor
+		reconciler *RabbitmqClusterReconciler
+	)
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		Expect(rabbitmqv1beta1.AddToScheme(scheme)).To(Succeed())
+		cluster = &rabbitmqv1beta1.RabbitmqCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "rabbit", Namespace: "default", Finalizers: []string{deletionFinalizer}},
+		}
+		fakeClient = fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(cluster, rabbitmqPod("rabbit-server-0", "rabbit-server-old")).
+			Build()
+		executor = &deletionMarkerPodExecutor{propagated: true}
+		reconciler = &RabbitmqClusterReconciler{
+			Client:      fakeClient,
+			Scheme:      scheme,
+			Recorder:    record.NewFakeRecorder(10),
+			PodExecutor: executor,
+		}
+	})
+
+	finalizers := func(ctx SpecContext) []string {
+		updated := &rabbitmqv1beta1.RabbitmqCluster{}
+		Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(cluster), updated)).To(Succeed())
+		return updated.Finalizers
+	}
+
+	It("labels the Pods and removes the finalizer once the label is propagated", func(ctx SpecContext) {
+		requeueAfter, err := reconciler.prepareForDeletion(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requeueAfter).To(BeZero())
+		Expect(executor.execs).To(Equal(1))
+		Expect(finalizers(ctx)).To(BeEmpty())
+
+		pod := &corev1.Pod{}
+		Expect(fakeClient.Get(ctx, client.ObjectKey{Name: "rabbit-server-0", Namespace: "default"}, pod)).To(Succeed())
+		Expect(pod.Labels).To(HaveKeyWithValue(resource.DeletionMarker, "true"))
+	})
+
+	It("waits for the kubelet sync period when pod exec is disabled", func(ctx SpecContext) {
+		reconciler.PodExecDisabled = true
+		requeueAfter, err := reconciler.prepareForDeletion(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requeueAfter).To(BeNumerically(">", 0))
+		Expect(executor.execs).To(BeZero())
+		Expect(finalizers(ctx)).To(ConsistOf(deletionFinalizer))
+
+		By("removing the finalizer once the sync period passed")
+		pod := &corev1.Pod{}
+		Expect(fakeClient.Get(ctx, client.ObjectKey{Name: "rabbit-server-0", Namespace: "default"}, pod)).To(Succeed())
+		pod.Annotations[deletionMarkedAtAnnotation] = time.Now().Add(-kubeletSyncPeriod).Format(time.RFC3339)
+		Expect(fakeClient.Update(ctx, pod)).To(Succeed())
+
+		requeueAfter, err = reconciler.prepareForDeletion(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requeueAfter).To(BeZero())
+		Expect(finalizers(ctx)).To(BeEmpty())
+	})
+})
@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// deletionMarkedAtAnnotation records when the deletion marker label was set on a Pod
+	deletionMarkedAtAnnotation = "rabbitmq.com/skipPreStopChecksSetAt"
+	// kubeletSyncPeriod is the default sync frequency of the kubelet, within which it updates the downward API files
+	// of a Pod after its labels changed
+	kubeletSyncPeriod = time.Minute
+)
+
+// reconcileFullRestart restarts all RabbitMQ nodes at the same time when the StatefulSet is annotated with
+// resource.FullRestartAnnotation. The StatefulSet uses the OnDelete update strategy while the annotation is present.
+// 1. Outdated Pods are labelled so that their preStop hook does not wait for quorum queues and streams
+// to have enough online replicas, which is impossible when all nodes stop.
+// 2. Once the label has propagated, all outdated Pods are deleted and recreated from the updated template.
+// 3. Once all Pods are updated and ready, the annotation is removed and the update strategy is reset.
+func (r *RabbitmqClusterReconciler) reconcileFullRestart(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster) (time.Duration, error) {
+	logger := ctrl.LoggerFrom(ctx)
+	sts, err := r.statefulSet(ctx, rmq)
+	if err != nil {
+		return 0, client.IgnoreNotFound(err)
+	}
+	if _, ok := sts.Annotations[resource.FullRestartAnnotation]; !ok {
+		return 0, nil
+	}
+	if sts.Status.ObservedGeneration != sts.Generation {
+		// wait for the StatefulSet controller to compute the update revision
+		return 2 * time.Second, nil
+	}
+
+	if fullRestartCompleted(sts) {
+		if err := r.deleteAnnotation(ctx, sts, resource.FullRestartAnnotation); err != nil {
+			return 0, err
+		}
+		logger.Info("completed full restart of RabbitMQ nodes")
+		r.Recorder.Event(rmq, corev1.EventTypeNormal, "SuccessfulUpdate", "Completed restart of all RabbitMQ nodes")
+		return 0, nil
+	}
+
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(rmq.Namespace), client.MatchingLabels(sts.Spec.Selector.MatchLabels)); err != nil {
+		return 0, err
+	}
+	var outdated []*corev1.Pod
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.DeletionTimestamp.IsZero() && pod.Labels[appsv1.ControllerRevisionHashLabelKey] != sts.Status.UpdateRevision {
+			outdated = append(outdated, pod)
+		}
+	}
+	if len(outdated) == 0 {
+		logger.V(1).Info("waiting for restarted RabbitMQ nodes to become ready")
+		return 10 * time.Second, nil
+	}
+
+	propagated := true
+	for _, pod := range outdated {
+		if markForDeletion(pod) {
+			if err := r.Update(ctx, pod); client.IgnoreNotFound(err) != nil {
+				return 0, fmt.Errorf("cannot Update Pod %s in Namespace %s: %w", pod.Name, pod.Namespace, err)
+			}
+		}
+		if !r.deletionMarkerPropagated(ctx, pod) {
+			propagated = false
+		}
+	}
+	if !propagated {
+		logger.V(1).Info("waiting for Pod labels to propagate before restarting all RabbitMQ nodes")
+		return 2 * time.Second, nil
+	}
+
+	r.exportDefinitionsBeforeDisruption(ctx, rmq, sts, "restarting all nodes")
+	for _, pod := range outdated {
+		if err := r.Delete(ctx, pod); client.IgnoreNotFound(err) != nil {
+			return 0, fmt.Errorf("cannot delete Pod %s in Namespace %s: %w", pod.Name, pod.Namespace, err)
+		}
+	}
+	msg := fmt.Sprintf("restarting all nodes of StatefulSet %s", sts.Name)
+	logger.Info(msg)
+	r.Recorder.Event(rmq, corev1.EventTypeNormal, "SuccessfulUpdate", msg)
+	return 10 * time.Second, nil
+}
+
+// With the OnDelete strategy, the StatefulSet controller does not update the current revision,
+// so the restart is completed once all Pods run the update revision and are ready.
+func fullRestartCompleted(sts *appsv1.StatefulSet) bool {
+	if sts.Spec.Replicas == nil {
+		return false
+	}
+	return sts.Status.UpdatedReplicas == *sts.Spec.Replicas && sts.Status.ReadyReplicas == *sts.Spec.Replicas
+}
+
+// markForDeletion sets the deletion marker label, so that the preStop hook of the Pod skips its checks,
+// and records when it was set. It returns true if the Pod was changed and needs to be updated.
+func markForDeletion(pod *corev1.Pod) bool {
+	if pod.Labels[resource.DeletionMarker] == "true" && pod.Annotations[deletionMarkedAtAnnotation] != "" {
+		return false
+	}
+	if pod.Labels == nil {
+		pod.Labels = map[string]string{}
+	}
+	if pod.Annotations == nil {
+		pod.Annotations = map[string]string{}
+	}
+	pod.Labels[resource.DeletionMarker] = "true"
+	pod.Annotations[deletionMarkedAtAnnotation] = time.Now().Format(time.RFC3339)
+	return true
+}
+
+// deletionMarkerPropagated returns true if the preStop hook of the Pod will skip its checks.
+// The hook reads the deletion marker label from a downward API file, which the kubelet updates within its sync period.
+// With pod exec, the file is read in the Pod. Pods that cannot be exec'ed into, e.g. because they are crash looping,
+// do not run the preStop checks successfully anyway. Without pod exec, the label is only assumed to be propagated
+// once the full sync period has passed since it was set.
+func (r *RabbitmqClusterReconciler) deletionMarkerPropagated(ctx context.Context, pod *corev1.Pod) bool {
+	logger := ctrl.LoggerFrom(ctx)
+	if r.PodExecDisabled {
+		markedAt, err := time.Parse(time.RFC3339, pod.Annotations[deletionMarkedAtAnnotation])
+		return err == nil && time.Since(markedAt) >= kubeletSyncPeriod
+	}
+	cmd := "cat /etc/pod-info/" + resource.DeletionMarker
+	stdout, _, err := r.exec(pod.Namespace, pod.Name, "rabbitmq", "sh", "-c", cmd)
+	if err != nil {
+		logger.Info("Failed to check for deletion label propagation, continuing anyway", "pod", pod.Name, "command", cmd, "stdout", stdout)
+		return true
+	}
+	return strings.HasPrefix(stdout, "true")
+}
@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("reconcileFullRestart", func() {
+	var (
+		cluster    *rabbitmqv1beta1.RabbitmqCluster
+		sts        *appsv1.StatefulSet
+		fakeClient client.Client
+		executor   *deletionMarkerPodExecutor
+		reconciler *RabbitmqClusterReconciler
+	)
+
+	BeforeEach(func() {
+		cluster = &rabbitmqv1beta1.RabbitmqCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "rabbit", Namespace: "default"},
+			Spec:       rabbitmqv1beta1.RabbitmqClusterSpec{Replicas: new(int32(2))},
+		}
+		sts = &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "rabbit-server",
+				Namespace:   "default",
+				Annotations: map[string]string{resource.FullRestartAnnotation: "2026-01-01T00:00:00Z"},
+			},
+			Spec: appsv1.StatefulSetSpec{
+				Replicas: new(int32(2)),
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app.kubernetes.io/name": "rabbit"}},
+			},
+			Status: appsv1.StatefulSetStatus{
+				UpdateRevision: "rabbit-server-new",
+				ReadyReplicas:  2,
+			},
+		}
+		executor = &deletionMarkerPodExecutor{}
+	})
+
+	JustBeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(rabbitmqv1beta1.AddToScheme(scheme)).To(Succeed())
+		fakeClient = fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(cluster, sts, rabbitmqPod("rabbit-server-0", "rabbit-server-old"), rabbitmqPod("rabbit-server-1", "rabbit-server-old")).
+			WithStatusSubresource(sts).
+			Build()
+		reconciler = &RabbitmqClusterReconciler{
+			Client:      fakeClient,
+			APIReader:   fakeClient,
+			Scheme:      scheme,
+			Recorder:    record.NewFakeRecorder(10),
+			PodExecutor: executor,
+		}
+	})
+
+	It("skips the preStop checks and deletes all outdated Pods at once", func(ctx SpecContext) {
+		By("waiting for the label to propagate")
+		requeueAfter, err := reconciler.reconcileFullRestart(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requeueAfter).To(BeNumerically(">", 0))
+		for _, name := range []string{"rabbit-server-0", "rabbit-server-1"} {
+			pod := &corev1.Pod{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, pod)).To(Succeed())
+			Expect(pod.Labels).To(HaveKeyWithValue(resource.DeletionMarker, "true"))
+		}
+
+		By("deleting the Pods")
+		executor.propagated = true
+		requeueAfter, err = reconciler.reconcileFullRestart(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requeueAfter).To(BeNumerically(">", 0))
+		for _, name := range []string{"rabbit-server-0", "rabbit-server-1"} {
+			err := fakeClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, &corev1.Pod{})
+			Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+		}
+	})
+
+	It("waits for the kubelet sync period when pod exec is disabled", func(ctx SpecContext) {
+		reconciler.PodExecDisabled = true
+		requeueAfter, err := reconciler.reconcileFullRestart(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requeueAfter).To(BeNumerically(">", 0))
+		Expect(executor.execs).To(BeZero())
+
+		By("not deleting the Pods although the label is set")
+		for _, name := range []string{"rabbit-server-0", "rabbit-server-1"} {
+			pod := &corev1.Pod{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, pod)).To(Succeed())
+			Expect(pod.Labels).To(HaveKeyWithValue(resource.DeletionMarker, "true"))
+			Expect(pod.Annotations).To(HaveKey(deletionMarkedAtAnnotation))
+
+			pod.Annotations[deletionMarkedAtAnnotation] = time.Now().Add(-kubeletSyncPeriod).Format(time.RFC3339)
+			Expect(fakeClient.Update(ctx, pod)).To(Succeed())
+		}
+
+		By("deleting the Pods once the sync period passed")
+		_, err = reconciler.reconcileFullRestart(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(executor.execs).To(BeZero())
+		for _, name := range []string{"rabbit-server-0", "rabbit-server-1"} {
+			err := fakeClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, &corev1.Pod{})
+			Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+		}
+	})
+
+	When("all Pods are updated and ready", func() {
+		BeforeEach(func() {
+			sts.Status.UpdatedReplicas = 2
+		})
+
+		It("removes the annotation", func(ctx SpecContext) {
+			requeueAfter, err := reconciler.reconcileFullRestart(ctx, cluster)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requeueAfter).To(BeZero())
+			updated := &appsv1.StatefulSet{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "rabbit-server", Namespace: "default"}, updated)).To(Succeed())
+			Expect(updated.Annotations).NotTo(HaveKey(resource.FullRestartAnnotation))
+		})
+	})
+
+	When("the StatefulSet is not annotated", func() {
+		BeforeEach(func() {
+			sts.Annotations = nil
+		})
+
+		It("does not restart any Pod", func(ctx SpecContext) {
+			requeueAfter, err := reconciler.reconcileFullRestart(ctx, cluster)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requeueAfter).To(BeZero())
+			Expect(executor.execs).To(BeZero())
+			pod := &corev1.Pod{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "rabbit-server-0", Namespace: "default"}, pod)).To(Succeed())
+			Expect(pod.Labels).NotTo(HaveKey(resource.DeletionMarker))
+		})
+	})
+})
+
+func rabbitmqPod(name, revision string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels: map[string]string{
+				"app.kubernetes.io/name":              "rabbit",
+				appsv1.ControllerRevisionHashLabelKey: revision,
+			},
+		},
+	}
+}
+
+type deletionMarkerPodExecutor struct {
+	propagated bool
+	execs      int
+}
+
+func (e *deletionMarkerPodExecutor) Exec(_ *kubernetes.Clientset, _ *rest.Config, _, _, _ string, _ ...string) (string, string, error) {
+	e.execs++
+	if e.propagated {
+		return "true", "", nil
+	}
+	return "", "", nil
+}
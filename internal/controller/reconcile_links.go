@@ -0,0 +1,321 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"time"
+
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/rabbitmqclient"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// linksRecheckInterval is how often links are declared again, which picks up changes of the other
+	// RabbitmqClusters which are not watched, such as CA Secrets issued outside of the operator
+	linksRecheckInterval = 5 * time.Minute
+	// linksRetryInterval is how soon links which could not be declared are retried
+	linksRetryInterval = 30 * time.Second
+)
+
+// linkUser is the user a RabbitmqCluster authenticates as on the other RabbitmqClusters of its links.
+// created is true if its Secret was just created, in which case its password is reset on the other RabbitmqClusters.
+type linkUser struct {
+	username string
+	password string
+	created  bool
+}
+
+// resolvedLink is a link of spec.links with the URI of the other RabbitmqCluster, or the reason it cannot be declared
+type resolvedLink struct {
+	spec    rabbitmqv1beta1.LinkSpec
+	uri     string
+	ca      []byte
+	message string
+}
+
+// reconcileLinks declares the federation upstreams and shovels of spec.links as runtime parameters.
+// The other RabbitmqCluster of each link is reached through its client Service, authenticating as the link user.
+// The link user is created on the other RabbitmqCluster with permissions in the virtual hosts of the links only,
+// and its password is kept in the link user Secret.
+// When the other RabbitmqCluster serves TLS, its CA certificate is copied to the links CA Secret, which is mounted on every node.
+// Parameters are only set when their value changed, since setting them restarts the link.
+// Links removed from spec.links are deleted from RabbitMQ; the link user Secret is deleted with the last link,
+// and the link users are left on the other RabbitmqClusters.
+// It returns the time after which the links need to be declared again.
+func (r *RabbitmqClusterReconciler) reconcileLinks(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster) (time.Duration, error) {
+	logger := ctrl.LoggerFrom(ctx)
+	if len(rmq.Spec.Links) == 0 && len(rmq.Status.Links) == 0 {
+		return 0, nil
+	}
+
+	sts, err := r.statefulSet(ctx, rmq)
+	if err != nil {
+		return 0, client.IgnoreNotFound(err)
+	}
+	if !allReplicasReadyAndUpdated(sts) {
+		logger.V(1).Info("not all replicas ready yet; requeuing request to declare links")
+		return 15 * time.Second, nil
+	}
+
+	var user linkUser
+	if len(rmq.Spec.Links) > 0 {
+		user.username = resource.LinkUsername(rmq)
+		user.password, user.created, err = r.userPassword(ctx, rmq, "link user", func(password string) *corev1.Secret {
+			return resource.LinkUserSecret(rmq, password)
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	links := make([]resolvedLink, 0, len(rmq.Spec.Links))
+	caData := map[string][]byte{}
+	for _, link := range rmq.Spec.Links {
+		resolved, err := r.resolveLink(ctx, rmq, link, user)
+		if err != nil {
+			return 0, err
+		}
+		if resolved.ca != nil {
+			caData[resource.LinkCAFilename(link)] = resolved.ca
+		}
+		links = append(links, resolved)
+	}
+	// the CA certificates are written before the parameters referencing them
+	if err := r.reconcileCASecret(ctx, rmq, resource.LinksCASecret(rmq, caData)); err != nil {
+		return 0, err
+	}
+
+	rabbitClient, err := r.RabbitmqClientFactory.GetClientForService(ctx, r.APIReader, rmq)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create RabbitMQ client: %w", err)
+	}
+
+	requeueAfter := linksRecheckInterval
+	linkStatuses := make([]rabbitmqv1beta1.LinkStatus, 0, len(links))
+	for _, link := range links {
+		linkStatus := rabbitmqv1beta1.LinkStatus{
+			Name:        link.spec.Name,
+			Type:        link.spec.Type,
+			VirtualHost: resource.LinkVirtualHost(link.spec),
+			Message:     link.message,
+		}
+		if link.message == "" {
+			component, value := resource.LinkParameter(link.spec, link.uri)
+			if err := putRuntimeParameterIfChanged(rabbitClient, component, linkStatus.VirtualHost, link.spec.Name, value); err != nil {
+				linkStatus.Message = err.Error()
+			} else {
+				linkStatus.Configured = true
+			}
+		}
+		if !linkStatus.Configured {
+			r.Recorder.Event(rmq, corev1.EventTypeWarning, "FailedLinkConfiguration", fmt.Sprintf("link %s: %s", link.spec.Name, linkStatus.Message))
+			requeueAfter = linksRetryInterval
+		}
+		linkStatuses = append(linkStatuses, linkStatus)
+	}
+
+	for _, previous := range rmq.Status.Links {
+		if slices.ContainsFunc(linkStatuses, func(current rabbitmqv1beta1.LinkStatus) bool {
+			return current.Name == previous.Name && current.Type == previous.Type && current.VirtualHost == previous.VirtualHost
+		}) {
+			continue
+		}
+		component := resource.LinkComponent(previous.Type)
+		if _, err := rabbitClient.DeleteRuntimeParameter(component, previous.VirtualHost, previous.Name); err != nil && !isRabbitmqNotFound(err) {
+			return 0, fmt.Errorf("failed to delete %s %s in vhost %s: %w", component, previous.Name, previous.VirtualHost, err)
+		}
+		logger.Info("deleted link", "link", previous.Name, "type", previous.Type, "vhost", previous.VirtualHost)
+	}
+
+	if len(linkStatuses) == 0 {
+		linkStatuses = nil
+		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: rmq.ChildResourceName(resource.LinkUserSecretName), Namespace: rmq.Namespace}}
+		if err := r.Delete(ctx, secret); client.IgnoreNotFound(err) != nil {
+			return 0, fmt.Errorf("failed to delete link user Secret: %w", err)
+		}
+	}
+	if !reflect.DeepEqual(rmq.Status.Links, linkStatuses) {
+		patch := client.MergeFrom(rmq.DeepCopy())
+		rmq.Status.Links = linkStatuses
+		if err := r.Status().Patch(ctx, rmq, patch); err != nil {
+			return 0, fmt.Errorf("failed to update status of links: %w", err)
+		}
+	}
+	return requeueAfter, nil
+}
+
+// resolveLink configures the link user on the other RabbitmqCluster of a link and returns the URI of the other
+// RabbitmqCluster, using TLS if its CA certificate is available. The port is read from its client Service.
+// A link which cannot be declared yet, for example because the other RabbitmqCluster does not exist, has a message.
+func (r *RabbitmqClusterReconciler) resolveLink(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster, link rabbitmqv1beta1.LinkSpec, user linkUser) (resolvedLink, error) {
+	resolved := resolvedLink{spec: link}
+	remoteName := rmq.ReferencedRabbitmqCluster(link.RabbitmqCluster)
+	remote := &rabbitmqv1beta1.RabbitmqCluster{}
+	if err := r.Get(ctx, remoteName, remote); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return resolved, err
+		}
+		resolved.message = fmt.Sprintf("RabbitmqCluster %s not found", remoteName)
+		return resolved, nil
+	}
+
+	caFile := ""
+	if remote.SecretTLSEnabled() {
+		ca, err := r.tlsCACertificate(ctx, remote)
+		if err != nil {
+			return resolved, err
+		}
+		if len(ca) > 0 {
+			resolved.ca = ca
+			caFile = resource.LinksCAPath + resource.LinkCAFilename(link)
+		}
+	}
+	if caFile == "" && remote.DisableNonTLSListeners() {
+		resolved.message = fmt.Sprintf("RabbitmqCluster %s only accepts TLS connections, but its TLS Secret has no CA certificate", remoteName)
+		return resolved, nil
+	}
+
+	portName := "amqp"
+	if caFile != "" {
+		portName = "amqps"
+	}
+	service := &corev1.Service{}
+	if err := r.Get(ctx, types.NamespacedName{Name: remote.ChildResourceName(""), Namespace: remote.Namespace}, service); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return resolved, err
+		}
+		resolved.message = fmt.Sprintf("Service of RabbitmqCluster %s not found", remoteName)
+		return resolved, nil
+	}
+	port := servicePort(service, portName)
+	if port == 0 {
+		resolved.message = fmt.Sprintf("Service of RabbitmqCluster %s has no %s port", remoteName, portName)
+		return resolved, nil
+	}
+
+	remoteClient, err := r.RabbitmqClientFactory.GetClientForService(ctx, r.APIReader, remote)
+	if err != nil {
+		resolved.message = fmt.Sprintf("failed to create RabbitMQ client for RabbitmqCluster %s: %s", remoteName, err)
+		return resolved, nil
+	}
+	vhost := resource.LinkRemoteVirtualHost(link)
+	if err := configureUser(remoteClient, "link user", user.username, user.password, user.created, []string{vhost}); err != nil {
+		resolved.message = err.Error()
+		return resolved, nil
+	}
+
+	resolved.uri = resource.LinkURI(remote, port, user.username, user.password, vhost, caFile)
+	return resolved, nil
+}
+
+// servicePort returns the port of a Service with the given name, or 0 if it has none
+func servicePort(service *corev1.Service, name string) int32 {
+	for _, port := range service.Spec.Ports {
+		if port.Name == name {
+			return port.Port
+		}
+	}
+	return 0
+}
+
+// reconcileCASecret creates or updates a Secret holding the CA certificates of other RabbitmqClusters,
+// and deletes it when the desired Secret has no data because they do not use TLS
+func (r *RabbitmqClusterReconciler) reconcileCASecret(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster, desired *corev1.Secret) error {
+	current := &corev1.Secret{}
+	err := r.Get(ctx, client.ObjectKeyFromObject(desired), current)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	exists := err == nil
+
+	switch {
+	case len(desired.Data) == 0 && exists:
+		if err := r.Delete(ctx, current); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete CA Secret %s: %w", desired.Name, err)
+		}
+	case len(desired.Data) == 0:
+	case !exists:
+		if err := controllerutil.SetControllerReference(rmq, desired, r.Scheme); err != nil {
+			return fmt.Errorf("failed setting controller reference: %w", err)
+		}
+		if err := r.Create(ctx, desired); err != nil {
+			return fmt.Errorf("failed to create CA Secret %s: %w", desired.Name, err)
+		}
+	case !maps.EqualFunc(current.Data, desired.Data, bytes.Equal):
+		current.Data = desired.Data
+		if err := r.Update(ctx, current); err != nil {
+			return fmt.Errorf("failed to update CA Secret %s: %w", desired.Name, err)
+		}
+	}
+	return nil
+}
+
+// putRuntimeParameterIfChanged sets a runtime parameter unless it already has the desired value,
+// since setting it restarts the federation link or the shovel
+func putRuntimeParameterIfChanged(rabbitClient rabbitmqclient.RabbitmqClient, component, vhost, name string, value map[string]any) error {
+	current, err := rabbitClient.GetRuntimeParameter(component, vhost, name)
+	if err != nil && !isRabbitmqNotFound(err) {
+		return fmt.Errorf("failed to get %s %s in vhost %s: %w", component, name, vhost, err)
+	}
+	if err == nil && sameParameterValue(current.Value, value) {
+		return nil
+	}
+	if _, err := rabbitClient.PutRuntimeParameter(component, vhost, name, value); err != nil {
+		return fmt.Errorf("failed to set %s %s in vhost %s: %w", component, name, vhost, err)
+	}
+	return nil
+}
+
+// rabbitmqClustersForLinks maps a RabbitmqCluster, or its default user, TLS or CA Secret, to the RabbitmqClusters
+// with links to it, so that links are declared again when the other RabbitmqCluster changes, its certificates rotate,
+// or the credentials the operator configures the link user with rotate.
+func (r *RabbitmqClusterReconciler) rabbitmqClustersForLinks(ctx context.Context, obj client.Object) []reconcile.Request {
+	clusters := &rabbitmqv1beta1.RabbitmqClusterList{}
+	if err := r.List(ctx, clusters); err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "failed to list RabbitmqClusters for links", "object", obj.GetName())
+		return nil
+	}
+
+	var remotes []types.NamespacedName
+	switch obj.(type) {
+	case *rabbitmqv1beta1.RabbitmqCluster:
+		remotes = append(remotes, client.ObjectKeyFromObject(obj))
+	case *corev1.Secret:
+		for _, cluster := range clusters.Items {
+			if cluster.Namespace != obj.GetNamespace() {
+				continue
+			}
+			name := obj.GetName()
+			if name == cluster.ChildResourceName("default-user") ||
+				(cluster.SecretTLSEnabled() && (name == cluster.TLSSecretName() || name == cluster.Spec.TLS.CaSecretName)) {
+				remotes = append(remotes, client.ObjectKeyFromObject(&cluster))
+			}
+		}
+	}
+	if len(remotes) == 0 {
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, cluster := range clusters.Items {
+		if slices.ContainsFunc(cluster.Spec.Links, func(link rabbitmqv1beta1.LinkSpec) bool {
+			return slices.Contains(remotes, cluster.ReferencedRabbitmqCluster(link.RabbitmqCluster))
+		}) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&cluster)})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/cron"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// maintenanceWindow returns whether disruptive changes may be applied at the given time, and otherwise the start of the
+// next window of spec.maintenanceWindows. Schedules which do not parse are ignored, since the webhook rejects them.
+func maintenanceWindow(rmq *rabbitmqv1beta1.RabbitmqCluster, now time.Time) (open bool, next time.Time) {
+	if len(rmq.Spec.MaintenanceWindows) == 0 {
+		return true, time.Time{}
+	}
+	for _, window := range rmq.Spec.MaintenanceWindows {
+		schedule, err := cron.Parse(window.Schedule)
+		if err != nil {
+			continue
+		}
+		if !schedule.WindowStart(now, window.Duration.Duration).IsZero() {
+			return true, time.Time{}
+		}
+		if start := schedule.Next(now); !start.IsZero() && (next.IsZero() || start.Before(next)) {
+			next = start
+		}
+	}
+	return false, next
+}
+
+// holdDisruptiveChanges restores the Pod template and the volume claim templates of an updated StatefulSet
+// to their previous state, so that the update neither rolls out the Pods nor expands the persistent volumes.
+// It returns which of these changes were held back.
+func holdDisruptiveChanges(sts, previous *appsv1.StatefulSet) map[rabbitmqv1beta1.DeferredAction]bool {
+	held := map[rabbitmqv1beta1.DeferredAction]bool{
+		rabbitmqv1beta1.DeferredPodTemplateRollout:        !equality.Semantic.DeepEqual(sts.Spec.Template, previous.Spec.Template),
+		rabbitmqv1beta1.DeferredPersistentVolumeExpansion: !equality.Semantic.DeepEqual(sts.Spec.VolumeClaimTemplates, previous.Spec.VolumeClaimTemplates),
+	}
+	if held[rabbitmqv1beta1.DeferredPodTemplateRollout] {
+		sts.Spec.Template = previous.Spec.Template
+		sts.Spec.UpdateStrategy = previous.Spec.UpdateStrategy
+		// a full restart is only triggered by the change to the Pod template
+		if fullRestart, ok := previous.Annotations[resource.FullRestartAnnotation]; ok {
+			if sts.Annotations == nil {
+				sts.Annotations = map[string]string{}
+			}
+			sts.Annotations[resource.FullRestartAnnotation] = fullRestart
+		} else {
+			delete(sts.Annotations, resource.FullRestartAnnotation)
+		}
+	}
+	if held[rabbitmqv1beta1.DeferredPersistentVolumeExpansion] {
+		sts.Spec.VolumeClaimTemplates = previous.Spec.VolumeClaimTemplates
+	}
+	return held
+}
+
+// updateDeferredActions records in status.maintenance which disruptive changes are held back until the next maintenance window.
+// Actions mapped to false are no longer held back, either because they were applied or because they are not needed anymore.
+func (r *RabbitmqClusterReconciler) updateDeferredActions(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster, deferred map[rabbitmqv1beta1.DeferredAction]bool) error {
+	var actions []rabbitmqv1beta1.DeferredAction
+	if rmq.Status.Maintenance != nil {
+		actions = slices.Clone(rmq.Status.Maintenance.DeferredActions)
+	}
+	var added []string
+	for action, held := range deferred {
+		switch {
+		case held && !slices.Contains(actions, action):
+			actions = append(actions, action)
+			added = append(added, string(action))
+		case !held:
+			actions = slices.DeleteFunc(actions, func(a rabbitmqv1beta1.DeferredAction) bool { return a == action })
+		}
+	}
+	slices.Sort(actions)
+
+	var maintenance *rabbitmqv1beta1.MaintenanceStatus
+	if len(actions) > 0 {
+		maintenance = &rabbitmqv1beta1.MaintenanceStatus{DeferredActions: actions}
+		if _, next := maintenanceWindow(rmq, time.Now()); !next.IsZero() {
+			maintenance.NextWindowStart = new(metav1.NewTime(next))
+		}
+	}
+	if equality.Semantic.DeepEqual(maintenance, rmq.Status.Maintenance) {
+		return nil
+	}
+
+	patch := client.MergeFrom(rmq.DeepCopy())
+	rmq.Status.Maintenance = maintenance
+	if err := r.Status().Patch(ctx, rmq, patch); err != nil {
+		return fmt.Errorf("failed to update status of deferred actions: %w", err)
+	}
+	if len(added) > 0 {
+		slices.Sort(added)
+		msg := fmt.Sprintf("deferred %s to the next maintenance window", strings.Join(added, ", "))
+		ctrl.LoggerFrom(ctx).Info(msg)
+		r.Recorder.Event(rmq, corev1.EventTypeNormal, "DeferredUpdate", msg)
+	}
+	return nil
+}
+
+// untilNextMaintenanceWindow returns when to reconcile again to apply the deferred actions, or 0 if none are deferred
+func untilNextMaintenanceWindow(rmq *rabbitmqv1beta1.RabbitmqCluster) time.Duration {
+	if rmq.Status.Maintenance == nil || rmq.Status.Maintenance.NextWindowStart == nil {
+		return 0
+	}
+	return max(time.Until(rmq.Status.Maintenance.NextWindowStart.Time), time.Second)
+}
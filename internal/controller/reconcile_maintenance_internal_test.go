@@ -0,0 +1,203 @@
+package controllers
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8sresource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Maintenance windows", func() {
+	// only open on the first minute of the year
+	closedWindow := rabbitmqv1beta1.MaintenanceWindow{Schedule: "0 0 1 1 *", Duration: metav1.Duration{Duration: time.Minute}}
+	openWindow := rabbitmqv1beta1.MaintenanceWindow{Schedule: "* * * * *", Duration: metav1.Duration{Duration: time.Hour}}
+
+	Describe("maintenanceWindow", func() {
+		// a Wednesday
+		now := time.Date(2026, time.January, 14, 10, 30, 0, 0, time.UTC)
+
+		It("is always open without windows", func() {
+			open, next := maintenanceWindow(&rabbitmqv1beta1.RabbitmqCluster{}, now)
+			Expect(open).To(BeTrue())
+			Expect(next).To(BeZero())
+		})
+
+		It("returns the start of the next window when all windows are closed", func() {
+			open, next := maintenanceWindow(&rabbitmqv1beta1.RabbitmqCluster{Spec: rabbitmqv1beta1.RabbitmqClusterSpec{
+				MaintenanceWindows: []rabbitmqv1beta1.MaintenanceWindow{
+					{Schedule: "0 2 * * sat", Duration: metav1.Duration{Duration: 4 * time.Hour}},
+					{Schedule: "0 22 * * *", Duration: metav1.Duration{Duration: time.Hour}},
+					{Schedule: "not a schedule", Duration: metav1.Duration{Duration: time.Hour}},
+				},
+			}}, now)
+			Expect(open).To(BeFalse())
+			Expect(next).To(Equal(time.Date(2026, time.January, 14, 22, 0, 0, 0, time.UTC)))
+		})
+
+		It("is open while any window is open", func() {
+			open, _ := maintenanceWindow(&rabbitmqv1beta1.RabbitmqCluster{Spec: rabbitmqv1beta1.RabbitmqClusterSpec{
+				MaintenanceWindows: []rabbitmqv1beta1.MaintenanceWindow{
+					{Schedule: "0 2 * * sat", Duration: metav1.Duration{Duration: 4 * time.Hour}},
+					{Schedule: "0 8 * * mon-fri", Duration: metav1.Duration{Duration: 3 * time.Hour}},
+				},
+			}}, now)
+			Expect(open).To(BeTrue())
+		})
+	})
+
+	Describe("holdDisruptiveChanges", func() {
+		var previous, updated *appsv1.StatefulSet
+
+		BeforeEach(func() {
+			previous = &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"some": "annotation"}},
+				Spec: appsv1.StatefulSetSpec{
+					Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "rabbitmq", Image: "rabbitmq:4.1"}}}},
+					VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{
+						ObjectMeta: metav1.ObjectMeta{Name: "persistence"},
+						Spec: corev1.PersistentVolumeClaimSpec{Resources: corev1.VolumeResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceStorage: k8sresource.MustParse("10Gi")},
+						}},
+					}},
+				},
+			}
+			updated = previous.DeepCopy()
+			updated.Spec.Replicas = new(int32(5))
+		})
+
+		It("keeps changes which are not disruptive", func() {
+			Expect(holdDisruptiveChanges(updated, previous)).To(Equal(map[rabbitmqv1beta1.DeferredAction]bool{
+				rabbitmqv1beta1.DeferredPodTemplateRollout:        false,
+				rabbitmqv1beta1.DeferredPersistentVolumeExpansion: false,
+			}))
+			Expect(*updated.Spec.Replicas).To(BeEquivalentTo(5))
+		})
+
+		It("restores the Pod template and a full restart it triggers", func() {
+			updated.Spec.Template.Spec.Containers[0].Image = "rabbitmq:4.2"
+			updated.Annotations[resource.FullRestartAnnotation] = "2026-01-14T10:30:00Z"
+			Expect(holdDisruptiveChanges(updated, previous)).To(HaveKeyWithValue(rabbitmqv1beta1.DeferredPodTemplateRollout, true))
+			Expect(updated.Spec.Template).To(Equal(previous.Spec.Template))
+			Expect(updated.Annotations).To(Equal(map[string]string{"some": "annotation"}))
+			Expect(*updated.Spec.Replicas).To(BeEquivalentTo(5))
+		})
+
+		It("restores the volume claim templates", func() {
+			updated.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests[corev1.ResourceStorage] = k8sresource.MustParse("20Gi")
+			Expect(holdDisruptiveChanges(updated, previous)).To(HaveKeyWithValue(rabbitmqv1beta1.DeferredPersistentVolumeExpansion, true))
+			Expect(updated.Spec.VolumeClaimTemplates).To(Equal(previous.Spec.VolumeClaimTemplates))
+		})
+	})
+
+	Describe("deferred steps", func() {
+		var (
+			cluster     *rabbitmqv1beta1.RabbitmqCluster
+			fakeClient  client.Client
+			executor    *recordingPodExecutor
+			rabbitAdmin *managementAPIRabbitmqClient
+			reconciler  *RabbitmqClusterReconciler
+		)
+
+		BeforeEach(func() {
+			cluster = &rabbitmqv1beta1.RabbitmqCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "rabbit",
+					Namespace:   "default",
+					Annotations: map[string]string{queueRebalanceAnnotation: "2026-01-14T10:00:00Z"},
+				},
+				Spec: rabbitmqv1beta1.RabbitmqClusterSpec{
+					Replicas:           new(int32(3)),
+					MaintenanceWindows: []rabbitmqv1beta1.MaintenanceWindow{closedWindow},
+				},
+			}
+		})
+
+		JustBeforeEach(func() {
+			scheme := runtime.NewScheme()
+			Expect(corev1.AddToScheme(scheme)).To(Succeed())
+			Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+			Expect(rabbitmqv1beta1.AddToScheme(scheme)).To(Succeed())
+			serverConf := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+				Name:        cluster.ChildResourceName(resource.ServerConfigMapName),
+				Namespace:   cluster.Namespace,
+				Annotations: map[string]string{serverConfAnnotation: time.Now().Format(time.RFC3339)},
+			}}
+			pluginsConf := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+				Name:        cluster.ChildResourceName(resource.PluginsConfigName),
+				Namespace:   cluster.Namespace,
+				Annotations: map[string]string{pluginsUpdateAnnotation: "2026-01-14T10:00:00Z"},
+			}}
+			sts := &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Name: cluster.ChildResourceName("server"), Namespace: cluster.Namespace},
+				Spec:       appsv1.StatefulSetSpec{Replicas: new(int32(3))},
+				Status:     appsv1.StatefulSetStatus{ReadyReplicas: 3},
+			}
+			fakeClient = fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(cluster, serverConf, pluginsConf, sts).
+				WithStatusSubresource(cluster).
+				Build()
+			executor = &recordingPodExecutor{}
+			rabbitAdmin = &managementAPIRabbitmqClient{}
+			reconciler = &RabbitmqClusterReconciler{
+				Client:                fakeClient,
+				Scheme:                scheme,
+				Recorder:              record.NewFakeRecorder(10),
+				PodExecutor:           executor,
+				RabbitmqClientFactory: &staticRabbitmqClientFactory{client: rabbitAdmin},
+			}
+		})
+
+		restartedAt := func(ctx SpecContext) string {
+			sts := &appsv1.StatefulSet{}
+			Expect(fakeClient.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.ChildResourceName("server")}, sts)).To(Succeed())
+			return sts.Spec.Template.Annotations[stsRestartAnnotation]
+		}
+
+		It("defers the restart until the next maintenance window", func(ctx SpecContext) {
+			_, err := reconciler.restartStatefulSetIfNeeded(ctx, ctrl.Log, cluster)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(restartedAt(ctx)).To(BeEmpty())
+			Expect(cluster.Status.Maintenance.DeferredActions).To(ConsistOf(rabbitmqv1beta1.DeferredConfigurationRestart))
+			Expect(cluster.Status.Maintenance.NextWindowStart.Time.Month()).To(Equal(time.January))
+			Expect(untilNextMaintenanceWindow(cluster)).To(BeNumerically(">", time.Minute))
+
+			By("restarting once the window opens")
+			cluster.Spec.MaintenanceWindows = []rabbitmqv1beta1.MaintenanceWindow{openWindow}
+			_, err = reconciler.restartStatefulSetIfNeeded(ctx, ctrl.Log, cluster)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(restartedAt(ctx)).NotTo(BeEmpty())
+			Expect(cluster.Status.Maintenance).To(BeNil())
+		})
+
+		It("defers plugin changes and queue rebalances until the next maintenance window", func(ctx SpecContext) {
+			_, err := reconciler.runRabbitmqCLICommandsIfAnnotated(ctx, cluster)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(executor.commands).NotTo(ContainElement(ContainSubstring("rabbitmq-plugins set")))
+			Expect(rabbitAdmin.requests).To(BeEmpty())
+			Expect(cluster.Status.Maintenance.DeferredActions).To(Equal([]rabbitmqv1beta1.DeferredAction{
+				rabbitmqv1beta1.DeferredPluginChange,
+				rabbitmqv1beta1.DeferredQueueRebalance,
+			}))
+
+			By("running them once the window opens")
+			cluster.Spec.MaintenanceWindows = []rabbitmqv1beta1.MaintenanceWindow{openWindow}
+			_, err = reconciler.runRabbitmqCLICommandsIfAnnotated(ctx, cluster)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(executor.commands).To(ContainElement(ContainSubstring("rabbitmq-plugins set")))
+			Expect(rabbitAdmin.requests).To(ContainElement("POST /api/rebalance/queues"))
+			Expect(cluster.Status.Maintenance).To(BeNil())
+		})
+	})
+})
@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"context"
+
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// deleteStaleManagementIngress deletes the Ingress, HTTPRoute and BackendTLSPolicy exposing the management UI
+// when they are no longer desired, e.g. because spec.management.ingress was removed or its type changed.
+// HTTPRoutes and BackendTLSPolicies are ignored if the Gateway API CRDs are not installed.
+// They are not watched either, so changes made to them directly are only reverted on the next reconcile
+// of the RabbitmqCluster.
+func (r *RabbitmqClusterReconciler) deleteStaleManagementIngress(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster) error {
+	logger := ctrl.LoggerFrom(ctx)
+	name := types.NamespacedName{Name: rmq.ChildResourceName(resource.ManagementIngressSuffix), Namespace: rmq.Namespace}
+
+	httpRouteDesired := rmq.ManagementIngressEnabled() && rmq.Spec.Management.Ingress.Type == rabbitmqv1beta1.ManagementIngressTypeHTTPRoute
+	ingressDesired := rmq.ManagementIngressEnabled() && !httpRouteDesired
+	backendTLSPolicyDesired := httpRouteDesired && rmq.TLSEnabled() && rmq.DisableNonTLSListeners()
+
+	if !ingressDesired {
+		// Ingresses are cached, so only issue a delete request if one exists
+		ingress := &networkingv1.Ingress{}
+		if err := r.Get(ctx, name, ingress); client.IgnoreNotFound(err) != nil {
+			return err
+		} else if err == nil {
+			if err := r.Delete(ctx, ingress); client.IgnoreNotFound(err) != nil {
+				return err
+			}
+			logger.Info("Deleted management Ingress", "name", name.Name)
+		}
+	}
+
+	for gvk, desired := range map[schema.GroupVersionKind]bool{
+		resource.HTTPRouteGVK:        httpRouteDesired,
+		resource.BackendTLSPolicyGVK: backendTLSPolicyDesired,
+	} {
+		if desired {
+			continue
+		}
+		// HTTPRoutes and BackendTLSPolicies are not cached: a cached read would start an unfiltered,
+		// cluster-wide informer that fails forever when the CRDs are missing. Read from the API server instead.
+		obj := &metav1.PartialObjectMetadata{}
+		obj.SetGroupVersionKind(gvk)
+		if err := r.APIReader.Get(ctx, name, obj); err != nil {
+			if client.IgnoreNotFound(err) == nil || meta.IsNoMatchError(err) {
+				continue
+			}
+			return err
+		}
+		if err := r.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		logger.Info("Deleted management "+gvk.Kind, "name", name.Name)
+	}
+	return nil
+}
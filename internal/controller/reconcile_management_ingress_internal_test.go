@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+var _ = Describe("deleteStaleManagementIngress", func() {
+	var (
+		cluster    *rabbitmqv1beta1.RabbitmqCluster
+		objects    []client.Object
+		deleted    []string
+		fakeClient client.Client
+		reconciler *RabbitmqClusterReconciler
+	)
+
+	BeforeEach(func() {
+		cluster = &rabbitmqv1beta1.RabbitmqCluster{ObjectMeta: metav1.ObjectMeta{Name: "rabbit", Namespace: "default"}}
+		objects = nil
+		deleted = nil
+	})
+
+	JustBeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(networkingv1.AddToScheme(scheme)).To(Succeed())
+		Expect(rabbitmqv1beta1.AddToScheme(scheme)).To(Succeed())
+		restMapper := meta.NewDefaultRESTMapper(nil)
+		restMapper.Add(networkingv1.SchemeGroupVersion.WithKind("Ingress"), meta.RESTScopeNamespace)
+		restMapper.Add(resource.HTTPRouteGVK, meta.RESTScopeNamespace)
+		apiReader := fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithRESTMapper(restMapper).
+			WithObjects(objects...).
+			Build()
+		fakeClient = fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithRESTMapper(restMapper).
+			WithObjects(objects...).
+			WithInterceptorFuncs(interceptor.Funcs{
+				// Gateway API objects must not be read through the cache
+				Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+					if _, ok := obj.(*metav1.PartialObjectMetadata); ok {
+						return errors.New("unexpected cached read")
+					}
+					return c.Get(ctx, key, obj, opts...)
+				},
+				Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+					deleted = append(deleted, obj.GetObjectKind().GroupVersionKind().Kind)
+					return c.Delete(ctx, obj, opts...)
+				},
+			}).
+			Build()
+		reconciler = &RabbitmqClusterReconciler{Client: fakeClient, APIReader: apiReader, Scheme: scheme}
+	})
+
+	It("does not send delete requests for objects which do not exist", func(ctx SpecContext) {
+		Expect(reconciler.deleteStaleManagementIngress(ctx, cluster)).To(Succeed())
+		Expect(deleted).To(BeEmpty())
+	})
+
+	When("an HTTPRoute is no longer desired", func() {
+		BeforeEach(func() {
+			route := &unstructured.Unstructured{}
+			route.SetGroupVersionKind(resource.HTTPRouteGVK)
+			route.SetName(cluster.ChildResourceName(resource.ManagementIngressSuffix))
+			route.SetNamespace(cluster.Namespace)
+			objects = append(objects, route)
+		})
+
+		It("deletes it", func(ctx SpecContext) {
+			Expect(reconciler.deleteStaleManagementIngress(ctx, cluster)).To(Succeed())
+			Expect(deleted).To(Equal([]string{"HTTPRoute"}))
+		})
+	})
+})
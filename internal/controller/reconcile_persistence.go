@@ -32,3 +32,9 @@ func persistenceStorageCapacity(templates []corev1.PersistentVolumeClaim) k8sres
 	}
 	return k8sresource.MustParse("0")
 }
+
+// persistenceExpansionRequired returns true if the persistent volumes of the current StatefulSet are smaller than desired
+func persistenceExpansionRequired(current, desired *appsv1.StatefulSet) bool {
+	currentCapacity := persistenceStorageCapacity(current.Spec.VolumeClaimTemplates)
+	return !currentCapacity.IsZero() && currentCapacity.Cmp(persistenceStorageCapacity(desired.Spec.VolumeClaimTemplates)) < 0
+}
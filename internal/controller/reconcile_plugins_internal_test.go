@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"errors"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Setting plugins", func() {
+	var (
+		cluster     *rabbitmqv1beta1.RabbitmqCluster
+		pluginsConf *corev1.ConfigMap
+		fakeClient  client.Client
+		executor    *pluginsPodExecutor
+		reconciler  *RabbitmqClusterReconciler
+	)
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		Expect(rabbitmqv1beta1.AddToScheme(scheme)).To(Succeed())
+		cluster = &rabbitmqv1beta1.RabbitmqCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "rabbit", Namespace: "default"},
+			Spec:       rabbitmqv1beta1.RabbitmqClusterSpec{Replicas: new(int32(7))},
+		}
+		pluginsConf = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        cluster.ChildResourceName(resource.PluginsConfigName),
+				Namespace:   cluster.Namespace,
+				Annotations: map[string]string{pluginsUpdateAnnotation: "2026-10-18T10:00:00Z"},
+			},
+			Data: map[string]string{"enabled_plugins": "[rabbitmq_peer_discovery_k8s,rabbitmq_prometheus,rabbitmq_management,rabbitmq_shovel]."},
+		}
+		serverConf := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+			Name:      cluster.ChildResourceName(resource.ServerConfigMapName),
+			Namespace: cluster.Namespace,
+		}}
+		fakeClient = fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(cluster, pluginsConf, serverConf).
+			WithStatusSubresource(cluster).
+			Build()
+		executor = &pluginsPodExecutor{
+			enabled: "rabbitmq_management\nrabbitmq_peer_discovery_k8s\nrabbitmq_prometheus\nrabbitmq_shovel\n",
+		}
+		reconciler = &RabbitmqClusterReconciler{
+			Client:          fakeClient,
+			Scheme:          scheme,
+			Recorder:        record.NewFakeRecorder(20),
+			PodExecutor:     executor,
+			PodExecFallback: true,
+		}
+	})
+
+	It("sets the plugins on every node and reports them in status", func(ctx SpecContext) {
+		Expect(reconciler.runSetPluginsCommand(ctx, cluster, pluginsConf)).To(Succeed())
+
+		Expect(executor.commands).To(HaveLen(14))
+		Expect(executor.commands).To(ContainElement("rabbit-server-6: rabbitmq-plugins set rabbitmq_peer_discovery_k8s rabbitmq_prometheus rabbitmq_management rabbitmq_shovel"))
+		Expect(cluster.Status.Plugins).To(HaveLen(7))
+		Expect(cluster.Status.Plugins[0]).To(Equal(rabbitmqv1beta1.NodePluginsStatus{
+			Pod:            "rabbit-server-0",
+			EnabledPlugins: []string{"rabbitmq_management", "rabbitmq_peer_discovery_k8s", "rabbitmq_prometheus", "rabbitmq_shovel"},
+			Converged:      true,
+		}))
+
+		updated := &corev1.ConfigMap{}
+		Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(pluginsConf), updated)).To(Succeed())
+		Expect(updated.Annotations).NotTo(HaveKey(pluginsUpdateAnnotation))
+	})
+
+	It("sets the plugins on the other nodes when a node fails, and reports all failures", func(ctx SpecContext) {
+		executor.failingPods = []string{"rabbit-server-2", "rabbit-server-5"}
+		err := reconciler.runSetPluginsCommand(ctx, cluster, pluginsConf)
+		Expect(err).To(MatchError(ContainSubstring("failed to set plugins on pod rabbit-server-2")))
+		Expect(err).To(MatchError(ContainSubstring("failed to set plugins on pod rabbit-server-5")))
+
+		Expect(executor.commands).To(ContainElement(HavePrefix("rabbit-server-6: rabbitmq-plugins set")))
+		Expect(cluster.Status.Plugins[2].Error).To(ContainSubstring("failed to set plugins on pod rabbit-server-2"))
+		Expect(cluster.Status.Plugins[3].Error).To(BeEmpty())
+		Expect(cluster.Status.Plugins[3].Converged).To(BeTrue())
+
+		By("keeping the annotation to retry")
+		updated := &corev1.ConfigMap{}
+		Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(pluginsConf), updated)).To(Succeed())
+		Expect(updated.Annotations).To(HaveKey(pluginsUpdateAnnotation))
+	})
+
+	It("reports nodes which have not converged", func(ctx SpecContext) {
+		executor.enabled = "rabbitmq_management\nrabbitmq_peer_discovery_k8s\nrabbitmq_prometheus\n"
+		Expect(reconciler.runSetPluginsCommand(ctx, cluster, pluginsConf)).To(Succeed())
+		Expect(cluster.Status.Plugins).To(HaveEach(HaveField("Converged", BeFalse())))
+	})
+
+	It("sets the plugins through pod exec without pod exec fallback", func(ctx SpecContext) {
+		// the management API cannot set plugins, so pod exec fallback does not apply
+		reconciler.PodExecFallback = false
+		Expect(reconciler.runSetPluginsCommand(ctx, cluster, pluginsConf)).To(Succeed())
+		Expect(executor.commands).To(HaveLen(14))
+		Expect(cluster.Status.Plugins).To(HaveLen(7))
+	})
+
+	It("restarts the nodes to set the plugins when pod exec is disabled", func(ctx SpecContext) {
+		reconciler.PodExecDisabled = true
+		Expect(reconciler.runSetPluginsCommand(ctx, cluster, pluginsConf)).To(Succeed())
+		Expect(executor.commands).To(BeEmpty())
+
+		serverConf := &corev1.ConfigMap{}
+		Expect(fakeClient.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.ChildResourceName(resource.ServerConfigMapName)}, serverConf)).To(Succeed())
+		Expect(serverConf.Annotations).To(HaveKey(serverConfAnnotation))
+		updated := &corev1.ConfigMap{}
+		Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(pluginsConf), updated)).To(Succeed())
+		Expect(updated.Annotations).NotTo(HaveKey(pluginsUpdateAnnotation))
+		Expect(cluster.Status.Plugins).To(BeNil())
+	})
+
+	It("reports the enabled plugins without setting them", func(ctx SpecContext) {
+		Expect(pluginsStatusIncomplete(cluster)).To(BeTrue())
+		Expect(reconciler.reportEnabledPlugins(ctx, cluster, pluginsConf)).To(Succeed())
+
+		Expect(executor.commands).To(HaveLen(7))
+		Expect(executor.commands).To(HaveEach(HaveSuffix(listEnabledPluginsCommand)))
+		Expect(cluster.Status.Plugins).To(HaveEach(HaveField("Converged", BeTrue())))
+		Expect(pluginsStatusIncomplete(cluster)).To(BeFalse())
+
+		By("listing the plugins again when the cluster is scaled out")
+		cluster.Spec.Replicas = new(int32(9))
+		Expect(pluginsStatusIncomplete(cluster)).To(BeTrue())
+	})
+})
+
+// pluginsPodExecutor records commands, lists the given enabled plugins, and fails to set plugins on the given pods.
+// Like rabbitmq-plugins, it also lists the dependencies of the enabled plugins unless only explicitly enabled plugins are listed.
+type pluginsPodExecutor struct {
+	recordingPodExecutor
+	enabled     string
+	failingPods []string
+}
+
+func (e *pluginsPodExecutor) Exec(clientset *kubernetes.Clientset, config *rest.Config, namespace, podName, containerName string, command ...string) (string, string, error) {
+	_, _, err := e.recordingPodExecutor.Exec(clientset, config, namespace, podName, containerName, command...)
+	if strings.HasPrefix(command[len(command)-1], "rabbitmq-plugins set") {
+		for _, pod := range e.failingPods {
+			if pod == podName {
+				return "", "plugin not found", errors.New("command terminated with exit code 70")
+			}
+		}
+		return "", "", err
+	}
+	if strings.Contains(command[len(command)-1], "--explicitly-enabled") {
+		return e.enabled, "", err
+	}
+	return e.enabled + "rabbitmq_management_agent\nrabbitmq_web_dispatch\n", "", err
+}
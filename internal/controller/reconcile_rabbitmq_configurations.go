@@ -3,6 +3,7 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -45,12 +46,19 @@ func (r *RabbitmqClusterReconciler) annotateIfNeeded(ctx context.Context, logger
 		annotationKey = pluginsUpdateAnnotation
 
 	case *resource.ServerConfigMapBuilder:
-		if operationResult != controllerutil.OperationResultUpdated || !b.UpdateRequiresStsRestart {
+		if operationResult != controllerutil.OperationResultUpdated {
+			return nil
+		}
+		switch {
+		case b.UpdateRequiresStsRestart:
+			annotationKey = serverConfAnnotation
+		case len(b.RuntimeConfigKeys) > 0:
+			annotationKey = runtimeConfigUpdateAnnotation
+		default:
 			return nil
 		}
 		obj = &corev1.ConfigMap{}
 		objName = rmq.ChildResourceName(resource.ServerConfigMapName)
-		annotationKey = serverConfAnnotation
 
 	case *resource.StatefulSetBuilder:
 		if operationResult != controllerutil.OperationResultCreated {
@@ -88,7 +96,7 @@ func (r *RabbitmqClusterReconciler) restartStatefulSetIfNeeded(ctx context.Conte
 	serverConfigUpdatedAt, ok := serverConf.Annotations[serverConfAnnotation]
 	if !ok {
 		// server-conf configmap hasn't been updated; no need to restart sts
-		return 0, nil
+		return 0, r.reconcileRestartPending(ctx, rmq, false)
 	}
 
 	sts, err := r.statefulSet(ctx, rmq)
@@ -100,7 +108,17 @@ func (r *RabbitmqClusterReconciler) restartStatefulSetIfNeeded(ctx context.Conte
 	stsRestartedAt, ok := sts.Spec.Template.Annotations[stsRestartAnnotation]
 	if ok && stsRestartedAt > serverConfigUpdatedAt {
 		// sts was updated after the last server-conf configmap update; no need to restart sts
-		return 0, nil
+		return 0, r.reconcileRestartPending(ctx, rmq, false)
+	}
+
+	if rmq.ManualRestart() && !rmq.RestartApproved() {
+		logger.Info("restart required by configuration change awaits approval", "annotation", rabbitmqv1beta1.ApproveRestartAnnotation)
+		return 0, r.reconcileRestartPending(ctx, rmq, true)
+	}
+
+	if open, _ := maintenanceWindow(rmq, time.Now()); !open {
+		logger.Info("restart required by configuration change is deferred to the next maintenance window")
+		return 0, r.updateDeferredActions(ctx, rmq, map[rabbitmqv1beta1.DeferredAction]bool{rabbitmqv1beta1.DeferredConfigurationRestart: true})
 	}
 
 	if err := clientretry.RetryOnConflict(clientretry.DefaultRetry, func() error {
@@ -125,7 +143,33 @@ func (r *RabbitmqClusterReconciler) restartStatefulSetIfNeeded(ctx context.Conte
 	logger.Info(msg)
 	r.Recorder.Event(rmq, corev1.EventTypeNormal, "SuccessfulUpdate", msg)
 
-	return 0, nil
+	return 0, r.reconcileRestartPending(ctx, rmq, false)
+}
+
+// reconcileRestartPending sets the RestartPending condition of RabbitmqClusters with spec.restartPolicy Manual.
+// Once no restart is pending, it removes the approval, so that it does not approve later restarts.
+// It is not called while the restart is deferred to a maintenance window, so it also removes the restart from the deferred actions.
+func (r *RabbitmqClusterReconciler) reconcileRestartPending(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster, pending bool) error {
+	if err := r.updateDeferredActions(ctx, rmq, map[rabbitmqv1beta1.DeferredAction]bool{rabbitmqv1beta1.DeferredConfigurationRestart: false}); err != nil {
+		return err
+	}
+	if !pending && rmq.RestartApproved() {
+		if err := r.deleteAnnotation(ctx, rmq, rabbitmqv1beta1.ApproveRestartAnnotation); err != nil {
+			return fmt.Errorf("failed to remove annotation %s: %w", rabbitmqv1beta1.ApproveRestartAnnotation, err)
+		}
+	}
+
+	var changedKeys []string
+	if rmq.Status.Configuration != nil {
+		changedKeys = rmq.Status.Configuration.RestartKeys
+	}
+	patch := client.MergeFrom(rmq.DeepCopy())
+	oldConditions := rmq.Status.DeepCopy().Conditions
+	rmq.Status.SetRestartPendingCondition(rmq.ManualRestart(), pending, changedKeys)
+	if reflect.DeepEqual(rmq.Status.Conditions, oldConditions) {
+		return nil
+	}
+	return r.Status().Patch(ctx, rmq, patch)
 }
 
 func pluginsConfigUpdatedRecently(cfg *corev1.ConfigMap) (bool, error) {
@@ -10,6 +10,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtimeClient "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 var _ = Describe("Reconcile rabbitmq Configurations", func() {
@@ -110,4 +111,40 @@ var _ = Describe("Reconcile rabbitmq Configurations", func() {
 			}, 3, 0.3).ShouldNot(HaveKey("rabbitmq.com/lastRestartAt"))
 		})
 	})
+
+	Context("runtime configuration change", func() {
+		It("does not restart StatefulSet and reports the keys", func() {
+			cluster = &rabbitmqv1beta1.RabbitmqCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: defaultNamespace,
+					Name:      "rabbitmq-runtime-config",
+				},
+				Spec: rabbitmqv1beta1.RabbitmqClusterSpec{
+					Rabbitmq: rabbitmqv1beta1.RabbitmqClusterConfigurationSpec{
+						AdditionalConfig: "log.default.level = info",
+					},
+				},
+			}
+			Expect(client.Create(ctx, cluster)).To(Succeed())
+			waitForClusterCreation(ctx, cluster, client)
+
+			Expect(updateWithRetry(cluster, func(r *rabbitmqv1beta1.RabbitmqCluster) {
+				r.Spec.Rabbitmq.AdditionalConfig = "log.default.level = debug"
+			})).To(Succeed())
+
+			Eventually(func() *rabbitmqv1beta1.ConfigurationStatus {
+				Expect(client.Get(ctx, runtimeClient.ObjectKeyFromObject(cluster), cluster)).To(Succeed())
+				return cluster.Status.Configuration
+			}, 5).Should(HaveField("LiveKeys", ConsistOf("log.default.level")))
+
+			Consistently(func() map[string]string {
+				return configMap(ctx, cluster, "server-conf").Annotations
+			}, 3, 0.3).ShouldNot(HaveKey("rabbitmq.com/serverConfUpdatedAt"))
+
+			Consistently(func() map[string]string {
+				sts := statefulSet(ctx, cluster)
+				return sts.Spec.Template.Annotations
+			}, 3, 0.3).ShouldNot(HaveKey("rabbitmq.com/lastRestartAt"))
+		})
+	})
 })
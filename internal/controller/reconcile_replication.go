@@ -0,0 +1,392 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	rabbithole "github.com/michaelklishin/rabbit-hole/v3"
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/rabbitmqclient"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// replicationRecheckInterval is how often the replication status of a downstream is refreshed
+const replicationRecheckInterval = time.Minute
+
+// replicationDownstreams returns the RabbitmqClusters which replicate from rmq with spec.replication
+func (r *RabbitmqClusterReconciler) replicationDownstreams(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster) ([]rabbitmqv1beta1.RabbitmqCluster, error) {
+	clusters := &rabbitmqv1beta1.RabbitmqClusterList{}
+	if err := r.List(ctx, clusters); err != nil {
+		return nil, fmt.Errorf("failed to list RabbitmqClusters: %w", err)
+	}
+	var downstreams []rabbitmqv1beta1.RabbitmqCluster
+	for _, cluster := range clusters.Items {
+		if cluster.ReplicationUpstream() == client.ObjectKeyFromObject(rmq) {
+			downstreams = append(downstreams, cluster)
+		}
+	}
+	return downstreams, nil
+}
+
+// rabbitmqClustersForReplication maps a downstream RabbitmqCluster to its upstream, so that the upstream is
+// reconfigured when spec.replication is set or removed on the downstream.
+// Updates are mapped for both the old and the new object.
+func (r *RabbitmqClusterReconciler) rabbitmqClustersForReplication(_ context.Context, obj client.Object) []reconcile.Request {
+	rmq, ok := obj.(*rabbitmqv1beta1.RabbitmqCluster)
+	if !ok || !rmq.ReplicationEnabled() {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: rmq.ReplicationUpstream()}}
+}
+
+// reconcileReplication configures warm standby replication of a downstream RabbitmqCluster with spec.replication.
+// The upstream is configured by its own reconciliation, which enables the replication plugin and sets its operating mode.
+// On the upstream, the replication user is created with permissions in the replicated virtual hosts,
+// which are tagged for standby replication. On the downstream, the upstream endpoints and the credentials of the
+// replication user are set as global parameters. When the upstream serves TLS, its CA certificate is copied to the
+// replication CA Secret, which is mounted on every node, and its TLS listeners are used.
+// The status of replication is then inspected on the downstream.
+// When spec.replication is removed, the global parameters and the replication user and CA Secrets are deleted;
+// the replication user is left on the upstream.
+// It returns the time after which the replication status needs to be refreshed.
+func (r *RabbitmqClusterReconciler) reconcileReplication(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster) (time.Duration, error) {
+	logger := ctrl.LoggerFrom(ctx)
+	if !rmq.ReplicationEnabled() {
+		if rmq.Status.Replication == nil {
+			return 0, nil
+		}
+		return 0, r.stopReplication(ctx, rmq)
+	}
+
+	// patching the status triggers another reconciliation, so the status is refreshed on schedule
+	// unless the spec changed since the last check
+	if last := rmq.Status.Replication; last != nil && last.LastCheckTime != nil && rmq.Status.ObservedGeneration == rmq.Generation {
+		if remaining := time.Until(last.LastCheckTime.Add(replicationRecheckInterval)); remaining > 0 {
+			return remaining, nil
+		}
+	}
+
+	sts, err := r.statefulSet(ctx, rmq)
+	if err != nil {
+		return 0, client.IgnoreNotFound(err)
+	}
+	if !allReplicasReadyAndUpdated(sts) {
+		logger.V(1).Info("not all replicas ready yet; requeuing request to configure replication")
+		return 15 * time.Second, nil
+	}
+
+	upstreamName := rmq.ReplicationUpstream()
+	replicationStatus := &rabbitmqv1beta1.ReplicationStatus{
+		Upstream: upstreamName.String(),
+		Username: resource.ReplicationUsername(rmq),
+	}
+	upstream := &rabbitmqv1beta1.RabbitmqCluster{}
+	if err := r.Get(ctx, upstreamName, upstream); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return 0, err
+		}
+		replicationStatus.Message = fmt.Sprintf("upstream RabbitmqCluster %s not found", upstreamName)
+		return replicationRecheckInterval, r.setReplicationStatus(ctx, rmq, replicationStatus)
+	}
+	if upstream.ReplicationEnabled() {
+		replicationStatus.Message = fmt.Sprintf("upstream RabbitmqCluster %s is itself a downstream; replication cannot be chained", upstreamName)
+		return replicationRecheckInterval, r.setReplicationStatus(ctx, rmq, replicationStatus)
+	}
+	upstreamSts, err := r.statefulSet(ctx, upstream)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return 0, err
+	}
+	if upstreamSts == nil || !allReplicasReadyAndUpdated(upstreamSts) {
+		replicationStatus.Message = fmt.Sprintf("waiting for upstream RabbitmqCluster %s to be ready", upstreamName)
+		return 15 * time.Second, r.setReplicationStatus(ctx, rmq, replicationStatus)
+	}
+
+	if err := r.configureReplication(ctx, rmq, upstream); err != nil {
+		replicationStatus.Message = err.Error()
+		r.Recorder.Event(rmq, corev1.EventTypeWarning, "FailedReplicationConfiguration", err.Error())
+		return 0, errors.Join(err, r.setReplicationStatus(ctx, rmq, replicationStatus))
+	}
+
+	r.inspectReplication(ctx, rmq, replicationStatus)
+	return replicationRecheckInterval, r.setReplicationStatus(ctx, rmq, replicationStatus)
+}
+
+func (r *RabbitmqClusterReconciler) configureReplication(ctx context.Context, rmq, upstream *rabbitmqv1beta1.RabbitmqCluster) error {
+	password, created, err := r.userPassword(ctx, rmq, "replication user", func(password string) *corev1.Secret {
+		return resource.ReplicationUserSecret(rmq, password)
+	})
+	if err != nil {
+		return err
+	}
+
+	upstreamClient, err := r.RabbitmqClientFactory.GetClientForService(ctx, r.APIReader, upstream)
+	if err != nil {
+		return fmt.Errorf("failed to create RabbitMQ client for upstream: %w", err)
+	}
+	username := resource.ReplicationUsername(rmq)
+	vhosts := append([]string{"/"}, rmq.Spec.Replication.VirtualHosts...)
+	if err := configureUser(upstreamClient, "replication user", username, password, created, vhosts); err != nil {
+		return err
+	}
+	for _, vhost := range rmq.Spec.Replication.VirtualHosts {
+		if err := tagVhostForReplication(upstreamClient, vhost); err != nil {
+			return err
+		}
+	}
+
+	// the CA certificate is written before the parameters referencing it
+	ca, err := r.replicationUpstreamCA(ctx, upstream)
+	if err != nil {
+		return err
+	}
+	if err := r.reconcileCASecret(ctx, rmq, resource.ReplicationCASecret(rmq, ca)); err != nil {
+		return err
+	}
+
+	rabbitClient, err := r.RabbitmqClientFactory.GetClientForService(ctx, r.APIReader, rmq)
+	if err != nil {
+		return fmt.Errorf("failed to create RabbitMQ client: %w", err)
+	}
+	for name, value := range resource.ReplicationUpstreamParameters(upstream, username, password, len(ca) > 0) {
+		if err := putGlobalParameterIfChanged(rabbitClient, name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replicationUpstreamCA returns the CA certificate of an upstream serving TLS, or nil if the downstream connects without TLS
+func (r *RabbitmqClusterReconciler) replicationUpstreamCA(ctx context.Context, upstream *rabbitmqv1beta1.RabbitmqCluster) ([]byte, error) {
+	var ca []byte
+	if upstream.SecretTLSEnabled() {
+		var err error
+		if ca, err = r.tlsCACertificate(ctx, upstream); err != nil {
+			return nil, fmt.Errorf("failed to get CA certificate of upstream: %w", err)
+		}
+	}
+	if len(ca) == 0 && upstream.DisableNonTLSListeners() {
+		return nil, fmt.Errorf("upstream RabbitmqCluster %s only accepts TLS connections, but its TLS Secret has no CA certificate",
+			client.ObjectKeyFromObject(upstream))
+	}
+	return ca, nil
+}
+
+// userPassword returns the password of a user the operator creates on other RabbitmqClusters, generating it and
+// storing it in the Secret returned by userSecret the first time. created is true if the Secret was created.
+func (r *RabbitmqClusterReconciler) userPassword(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster, description string,
+	userSecret func(password string) *corev1.Secret) (password string, created bool, err error) {
+	secret := &corev1.Secret{}
+	err = r.Get(ctx, client.ObjectKeyFromObject(userSecret("")), secret)
+	if err == nil {
+		return string(secret.Data["password"]), false, nil
+	}
+	if !k8serrors.IsNotFound(err) {
+		return "", false, err
+	}
+
+	if password, err = resource.GenerateUserPassword(); err != nil {
+		return "", false, fmt.Errorf("failed to generate %s password: %w", description, err)
+	}
+	secret = userSecret(password)
+	if err := controllerutil.SetControllerReference(rmq, secret, r.Scheme); err != nil {
+		return "", false, fmt.Errorf("failed setting controller reference: %w", err)
+	}
+	if err := r.Create(ctx, secret); err != nil {
+		return "", false, fmt.Errorf("failed to create %s Secret: %w", description, err)
+	}
+	return password, true, nil
+}
+
+// configureUser creates a user on another RabbitmqCluster, or resets its password when the Secret holding it was
+// just created, and grants it full permissions in the given virtual hosts. The user has no tags, so it cannot
+// use the management API.
+func configureUser(rabbitClient rabbitmqclient.RabbitmqClient, description, username, password string, resetPassword bool, vhosts []string) error {
+	_, err := rabbitClient.GetUser(username)
+	if err != nil && !isRabbitmqNotFound(err) {
+		return fmt.Errorf("failed to get %s %s: %w", description, username, err)
+	}
+	if err != nil || resetPassword {
+		if _, err := rabbitClient.PutUser(username, rabbithole.UserSettings{Name: username, Password: password, Tags: rabbithole.UserTags{}}); err != nil {
+			return fmt.Errorf("failed to create %s %s: %w", description, username, err)
+		}
+	}
+
+	permissions, err := rabbitClient.ListPermissionsOf(username)
+	if err != nil {
+		return fmt.Errorf("failed to list permissions of %s %s: %w", description, username, err)
+	}
+	for _, vhost := range vhosts {
+		if slices.ContainsFunc(permissions, func(p rabbithole.PermissionInfo) bool {
+			return p.Vhost == vhost && p.Configure == ".*" && p.Write == ".*" && p.Read == ".*"
+		}) {
+			continue
+		}
+		if _, err := rabbitClient.UpdatePermissionsIn(vhost, username, rabbithole.Permissions{Configure: ".*", Write: ".*", Read: ".*"}); err != nil {
+			return fmt.Errorf("failed to set permissions of %s %s in vhost %s: %w", description, username, vhost, err)
+		}
+	}
+	return nil
+}
+
+// tagVhostForReplication adds the standby replication tag to a virtual host of the upstream, creating it if needed
+func tagVhostForReplication(rabbitClient rabbitmqclient.RabbitmqClient, vhost string) error {
+	settings := rabbithole.VhostSettings{}
+	info, err := rabbitClient.GetVhost(vhost)
+	switch {
+	case err == nil:
+		if slices.Contains(info.Tags, resource.StandbyReplicationVhostTag) {
+			return nil
+		}
+		settings = rabbithole.VhostSettings{Description: info.Description, Tags: info.Tags, DefaultQueueType: info.DefaultQueueType, Tracing: info.Tracing}
+	case !isRabbitmqNotFound(err):
+		return fmt.Errorf("failed to get vhost %s on upstream: %w", vhost, err)
+	}
+	settings.Tags = append(settings.Tags, resource.StandbyReplicationVhostTag)
+	if _, err := rabbitClient.PutVhost(vhost, settings); err != nil {
+		return fmt.Errorf("failed to tag vhost %s for replication on upstream: %w", vhost, err)
+	}
+	return nil
+}
+
+// putGlobalParameterIfChanged sets a global parameter unless it already has the desired value,
+// since setting it restarts the replication links
+func putGlobalParameterIfChanged(rabbitClient rabbitmqclient.RabbitmqClient, name string, value map[string]any) error {
+	current, err := rabbitClient.GetGlobalParameter(name)
+	if err != nil && !isRabbitmqNotFound(err) {
+		return fmt.Errorf("failed to get global parameter %s: %w", name, err)
+	}
+	if err == nil && sameParameterValue(current.Value, value) {
+		return nil
+	}
+	if _, err := rabbitClient.PutGlobalParameter(name, value); err != nil {
+		return fmt.Errorf("failed to set global parameter %s: %w", name, err)
+	}
+	return nil
+}
+
+// inspectReplication sets the health, the time of the last replicated message and the lag of replication
+// from the standby replication metrics of the first node of the downstream
+func (r *RabbitmqClusterReconciler) inspectReplication(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster, replicationStatus *rabbitmqv1beta1.ReplicationStatus) {
+	logger := ctrl.LoggerFrom(ctx)
+	now := metav1.Now()
+	replicationStatus.LastCheckTime = &now
+
+	podName := fmt.Sprintf("%s-0", rmq.ChildResourceName("server"))
+	cmd := "rabbitmq-diagnostics inspect_standby_downstream_metrics --formatter json"
+	stdout, stderr, err := r.exec(rmq.Namespace, podName, "rabbitmq", "sh", "-c", cmd)
+	if err != nil {
+		logger.Error(err, "failed to inspect standby replication metrics", "pod", podName, "command", cmd, "stdout", stdout, "stderr", stderr)
+		replicationStatus.Message = fmt.Sprintf("failed to inspect standby replication metrics on pod %s", podName)
+		return
+	}
+	lastReplicated, err := lastReplicatedMessageTime(stdout)
+	if err != nil {
+		replicationStatus.Message = fmt.Sprintf("failed to parse standby replication metrics: %s", err)
+		return
+	}
+
+	replicationStatus.Healthy = true
+	if !lastReplicated.IsZero() {
+		replicationStatus.LastReplicatedMessageTime = &metav1.Time{Time: lastReplicated}
+		replicationStatus.Lag = &metav1.Duration{Duration: max(now.Sub(lastReplicated), 0).Truncate(time.Second)}
+	}
+}
+
+// lastReplicatedMessageTime returns the latest timestamp reported by inspect_standby_downstream_metrics,
+// or the zero time if nothing has been replicated yet.
+// The metrics are reported per virtual host; timestamps are in milliseconds since the epoch.
+func lastReplicatedMessageTime(output string) (time.Time, error) {
+	var metrics any
+	if err := json.Unmarshal([]byte(output), &metrics); err != nil {
+		return time.Time{}, err
+	}
+	var latest int64
+	var walk func(key string, value any)
+	walk = func(key string, value any) {
+		switch v := value.(type) {
+		case map[string]any:
+			for k, item := range v {
+				walk(k, item)
+			}
+		case []any:
+			for _, item := range v {
+				walk(key, item)
+			}
+		case float64:
+			if strings.Contains(key, "timestamp") && int64(v) > latest {
+				latest = int64(v)
+			}
+		}
+	}
+	walk("", metrics)
+	if latest == 0 {
+		return time.Time{}, nil
+	}
+	return time.UnixMilli(latest), nil
+}
+
+// stopReplication deletes the global parameters and the replication user Secret of a former downstream
+func (r *RabbitmqClusterReconciler) stopReplication(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster) error {
+	sts, err := r.statefulSet(ctx, rmq)
+	if err == nil && allReplicasReadyAndUpdated(sts) {
+		rabbitClient, err := r.RabbitmqClientFactory.GetClientForService(ctx, r.APIReader, rmq)
+		if err != nil {
+			return fmt.Errorf("failed to create RabbitMQ client: %w", err)
+		}
+		for _, name := range []string{resource.SchemaSyncUpstreamParameter, resource.StandbyReplicationUpstreamParameter} {
+			if _, err := rabbitClient.DeleteGlobalParameter(name); err != nil && !isRabbitmqNotFound(err) {
+				return fmt.Errorf("failed to delete global parameter %s: %w", name, err)
+			}
+		}
+	} else if client.IgnoreNotFound(err) != nil {
+		return err
+	}
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: rmq.ChildResourceName(resource.ReplicationUserSecretName), Namespace: rmq.Namespace}}
+	if err := r.Delete(ctx, secret); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to delete replication user Secret: %w", err)
+	}
+	if err := r.reconcileCASecret(ctx, rmq, resource.ReplicationCASecret(rmq, nil)); err != nil {
+		return err
+	}
+	ctrl.LoggerFrom(ctx).Info("stopped replication", "upstream", rmq.Status.Replication.Upstream)
+	return r.setReplicationStatus(ctx, rmq, nil)
+}
+
+func (r *RabbitmqClusterReconciler) setReplicationStatus(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster, replicationStatus *rabbitmqv1beta1.ReplicationStatus) error {
+	patch := client.MergeFrom(rmq.DeepCopy())
+	rmq.Status.Replication = replicationStatus
+	return r.Status().Patch(ctx, rmq, patch)
+}
+
+// sameParameterValue returns true if the value of a parameter, as decoded from JSON by the client, equals the desired value
+func sameParameterValue(current any, desired map[string]any) bool {
+	encoded, err := json.Marshal(desired)
+	if err != nil {
+		return false
+	}
+	var decoded any
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(current, decoded)
+}
+
+func isRabbitmqNotFound(err error) bool {
+	var errorResponse rabbithole.ErrorResponse
+	return errors.As(err, &errorResponse) && errorResponse.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,316 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	rabbithole "github.com/michaelklishin/rabbit-hole/v3"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/rabbitmqclient"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("reconcileReplication", func() {
+	var (
+		downstream       *rabbitmqv1beta1.RabbitmqCluster
+		upstream         *rabbitmqv1beta1.RabbitmqCluster
+		fakeClient       client.Client
+		upstreamClient   *replicationRabbitmqClient
+		downstreamClient *replicationRabbitmqClient
+		executor         *metricsPodExecutor
+		reconciler       *RabbitmqClusterReconciler
+	)
+
+	BeforeEach(func() {
+		downstream = &rabbitmqv1beta1.RabbitmqCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "standby", Namespace: "dr"},
+			Spec: rabbitmqv1beta1.RabbitmqClusterSpec{
+				Replicas: new(int32(1)),
+				Replication: &rabbitmqv1beta1.ReplicationSpec{
+					Upstream:     rabbitmqv1beta1.RabbitmqClusterReference{Name: "primary", Namespace: "prod"},
+					VirtualHosts: []string{"orders"},
+				},
+			},
+		}
+		upstream = &rabbitmqv1beta1.RabbitmqCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "primary", Namespace: "prod"},
+			Spec:       rabbitmqv1beta1.RabbitmqClusterSpec{Replicas: new(int32(1))},
+		}
+		upstreamClient = newReplicationRabbitmqClient()
+		upstreamClient.vhosts["orders"] = rabbithole.VhostInfo{Name: "orders", Description: "orders", Tags: rabbithole.VhostTags{"team-a"}}
+		downstreamClient = newReplicationRabbitmqClient()
+		executor = &metricsPodExecutor{stdout: `[{"vhost":"orders","last_message_timestamp":1700000000000},{"vhost":"orders","last_message_timestamp":1700000060000}]`}
+	})
+
+	JustBeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(rabbitmqv1beta1.AddToScheme(scheme)).To(Succeed())
+		readyStatefulSet := func(rmq *rabbitmqv1beta1.RabbitmqCluster) *appsv1.StatefulSet {
+			return &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Name: rmq.ChildResourceName("server"), Namespace: rmq.Namespace},
+				Spec:       appsv1.StatefulSetSpec{Replicas: new(int32(1))},
+				Status:     appsv1.StatefulSetStatus{ReadyReplicas: 1},
+			}
+		}
+		fakeClient = fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(downstream, upstream, readyStatefulSet(downstream), readyStatefulSet(upstream)).
+			WithStatusSubresource(downstream).
+			Build()
+		reconciler = &RabbitmqClusterReconciler{
+			Client:      fakeClient,
+			APIReader:   fakeClient,
+			Scheme:      scheme,
+			Recorder:    record.NewFakeRecorder(10),
+			PodExecutor: executor,
+			RabbitmqClientFactory: &replicationRabbitmqClientFactory{clients: map[types.NamespacedName]rabbitmqclient.RabbitmqClient{
+				{Name: "standby", Namespace: "dr"}:   downstreamClient,
+				{Name: "primary", Namespace: "prod"}: upstreamClient,
+			}},
+		}
+	})
+
+	getDownstream := func(ctx context.Context) *rabbitmqv1beta1.RabbitmqCluster {
+		updated := &rabbitmqv1beta1.RabbitmqCluster{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "standby", Namespace: "dr"}, updated)).To(Succeed())
+		return updated
+	}
+
+	It("configures the replication user, the virtual hosts and the upstream endpoints", func(ctx SpecContext) {
+		requeueAfter, err := reconciler.reconcileReplication(ctx, downstream)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requeueAfter).To(Equal(replicationRecheckInterval))
+
+		secret := &corev1.Secret{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "standby-replication-user", Namespace: "dr"}, secret)).To(Succeed())
+		Expect(string(secret.Data["username"])).To(Equal("standby_replicator_dr_standby"))
+		password := string(secret.Data["password"])
+		Expect(password).NotTo(BeEmpty())
+		Expect(secret.OwnerReferences).To(ConsistOf(HaveField("Name", "standby")))
+
+		Expect(upstreamClient.users).To(HaveKeyWithValue("standby_replicator_dr_standby", HaveField("Password", password)))
+		Expect(upstreamClient.permissions["standby_replicator_dr_standby"]).To(ConsistOf(
+			HaveField("Vhost", "/"),
+			HaveField("Vhost", "orders"),
+		))
+		Expect(upstreamClient.vhosts["orders"].Tags).To(Equal(rabbithole.VhostTags{"team-a", "standby_replication"}))
+		Expect(upstreamClient.vhosts["orders"].Description).To(Equal("orders"))
+
+		Expect(downstreamClient.parameters).To(HaveKeyWithValue(resource.StandbyReplicationUpstreamParameter, map[string]any{
+			"endpoints": []any{"primary.prod.svc:5552"},
+			"username":  "standby_replicator_dr_standby",
+			"password":  password,
+		}))
+		Expect(downstreamClient.parameters).To(HaveKeyWithValue(resource.SchemaSyncUpstreamParameter, HaveKeyWithValue("endpoints", []any{"primary.prod.svc:5672"})))
+
+		Expect(executor.commands).To(ConsistOf("standby-server-0: rabbitmq-diagnostics inspect_standby_downstream_metrics --formatter json"))
+		replicationStatus := getDownstream(ctx).Status.Replication
+		Expect(replicationStatus).NotTo(BeNil())
+		Expect(replicationStatus.Upstream).To(Equal("prod/primary"))
+		Expect(replicationStatus.Healthy).To(BeTrue())
+		Expect(replicationStatus.Message).To(BeEmpty())
+		Expect(replicationStatus.LastReplicatedMessageTime.Time).To(BeTemporally("==", time.UnixMilli(1700000060000)))
+		Expect(replicationStatus.Lag.Duration).To(BeNumerically(">", 0))
+	})
+
+	It("does not reset parameters which did not change", func(ctx SpecContext) {
+		_, err := reconciler.reconcileReplication(ctx, downstream)
+		Expect(err).NotTo(HaveOccurred())
+		downstreamClient.puts = 0
+
+		updated := getDownstream(ctx)
+		updated.Status.Replication.LastCheckTime = &metav1.Time{Time: time.Now().Add(-2 * replicationRecheckInterval)}
+		_, err = reconciler.reconcileReplication(ctx, updated)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(downstreamClient.puts).To(BeZero())
+	})
+
+	When("the upstream serves TLS", func() {
+		BeforeEach(func() {
+			upstream.Spec.TLS = rabbitmqv1beta1.TLSSpec{SecretName: "primary-tls", DisableNonTLSListeners: true}
+		})
+
+		It("connects to the TLS listeners and verifies the upstream against its CA certificate", func(ctx SpecContext) {
+			Expect(fakeClient.Create(ctx, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "primary-tls", Namespace: "prod"},
+				Data:       map[string][]byte{"ca.crt": []byte("upstream CA"), "tls.crt": []byte("cert"), "tls.key": []byte("key")},
+			})).To(Succeed())
+
+			_, err := reconciler.reconcileReplication(ctx, downstream)
+			Expect(err).NotTo(HaveOccurred())
+
+			secret := &corev1.Secret{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "standby-replication-ca", Namespace: "dr"}, secret)).To(Succeed())
+			Expect(secret.Data).To(Equal(map[string][]byte{"ca.crt": []byte("upstream CA")}))
+			sslOptions := map[string]any{
+				"cacertfile":             "/etc/rabbitmq-replication-ca/ca.crt",
+				"verify":                 "verify_peer",
+				"server_name_indication": "primary.prod.svc",
+			}
+			Expect(downstreamClient.parameters).To(HaveKeyWithValue(resource.SchemaSyncUpstreamParameter, And(
+				HaveKeyWithValue("endpoints", []any{"primary.prod.svc:5671"}),
+				HaveKeyWithValue("ssl_options", sslOptions),
+			)))
+			Expect(downstreamClient.parameters).To(HaveKeyWithValue(resource.StandbyReplicationUpstreamParameter, And(
+				HaveKeyWithValue("endpoints", []any{"primary.prod.svc:5551"}),
+				HaveKeyWithValue("ssl_options", sslOptions),
+			)))
+		})
+
+		It("reports a missing CA certificate when the upstream only accepts TLS", func(ctx SpecContext) {
+			_, err := reconciler.reconcileReplication(ctx, downstream)
+			Expect(err).To(MatchError(ContainSubstring("only accepts TLS connections")))
+			Expect(getDownstream(ctx).Status.Replication.Message).To(ContainSubstring("only accepts TLS connections"))
+		})
+	})
+
+	When("the upstream does not exist", func() {
+		BeforeEach(func() {
+			downstream.Spec.Replication.Upstream.Name = "missing"
+		})
+
+		It("reports it in the status", func(ctx SpecContext) {
+			_, err := reconciler.reconcileReplication(ctx, downstream)
+			Expect(err).NotTo(HaveOccurred())
+			replicationStatus := getDownstream(ctx).Status.Replication
+			Expect(replicationStatus.Healthy).To(BeFalse())
+			Expect(replicationStatus.Message).To(Equal("upstream RabbitmqCluster prod/missing not found"))
+		})
+	})
+
+	When("spec.replication is removed", func() {
+		BeforeEach(func() {
+			downstream.Spec.Replication = nil
+			downstream.Status.Replication = &rabbitmqv1beta1.ReplicationStatus{Upstream: "prod/primary"}
+			downstreamClient.parameters[resource.StandbyReplicationUpstreamParameter] = map[string]any{}
+		})
+
+		It("deletes the upstream parameters and the replication user Secret", func(ctx SpecContext) {
+			Expect(fakeClient.Create(ctx, resource.ReplicationUserSecret(downstream, "password"))).To(Succeed())
+
+			_, err := reconciler.reconcileReplication(ctx, downstream)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(downstreamClient.parameters).To(BeEmpty())
+			err = fakeClient.Get(ctx, types.NamespacedName{Name: "standby-replication-user", Namespace: "dr"}, &corev1.Secret{})
+			Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+			Expect(getDownstream(ctx).Status.Replication).To(BeNil())
+		})
+	})
+})
+
+var _ = Describe("lastReplicatedMessageTime", func() {
+	It("returns the zero time when nothing was replicated", func() {
+		lastReplicated, err := lastReplicatedMessageTime(`[]`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lastReplicated.IsZero()).To(BeTrue())
+	})
+
+	It("rejects output which is not JSON", func() {
+		_, err := lastReplicatedMessageTime("Error: standby replication is not running")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+// replicationRabbitmqClientFactory returns the client of each RabbitmqCluster
+type replicationRabbitmqClientFactory struct {
+	clients map[types.NamespacedName]rabbitmqclient.RabbitmqClient
+}
+
+func (f *replicationRabbitmqClientFactory) GetClientForPod(_ context.Context, _ client.Reader, rmq *rabbitmqv1beta1.RabbitmqCluster, _ string) (rabbitmqclient.RabbitmqClient, error) {
+	return f.clients[client.ObjectKeyFromObject(rmq)], nil
+}
+
+func (f *replicationRabbitmqClientFactory) GetClientForService(_ context.Context, _ client.Reader, rmq *rabbitmqv1beta1.RabbitmqCluster) (rabbitmqclient.RabbitmqClient, error) {
+	return f.clients[client.ObjectKeyFromObject(rmq)], nil
+}
+
+// replicationRabbitmqClient keeps users, permissions, virtual hosts and global parameters in memory
+type replicationRabbitmqClient struct {
+	userRotationRabbitmqClient
+	vhosts     map[string]rabbithole.VhostInfo
+	parameters map[string]any
+	puts       int
+}
+
+func newReplicationRabbitmqClient() *replicationRabbitmqClient {
+	return &replicationRabbitmqClient{
+		userRotationRabbitmqClient: userRotationRabbitmqClient{
+			users:       map[string]rabbithole.UserSettings{},
+			permissions: map[string][]rabbithole.PermissionInfo{},
+		},
+		vhosts:     map[string]rabbithole.VhostInfo{},
+		parameters: map[string]any{},
+	}
+}
+
+func (c *replicationRabbitmqClient) GetVhost(vhost string) (*rabbithole.VhostInfo, error) {
+	info, ok := c.vhosts[vhost]
+	if !ok {
+		return nil, rabbithole.ErrorResponse{StatusCode: http.StatusNotFound}
+	}
+	return &info, nil
+}
+
+func (c *replicationRabbitmqClient) PutVhost(vhost string, settings rabbithole.VhostSettings) (*http.Response, error) {
+	c.vhosts[vhost] = rabbithole.VhostInfo{Name: vhost, Description: settings.Description, Tags: settings.Tags}
+	return nil, nil
+}
+
+func (c *replicationRabbitmqClient) GetGlobalParameter(name string) (*rabbithole.GlobalRuntimeParameter, error) {
+	value, ok := c.parameters[name]
+	if !ok {
+		return nil, rabbithole.ErrorResponse{StatusCode: http.StatusNotFound}
+	}
+	return &rabbithole.GlobalRuntimeParameter{Name: name, Value: value}, nil
+}
+
+// PutGlobalParameter stores the value as decoded from JSON, like the management API returns it
+func (c *replicationRabbitmqClient) PutGlobalParameter(name string, value any) (*http.Response, error) {
+	c.puts++
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var decoded any
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return nil, err
+	}
+	c.parameters[name] = decoded
+	return nil, nil
+}
+
+func (c *replicationRabbitmqClient) DeleteGlobalParameter(name string) (*http.Response, error) {
+	if _, ok := c.parameters[name]; !ok {
+		return nil, rabbithole.ErrorResponse{StatusCode: http.StatusNotFound}
+	}
+	delete(c.parameters, name)
+	return nil, nil
+}
+
+// metricsPodExecutor records commands and prints the given standby replication metrics
+type metricsPodExecutor struct {
+	recordingPodExecutor
+	stdout string
+}
+
+func (e *metricsPodExecutor) Exec(clientset *kubernetes.Clientset, config *rest.Config, namespace, podName, containerName string, command ...string) (string, string, error) {
+	_, _, err := e.recordingPodExecutor.Exec(clientset, config, namespace, podName, containerName, command...)
+	return e.stdout, "", err
+}
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	"github.com/rabbitmq/cluster-operator/v2/internal/status"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Restart policy", func() {
+	var (
+		cluster    *rabbitmqv1beta1.RabbitmqCluster
+		fakeClient client.Client
+		reconciler *RabbitmqClusterReconciler
+	)
+
+	BeforeEach(func() {
+		cluster = &rabbitmqv1beta1.RabbitmqCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "rabbit", Namespace: "default"},
+			Spec: rabbitmqv1beta1.RabbitmqClusterSpec{
+				Replicas:      new(int32(3)),
+				RestartPolicy: rabbitmqv1beta1.RestartPolicyManual,
+			},
+			Status: rabbitmqv1beta1.RabbitmqClusterStatus{
+				Configuration: &rabbitmqv1beta1.ConfigurationStatus{RestartKeys: []string{"listeners.tcp.default"}},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(rabbitmqv1beta1.AddToScheme(scheme)).To(Succeed())
+		serverConf := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+			Name:        cluster.ChildResourceName(resource.ServerConfigMapName),
+			Namespace:   cluster.Namespace,
+			Annotations: map[string]string{serverConfAnnotation: time.Now().Format(time.RFC3339)},
+		}}
+		sts := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: cluster.ChildResourceName("server"), Namespace: cluster.Namespace}}
+		fakeClient = fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(cluster, serverConf, sts).
+			WithStatusSubresource(cluster).
+			Build()
+		reconciler = &RabbitmqClusterReconciler{
+			Client:   fakeClient,
+			Scheme:   scheme,
+			Recorder: record.NewFakeRecorder(10),
+		}
+	})
+
+	restartedAt := func(ctx SpecContext) string {
+		sts := &appsv1.StatefulSet{}
+		Expect(fakeClient.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.ChildResourceName("server")}, sts)).To(Succeed())
+		return sts.Spec.Template.Annotations[stsRestartAnnotation]
+	}
+
+	It("holds back the restart until it is approved", func(ctx SpecContext) {
+		_, err := reconciler.restartStatefulSetIfNeeded(ctx, ctrl.Log, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(restartedAt(ctx)).To(BeEmpty())
+		Expect(cluster.Status.RestartPending()).To(BeTrue())
+		Expect(cluster.Status.Conditions).To(ContainElement(And(
+			HaveField("Type", status.RestartPending),
+			HaveField("Message", ContainSubstring("listeners.tcp.default")),
+		)))
+
+		By("restarting once approved, and removing the approval")
+		cluster.Annotations = map[string]string{rabbitmqv1beta1.ApproveRestartAnnotation: "true"}
+		Expect(fakeClient.Update(ctx, cluster)).To(Succeed())
+		_, err = reconciler.restartStatefulSetIfNeeded(ctx, ctrl.Log, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(restartedAt(ctx)).NotTo(BeEmpty())
+		Expect(cluster.Status.RestartPending()).To(BeFalse())
+
+		updated := &rabbitmqv1beta1.RabbitmqCluster{}
+		Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(cluster), updated)).To(Succeed())
+		Expect(updated.Annotations).NotTo(HaveKey(rabbitmqv1beta1.ApproveRestartAnnotation))
+	})
+
+	When("restarts are automatic", func() {
+		BeforeEach(func() {
+			cluster.Spec.RestartPolicy = ""
+		})
+
+		It("restarts without approval and does not set the condition", func(ctx SpecContext) {
+			_, err := reconciler.restartStatefulSetIfNeeded(ctx, ctrl.Log, cluster)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(restartedAt(ctx)).NotTo(BeEmpty())
+			for _, condition := range cluster.Status.Conditions {
+				Expect(condition.Type).NotTo(Equal(status.RestartPending))
+			}
+		})
+	})
+})
@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// runtimeConfigUpdateAnnotation is set on the server ConfigMap when it changed only in keys which are applied on the running nodes
+const runtimeConfigUpdateAnnotation = "rabbitmq.com/runtimeConfigUpdatedAt"
+
+// recordConfigurationChange reports in status.configuration which keys of an update to the server ConfigMap
+// restart the nodes, and which are applied on the running nodes.
+func (r *RabbitmqClusterReconciler) recordConfigurationChange(ctx context.Context, builder resource.ResourceBuilder, operationResult controllerutil.OperationResult, rmq *rabbitmqv1beta1.RabbitmqCluster) error {
+	b, ok := builder.(*resource.ServerConfigMapBuilder)
+	if !ok || operationResult != controllerutil.OperationResultUpdated {
+		return nil
+	}
+	configuration := &rabbitmqv1beta1.ConfigurationStatus{LastChangeTime: new(metav1.Now())}
+	switch {
+	case b.UpdateRequiresStsRestart:
+		configuration.RestartKeys = b.RestartConfigKeys
+		// a restart awaiting approval applies all changes since the last restart
+		if rmq.Status.RestartPending() && rmq.Status.Configuration != nil {
+			restartKeys := append(slices.Clone(rmq.Status.Configuration.RestartKeys), b.RestartConfigKeys...)
+			slices.Sort(restartKeys)
+			configuration.RestartKeys = slices.Compact(restartKeys)
+		}
+	case len(b.RuntimeConfigKeys) > 0:
+		configuration.LiveKeys = b.RuntimeConfigKeys
+	default:
+		return nil
+	}
+	patch := client.MergeFrom(rmq.DeepCopy())
+	rmq.Status.Configuration = configuration
+	if err := r.Status().Patch(ctx, rmq, patch); err != nil {
+		return fmt.Errorf("failed to update status of configuration change: %w", err)
+	}
+	return nil
+}
+
+// runApplyRuntimeConfigCommands applies the runtime keys of rabbitmq.conf on every node with rabbitmqctl,
+// after the server ConfigMap changed only in such keys. Restarted nodes read them from the ConfigMap.
+func (r *RabbitmqClusterReconciler) runApplyRuntimeConfigCommands(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster, configMap *corev1.ConfigMap) error {
+	logger := ctrl.LoggerFrom(ctx)
+	commands, err := resource.RuntimeConfigCommands(configMap)
+	if err != nil {
+		return err
+	}
+	if len(commands) > 0 {
+		cmd := strings.Join(commands, " && ")
+		for i := int32(0); i < *rmq.Spec.Replicas; i++ {
+			podName := fmt.Sprintf("%s-%d", rmq.ChildResourceName("server"), i)
+			stdout, stderr, err := r.exec(rmq.Namespace, podName, "rabbitmq", "sh", "-c", cmd)
+			if err != nil {
+				msg := "failed to apply configuration on pod"
+				logger.Error(err, msg, "pod", podName, "command", cmd, "stdout", stdout, "stderr", stderr)
+				r.Recorder.Event(rmq, corev1.EventTypeWarning, "FailedReconcile", fmt.Sprintf("%s %s", msg, podName))
+				return fmt.Errorf("%s %s: %w", msg, podName, err)
+			}
+		}
+	}
+
+	if configuration := rmq.Status.Configuration; configuration != nil && len(configuration.LiveKeys) > 0 && !configuration.LiveKeysApplied {
+		patch := client.MergeFrom(rmq.DeepCopy())
+		rmq.Status.Configuration.LiveKeysApplied = true
+		if err := r.Status().Patch(ctx, rmq, patch); err != nil {
+			return fmt.Errorf("failed to update status of configuration change: %w", err)
+		}
+		msg := fmt.Sprintf("applied %s on running nodes without restarting them", strings.Join(configuration.LiveKeys, ", "))
+		r.Recorder.Event(rmq, corev1.EventTypeNormal, "SuccessfulUpdate", msg)
+	}
+	logger.Info("successfully applied runtime configuration")
+	return r.deleteAnnotation(ctx, configMap, runtimeConfigUpdateAnnotation)
+}
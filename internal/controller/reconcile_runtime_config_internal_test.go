@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+var _ = Describe("Runtime configuration", func() {
+	var (
+		cluster    *rabbitmqv1beta1.RabbitmqCluster
+		serverConf *corev1.ConfigMap
+		fakeClient client.Client
+		executor   *recordingPodExecutor
+		reconciler *RabbitmqClusterReconciler
+	)
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		Expect(rabbitmqv1beta1.AddToScheme(scheme)).To(Succeed())
+		cluster = &rabbitmqv1beta1.RabbitmqCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "rabbit", Namespace: "default"},
+			Spec:       rabbitmqv1beta1.RabbitmqClusterSpec{Replicas: new(int32(2))},
+		}
+		serverConf = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        cluster.ChildResourceName(resource.ServerConfigMapName),
+				Namespace:   cluster.Namespace,
+				Annotations: map[string]string{runtimeConfigUpdateAnnotation: "2026-10-18T10:00:00Z"},
+			},
+			Data: map[string]string{
+				"userDefinedConfiguration.conf": "total_memory_available_override_value = 1073741824\nlog.default.level = debug\ndisk_free_limit.absolute = 4GB\n",
+			},
+		}
+		fakeClient = fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(cluster, serverConf).
+			WithStatusSubresource(cluster).
+			Build()
+		executor = &recordingPodExecutor{}
+		reconciler = &RabbitmqClusterReconciler{
+			Client:      fakeClient,
+			Scheme:      scheme,
+			Recorder:    record.NewFakeRecorder(10),
+			PodExecutor: executor,
+		}
+	})
+
+	It("reports keys which are applied on the running nodes", func(ctx SpecContext) {
+		builder := (&resource.RabbitmqResourceBuilder{Instance: cluster}).ServerConfigMap()
+		builder.UpdateRequiresStsRestart = false
+		builder.RuntimeConfigKeys = []string{"log.default.level"}
+		Expect(reconciler.recordConfigurationChange(ctx, builder, controllerutil.OperationResultUpdated, cluster)).To(Succeed())
+
+		Expect(cluster.Status.Configuration.LiveKeys).To(ConsistOf("log.default.level"))
+		Expect(cluster.Status.Configuration.RestartKeys).To(BeEmpty())
+		Expect(cluster.Status.Configuration.LiveKeysApplied).To(BeFalse())
+		Expect(cluster.Status.Configuration.LastChangeTime).NotTo(BeNil())
+	})
+
+	It("reports keys which restart the nodes", func(ctx SpecContext) {
+		builder := (&resource.RabbitmqResourceBuilder{Instance: cluster}).ServerConfigMap()
+		builder.RestartConfigKeys = []string{"advanced.config", "listeners.tcp.default"}
+		Expect(reconciler.recordConfigurationChange(ctx, builder, controllerutil.OperationResultUpdated, cluster)).To(Succeed())
+
+		Expect(cluster.Status.Configuration.RestartKeys).To(ConsistOf("advanced.config", "listeners.tcp.default"))
+		Expect(cluster.Status.Configuration.LiveKeys).To(BeEmpty())
+	})
+
+	It("accumulates the keys of changes while a restart awaits approval", func(ctx SpecContext) {
+		cluster.Spec.RestartPolicy = rabbitmqv1beta1.RestartPolicyManual
+		cluster.Status.Configuration = &rabbitmqv1beta1.ConfigurationStatus{RestartKeys: []string{"listeners.tcp.default"}}
+		cluster.Status.SetRestartPendingCondition(true, true, cluster.Status.Configuration.RestartKeys)
+		Expect(fakeClient.Status().Update(ctx, cluster)).To(Succeed())
+
+		builder := (&resource.RabbitmqResourceBuilder{Instance: cluster}).ServerConfigMap()
+		builder.RestartConfigKeys = []string{"advanced.config", "listeners.tcp.default"}
+		Expect(reconciler.recordConfigurationChange(ctx, builder, controllerutil.OperationResultUpdated, cluster)).To(Succeed())
+
+		Expect(cluster.Status.Configuration.RestartKeys).To(Equal([]string{"advanced.config", "listeners.tcp.default"}))
+	})
+
+	It("does not report unchanged ConfigMaps", func(ctx SpecContext) {
+		builder := (&resource.RabbitmqResourceBuilder{Instance: cluster}).ServerConfigMap()
+		Expect(reconciler.recordConfigurationChange(ctx, builder, controllerutil.OperationResultNone, cluster)).To(Succeed())
+		Expect(cluster.Status.Configuration).To(BeNil())
+	})
+
+	It("applies the runtime keys on every node and removes the annotation", func(ctx SpecContext) {
+		cluster.Status.Configuration = &rabbitmqv1beta1.ConfigurationStatus{LiveKeys: []string{"log.default.level"}}
+		Expect(fakeClient.Status().Update(ctx, cluster)).To(Succeed())
+
+		Expect(reconciler.runApplyRuntimeConfigCommands(ctx, cluster, serverConf)).To(Succeed())
+
+		Expect(executor.commands).To(Equal([]string{
+			"rabbit-server-0: rabbitmqctl set_log_level debug && rabbitmqctl set_disk_free_limit 4GB",
+			"rabbit-server-1: rabbitmqctl set_log_level debug && rabbitmqctl set_disk_free_limit 4GB",
+		}))
+		Expect(cluster.Status.Configuration.LiveKeysApplied).To(BeTrue())
+
+		updated := &corev1.ConfigMap{}
+		Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(serverConf), updated)).To(Succeed())
+		Expect(updated.Annotations).NotTo(HaveKey(runtimeConfigUpdateAnnotation))
+	})
+})
@@ -0,0 +1,175 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/metadata"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const streamAdvertisedUpdateAnnotation = "rabbitmq.com/streamAdvertisedAddressesUpdatedAt"
+
+// reconcileStreamAdvertisedAddresses computes the address each node advertises to stream clients from the per-Pod
+// stream Services, and stores it in the stream-advertised ConfigMap mounted by the StatefulSet.
+// There are 2 paths how the advertised address is set, similar to plugins:
+// 1. When a node (re)starts, it reads the key named after its Pod from the ConfigMap.
+// 2. When an address changes, e.g. because a LoadBalancer got provisioned, the ConfigMap is annotated
+// and runSetStreamAdvertisedAddressesCommand updates the running nodes.
+// It must run before the StatefulSet is created or scaled so that the ConfigMap contains a key for every Pod.
+func (r *RabbitmqClusterReconciler) reconcileStreamAdvertisedAddresses(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster) error {
+	if err := r.deleteStaleStreamPodServices(ctx, rmq); err != nil {
+		return err
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rmq.ChildResourceName(resource.StreamAdvertisedConfigMapName),
+			Namespace: rmq.Namespace,
+		},
+	}
+
+	if !rmq.StreamPerPodServicesEnabled() {
+		if err := r.Delete(ctx, configMap); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		return r.setStreamAdvertisedAddressesStatus(ctx, rmq, nil)
+	}
+
+	replicas := ptr.Deref(rmq.Spec.Replicas, 1)
+	addresses := make([]rabbitmqv1beta1.StreamAdvertisedAddress, 0, replicas)
+	for i := range replicas {
+		service := &corev1.Service{}
+		err := r.Get(ctx, types.NamespacedName{Name: resource.StreamPodServiceName(rmq, i), Namespace: rmq.Namespace}, service)
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		} else if err != nil {
+			service = nil
+		}
+		addresses = append(addresses, resource.StreamAdvertisedAddressForPod(rmq, i, service))
+	}
+
+	operationResult, err := controllerutil.CreateOrUpdate(ctx, r.Client, configMap, func() error {
+		previousData := configMap.Data
+		configMap.Labels = metadata.GetLabels(rmq.Name, rmq.Labels)
+		configMap.Data = make(map[string]string, len(addresses))
+		for _, address := range addresses {
+			configMap.Data[address.Pod+".conf"] = resource.StreamAdvertisedConf(address)
+		}
+		// Running nodes only need to be updated when an address that was previously rendered changes
+		if configMap.ResourceVersion == "" || reflect.DeepEqual(previousData, configMap.Data) {
+			return controllerutil.SetControllerReference(rmq, configMap, r.Scheme)
+		}
+		if configMap.Annotations == nil {
+			configMap.Annotations = map[string]string{}
+		}
+		configMap.Annotations[streamAdvertisedUpdateAnnotation] = time.Now().Format(time.RFC3339)
+		return controllerutil.SetControllerReference(rmq, configMap, r.Scheme)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reconcile stream advertised addresses ConfigMap: %w", err)
+	}
+	if operationResult != controllerutil.OperationResultNone {
+		ctrl.LoggerFrom(ctx).Info("updated stream advertised addresses", "addresses", addresses)
+	}
+
+	return r.setStreamAdvertisedAddressesStatus(ctx, rmq, addresses)
+}
+
+// deleteStaleStreamPodServices deletes per-Pod stream Services which select Pods beyond the desired number of replicas,
+// or all of them if per-Pod Services are not desired.
+func (r *RabbitmqClusterReconciler) deleteStaleStreamPodServices(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster) error {
+	services := &corev1.ServiceList{}
+	if err := r.List(ctx, services,
+		client.InNamespace(rmq.Namespace),
+		client.MatchingLabels{"app.kubernetes.io/name": rmq.Name},
+		client.HasLabels{resource.StreamPodServiceLabel},
+	); err != nil {
+		return err
+	}
+
+	desired := map[string]bool{}
+	if rmq.StreamPerPodServicesEnabled() {
+		for i := range ptr.Deref(rmq.Spec.Replicas, 1) {
+			desired[resource.StreamPodServiceName(rmq, i)] = true
+		}
+	}
+
+	for i := range services.Items {
+		service := &services.Items[i]
+		if desired[service.Name] || !metav1.IsControlledBy(service, rmq) {
+			continue
+		}
+		if err := r.Delete(ctx, service); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		ctrl.LoggerFrom(ctx).Info("deleted stream Service", "service", service.Name)
+	}
+	return nil
+}
+
+func (r *RabbitmqClusterReconciler) setStreamAdvertisedAddressesStatus(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster, addresses []rabbitmqv1beta1.StreamAdvertisedAddress) error {
+	if len(addresses) == 0 {
+		addresses = nil
+	}
+	if reflect.DeepEqual(rmq.Status.StreamAdvertisedAddresses, addresses) {
+		return nil
+	}
+	patch := client.MergeFrom(rmq.DeepCopy())
+	rmq.Status.StreamAdvertisedAddresses = addresses
+	return r.Status().Patch(ctx, rmq, patch)
+}
+
+// runSetStreamAdvertisedAddressesCommand updates the advertised stream address of running nodes.
+// The stream plugin reads the advertised host and ports from its application environment whenever it answers
+// a metadata request, so nodes do not need to be restarted.
+func (r *RabbitmqClusterReconciler) runSetStreamAdvertisedAddressesCommand(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster, configMap *corev1.ConfigMap) error {
+	logger := ctrl.LoggerFrom(ctx)
+	for _, address := range rmq.Status.StreamAdvertisedAddresses {
+		cmd := fmt.Sprintf("rabbitmqctl eval '%s'", setStreamAdvertisedAddressExpression(address))
+		stdout, stderr, err := r.exec(rmq.Namespace, address.Pod, "rabbitmq", "sh", "-c", cmd)
+		if err != nil {
+			msg := "failed to set stream advertised address on pod"
+			logger.Error(err, msg, "pod", address.Pod, "command", cmd, "stdout", stdout, "stderr", stderr)
+			r.Recorder.Event(rmq, corev1.EventTypeWarning, "FailedReconcile", fmt.Sprintf("%s %s", msg, address.Pod))
+			return fmt.Errorf("%s %s: %w", msg, address.Pod, err)
+		}
+	}
+	logger.Info("successfully set stream advertised addresses")
+	return r.deleteAnnotation(ctx, configMap, streamAdvertisedUpdateAnnotation)
+}
+
+func setStreamAdvertisedAddressExpression(address rabbitmqv1beta1.StreamAdvertisedAddress) string {
+	expression := ""
+	if address.Host == "" {
+		expression += "application:unset_env(rabbitmq_stream, advertised_host), "
+	} else {
+		expression += fmt.Sprintf("application:set_env(rabbitmq_stream, advertised_host, %s), ", strconv.Quote(address.Host))
+	}
+	ports := []struct {
+		key  string
+		port int32
+	}{
+		{"advertised_port", address.Port},
+		{"advertised_tls_port", address.TLSPort},
+	}
+	for _, p := range ports {
+		if p.port == 0 {
+			expression += fmt.Sprintf("application:unset_env(rabbitmq_stream, %s), ", p.key)
+		} else {
+			expression += fmt.Sprintf("application:set_env(rabbitmq_stream, %s, %d), ", p.key, p.port)
+		}
+	}
+	return expression + "ok."
+}
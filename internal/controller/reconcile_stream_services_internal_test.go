@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("reconcileStreamAdvertisedAddresses", func() {
+	var (
+		scheme     *runtime.Scheme
+		cluster    *rabbitmqv1beta1.RabbitmqCluster
+		fakeClient client.Client
+		reconciler *RabbitmqClusterReconciler
+	)
+
+	BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		Expect(rabbitmqv1beta1.AddToScheme(scheme)).To(Succeed())
+		cluster = &rabbitmqv1beta1.RabbitmqCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "rabbit", Namespace: "default", UID: "rabbit-uid"},
+			Spec: rabbitmqv1beta1.RabbitmqClusterSpec{
+				Replicas: new(int32(2)),
+				Rabbitmq: rabbitmqv1beta1.RabbitmqClusterConfigurationSpec{
+					AdditionalPlugins: []rabbitmqv1beta1.Plugin{"rabbitmq_stream"},
+				},
+				Stream: rabbitmqv1beta1.StreamSpec{
+					PerPodServices: &rabbitmqv1beta1.StreamPerPodServicesSpec{Type: corev1.ServiceTypeLoadBalancer},
+				},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		loadBalancer := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "rabbit-server-0-stream", Namespace: "default"},
+			Spec: corev1.ServiceSpec{
+				Type:  corev1.ServiceTypeLoadBalancer,
+				Ports: []corev1.ServicePort{{Name: "stream", Port: 5552}},
+			},
+			Status: corev1.ServiceStatus{
+				LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{{Hostname: "lb.example.com"}}},
+			},
+		}
+		fakeClient = fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(cluster, loadBalancer).
+			WithStatusSubresource(cluster).
+			Build()
+		reconciler = &RabbitmqClusterReconciler{Client: fakeClient, Scheme: scheme}
+	})
+
+	It("renders a key per Pod and publishes the addresses in the status", func(ctx SpecContext) {
+		Expect(reconciler.reconcileStreamAdvertisedAddresses(ctx, cluster)).To(Succeed())
+
+		configMap := &corev1.ConfigMap{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "rabbit-stream-advertised", Namespace: "default"}, configMap)).To(Succeed())
+		Expect(configMap.Data).To(Equal(map[string]string{
+			"rabbit-server-0.conf": "stream.advertised_host = lb.example.com\nstream.advertised_port = 5552\n",
+			"rabbit-server-1.conf": "",
+		}))
+		Expect(configMap.Annotations).NotTo(HaveKey(streamAdvertisedUpdateAnnotation))
+
+		Expect(cluster.Status.StreamAdvertisedAddresses).To(ConsistOf(
+			rabbitmqv1beta1.StreamAdvertisedAddress{Pod: "rabbit-server-0", Host: "lb.example.com", Port: 5552},
+			rabbitmqv1beta1.StreamAdvertisedAddress{Pod: "rabbit-server-1"},
+		))
+	})
+
+	It("marks running nodes for update when an address changes", func(ctx SpecContext) {
+		Expect(reconciler.reconcileStreamAdvertisedAddresses(ctx, cluster)).To(Succeed())
+
+		service := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "rabbit-server-1-stream", Namespace: "default"},
+			Spec: corev1.ServiceSpec{
+				Type:  corev1.ServiceTypeLoadBalancer,
+				Ports: []corev1.ServicePort{{Name: "stream", Port: 5552}},
+			},
+			Status: corev1.ServiceStatus{
+				LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{{IP: "203.0.113.8"}}},
+			},
+		}
+		Expect(fakeClient.Create(ctx, service)).To(Succeed())
+		Expect(reconciler.reconcileStreamAdvertisedAddresses(ctx, cluster)).To(Succeed())
+
+		configMap := &corev1.ConfigMap{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "rabbit-stream-advertised", Namespace: "default"}, configMap)).To(Succeed())
+		Expect(configMap.Data).To(HaveKeyWithValue("rabbit-server-1.conf", "stream.advertised_host = 203.0.113.8\nstream.advertised_port = 5552\n"))
+		Expect(configMap.Annotations).To(HaveKey(streamAdvertisedUpdateAnnotation))
+	})
+
+	When("per-Pod stream Services are no longer requested", func() {
+		It("deletes the ConfigMap and clears the status", func(ctx SpecContext) {
+			Expect(reconciler.reconcileStreamAdvertisedAddresses(ctx, cluster)).To(Succeed())
+
+			cluster.Spec.Stream.PerPodServices = nil
+			Expect(reconciler.reconcileStreamAdvertisedAddresses(ctx, cluster)).To(Succeed())
+
+			err := fakeClient.Get(ctx, types.NamespacedName{Name: "rabbit-stream-advertised", Namespace: "default"}, &corev1.ConfigMap{})
+			Expect(client.IgnoreNotFound(err)).To(Succeed())
+			Expect(err).To(HaveOccurred())
+			Expect(cluster.Status.StreamAdvertisedAddresses).To(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("setStreamAdvertisedAddressExpression", func() {
+	It("sets the advertised host and ports", func() {
+		Expect(setStreamAdvertisedAddressExpression(rabbitmqv1beta1.StreamAdvertisedAddress{
+			Pod:  "rabbit-server-0",
+			Host: "lb.example.com",
+			Port: 5552,
+		})).To(Equal(`application:set_env(rabbitmq_stream, advertised_host, "lb.example.com"), ` +
+			`application:set_env(rabbitmq_stream, advertised_port, 5552), ` +
+			`application:unset_env(rabbitmq_stream, advertised_tls_port), ok.`))
+	})
+
+	It("unsets the advertised host when it is unknown", func() {
+		Expect(setStreamAdvertisedAddressExpression(rabbitmqv1beta1.StreamAdvertisedAddress{Pod: "rabbit-server-0"})).To(HavePrefix(
+			"application:unset_env(rabbitmq_stream, advertised_host), "))
+	})
+})
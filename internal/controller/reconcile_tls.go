@@ -8,12 +8,21 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
-var errDisableNonTLSConfig = errors.New("TLS must be enabled if disableNonTLSListeners is set to true")
+var (
+	errDisableNonTLSConfig = errors.New("TLS must be enabled if disableNonTLSListeners is set to true")
+	// errCertificateNotReady is returned while cert-manager has not issued the server certificate yet
+	errCertificateNotReady = errors.New("waiting for cert-manager to issue the TLS certificate")
+)
 
 func (r *RabbitmqClusterReconciler) reconcileTLS(ctx context.Context, rabbitmqCluster *rabbitmqv1beta1.RabbitmqCluster) error {
 	// if tls.disableNonTLSListeners set to true and TLS is not enabled, it's a configuration error
@@ -25,18 +34,46 @@ func (r *RabbitmqClusterReconciler) reconcileTLS(ctx context.Context, rabbitmqCl
 		return errDisableNonTLSConfig
 	}
 
-	if rabbitmqCluster.SecretTLSEnabled() {
-		if err := r.checkTLSSecrets(ctx, rabbitmqCluster); err != nil {
-			r.setReconcileSuccess(ctx, rabbitmqCluster, corev1.ConditionFalse, "TLSError", err.Error())
-			return err
+	if err := r.reconcileTLSCertificate(ctx, rabbitmqCluster); err != nil {
+		r.setReconcileSuccess(ctx, rabbitmqCluster, corev1.ConditionFalse, "TLSError", err.Error())
+		return err
+	}
+
+	if rabbitmqCluster.CertManagerEnabled() {
+		// cert-manager creates the Secret once the certificate is issued
+		err := r.APIReader.Get(ctx, types.NamespacedName{Namespace: rabbitmqCluster.Namespace, Name: rabbitmqCluster.TLSSecretName()}, &corev1.Secret{})
+		if k8serrors.IsNotFound(err) {
+			r.setReconcileSuccess(ctx, rabbitmqCluster, corev1.ConditionFalse, "TLSCertificateNotReady", errCertificateNotReady.Error())
+			return errCertificateNotReady
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get TLS secret %s: %w", rabbitmqCluster.TLSSecretName(), err)
 		}
 	}
-	return nil
+
+	if !rabbitmqCluster.SecretTLSEnabled() {
+		return r.setTLSStatus(ctx, rabbitmqCluster, nil)
+	}
+
+	certificate, caCertificate, err := r.checkTLSSecrets(ctx, rabbitmqCluster)
+	if err != nil {
+		r.setReconcileSuccess(ctx, rabbitmqCluster, corev1.ConditionFalse, "TLSError", err.Error())
+		return err
+	}
+	listenerCertificates, err := r.listenerCertificates(ctx, rabbitmqCluster)
+	if err != nil {
+		r.Recorder.Event(rabbitmqCluster, corev1.EventTypeWarning, "TLSError", err.Error())
+		r.setReconcileSuccess(ctx, rabbitmqCluster, corev1.ConditionFalse, "TLSError", err.Error())
+		return err
+	}
+	return r.reconcileTLSCertificateRotation(ctx, rabbitmqCluster, certificate, append([][]byte{caCertificate}, listenerCertificates...)...)
 }
 
-func (r *RabbitmqClusterReconciler) checkTLSSecrets(ctx context.Context, rabbitmqCluster *rabbitmqv1beta1.RabbitmqCluster) error {
+// checkTLSSecrets verifies that the TLS and CA Secrets contain the expected keys,
+// and returns the server certificate and, if mutual TLS is enabled, the CA certificate.
+func (r *RabbitmqClusterReconciler) checkTLSSecrets(ctx context.Context, rabbitmqCluster *rabbitmqv1beta1.RabbitmqCluster) ([]byte, []byte, error) {
 	logger := ctrl.LoggerFrom(ctx)
-	secretName := rabbitmqCluster.Spec.TLS.SecretName
+	secretName := rabbitmqCluster.TLSSecretName()
 	logger.V(1).Info("TLS enabled, looking for secret", "secret", secretName)
 
 	// check if secret exists - we need to use the APIReader because if the Secret doesn't have
@@ -46,16 +83,16 @@ func (r *RabbitmqClusterReconciler) checkTLSSecrets(ctx context.Context, rabbitm
 		r.Recorder.Event(rabbitmqCluster, corev1.EventTypeWarning, "TLSError",
 			fmt.Sprintf("Failed to get TLS secret %s in namespace %s: %v", secretName, rabbitmqCluster.Namespace, err.Error()))
 		logger.Error(err, "Error setting up TLS")
-		return err
+		return nil, nil, err
 	}
 	// check if secret has the right keys
 	_, hasTLSKey := secret.Data["tls.key"]
-	_, hasTLSCert := secret.Data["tls.crt"]
+	certificate, hasTLSCert := secret.Data["tls.crt"]
 	if !hasTLSCert || !hasTLSKey {
 		err := k8serrors.NewBadRequest(fmt.Sprintf("TLS secret %s in namespace %s does not have the fields tls.crt and tls.key", secretName, rabbitmqCluster.Namespace))
 		r.Recorder.Event(rabbitmqCluster, corev1.EventTypeWarning, "TLSError", err.Error())
 		logger.Error(err, "Error setting up TLS")
-		return err
+		return nil, nil, err
 	}
 
 	// Mutual TLS: check if CA certificate is stored in a separate secret
@@ -71,17 +108,70 @@ func (r *RabbitmqClusterReconciler) checkTLSSecrets(ctx context.Context, rabbitm
 				r.Recorder.Event(rabbitmqCluster, corev1.EventTypeWarning, "TLSError",
 					fmt.Sprintf("Failed to get CA certificate secret %v in namespace %v: %v", secretName, rabbitmqCluster.Namespace, err.Error()))
 				logger.Error(err, "Error setting up TLS")
-				return err
+				return nil, nil, err
 			}
 		}
 
 		// Mutual TLS: verify that CA certificate is present in secret
-		if _, hasCaCert := secret.Data["ca.crt"]; !hasCaCert {
+		caCertificate, hasCaCert := secret.Data["ca.crt"]
+		if !hasCaCert {
 			err := k8serrors.NewBadRequest(fmt.Sprintf("TLS secret %s in namespace %s does not have the field ca.crt", rabbitmqCluster.Spec.TLS.CaSecretName, rabbitmqCluster.Namespace))
 			r.Recorder.Event(rabbitmqCluster, corev1.EventTypeWarning, "TLSError", err.Error())
 			logger.Error(err, "Error setting up TLS")
+			return nil, nil, err
+		}
+		return certificate, caCertificate, nil
+	}
+	return certificate, nil, nil
+}
+
+// tlsCACertificate returns the CA certificate clients verify the server certificate of a RabbitmqCluster against,
+// from its CA Secret or else from its TLS Secret, such as Secrets issued by cert-manager, or nil if there is none
+func (r *RabbitmqClusterReconciler) tlsCACertificate(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster) ([]byte, error) {
+	secretName := rmq.TLSSecretName()
+	if rmq.Spec.TLS.CaSecretName != "" {
+		secretName = rmq.Spec.TLS.CaSecretName
+	}
+	secret := &corev1.Secret{}
+	if err := r.APIReader.Get(ctx, types.NamespacedName{Name: secretName, Namespace: rmq.Namespace}, secret); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	return secret.Data["ca.crt"], nil
+}
+
+// reconcileTLSCertificate creates or updates the cert-manager Certificate issuing the server certificate
+// when spec.tls.certManager is set, and deletes it otherwise.
+// Certificates are ignored if the cert-manager CRDs are not installed and spec.tls.certManager is not set.
+func (r *RabbitmqClusterReconciler) reconcileTLSCertificate(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster) error {
+	builder := (&resource.RabbitmqResourceBuilder{Instance: rmq, Scheme: r.Scheme}).TLSCertificate()
+	certificate, err := builder.Build()
+	if err != nil {
+		return err
+	}
+
+	if !rmq.CertManagerEnabled() {
+		// only issue a delete request if the Certificate exists in the cache
+		existing := &metav1.PartialObjectMetadata{}
+		existing.SetGroupVersionKind(resource.CertificateGVK)
+		if err := r.Get(ctx, client.ObjectKeyFromObject(certificate), existing); err != nil {
+			if client.IgnoreNotFound(err) == nil || meta.IsNoMatchError(err) {
+				return nil
+			}
 			return err
 		}
+		return client.IgnoreNotFound(r.Delete(ctx, existing))
+	}
+
+	operationResult, err := controllerutil.CreateOrUpdate(ctx, r.Client, certificate, func() error {
+		return builder.Update(certificate)
+	})
+	if err != nil {
+		r.Recorder.Event(rmq, corev1.EventTypeWarning, "TLSError",
+			fmt.Sprintf("Failed to create or update Certificate %s in namespace %s: %v", certificate.GetName(), rmq.Namespace, err.Error()))
+		return fmt.Errorf("failed to reconcile cert-manager Certificate: %w", err)
+	}
+	if operationResult != controllerutil.OperationResultNone {
+		ctrl.LoggerFrom(ctx).Info("reconciled cert-manager Certificate", "certificate", certificate.GetName(), "operationResult", operationResult)
 	}
 	return nil
 }
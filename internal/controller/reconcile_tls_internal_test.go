@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+var _ = Describe("reconcileTLS", func() {
+	var (
+		cluster    *rabbitmqv1beta1.RabbitmqCluster
+		objects    []client.Object
+		deleted    []string
+		reconciler *RabbitmqClusterReconciler
+	)
+
+	BeforeEach(func() {
+		cluster = &rabbitmqv1beta1.RabbitmqCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "rabbit", Namespace: "default"},
+			Spec:       rabbitmqv1beta1.RabbitmqClusterSpec{Replicas: new(int32(1))},
+		}
+		objects = nil
+		deleted = nil
+	})
+
+	JustBeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		Expect(rabbitmqv1beta1.AddToScheme(scheme)).To(Succeed())
+		restMapper := meta.NewDefaultRESTMapper(nil)
+		restMapper.Add(corev1.SchemeGroupVersion.WithKind("Secret"), meta.RESTScopeNamespace)
+		restMapper.Add(rabbitmqv1beta1.GroupVersion.WithKind("RabbitmqCluster"), meta.RESTScopeNamespace)
+		restMapper.Add(resource.CertificateGVK, meta.RESTScopeNamespace)
+		fakeClient := fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithRESTMapper(restMapper).
+			WithObjects(append(objects, cluster)...).
+			WithStatusSubresource(cluster).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+					if _, ok := obj.(*corev1.Secret); ok {
+						return k8serrors.NewForbidden(corev1.Resource("secrets"), key.Name, nil)
+					}
+					return c.Get(ctx, key, obj, opts...)
+				},
+				Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+					deleted = append(deleted, obj.GetObjectKind().GroupVersionKind().Kind)
+					return c.Delete(ctx, obj, opts...)
+				},
+			}).
+			Build()
+		reconciler = &RabbitmqClusterReconciler{
+			Client:    fakeClient,
+			APIReader: fakeClient,
+			Scheme:    scheme,
+			Recorder:  record.NewFakeRecorder(10),
+		}
+	})
+
+	It("does not send a delete request for a Certificate which does not exist", func(ctx SpecContext) {
+		Expect(reconciler.reconcileTLS(ctx, cluster)).To(Succeed())
+		Expect(deleted).To(BeEmpty())
+	})
+
+	When("cert-manager is no longer used", func() {
+		BeforeEach(func() {
+			certificate := &unstructured.Unstructured{}
+			certificate.SetGroupVersionKind(resource.CertificateGVK)
+			certificate.SetName(cluster.ChildResourceName(resource.TLSCertificateSuffix))
+			certificate.SetNamespace(cluster.Namespace)
+			objects = append(objects, certificate)
+		})
+
+		It("deletes the Certificate", func(ctx SpecContext) {
+			Expect(reconciler.reconcileTLS(ctx, cluster)).To(Succeed())
+			Expect(deleted).To(Equal([]string{"Certificate"}))
+		})
+	})
+
+	When("the Secret issued by cert-manager cannot be read", func() {
+		BeforeEach(func() {
+			cluster.Spec.TLS.CertManager = &rabbitmqv1beta1.CertManagerTLSSpec{
+				IssuerRef: rabbitmqv1beta1.CertManagerIssuerReference{Name: "ca-issuer", Kind: "Issuer"},
+			}
+		})
+
+		It("returns the error", func(ctx SpecContext) {
+			Expect(reconciler.reconcileTLS(ctx, cluster)).To(MatchError(ContainSubstring("forbidden")))
+		})
+	})
+})
@@ -0,0 +1,219 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	tlsCertificatesUpdateAnnotation = "rabbitmq.com/tlsCertificatesUpdatedAt"
+	// TLS Secrets which are not labelled with app.kubernetes.io/part-of=rabbitmq are not watched,
+	// so their rotation and the expiry of their certificate are only noticed periodically
+	tlsCertificateRecheckInterval = 10 * time.Minute
+	tlsMountPath                  = "/etc/rabbitmq-tls"
+)
+
+// reconcileTLSCertificateRotation publishes the expiry date of the server certificate in the status, and marks
+// the cluster for reloading the certificates when the content of the TLS or CA Secrets changed, including the Secrets
+// of spec.tls.listeners, which are passed as otherCertificates in the order of mountedCertificateFiles.
+// Kubelet updates the projected TLS volumes in the Pods, but RabbitMQ caches certificates read from disk.
+// Clearing the cache makes new connections use the rotated certificates without restarting the nodes.
+func (r *RabbitmqClusterReconciler) reconcileTLSCertificateRotation(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster, certificate []byte, otherCertificates ...[]byte) error {
+	logger := ctrl.LoggerFrom(ctx)
+	tlsStatus := &rabbitmqv1beta1.TLSStatus{
+		CertificatesHash: certificatesHash(append([][]byte{certificate}, otherCertificates...)...),
+	}
+
+	notAfter, err := certificateNotAfter(certificate)
+	if err != nil {
+		r.Recorder.Event(rmq, corev1.EventTypeWarning, "TLSError",
+			fmt.Sprintf("Failed to parse certificate in TLS secret %s: %v", rmq.TLSSecretName(), err))
+		logger.Error(err, "Failed to parse server certificate")
+	} else {
+		tlsStatus.NotAfter = &metav1.Time{Time: notAfter}
+	}
+
+	if previous := rmq.Status.TLS; previous != nil && previous.CertificatesHash != "" && previous.CertificatesHash != tlsStatus.CertificatesHash {
+		logger.Info("TLS certificates rotated; marking cluster for certificate reload")
+		if rmq.Annotations == nil {
+			rmq.Annotations = make(map[string]string)
+		}
+		rmq.Annotations[tlsCertificatesUpdateAnnotation] = time.Now().Format(time.RFC3339)
+		if err := r.Update(ctx, rmq); err != nil {
+			return err
+		}
+	}
+
+	return r.setTLSStatus(ctx, rmq, tlsStatus)
+}
+
+// setTLSStatus sets status.tls and the TLSCertificateExpiring condition. A nil tlsStatus removes both.
+func (r *RabbitmqClusterReconciler) setTLSStatus(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster, tlsStatus *rabbitmqv1beta1.TLSStatus) error {
+	patch := client.MergeFrom(rmq.DeepCopy())
+	oldConditions := rmq.Status.DeepCopy().Conditions
+	var notAfter *metav1.Time
+	if tlsStatus != nil {
+		notAfter = tlsStatus.NotAfter
+	}
+	rmq.Status.SetTLSCertificateExpiringCondition(notAfter)
+
+	if reflect.DeepEqual(rmq.Status.TLS, tlsStatus) && reflect.DeepEqual(rmq.Status.Conditions, oldConditions) {
+		return nil
+	}
+	rmq.Status.TLS = tlsStatus
+	return r.Status().Patch(ctx, rmq, patch)
+}
+
+// certificatesHash returns the hash of the concatenated certificates, like 'cat <files> | sha256sum' in the Pods
+func certificatesHash(certificates ...[]byte) string {
+	hash := sha256.New()
+	for _, certificate := range certificates {
+		hash.Write(certificate)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// mountedCertificateFiles returns the certificate files in the Pods which are reloaded after a rotation
+func mountedCertificateFiles(rmq *rabbitmqv1beta1.RabbitmqCluster) []string {
+	files := []string{tlsMountPath + "/tls.crt"}
+	if rmq.MutualTLSEnabled() {
+		files = append(files, tlsMountPath+"/ca.crt")
+	}
+	for _, listener := range resource.ListenerCertificates(rmq) {
+		files = append(files, listener.MountPath+"tls.crt")
+		if listener.Spec.CaSecretName != "" {
+			files = append(files, listener.MountPath+"ca.crt")
+		}
+	}
+	return files
+}
+
+// listenerCertificates reads the certificates of spec.tls.listeners in the order of mountedCertificateFiles
+func (r *RabbitmqClusterReconciler) listenerCertificates(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster) ([][]byte, error) {
+	var certificates [][]byte
+	read := func(secretName, key string) error {
+		secret := &corev1.Secret{}
+		if err := r.APIReader.Get(ctx, types.NamespacedName{Namespace: rmq.Namespace, Name: secretName}, secret); err != nil {
+			return fmt.Errorf("failed to get listener TLS secret %s: %w", secretName, err)
+		}
+		certificate, ok := secret.Data[key]
+		if !ok {
+			return fmt.Errorf("listener TLS secret %s does not have the field %s", secretName, key)
+		}
+		certificates = append(certificates, certificate)
+		return nil
+	}
+	for _, listener := range resource.ListenerCertificates(rmq) {
+		if err := read(listener.Spec.SecretName, "tls.crt"); err != nil {
+			return nil, err
+		}
+		if listener.Spec.CaSecretName != "" {
+			if err := read(listener.Spec.CaSecretName, "ca.crt"); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return certificates, nil
+}
+
+// certificateNotAfter returns the expiry date of the first certificate in the PEM encoded chain, i.e. the leaf certificate
+func certificateNotAfter(pemCertificates []byte) (time.Time, error) {
+	block, _ := pem.Decode(pemCertificates)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return time.Time{}, errors.New("no PEM encoded certificate found")
+	}
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return certificate.NotAfter, nil
+}
+
+// runClearPEMCacheCommand clears the PEM cache of every node once kubelet has updated the TLS volume of its Pod.
+// The update of projected volumes can take a minute or more, so the request is requeued until all Pods
+// mount the certificates observed by the operator.
+func (r *RabbitmqClusterReconciler) runClearPEMCacheCommand(ctx context.Context, rmq *rabbitmqv1beta1.RabbitmqCluster) (time.Duration, error) {
+	logger := ctrl.LoggerFrom(ctx)
+	if rmq.Status.TLS == nil {
+		return 0, r.deleteAnnotation(ctx, rmq, tlsCertificatesUpdateAnnotation)
+	}
+
+	hashCmd := fmt.Sprintf("cat %s | sha256sum", strings.Join(mountedCertificateFiles(rmq), " "))
+	clearCmd := "rabbitmqctl eval 'ssl:clear_pem_cache().'"
+
+	for i := int32(0); i < *rmq.Spec.Replicas; i++ {
+		podName := fmt.Sprintf("%s-%d", rmq.ChildResourceName("server"), i)
+		stdout, stderr, err := r.exec(rmq.Namespace, podName, "rabbitmq", "sh", "-c", hashCmd)
+		if err != nil {
+			msg := "failed to read TLS certificates on pod"
+			logger.Error(err, msg, "pod", podName, "command", hashCmd, "stdout", stdout, "stderr", stderr)
+			return 0, fmt.Errorf("%s %s: %w", msg, podName, err)
+		}
+		if !strings.HasPrefix(stdout, rmq.Status.TLS.CertificatesHash) {
+			logger.V(1).Info("rotated TLS certificates not mounted yet; requeuing request to reload certificates", "pod", podName)
+			return 15 * time.Second, nil
+		}
+	}
+
+	for i := int32(0); i < *rmq.Spec.Replicas; i++ {
+		podName := fmt.Sprintf("%s-%d", rmq.ChildResourceName("server"), i)
+		stdout, stderr, err := r.exec(rmq.Namespace, podName, "rabbitmq", "sh", "-c", clearCmd)
+		if err != nil {
+			msg := "failed to clear PEM cache on pod"
+			logger.Error(err, msg, "pod", podName, "command", clearCmd, "stdout", stdout, "stderr", stderr)
+			r.Recorder.Event(rmq, corev1.EventTypeWarning, "FailedReconcile", fmt.Sprintf("%s %s", msg, podName))
+			return 0, fmt.Errorf("%s %s: %w", msg, podName, err)
+		}
+	}
+	logger.Info("successfully reloaded TLS certificates")
+	r.Recorder.Event(rmq, corev1.EventTypeNormal, "TLSCertificatesReloaded", "Rotated TLS certificates loaded by all nodes")
+	return 0, r.deleteAnnotation(ctx, rmq, tlsCertificatesUpdateAnnotation)
+}
+
+// rabbitmqClustersForTLSSecret maps a Secret to the RabbitmqClusters in its Namespace using it as TLS or CA Secret.
+func (r *RabbitmqClusterReconciler) rabbitmqClustersForTLSSecret(ctx context.Context, secret client.Object) []reconcile.Request {
+	clusters := &rabbitmqv1beta1.RabbitmqClusterList{}
+	if err := r.List(ctx, clusters, client.InNamespace(secret.GetNamespace())); err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "failed to list RabbitmqClusters for TLS secret", "secret", secret.GetName())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, cluster := range clusters.Items {
+		if !cluster.SecretTLSEnabled() {
+			continue
+		}
+		if cluster.TLSSecretName() == secret.GetName() || cluster.Spec.TLS.CaSecretName == secret.GetName() || usedByListener(&cluster, secret.GetName()) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace},
+			})
+		}
+	}
+	return requests
+}
+
+// usedByListener returns true if a group of listeners in spec.tls.listeners uses the Secret
+func usedByListener(cluster *rabbitmqv1beta1.RabbitmqCluster, secretName string) bool {
+	for _, listener := range resource.ListenerCertificates(cluster) {
+		if listener.Spec.SecretName == secretName || listener.Spec.CaSecretName == secretName {
+			return true
+		}
+	}
+	return false
+}
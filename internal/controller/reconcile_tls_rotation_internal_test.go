@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("TLS certificate rotation", func() {
+	var (
+		cluster    *rabbitmqv1beta1.RabbitmqCluster
+		fakeClient client.Client
+		executor   *certificateHashPodExecutor
+		reconciler *RabbitmqClusterReconciler
+	)
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		Expect(rabbitmqv1beta1.AddToScheme(scheme)).To(Succeed())
+		cluster = &rabbitmqv1beta1.RabbitmqCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "rabbit", Namespace: "default"},
+			Spec: rabbitmqv1beta1.RabbitmqClusterSpec{
+				Replicas: new(int32(2)),
+				TLS:      rabbitmqv1beta1.TLSSpec{SecretName: "tls-secret"},
+			},
+		}
+		fakeClient = fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(cluster).
+			WithStatusSubresource(cluster).
+			Build()
+		executor = &certificateHashPodExecutor{}
+		reconciler = &RabbitmqClusterReconciler{
+			Client:      fakeClient,
+			APIReader:   fakeClient,
+			Scheme:      scheme,
+			Recorder:    record.NewFakeRecorder(10),
+			PodExecutor: executor,
+		}
+	})
+
+	It("publishes the expiry date and the expiring condition", func(ctx SpecContext) {
+		notAfter := time.Now().Add(7 * 24 * time.Hour).Truncate(time.Second)
+		Expect(reconciler.reconcileTLSCertificateRotation(ctx, cluster, selfSignedCertificate(notAfter), nil)).To(Succeed())
+
+		Expect(cluster.Status.TLS.NotAfter.Time).To(BeTemporally("==", notAfter))
+		Expect(cluster.Status.TLS.CertificatesHash).NotTo(BeEmpty())
+		Expect(cluster.Status.Conditions).To(ContainElement(And(
+			HaveField("Type", status.TLSCertificateExpiring),
+			HaveField("Status", corev1.ConditionTrue),
+		)))
+		Expect(cluster.Annotations).NotTo(HaveKey(tlsCertificatesUpdateAnnotation))
+	})
+
+	It("clears the PEM cache once all Pods mount the rotated certificate", func(ctx SpecContext) {
+		Expect(reconciler.reconcileTLSCertificateRotation(ctx, cluster, selfSignedCertificate(time.Now().Add(time.Hour)), nil)).To(Succeed())
+		rotated := selfSignedCertificate(time.Now().Add(90 * 24 * time.Hour))
+		Expect(reconciler.reconcileTLSCertificateRotation(ctx, cluster, rotated, nil)).To(Succeed())
+		Expect(cluster.Annotations).To(HaveKey(tlsCertificatesUpdateAnnotation))
+
+		By("waiting for kubelet to update the volume")
+		executor.mountedHash = "outdated"
+		requeueAfter, err := reconciler.runClearPEMCacheCommand(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requeueAfter).To(BeNumerically(">", 0))
+		Expect(executor.commands).NotTo(ContainElement(ContainSubstring("clear_pem_cache")))
+
+		By("clearing the cache on every node")
+		executor.mountedHash = certificatesHash(rotated, nil)
+		requeueAfter, err = reconciler.runClearPEMCacheCommand(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requeueAfter).To(BeZero())
+		Expect(executor.commands).To(HaveEach(Or(
+			Equal("cat /etc/rabbitmq-tls/tls.crt | sha256sum"),
+			Equal("rabbitmqctl eval 'ssl:clear_pem_cache().'"),
+		)))
+		Expect(executor.pods).To(ContainElements("rabbit-server-0", "rabbit-server-1"))
+		Expect(cluster.Annotations).NotTo(HaveKey(tlsCertificatesUpdateAnnotation))
+	})
+
+	It("reloads the certificates of listener groups when they rotate", func(ctx SpecContext) {
+		cluster.Spec.TLS.Listeners = &rabbitmqv1beta1.TLSListenersSpec{
+			Management: &rabbitmqv1beta1.ListenerTLSSpec{SecretName: "management-tls", CaSecretName: "management-ca"},
+		}
+		Expect(fakeClient.Update(ctx, cluster)).To(Succeed())
+		Expect(fakeClient.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "management-tls", Namespace: "default"},
+			Data:       map[string][]byte{"tls.crt": []byte("management certificate"), "tls.key": []byte("key")},
+		})).To(Succeed())
+		Expect(fakeClient.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "management-ca", Namespace: "default"},
+			Data:       map[string][]byte{"ca.crt": []byte("management CA")},
+		})).To(Succeed())
+
+		Expect(reconciler.listenerCertificates(ctx, cluster)).To(Equal([][]byte{[]byte("management certificate"), []byte("management CA")}))
+		Expect(mountedCertificateFiles(cluster)).To(Equal([]string{
+			"/etc/rabbitmq-tls/tls.crt",
+			"/etc/rabbitmq-tls-management/tls.crt",
+			"/etc/rabbitmq-tls-management/ca.crt",
+		}))
+		Expect(reconciler.rabbitmqClustersForTLSSecret(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "management-ca", Namespace: "default"},
+		})).To(HaveLen(1))
+	})
+
+	It("removes the TLS status when TLS is disabled", func(ctx SpecContext) {
+		Expect(reconciler.reconcileTLSCertificateRotation(ctx, cluster, selfSignedCertificate(time.Now().Add(time.Hour)), nil)).To(Succeed())
+		Expect(reconciler.setTLSStatus(ctx, cluster, nil)).To(Succeed())
+		Expect(cluster.Status.TLS).To(BeNil())
+		Expect(cluster.Status.Conditions).NotTo(ContainElement(HaveField("Type", status.TLSCertificateExpiring)))
+	})
+})
+
+type certificateHashPodExecutor struct {
+	mountedHash string
+	commands    []string
+	pods        []string
+}
+
+func (e *certificateHashPodExecutor) Exec(_ *kubernetes.Clientset, _ *rest.Config, _, podName, _ string, command ...string) (string, string, error) {
+	cmd := command[len(command)-1]
+	e.commands = append(e.commands, cmd)
+	e.pods = append(e.pods, podName)
+	if strings.HasSuffix(cmd, "sha256sum") {
+		return e.mountedHash + "  -\n", "", nil
+	}
+	return "", "", nil
+}
+
+func selfSignedCertificate(notAfter time.Time) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "rabbit.default.svc"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
@@ -13,7 +13,10 @@ package controllers_test
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"path/filepath"
+	"slices"
+	"sync"
 	"testing"
 
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
@@ -169,26 +172,52 @@ func (f *fakePodExecutor) ResetExecutedCommands() { f.executedCommands = []comma
 
 type fakeRabbitmqClientFactory struct {
 	client *fakeRabbitmqClient
+
+	mu       sync.Mutex
+	requests []string
 }
 
 func (f *fakeRabbitmqClientFactory) GetClientForPod(ctx context.Context, k8sClient runtimeClient.Reader, rmq *rabbitmqv1beta1.RabbitmqCluster, podName string) (rabbitmqclient.RabbitmqClient, error) {
 	if f.client == nil {
-		return &fakeRabbitmqClient{}, nil
+		return &fakeRabbitmqClient{factory: f}, nil
 	}
 	return f.client, nil
 }
 
 func (f *fakeRabbitmqClientFactory) GetClientForService(ctx context.Context, k8sClient runtimeClient.Reader, rmq *rabbitmqv1beta1.RabbitmqCluster) (rabbitmqclient.RabbitmqClient, error) {
 	if f.client == nil {
-		return &fakeRabbitmqClient{}, nil
+		return &fakeRabbitmqClient{factory: f}, nil
 	}
 	return f.client, nil
 }
 
+// Requests returns the management API requests changing RabbitMQ made through the default clients
+func (f *fakeRabbitmqClientFactory) Requests() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.requests)
+}
+
+func (f *fakeRabbitmqClientFactory) ResetRequests() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = nil
+}
+
 type fakeRabbitmqClient struct {
 	overview           *rabbithole.Overview
 	deprecatedFeatures []rabbithole.DeprecatedFeature
 	err                error
+	factory            *fakeRabbitmqClientFactory
+}
+
+func (f *fakeRabbitmqClient) record(request string) {
+	if f.factory == nil {
+		return
+	}
+	f.factory.mu.Lock()
+	defer f.factory.mu.Unlock()
+	f.factory.requests = append(f.factory.requests, request)
 }
 
 func (f *fakeRabbitmqClient) Overview() (*rabbithole.Overview, error) {
@@ -208,13 +237,99 @@ func (f *fakeRabbitmqClient) ListDeprecatedFeaturesUsed() ([]rabbithole.Deprecat
 	return f.deprecatedFeatures, f.err
 }
 
+func (f *fakeRabbitmqClient) GetUser(username string) (*rabbithole.UserInfo, error) {
+	return &rabbithole.UserInfo{Name: username, Tags: rabbithole.UserTags{"administrator"}}, f.err
+}
+
+func (f *fakeRabbitmqClient) PutUser(username string, info rabbithole.UserSettings) (*http.Response, error) {
+	return nil, f.err
+}
+
+func (f *fakeRabbitmqClient) DeleteUser(username string) (*http.Response, error) {
+	return nil, f.err
+}
+
+func (f *fakeRabbitmqClient) ListPermissionsOf(username string) ([]rabbithole.PermissionInfo, error) {
+	return []rabbithole.PermissionInfo{}, f.err
+}
+
+func (f *fakeRabbitmqClient) UpdatePermissionsIn(vhost, username string, permissions rabbithole.Permissions) (*http.Response, error) {
+	return nil, f.err
+}
+
+func (f *fakeRabbitmqClient) ListTopicPermissionsOf(username string) ([]rabbithole.TopicPermissionInfo, error) {
+	return []rabbithole.TopicPermissionInfo{}, f.err
+}
+
+func (f *fakeRabbitmqClient) UpdateTopicPermissionsIn(vhost, username string, topicPermissions rabbithole.TopicPermissions) (*http.Response, error) {
+	return nil, f.err
+}
+
+func (f *fakeRabbitmqClient) UploadDefinitions(definitions *rabbithole.ExportedDefinitions) (*http.Response, error) {
+	return nil, f.err
+}
+
+func (f *fakeRabbitmqClient) ListDefinitions() (*rabbithole.ExportedDefinitions, error) {
+	return &rabbithole.ExportedDefinitions{}, f.err
+}
+
+func (f *fakeRabbitmqClient) GetVhost(vhost string) (*rabbithole.VhostInfo, error) {
+	return &rabbithole.VhostInfo{Name: vhost}, f.err
+}
+
+func (f *fakeRabbitmqClient) PutVhost(vhost string, settings rabbithole.VhostSettings) (*http.Response, error) {
+	return nil, f.err
+}
+
+func (f *fakeRabbitmqClient) GetGlobalParameter(name string) (*rabbithole.GlobalRuntimeParameter, error) {
+	return &rabbithole.GlobalRuntimeParameter{Name: name}, f.err
+}
+
+func (f *fakeRabbitmqClient) PutGlobalParameter(name string, value any) (*http.Response, error) {
+	return nil, f.err
+}
+
+func (f *fakeRabbitmqClient) DeleteGlobalParameter(name string) (*http.Response, error) {
+	return nil, f.err
+}
+
+func (f *fakeRabbitmqClient) GetRuntimeParameter(component, vhost, name string) (*rabbithole.RuntimeParameter, error) {
+	return &rabbithole.RuntimeParameter{Component: component, Vhost: vhost, Name: name}, f.err
+}
+
+func (f *fakeRabbitmqClient) PutRuntimeParameter(component, vhost, name string, value any) (*http.Response, error) {
+	return nil, f.err
+}
+
+func (f *fakeRabbitmqClient) DeleteRuntimeParameter(component, vhost, name string) (*http.Response, error) {
+	return nil, f.err
+}
+
 func (f *fakeRabbitmqClient) HealthCheckNodeIsQuorumCritical() (rabbithole.HealthCheckStatus, error) {
 	// Not used in reconcile_cli_test, mock realistically
 	res := rabbithole.HealthCheckStatus{Status: "ok"}
 	return res, nil
 }
 
+func (f *fakeRabbitmqClient) ListFeatureFlags() ([]rabbithole.FeatureFlag, error) {
+	return []rabbithole.FeatureFlag{
+		{Name: "khepri_db", State: rabbithole.StateDisabled, Stability: rabbithole.StabilityStable},
+		{Name: "rabbitmq_4.0.0", State: rabbithole.StateEnabled, Stability: rabbithole.StabilityStable},
+	}, f.err
+}
+
+func (f *fakeRabbitmqClient) EnableFeatureFlag(featureFlagName string) (*http.Response, error) {
+	f.record(fmt.Sprintf("PUT /api/feature-flags/%s/enable", featureFlagName))
+	return nil, f.err
+}
+
+func (f *fakeRabbitmqClient) RebalanceQueues() (*http.Response, error) {
+	f.record("POST /api/rebalance/queues")
+	return nil, f.err
+}
+
 var _ = AfterEach(func() {
 	fakeExecutor.ResetExecutedCommands()
 	fakeRabbitmqFactory.client = nil
+	fakeRabbitmqFactory.ResetRequests()
 })
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"context"
+	"time"
 
 	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
 	appsv1 "k8s.io/api/apps/v1"
@@ -72,3 +73,14 @@ func (r *RabbitmqClusterReconciler) configMap(ctx context.Context, rmq *rabbitmq
 	}
 	return configMap, nil
 }
+
+// earliest returns the shortest of the non-zero durations, or 0 if all durations are 0
+func earliest(durations ...time.Duration) time.Duration {
+	var result time.Duration
+	for _, d := range durations {
+		if d > 0 && (result == 0 || d < result) {
+			result = d
+		}
+	}
+	return result
+}
@@ -0,0 +1,202 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+//
+
+// Package cron parses schedules in the standard five field cron format.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron schedule. Times are evaluated in UTC.
+type Schedule struct {
+	minute, hour, dayOfMonth, month, dayOfWeek uint64
+	// As in crontab(5), when both days of month and days of week are restricted, a day matches if either matches
+	dayOfMonthRestricted, dayOfWeekRestricted bool
+}
+
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField     = field{name: "minute", min: 0, max: 59}
+	hourField       = field{name: "hour", min: 0, max: 23}
+	dayOfMonthField = field{name: "day of month", min: 1, max: 31}
+	monthField      = field{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 7 is accepted for Sunday and folded onto 0
+	dayOfWeekField = field{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Schedules which cannot be satisfied, such as "0 0 30 2 *", are rejected by Parse
+const searchLimit = 5 * 366 * 24 * time.Hour
+
+// Parse parses a schedule with the fields minute, hour, day of month, month and day of week,
+// or one of the descriptors @yearly, @annually, @monthly, @weekly, @daily, @midnight and @hourly.
+// Fields can be "*", values, ranges "a-b" and lists "a,b", optionally with steps "*/n" or "a-b/n".
+// Months and days of week can also be given by their three letter English names.
+func Parse(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if expanded, ok := descriptors[strings.ToLower(spec)]; ok {
+		spec = expanded
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields in cron schedule %q, found %d", spec, len(fields))
+	}
+
+	schedule := &Schedule{
+		dayOfMonthRestricted: fields[2] != "*" && fields[2] != "?",
+		dayOfWeekRestricted:  fields[4] != "*" && fields[4] != "?",
+	}
+	var err error
+	if schedule.minute, err = minuteField.parse(fields[0]); err != nil {
+		return nil, err
+	}
+	if schedule.hour, err = hourField.parse(fields[1]); err != nil {
+		return nil, err
+	}
+	if schedule.dayOfMonth, err = dayOfMonthField.parse(fields[2]); err != nil {
+		return nil, err
+	}
+	if schedule.month, err = monthField.parse(fields[3]); err != nil {
+		return nil, err
+	}
+	if schedule.dayOfWeek, err = dayOfWeekField.parse(fields[4]); err != nil {
+		return nil, err
+	}
+	if schedule.dayOfWeek&(1<<7) != 0 {
+		schedule.dayOfWeek |= 1
+	}
+
+	if schedule.Next(time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)).IsZero() {
+		return nil, fmt.Errorf("cron schedule %q never matches", spec)
+	}
+	return schedule, nil
+}
+
+// parse returns the values matched by a field as a bit set
+func (f field) parse(expression string) (uint64, error) {
+	var bits uint64
+	for item := range strings.SplitSeq(expression, ",") {
+		rangeExpression, stepExpression, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepExpression)
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step %q in %s field", stepExpression, f.name)
+			}
+		}
+
+		var low, high int
+		switch {
+		case rangeExpression == "*" || rangeExpression == "?":
+			low, high = f.min, f.max
+		case strings.Contains(rangeExpression, "-"):
+			lowExpression, highExpression, _ := strings.Cut(rangeExpression, "-")
+			var err error
+			if low, err = f.value(lowExpression); err != nil {
+				return 0, err
+			}
+			if high, err = f.value(highExpression); err != nil {
+				return 0, err
+			}
+			if low > high {
+				return 0, fmt.Errorf("invalid range %q in %s field", rangeExpression, f.name)
+			}
+		default:
+			var err error
+			if low, err = f.value(rangeExpression); err != nil {
+				return 0, err
+			}
+			high = low
+			if hasStep {
+				high = f.max
+			}
+		}
+
+		for v := low; v <= high; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func (f field) value(expression string) (int, error) {
+	if v, ok := f.names[strings.ToLower(expression)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(expression)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid value %q in %s field, must be between %d and %d", expression, f.name, f.min, f.max)
+	}
+	return v, nil
+}
+
+// Next returns the first time matching the schedule strictly after t, in UTC.
+// It returns the zero time if the schedule does not match within the next five years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(searchLimit)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dayOfMonth := s.dayOfMonth&(1<<uint(t.Day())) != 0
+	dayOfWeek := s.dayOfWeek&(1<<uint(t.Weekday())) != 0
+	if s.dayOfMonthRestricted && s.dayOfWeekRestricted {
+		return dayOfMonth || dayOfWeek
+	}
+	return dayOfMonth && dayOfWeek
+}
+
+// WindowStart returns the start of the window of length d opened by the schedule which t falls in,
+// or the zero time if t is outside of all windows.
+func (s *Schedule) WindowStart(t time.Time, d time.Duration) time.Time {
+	start := s.Next(t.Add(-d))
+	if start.IsZero() || start.After(t) {
+		return time.Time{}
+	}
+	return start
+}
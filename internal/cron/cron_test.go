@@ -0,0 +1,73 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+//
+
+package cron_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rabbitmq/cluster-operator/v2/internal/cron"
+)
+
+var _ = Describe("Schedule", func() {
+	// a Wednesday
+	start := time.Date(2026, time.January, 14, 10, 30, 15, 0, time.UTC)
+
+	DescribeTable("Next",
+		func(spec string, expected time.Time) {
+			schedule, err := cron.Parse(spec)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(schedule.Next(start)).To(Equal(expected))
+		},
+		Entry("every minute", "* * * * *", time.Date(2026, time.January, 14, 10, 31, 0, 0, time.UTC)),
+		Entry("steps", "*/20 * * * *", time.Date(2026, time.January, 14, 10, 40, 0, 0, time.UTC)),
+		Entry("daily", "@daily", time.Date(2026, time.January, 15, 0, 0, 0, 0, time.UTC)),
+		Entry("lists and ranges", "15 2,9-11 * * *", time.Date(2026, time.January, 14, 11, 15, 0, 0, time.UTC)),
+		Entry("day of week name", "0 3 * * sun", time.Date(2026, time.January, 18, 3, 0, 0, 0, time.UTC)),
+		Entry("day of week 7 is Sunday", "0 3 * * 7", time.Date(2026, time.January, 18, 3, 0, 0, 0, time.UTC)),
+		Entry("month name", "0 0 1 mar *", time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)),
+		Entry("day of month or day of week", "0 0 20 * 5", time.Date(2026, time.January, 16, 0, 0, 0, 0, time.UTC)),
+		Entry("leap day", "0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)),
+	)
+
+	It("evaluates schedules in UTC", func() {
+		schedule, err := cron.Parse("0 12 * * *")
+		Expect(err).NotTo(HaveOccurred())
+		location := time.FixedZone("UTC+2", 2*60*60)
+		Expect(schedule.Next(time.Date(2026, time.January, 14, 15, 0, 0, 0, location))).To(Equal(time.Date(2026, time.January, 15, 12, 0, 0, 0, time.UTC)))
+	})
+
+	DescribeTable("WindowStart",
+		func(spec string, duration time.Duration, expected time.Time) {
+			schedule, err := cron.Parse(spec)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(schedule.WindowStart(start, duration)).To(Equal(expected))
+		},
+		Entry("inside a window", "0 10 * * *", time.Hour, time.Date(2026, time.January, 14, 10, 0, 0, 0, time.UTC)),
+		Entry("after a window", "0 10 * * *", 30*time.Minute, time.Time{}),
+		Entry("before a window", "0 11 * * *", 4*time.Hour, time.Time{}),
+		Entry("window opened the day before", "0 22 * * 2", 14*time.Hour, time.Date(2026, time.January, 13, 22, 0, 0, 0, time.UTC)),
+		Entry("window opening at the same minute", "30 10 * * *", time.Minute, time.Date(2026, time.January, 14, 10, 30, 0, 0, time.UTC)),
+	)
+
+	DescribeTable("invalid schedules",
+		func(spec, message string) {
+			_, err := cron.Parse(spec)
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("too few fields", "* * * *", "expected 5 fields"),
+		Entry("out of range", "60 * * * *", `invalid value "60" in minute field`),
+		Entry("unknown name", "0 0 * * funday", `invalid value "funday" in day of week field`),
+		Entry("reversed range", "0 10-2 * * *", `invalid range "10-2" in hour field`),
+		Entry("invalid step", "*/0 * * * *", `invalid step "0" in minute field`),
+		Entry("impossible date", "0 0 30 2 *", "never matches"),
+	)
+})
@@ -0,0 +1,42 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+//
+
+package erlang
+
+import "fmt"
+
+// ParseConfig parses an Erlang configuration file, such as advanced.config. It consists of a list followed by a dot,
+// whose elements are {Application, [{Parameter, Value}]} tuples or names of other configuration files.
+func ParseConfig(text string) (Term, error) {
+	config, err := Parse(text)
+	if err != nil {
+		return Term{}, err
+	}
+	if config.Kind != List || config.Tail != nil {
+		return Term{}, &SyntaxError{Line: config.Line, Msg: fmt.Sprintf("expected a list of {Application, [{Parameter, Value}]} tuples, got %s", config.Kind)}
+	}
+	for _, application := range config.Elements {
+		if application.Kind == String {
+			continue
+		}
+		if application.Kind != Tuple || len(application.Elements) != 2 || application.Elements[0].Kind != Atom {
+			return Term{}, &SyntaxError{Line: application.Line, Msg: "expected an {Application, [{Parameter, Value}]} tuple"}
+		}
+		name, env := application.Elements[0].Value, application.Elements[1]
+		if env.Kind != List || env.Tail != nil {
+			return Term{}, &SyntaxError{Line: env.Line, Msg: fmt.Sprintf("expected a list of {Parameter, Value} tuples for application %s, got %s", name, env.Kind)}
+		}
+		for _, parameter := range env.Elements {
+			if parameter.Kind != Tuple || len(parameter.Elements) != 2 || parameter.Elements[0].Kind != Atom {
+				return Term{}, &SyntaxError{Line: parameter.Line, Msg: fmt.Sprintf("expected a {Parameter, Value} tuple for application %s", name)}
+			}
+		}
+	}
+	return config, nil
+}
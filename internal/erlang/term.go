@@ -0,0 +1,649 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+//
+
+// Package erlang parses Erlang terms, as found in the advanced.config file of RabbitMQ.
+// Only data is supported: atoms, numbers, characters, strings, binaries, lists, tuples and maps.
+// Variables, functions, records and macros are rejected.
+package erlang
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Kind is the type of a Term
+type Kind int
+
+const (
+	Atom Kind = iota
+	Integer
+	Float
+	String
+	Binary
+	List
+	Tuple
+	Map
+)
+
+var kindNames = map[Kind]string{
+	Atom:    "atom",
+	Integer: "integer",
+	Float:   "float",
+	String:  "string",
+	Binary:  "binary",
+	List:    "list",
+	Tuple:   "tuple",
+	Map:     "map",
+}
+
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+// Term is a parsed Erlang term
+type Term struct {
+	Kind Kind
+	// Value is the name of an atom, the literal of a number, or the content of a string
+	Value string
+	// Elements are the elements of a list, tuple or binary, or the keys and values of a map, alternately
+	Elements []Term
+	// Tail is the tail of an improper list, such as b in [a | b]
+	Tail *Term
+	// Line is the line the term starts on, starting at 1
+	Line int
+}
+
+// SyntaxError is an error in the syntax of the parsed text
+type SyntaxError struct {
+	Line int
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// Parse parses a single term followed by a dot, such as the content of advanced.config.
+// Only whitespace and comments may follow the dot.
+func Parse(text string) (Term, error) {
+	p := &parser{lexer: &lexer{text: text, line: 1}}
+	if err := p.next(); err != nil {
+		return Term{}, err
+	}
+	if p.token.kind == tokenEOF {
+		return Term{}, &SyntaxError{Line: p.token.line, Msg: "expected a term, got end of input"}
+	}
+	term, err := p.term()
+	if err != nil {
+		return Term{}, err
+	}
+	if p.token.kind != tokenDot {
+		return Term{}, p.unexpected("'.' after the term")
+	}
+	if err := p.next(); err != nil {
+		return Term{}, err
+	}
+	if p.token.kind != tokenEOF {
+		return Term{}, p.unexpected("end of input after '.'")
+	}
+	return term, nil
+}
+
+type parser struct {
+	lexer *lexer
+	token token
+}
+
+func (p *parser) next() error {
+	t, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.token = t
+	return nil
+}
+
+func (p *parser) unexpected(expected string) error {
+	return &SyntaxError{Line: p.token.line, Msg: fmt.Sprintf("expected %s, got %s", expected, p.token)}
+}
+
+// expect consumes a punctuation token
+func (p *parser) expect(punctuation string) error {
+	if p.token.kind != tokenPunctuation || p.token.value != punctuation {
+		return p.unexpected("'" + punctuation + "'")
+	}
+	return p.next()
+}
+
+func (p *parser) is(punctuation string) bool {
+	return p.token.kind == tokenPunctuation && p.token.value == punctuation
+}
+
+func (p *parser) term() (Term, error) {
+	t := p.token
+	switch t.kind {
+	case tokenAtom:
+		return Term{Kind: Atom, Value: t.value, Line: t.line}, p.next()
+	case tokenInteger:
+		return Term{Kind: Integer, Value: t.value, Line: t.line}, p.next()
+	case tokenFloat:
+		return Term{Kind: Float, Value: t.value, Line: t.line}, p.next()
+	case tokenString:
+		// adjacent strings are concatenated
+		var value strings.Builder
+		for p.token.kind == tokenString {
+			value.WriteString(p.token.value)
+			if err := p.next(); err != nil {
+				return Term{}, err
+			}
+		}
+		return Term{Kind: String, Value: value.String(), Line: t.line}, nil
+	case tokenVariable:
+		return Term{}, &SyntaxError{Line: t.line, Msg: fmt.Sprintf("variables are not allowed, got %s; quote atoms starting with an upper case letter", t)}
+	case tokenPunctuation:
+		switch t.value {
+		case "-":
+			if err := p.next(); err != nil {
+				return Term{}, err
+			}
+			if p.token.kind != tokenInteger && p.token.kind != tokenFloat {
+				return Term{}, p.unexpected("a number after '-'")
+			}
+			term := Term{Kind: Integer, Value: "-" + p.token.value, Line: t.line}
+			if p.token.kind == tokenFloat {
+				term.Kind = Float
+			}
+			return term, p.next()
+		case "[":
+			return p.list()
+		case "{":
+			return p.sequence(Tuple, "}")
+		case "#{":
+			return p.mapTerm()
+		case "<<":
+			return p.binary()
+		}
+	}
+	return Term{}, p.unexpected("a term")
+}
+
+func (p *parser) list() (Term, error) {
+	list := Term{Kind: List, Line: p.token.line}
+	if err := p.next(); err != nil {
+		return Term{}, err
+	}
+	if p.is("]") {
+		return list, p.next()
+	}
+	for {
+		element, err := p.term()
+		if err != nil {
+			return Term{}, err
+		}
+		list.Elements = append(list.Elements, element)
+		switch {
+		case p.is(","):
+			if err := p.next(); err != nil {
+				return Term{}, err
+			}
+		case p.is("|"):
+			if err := p.next(); err != nil {
+				return Term{}, err
+			}
+			tail, err := p.term()
+			if err != nil {
+				return Term{}, err
+			}
+			list.Tail = &tail
+			return list, p.expect("]")
+		case p.is("]"):
+			return list, p.next()
+		default:
+			return Term{}, p.unexpected("',' or ']' in list")
+		}
+	}
+}
+
+// sequence parses the elements of a tuple
+func (p *parser) sequence(kind Kind, closing string) (Term, error) {
+	term := Term{Kind: kind, Line: p.token.line}
+	if err := p.next(); err != nil {
+		return Term{}, err
+	}
+	if p.is(closing) {
+		return term, p.next()
+	}
+	for {
+		element, err := p.term()
+		if err != nil {
+			return Term{}, err
+		}
+		term.Elements = append(term.Elements, element)
+		switch {
+		case p.is(","):
+			if err := p.next(); err != nil {
+				return Term{}, err
+			}
+		case p.is(closing):
+			return term, p.next()
+		default:
+			return Term{}, p.unexpected(fmt.Sprintf("',' or '%s' in %s", closing, kind))
+		}
+	}
+}
+
+func (p *parser) mapTerm() (Term, error) {
+	term := Term{Kind: Map, Line: p.token.line}
+	if err := p.next(); err != nil {
+		return Term{}, err
+	}
+	if p.is("}") {
+		return term, p.next()
+	}
+	for {
+		key, err := p.term()
+		if err != nil {
+			return Term{}, err
+		}
+		if !p.is("=>") {
+			return Term{}, p.unexpected("'=>' in map")
+		}
+		if err := p.next(); err != nil {
+			return Term{}, err
+		}
+		value, err := p.term()
+		if err != nil {
+			return Term{}, err
+		}
+		term.Elements = append(term.Elements, key, value)
+		switch {
+		case p.is(","):
+			if err := p.next(); err != nil {
+				return Term{}, err
+			}
+		case p.is("}"):
+			return term, p.next()
+		default:
+			return Term{}, p.unexpected("',' or '}' in map")
+		}
+	}
+}
+
+// binary parses binaries such as <<"text">>, <<"text"/utf8>> and <<1, 2:16/big>>
+func (p *parser) binary() (Term, error) {
+	term := Term{Kind: Binary, Line: p.token.line}
+	if err := p.next(); err != nil {
+		return Term{}, err
+	}
+	if p.is(">>") {
+		return term, p.next()
+	}
+	for {
+		var element Term
+		var err error
+		switch p.token.kind {
+		case tokenString, tokenInteger:
+			element, err = p.term()
+		default:
+			if p.is("-") {
+				element, err = p.term()
+			} else {
+				return Term{}, p.unexpected("a string or an integer in binary")
+			}
+		}
+		if err != nil {
+			return Term{}, err
+		}
+		term.Elements = append(term.Elements, element)
+		if p.is(":") {
+			if err := p.next(); err != nil {
+				return Term{}, err
+			}
+			if p.token.kind != tokenInteger {
+				return Term{}, p.unexpected("a size after ':' in binary")
+			}
+			if err := p.next(); err != nil {
+				return Term{}, err
+			}
+		}
+		if p.is("/") {
+			// type specifiers, such as utf8 or unsigned-big-integer
+			for {
+				if err := p.next(); err != nil {
+					return Term{}, err
+				}
+				if p.token.kind != tokenAtom {
+					return Term{}, p.unexpected("a type specifier in binary")
+				}
+				if err := p.next(); err != nil {
+					return Term{}, err
+				}
+				if !p.is("-") {
+					break
+				}
+			}
+		}
+		switch {
+		case p.is(","):
+			if err := p.next(); err != nil {
+				return Term{}, err
+			}
+		case p.is(">>"):
+			return term, p.next()
+		default:
+			return Term{}, p.unexpected("',' or '>>' in binary")
+		}
+	}
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenAtom
+	tokenVariable
+	tokenInteger
+	tokenFloat
+	tokenString
+	tokenPunctuation
+	tokenDot
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	line  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of input"
+	case tokenString:
+		return strconv.Quote(t.value)
+	case tokenDot:
+		return "'.'"
+	case tokenAtom:
+		return "atom " + t.value
+	case tokenVariable:
+		return "variable " + t.value
+	default:
+		return "'" + t.value + "'"
+	}
+}
+
+type lexer struct {
+	text string
+	pos  int
+	line int
+}
+
+// punctuations are matched in order, so longer ones come first
+var punctuations = []string{"#{", "=>", "<<", ">>", "[", "]", "{", "}", ",", "|", "-", ":", "/"}
+
+func (l *lexer) peek(offset int) byte {
+	if l.pos+offset < len(l.text) {
+		return l.text[l.pos+offset]
+	}
+	return 0
+}
+
+func (l *lexer) errorf(format string, args ...any) error {
+	return &SyntaxError{Line: l.line, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (l *lexer) next() (token, error) {
+	l.skipWhitespaceAndComments()
+	if l.pos >= len(l.text) {
+		return token{kind: tokenEOF, line: l.line}, nil
+	}
+	c := l.text[l.pos]
+	switch {
+	case c == '.':
+		// a dot ends the term when followed by whitespace, a comment or the end of input
+		if next := l.peek(1); next == 0 || isWhitespace(next) || next == '%' {
+			l.pos++
+			return token{kind: tokenDot, line: l.line}, nil
+		}
+		return token{}, l.errorf("unexpected '.'")
+	case isLower(c):
+		return token{kind: tokenAtom, value: l.name(), line: l.line}, nil
+	case isUpper(c) || c == '_':
+		return token{kind: tokenVariable, value: l.name(), line: l.line}, nil
+	case c == '\'':
+		line := l.line
+		value, err := l.quoted('\'')
+		return token{kind: tokenAtom, value: value, line: line}, err
+	case c == '"':
+		line := l.line
+		value, err := l.quoted('"')
+		return token{kind: tokenString, value: value, line: line}, err
+	case c == '$':
+		return l.character()
+	case isDigit(c):
+		return l.number()
+	}
+	for _, punctuation := range punctuations {
+		if strings.HasPrefix(l.text[l.pos:], punctuation) {
+			l.pos += len(punctuation)
+			return token{kind: tokenPunctuation, value: punctuation, line: l.line}, nil
+		}
+	}
+	return token{}, l.errorf("unexpected character %q", c)
+}
+
+func (l *lexer) skipWhitespaceAndComments() {
+	for l.pos < len(l.text) {
+		c := l.text[l.pos]
+		switch {
+		case c == '\n':
+			l.line++
+			l.pos++
+		case isWhitespace(c):
+			l.pos++
+		case c == '%':
+			for l.pos < len(l.text) && l.text[l.pos] != '\n' {
+				l.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (l *lexer) name() string {
+	start := l.pos
+	for l.pos < len(l.text) && (isLower(l.text[l.pos]) || isUpper(l.text[l.pos]) || isDigit(l.text[l.pos]) || l.text[l.pos] == '_' || l.text[l.pos] == '@') {
+		l.pos++
+	}
+	return l.text[start:l.pos]
+}
+
+// quoted reads a quoted atom or string, resolving escape sequences
+func (l *lexer) quoted(quote byte) (string, error) {
+	line := l.line
+	l.pos++
+	var value strings.Builder
+	for {
+		if l.pos >= len(l.text) {
+			return "", &SyntaxError{Line: line, Msg: fmt.Sprintf("unterminated %s starting here", quotedName(quote))}
+		}
+		c := l.text[l.pos]
+		switch c {
+		case quote:
+			l.pos++
+			return value.String(), nil
+		case '\\':
+			l.pos++
+			escaped, err := l.escape()
+			if err != nil {
+				return "", err
+			}
+			value.WriteString(escaped)
+		case '\n':
+			l.line++
+			l.pos++
+			value.WriteByte(c)
+		default:
+			l.pos++
+			value.WriteByte(c)
+		}
+	}
+}
+
+func quotedName(quote byte) string {
+	if quote == '"' {
+		return "string"
+	}
+	return "quoted atom"
+}
+
+var escapes = map[byte]string{
+	'b': "\b", 'd': "\x7f", 'e': "\x1b", 'f': "\f", 'n': "\n", 'r': "\r", 's': " ", 't': "\t", 'v': "\v",
+	'\\': "\\", '"': "\"", '\'': "'",
+}
+
+// escape reads the escape sequence following a backslash
+func (l *lexer) escape() (string, error) {
+	if l.pos >= len(l.text) {
+		return "", l.errorf("unterminated escape sequence")
+	}
+	c := l.text[l.pos]
+	if escaped, ok := escapes[c]; ok {
+		l.pos++
+		return escaped, nil
+	}
+	if isOctal(c) {
+		start := l.pos
+		for l.pos < len(l.text) && l.pos-start < 3 && isOctal(l.text[l.pos]) {
+			l.pos++
+		}
+		code, _ := strconv.ParseInt(l.text[start:l.pos], 8, 32)
+		return string(rune(code)), nil
+	}
+	if c == 'x' {
+		l.pos++
+		var digits string
+		if l.peek(0) == '{' {
+			end := strings.IndexByte(l.text[l.pos:], '}')
+			if end < 0 {
+				return "", l.errorf("unterminated \\x{...} escape sequence")
+			}
+			digits = l.text[l.pos+1 : l.pos+end]
+			l.pos += end + 1
+		} else {
+			digits = l.text[l.pos:min(l.pos+2, len(l.text))]
+			l.pos += len(digits)
+		}
+		code, err := strconv.ParseInt(digits, 16, 32)
+		if err != nil {
+			return "", l.errorf("invalid \\x escape sequence %q", digits)
+		}
+		return string(rune(code)), nil
+	}
+	if c == '^' {
+		if l.pos+1 >= len(l.text) {
+			return "", l.errorf("unterminated \\^ escape sequence")
+		}
+		l.pos += 2
+		return string(rune(l.text[l.pos-1] & 0x1f)), nil
+	}
+	// any other escaped character stands for itself
+	l.pos++
+	if c == '\n' {
+		l.line++
+	}
+	return string(c), nil
+}
+
+// character reads a character literal such as $a or $\n, which is an integer
+func (l *lexer) character() (token, error) {
+	line := l.line
+	l.pos++
+	if l.pos >= len(l.text) {
+		return token{}, l.errorf("expected a character after '$'")
+	}
+	var value string
+	if l.text[l.pos] == '\\' {
+		l.pos++
+		escaped, err := l.escape()
+		if err != nil {
+			return token{}, err
+		}
+		value = escaped
+	} else {
+		value = l.text[l.pos : l.pos+1]
+		if value == "\n" {
+			l.line++
+		}
+		l.pos++
+	}
+	return token{kind: tokenInteger, value: strconv.Itoa(int([]rune(value)[0])), line: line}, nil
+}
+
+// number reads integers such as 42, 1_000 and 16#ff, and floats such as 1.5 and 2.0e-3
+func (l *lexer) number() (token, error) {
+	start := l.pos
+	l.digits(isDigit)
+	if l.peek(0) == '#' {
+		base, err := strconv.Atoi(strings.ReplaceAll(l.text[start:l.pos], "_", ""))
+		if err != nil || base < 2 || base > 36 {
+			return token{}, l.errorf("invalid base %s", l.text[start:l.pos])
+		}
+		l.pos++
+		digitsStart := l.pos
+		l.digits(func(c byte) bool { return isDigit(c) || isLower(c) || isUpper(c) })
+		if _, err := strconv.ParseInt(strings.ReplaceAll(l.text[digitsStart:l.pos], "_", ""), base, 64); err != nil && !isRangeError(err) {
+			return token{}, l.errorf("invalid integer %s", l.text[start:l.pos])
+		}
+		return token{kind: tokenInteger, value: l.text[start:l.pos], line: l.line}, nil
+	}
+	// a dot followed by a digit makes a float, any other dot ends the term
+	if l.peek(0) == '.' && isDigit(l.peek(1)) {
+		l.pos++
+		l.digits(isDigit)
+		if c := l.peek(0); c == 'e' || c == 'E' {
+			l.pos++
+			if c := l.peek(0); c == '+' || c == '-' {
+				l.pos++
+			}
+			if !isDigit(l.peek(0)) {
+				return token{}, l.errorf("invalid float %s", l.text[start:l.pos])
+			}
+			l.digits(isDigit)
+		}
+		return token{kind: tokenFloat, value: l.text[start:l.pos], line: l.line}, nil
+	}
+	if c := l.peek(0); isLower(c) || isUpper(c) {
+		return token{}, l.errorf("invalid number %s%c", l.text[start:l.pos], c)
+	}
+	return token{kind: tokenInteger, value: l.text[start:l.pos], line: l.line}, nil
+}
+
+// digits reads digits, which may be separated by single underscores
+func (l *lexer) digits(isDigit func(byte) bool) {
+	for l.pos < len(l.text) && (isDigit(l.text[l.pos]) || (l.text[l.pos] == '_' && isDigit(l.peek(1)))) {
+		l.pos++
+	}
+}
+
+func isRangeError(err error) bool {
+	numErr, ok := err.(*strconv.NumError)
+	return ok && numErr.Err == strconv.ErrRange
+}
+
+func isWhitespace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == '\v'
+}
+
+func isLower(c byte) bool { return c >= 'a' && c <= 'z' }
+func isUpper(c byte) bool { return c >= 'A' && c <= 'Z' }
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+func isOctal(c byte) bool { return c >= '0' && c <= '7' }
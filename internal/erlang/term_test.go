@@ -0,0 +1,101 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+//
+
+package erlang_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rabbitmq/cluster-operator/v2/internal/erlang"
+)
+
+var _ = Describe("Parse", func() {
+	It("parses nested terms with their line", func() {
+		term, err := erlang.Parse(`
+% rabbit settings
+[{rabbit, [{tcp_listeners, [5672]},
+           {'Quoted Atom', "a" "b"},
+           {vm_memory_high_watermark, -0.4e1}]}].
+`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(term.Kind).To(Equal(erlang.List))
+		Expect(term.Line).To(Equal(3))
+		rabbit := term.Elements[0]
+		Expect(rabbit.Elements[0]).To(Equal(erlang.Term{Kind: erlang.Atom, Value: "rabbit", Line: 3}))
+		settings := rabbit.Elements[1].Elements
+		Expect(settings).To(HaveLen(3))
+		Expect(settings[1].Elements).To(Equal([]erlang.Term{
+			{Kind: erlang.Atom, Value: "Quoted Atom", Line: 4},
+			{Kind: erlang.String, Value: "ab", Line: 4},
+		}))
+		Expect(settings[2].Elements[1]).To(Equal(erlang.Term{Kind: erlang.Float, Value: "-0.4e1", Line: 5}))
+	})
+
+	DescribeTable("literals",
+		func(text string, expected erlang.Term) {
+			expected.Line = 1
+			Expect(erlang.Parse(text)).To(Equal(expected))
+		},
+		Entry("atom with @", "rabbit@host.", erlang.Term{Kind: erlang.Atom, Value: "rabbit@host"}),
+		Entry("integer with underscores", "1_000.", erlang.Term{Kind: erlang.Integer, Value: "1_000"}),
+		Entry("integer with base", "16#ff.", erlang.Term{Kind: erlang.Integer, Value: "16#ff"}),
+		Entry("character", "$a.", erlang.Term{Kind: erlang.Integer, Value: "97"}),
+		Entry("escaped character", `$\n.`, erlang.Term{Kind: erlang.Integer, Value: "10"}),
+		Entry("string escapes", `"tab\there \"quoted\" \x41\101".`, erlang.Term{Kind: erlang.String, Value: "tab\there \"quoted\" AA"}),
+		Entry("binary", `<<"secret">>.`, erlang.Term{Kind: erlang.Binary, Elements: []erlang.Term{{Kind: erlang.String, Value: "secret", Line: 1}}}),
+		Entry("binary with type specifiers", `<<"é"/utf8, 1:16/unsigned-big>>.`, erlang.Term{Kind: erlang.Binary, Elements: []erlang.Term{
+			{Kind: erlang.String, Value: "é", Line: 1},
+			{Kind: erlang.Integer, Value: "1", Line: 1},
+		}}),
+		Entry("map", `#{a => 1}.`, erlang.Term{Kind: erlang.Map, Elements: []erlang.Term{
+			{Kind: erlang.Atom, Value: "a", Line: 1},
+			{Kind: erlang.Integer, Value: "1", Line: 1},
+		}}),
+		Entry("improper list", `[a | b].`, erlang.Term{Kind: erlang.List,
+			Elements: []erlang.Term{{Kind: erlang.Atom, Value: "a", Line: 1}},
+			Tail:     &erlang.Term{Kind: erlang.Atom, Value: "b", Line: 1},
+		}),
+		Entry("empty tuple", `{}.`, erlang.Term{Kind: erlang.Tuple}),
+	)
+
+	DescribeTable("syntax errors",
+		func(text, expected string) {
+			_, err := erlang.Parse(text)
+			Expect(err).To(MatchError(expected))
+		},
+		Entry("missing dot", "[{rabbit, []}]\n", "line 2: expected '.' after the term, got end of input"),
+		Entry("missing comma", "[{rabbit, [\n  {a, 1}\n  {b, 2}]}].", "line 3: expected ',' or ']' in list, got '{'"),
+		Entry("unbalanced brackets", "[{rabbit, [{a, 1}]].", "line 1: expected ',' or '}' in tuple, got ']'"),
+		Entry("variable", "[{rabbit, [{a, True}]}].", "line 1: variables are not allowed, got variable True; quote atoms starting with an upper case letter"),
+		Entry("unterminated string", "[{rabbit, [{a, \"b}]}].\n", "line 1: unterminated string starting here"),
+		Entry("trailing term", "[]. []", "line 1: expected end of input after '.', got '['"),
+		Entry("trailing comma", "[a, ].", "line 1: expected a term, got ']'"),
+		Entry("empty", "% nothing\n", "line 2: expected a term, got end of input"),
+		Entry("unexpected character", "[a; b].", "line 1: unexpected character ';'"),
+		Entry("invalid base", "99#1.", "line 1: invalid base 99"),
+	)
+})
+
+var _ = Describe("ParseConfig", func() {
+	It("accepts application environments and included files", func() {
+		_, err := erlang.ParseConfig(`["/etc/rabbitmq/other.config", {rabbit, [{log, [{console, [{level, debug}]}]}]}, {ssl, []}].`)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	DescribeTable("rejects terms which are not configurations",
+		func(text, expected string) {
+			_, err := erlang.ParseConfig(text)
+			Expect(err).To(MatchError(expected))
+		},
+		Entry("not a list", "{rabbit, []}.", "line 1: expected a list of {Application, [{Parameter, Value}]} tuples, got tuple"),
+		Entry("not an application tuple", "[\n{rabbit}].", "line 2: expected an {Application, [{Parameter, Value}]} tuple"),
+		Entry("environment not a list", "[{rabbit, {a, 1}}].", "line 1: expected a list of {Parameter, Value} tuples for application rabbit, got tuple"),
+		Entry("parameter not a tuple", "[{rabbit, [\n  tcp_listeners]}].", "line 2: expected a {Parameter, Value} tuple for application rabbit"),
+	)
+})
@@ -73,10 +73,11 @@ func managementHTTPSTransport(ctx context.Context, k8sClient client.Reader, rmq
 		return nil, fmt.Errorf("failed to get system cert pool: %w", err)
 	}
 
-	if rmq.Spec.TLS.CaSecretName != "" {
+	// The management listener may use its own certificate, see spec.tls.listeners.management
+	if caSecretName := rmq.ManagementCaSecretName(); caSecretName != "" {
 		caSecret := &corev1.Secret{}
 		err := k8sClient.Get(ctx, types.NamespacedName{
-			Name:      rmq.Spec.TLS.CaSecretName,
+			Name:      caSecretName,
 			Namespace: rmq.Namespace,
 		}, caSecret)
 		if err != nil {
@@ -85,7 +86,7 @@ func managementHTTPSTransport(ctx context.Context, k8sClient client.Reader, rmq
 
 		caCert, ok := caSecret.Data["ca.crt"]
 		if !ok {
-			return nil, fmt.Errorf("CA certificate not found in secret %s", rmq.Spec.TLS.CaSecretName)
+			return nil, fmt.Errorf("CA certificate not found in secret %s", caSecretName)
 		}
 		if !certPool.AppendCertsFromPEM(caCert) {
 			return nil, fmt.Errorf("failed to append CA certificate to cert pool")
@@ -140,7 +141,7 @@ func getClientInfoForPod(ctx context.Context, k8sClient client.Reader, rmq *rabb
 		scheme = "http"
 	}
 
-	baseURL := fmt.Sprintf("%s://%s:%d", scheme, podFQDN, port)
+	baseURL := fmt.Sprintf("%s://%s:%d%s", scheme, podFQDN, port, rmq.Spec.Management.PathPrefix)
 
 	return &ClientInfo{
 		BaseURL:   baseURL,
@@ -187,7 +188,7 @@ func getClientInfoForService(ctx context.Context, k8sClient client.Reader, rmq *
 		scheme = "http"
 	}
 
-	baseURL := fmt.Sprintf("%s://%s:%d", scheme, serviceHost, svcPort)
+	baseURL := fmt.Sprintf("%s://%s:%d%s", scheme, serviceHost, svcPort, rmq.Spec.Management.PathPrefix)
 
 	return &ClientInfo{
 		BaseURL:   baseURL,
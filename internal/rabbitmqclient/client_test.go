@@ -12,6 +12,14 @@ package rabbitmqclient
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -95,6 +103,13 @@ var _ = Describe("RabbitMQ Client", func() {
 				Expect(info.BaseURL).To(Equal("https://test-cluster-server-1.test-cluster-nodes.test-namespace.svc:15671"))
 			})
 
+			It("appends the management path prefix to the base URL", func() {
+				rmq.Spec.Management.PathPrefix = "/rabbitmq"
+				info, err := getClientInfoForPod(ctx, k8sClient, rmq, "test-cluster-server-0")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(info.BaseURL).To(Equal("http://test-cluster-server-0.test-cluster-nodes.test-namespace.svc:15672/rabbitmq"))
+			})
+
 			It("returns an HTTP transport for TLS with correct ServerName", func() {
 				rmq.Spec.TLS.SecretName = "tls-secret"
 				rmq.Spec.TLS.DisableNonTLSListeners = true
@@ -112,6 +127,25 @@ var _ = Describe("RabbitMQ Client", func() {
 				Expect(err).NotTo(HaveOccurred())
 				Expect(info.Transport).To(BeNil())
 			})
+
+			It("trusts the CA of the management listener", func() {
+				rmq.Spec.TLS.SecretName = "tls-secret"
+				rmq.Spec.TLS.CaSecretName = "internal-ca"
+				rmq.Spec.TLS.DisableNonTLSListeners = true
+				rmq.Spec.TLS.Listeners = &rabbitmqv1beta1.TLSListenersSpec{
+					Management: &rabbitmqv1beta1.ListenerTLSSpec{SecretName: "management-tls", CaSecretName: "management-ca"},
+				}
+				_, err := getClientInfoForPod(ctx, k8sClient, rmq, "test-cluster-server-0")
+				Expect(err).To(MatchError(ContainSubstring("failed to get CA secret")))
+
+				Expect(k8sClient.Create(ctx, &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "management-ca", Namespace: namespace},
+					Data:       map[string][]byte{"ca.crt": selfSignedCACertificate()},
+				})).To(Succeed())
+				info, err := getClientInfoForPod(ctx, k8sClient, rmq, "test-cluster-server-0")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(info.Transport.TLSClientConfig.RootCAs).NotTo(BeNil())
+			})
 		})
 
 		Context("when the secret does not exist", func() {
@@ -331,3 +365,19 @@ var _ = Describe("RabbitMQ Client", func() {
 		})
 	})
 })
+
+func selfSignedCACertificate() []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "management-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
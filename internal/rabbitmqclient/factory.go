@@ -13,6 +13,7 @@ package rabbitmqclient
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"time"
 
 	rabbithole "github.com/michaelklishin/rabbit-hole/v3"
@@ -25,6 +26,26 @@ type RabbitmqClient interface {
 	Overview() (*rabbithole.Overview, error)
 	HealthCheckNodeIsQuorumCritical() (rabbithole.HealthCheckStatus, error)
 	ListDeprecatedFeaturesUsed() ([]rabbithole.DeprecatedFeature, error)
+	GetUser(username string) (*rabbithole.UserInfo, error)
+	PutUser(username string, info rabbithole.UserSettings) (*http.Response, error)
+	DeleteUser(username string) (*http.Response, error)
+	ListPermissionsOf(username string) ([]rabbithole.PermissionInfo, error)
+	UpdatePermissionsIn(vhost, username string, permissions rabbithole.Permissions) (*http.Response, error)
+	ListTopicPermissionsOf(username string) ([]rabbithole.TopicPermissionInfo, error)
+	UpdateTopicPermissionsIn(vhost, username string, topicPermissions rabbithole.TopicPermissions) (*http.Response, error)
+	UploadDefinitions(definitions *rabbithole.ExportedDefinitions) (*http.Response, error)
+	ListDefinitions() (*rabbithole.ExportedDefinitions, error)
+	GetVhost(vhost string) (*rabbithole.VhostInfo, error)
+	PutVhost(vhost string, settings rabbithole.VhostSettings) (*http.Response, error)
+	GetGlobalParameter(name string) (*rabbithole.GlobalRuntimeParameter, error)
+	PutGlobalParameter(name string, value any) (*http.Response, error)
+	DeleteGlobalParameter(name string) (*http.Response, error)
+	GetRuntimeParameter(component, vhost, name string) (*rabbithole.RuntimeParameter, error)
+	PutRuntimeParameter(component, vhost, name string, value any) (*http.Response, error)
+	DeleteRuntimeParameter(component, vhost, name string) (*http.Response, error)
+	ListFeatureFlags() ([]rabbithole.FeatureFlag, error)
+	EnableFeatureFlag(featureFlagName string) (*http.Response, error)
+	RebalanceQueues() (*http.Response, error)
 }
 
 // RabbitmqClientFactory creates a RabbitmqClient targeting either a specific pod or the cluster Service.
@@ -0,0 +1,91 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+//
+
+// Package rabbitmqconf parses rabbitmq.conf files and checks their keys against the settings known to each RabbitMQ version.
+package rabbitmqconf
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Setting is a key and value of rabbitmq.conf
+type Setting struct {
+	Key   string
+	Value string
+	// Line is the line the setting starts on, starting at 1
+	Line int
+}
+
+// SyntaxError is a line of rabbitmq.conf which is not a setting
+type SyntaxError struct {
+	Line int
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// multiLineQuote starts and ends values spanning several lines, such as PEM certificates
+const multiLineQuote = `"""`
+
+var keyRegexp = regexp.MustCompile(`^[A-Za-z0-9_@-]+(\.[A-Za-z0-9_@-]+)*$`)
+
+// Parse parses the settings of rabbitmq.conf, in which every line is a key = value setting, a comment starting with # or ;,
+// or empty. It returns a *SyntaxError for the first line which is none of these.
+func Parse(text string) ([]Setting, error) {
+	var settings []Setting
+	lines := strings.Split(text, "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNumber := i + 1
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			return nil, &SyntaxError{Line: lineNumber, Msg: fmt.Sprintf("sections are not supported, got %s", line)}
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, &SyntaxError{Line: lineNumber, Msg: fmt.Sprintf("expected key = value, got %s", line)}
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !keyRegexp.MatchString(key) {
+			return nil, &SyntaxError{Line: lineNumber, Msg: fmt.Sprintf("invalid key %q, keys are dot-separated names of letters, digits, '_', '-' and '@'", key)}
+		}
+		if rest, ok := strings.CutPrefix(value, multiLineQuote); ok {
+			if inner, ok := strings.CutSuffix(rest, multiLineQuote); ok {
+				value = inner
+			} else {
+				parts := []string{rest}
+				closed := false
+				for i+1 < len(lines) {
+					i++
+					if inner, ok := strings.CutSuffix(strings.TrimRight(lines[i], " \t\r"), multiLineQuote); ok {
+						parts = append(parts, inner)
+						closed = true
+						break
+					}
+					parts = append(parts, lines[i])
+				}
+				if !closed {
+					return nil, &SyntaxError{Line: lineNumber, Msg: fmt.Sprintf("unterminated %s value of key %s", multiLineQuote, key)}
+				}
+				value = strings.Join(parts, "\n")
+			}
+		}
+		if value == "" {
+			return nil, &SyntaxError{Line: lineNumber, Msg: fmt.Sprintf("missing value of key %s", key)}
+		}
+		settings = append(settings, Setting{Key: key, Value: value, Line: lineNumber})
+	}
+	return settings, nil
+}
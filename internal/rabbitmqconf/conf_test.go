@@ -0,0 +1,106 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+//
+
+package rabbitmqconf_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rabbitmq/cluster-operator/v2/internal/rabbitmqconf"
+)
+
+var _ = Describe("Parse", func() {
+	It("parses settings with their line", func() {
+		settings, err := rabbitmqconf.Parse(`# a comment
+; another comment
+
+cluster_name = my-cluster
+  listeners.tcp.default=5672
+ssl_options.cacertfile = """
+-----BEGIN CERTIFICATE-----
+-----END CERTIFICATE-----"""
+log.console.level = debug
+`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(settings).To(Equal([]rabbitmqconf.Setting{
+			{Key: "cluster_name", Value: "my-cluster", Line: 4},
+			{Key: "listeners.tcp.default", Value: "5672", Line: 5},
+			{Key: "ssl_options.cacertfile", Value: "\n-----BEGIN CERTIFICATE-----\n-----END CERTIFICATE-----", Line: 6},
+			{Key: "log.console.level", Value: "debug", Line: 9},
+		}))
+	})
+
+	DescribeTable("syntax errors",
+		func(text, message string) {
+			_, err := rabbitmqconf.Parse(text)
+			Expect(err).To(MatchError(message))
+		},
+		Entry("missing =", "cluster_name = a\nlog.console.level debug", "line 2: expected key = value, got log.console.level debug"),
+		Entry("section", "[rabbit]", "line 1: sections are not supported, got [rabbit]"),
+		Entry("invalid key", "\n\nlog..level = debug", `line 3: invalid key "log..level", keys are dot-separated names of letters, digits, '_', '-' and '@'`),
+		Entry("missing value", "cluster_name =", "line 1: missing value of key cluster_name"),
+		Entry("unterminated value", "a = b\nssl_options.cacertfile = \"\"\"\nabc", `line 2: unterminated """ value of key ssl_options.cacertfile`),
+	)
+})
+
+var _ = Describe("Lint", func() {
+	lint := func(text, version string) []string {
+		settings, err := rabbitmqconf.Parse(text)
+		Expect(err).NotTo(HaveOccurred())
+		return rabbitmqconf.Lint(settings, version)
+	}
+
+	It("accepts known keys", func() {
+		Expect(lint(`listeners.tcp.default = 5672
+auth_backends.1.authn = ldap
+ssl_options.versions.1 = tlsv1.3
+management.tcp.port = 15672
+default_users.alice.password = secret`, "4.1.0")).To(BeEmpty())
+	})
+
+	It("warns about unknown keys with their line", func() {
+		Expect(lint("cluster_name = a\nclustr_name = b\nlisteners.tcp = 5672\nlisteners.tcp.default.port = 5672", "4.1.0")).To(Equal([]string{
+			"line 2: unknown key clustr_name",
+			"line 4: unknown key listeners.tcp.default.port",
+		}))
+	})
+
+	It("does not match namespaces without sub-keys", func() {
+		Expect(lint("management = true", "4.1.0")).To(Equal([]string{"line 1: unknown key management"}))
+	})
+
+	It("warns about keys the version does not support", func() {
+		Expect(lint("mirroring_sync_batch_size = 1024\nanonymous_login_user = none", "4.0.5")).To(Equal([]string{
+			"line 1: key mirroring_sync_batch_size was removed in RabbitMQ 4.0.0, but the image is 4.0.5",
+		}))
+		Expect(lint("mirroring_sync_batch_size = 1024\nanonymous_login_user = none", "3.13.7")).To(Equal([]string{
+			"line 2: key anonymous_login_user requires RabbitMQ 4.0.0 or later, but the image is 3.13.7",
+		}))
+	})
+
+	It("does not check versions if the version is unknown", func() {
+		Expect(lint("mirroring_sync_batch_size = 1024\nanonymous_login_user = none", "")).To(BeEmpty())
+	})
+})
+
+var _ = Describe("ImageVersion", func() {
+	DescribeTable("returns the version of the image tag",
+		func(image, version string) {
+			Expect(rabbitmqconf.ImageVersion(image)).To(Equal(version))
+		},
+		Entry("plain tag", "rabbitmq:4.1.0", "4.1.0"),
+		Entry("tag with a suffix", "rabbitmq:4.0.5-management", "4.0.5"),
+		Entry("minor version tag", "rabbitmq:3.13-management-alpine", "3.13"),
+		Entry("registry with a port", "registry.example.com:5000/rabbitmq:4.1.0", "4.1.0"),
+		Entry("tag and digest", "rabbitmq:4.1.0@sha256:abc", "4.1.0"),
+		Entry("digest only", "rabbitmq@sha256:abc", ""),
+		Entry("registry with a port and no tag", "registry.example.com:5000/rabbitmq", ""),
+		Entry("tag without a version", "rabbitmq:latest", ""),
+	)
+})
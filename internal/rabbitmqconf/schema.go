@@ -0,0 +1,231 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+//
+
+package rabbitmqconf
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// key is a rabbitmq.conf key of the schema. As in the cuttlefish schemas of RabbitMQ, segments starting with $
+// match any segment, such as the name of a listener. A last segment ** matches any number of segments,
+// for settings passed through to libraries or plugins, such as TLS options.
+type key struct {
+	pattern string
+	// since is the first version supporting the key, if it was added after 3.9
+	since string
+	// until is the first version which no longer supports the key
+	until string
+}
+
+// schema is a curated list of the keys of RabbitMQ and of the plugins shipped with it
+var schema = []key{
+	// listeners and connections
+	{pattern: "listeners.tcp"},
+	{pattern: "listeners.tcp.$name"},
+	{pattern: "listeners.ssl"},
+	{pattern: "listeners.ssl.$name"},
+	{pattern: "num_acceptors.tcp"},
+	{pattern: "num_acceptors.ssl"},
+	{pattern: "handshake_timeout"},
+	{pattern: "ssl_handshake_timeout"},
+	{pattern: "reverse_dns_lookups"},
+	{pattern: "proxy_protocol"},
+	{pattern: "tcp_listen_options.**"},
+	{pattern: "ssl_options.**"},
+	{pattern: "ssl_cert_login_from"},
+	{pattern: "ssl_cert_login_san_type"},
+	{pattern: "ssl_cert_login_san_index"},
+	{pattern: "socket_writer.gc_threshold"},
+	{pattern: "heartbeat"},
+	{pattern: "frame_max"},
+	{pattern: "initial_frame_max"},
+	{pattern: "channel_max"},
+	{pattern: "channel_max_per_node", since: "3.12.0"},
+	{pattern: "connection_max"},
+	{pattern: "consumer_max_per_channel", since: "3.12.0"},
+	{pattern: "session_max_per_connection", since: "4.0.0"},
+	{pattern: "link_max_per_session", since: "4.0.0"},
+	{pattern: "max_message_size"},
+	{pattern: "consumer_timeout"},
+	{pattern: "vhost_max"},
+	{pattern: "cluster_queue_limit", since: "3.12.0"},
+	{pattern: "distribution.listener.**"},
+	{pattern: "net_ticktime"},
+	{pattern: "delegate_count"},
+
+	// authentication and authorisation
+	{pattern: "auth_mechanisms.$n"},
+	{pattern: "auth_backends.$n"},
+	{pattern: "auth_backends.$n.authn"},
+	{pattern: "auth_backends.$n.authz"},
+	{pattern: "auth_cache.**"},
+	{pattern: "password_hashing_module"},
+	{pattern: "credential_validator.validation_backend"},
+	{pattern: "credential_validator.min_length"},
+	{pattern: "credential_validator.regexp"},
+	{pattern: "loopback_users"},
+	{pattern: "loopback_users.$user"},
+	{pattern: "track_auth_attempt_source"},
+	{pattern: "anonymous_login_user", since: "4.0.0"},
+	{pattern: "anonymous_login_pass", since: "4.0.0"},
+	{pattern: "default_vhost"},
+	{pattern: "default_user"},
+	{pattern: "default_pass"},
+	{pattern: "default_permissions.configure"},
+	{pattern: "default_permissions.read"},
+	{pattern: "default_permissions.write"},
+	{pattern: "default_user_tags.$tag"},
+	{pattern: "default_users.$name.**", since: "3.12.0"},
+	{pattern: "default_policies.operator.$name.**", since: "3.12.0"},
+	{pattern: "default_limits.vhosts.$n.**", since: "3.12.0"},
+	{pattern: "default_queue_type", since: "3.13.0"},
+
+	// resources and clustering
+	{pattern: "vm_memory_high_watermark.relative"},
+	{pattern: "vm_memory_high_watermark.absolute"},
+	{pattern: "vm_memory_high_watermark_paging_ratio", until: "4.0.0"},
+	{pattern: "vm_memory_calculation_strategy"},
+	{pattern: "memory_monitor_interval"},
+	{pattern: "total_memory_available_override_value"},
+	{pattern: "disk_free_limit.relative"},
+	{pattern: "disk_free_limit.absolute"},
+	{pattern: "disk_monitor_failure_retries"},
+	{pattern: "disk_monitor_failure_retry_interval"},
+	{pattern: "cluster_name"},
+	{pattern: "cluster_tags.$tag", since: "3.12.0"},
+	{pattern: "node_tags.$tag", since: "4.0.0"},
+	{pattern: "cluster_partition_handling"},
+	{pattern: "cluster_partition_handling.pause_if_all_down.recover"},
+	{pattern: "cluster_partition_handling.pause_if_all_down.nodes.$n"},
+	{pattern: "cluster_keepalive_interval"},
+	{pattern: "cluster_formation.**"},
+	{pattern: "mnesia_table_loading_retry_timeout"},
+	{pattern: "mnesia_table_loading_retry_limit"},
+	{pattern: "background_gc_enabled"},
+	{pattern: "background_gc_target_interval"},
+	{pattern: "collect_statistics"},
+	{pattern: "collect_statistics_interval"},
+	{pattern: "metadata_store.**", since: "4.0.0"},
+	{pattern: "deprecated_features.permit.$feature", since: "3.13.0"},
+
+	// queues and messages
+	{pattern: "queue_master_locator"},
+	{pattern: "queue_leader_locator", since: "3.10.0"},
+	{pattern: "mirroring_sync_batch_size", until: "4.0.0"},
+	{pattern: "mirroring_sync_max_throughput", until: "4.0.0"},
+	{pattern: "queue_index_embed_msgs_below"},
+	{pattern: "queue_index_max_journal_entries"},
+	{pattern: "msg_store_index_module"},
+	{pattern: "msg_store_file_size_limit"},
+	{pattern: "msg_store_credit_disc_bound"},
+	{pattern: "lazy_queue_explicit_gc_run_operation_threshold", until: "4.0.0"},
+	{pattern: "queue_explicit_gc_run_operation_threshold", until: "4.0.0"},
+	{pattern: "classic_queue.default_version", since: "3.12.0"},
+	{pattern: "quorum_queue.**", since: "3.10.0"},
+	{pattern: "stream.**"},
+	{pattern: "raft.**"},
+	{pattern: "dead_letter_worker_consumer_prefetch", since: "3.10.0"},
+	{pattern: "dead_letter_worker_publisher_confirm_timeout", since: "3.10.0"},
+	{pattern: "message_interceptors.**", since: "3.12.0"},
+
+	// definitions and logging
+	{pattern: "load_definitions"},
+	{pattern: "definitions.**", since: "3.10.0"},
+	{pattern: "log.**"},
+
+	// plugins shipped with RabbitMQ
+	{pattern: "management.**"},
+	{pattern: "management_agent.**"},
+	{pattern: "prometheus.**"},
+	{pattern: "mqtt.**"},
+	{pattern: "web_mqtt.**"},
+	{pattern: "stomp.**"},
+	{pattern: "web_stomp.**"},
+	{pattern: "amqp1_0.**", until: "4.0.0"},
+	{pattern: "auth_ldap.**"},
+	{pattern: "auth_http.**"},
+	{pattern: "auth_oauth2.**"},
+	{pattern: "federation.**"},
+	{pattern: "shovel.**"},
+	{pattern: "web_dispatch.**"},
+	{pattern: "peer_discovery.**"},
+	{pattern: "schema_definition_sync.**"},
+	{pattern: "standby.**"},
+}
+
+func (k key) matches(setting string) bool {
+	patternSegments := strings.Split(k.pattern, ".")
+	settingSegments := strings.Split(setting, ".")
+	for i, segment := range patternSegments {
+		if segment == "**" {
+			return len(settingSegments) > i
+		}
+		if i >= len(settingSegments) || (!strings.HasPrefix(segment, "$") && segment != settingSegments[i]) {
+			return false
+		}
+	}
+	return len(patternSegments) == len(settingSegments)
+}
+
+// imageTagVersion matches the RabbitMQ version at the start of an image tag, such as 4.1.0 in 4.1.0-management
+var imageTagVersion = regexp.MustCompile(`^v?\d+\.\d+(\.\d+)?`)
+
+// ImageVersion returns the RabbitMQ version of the image tag, or an empty string if the tag does not start with a version,
+// such as latest, or if the image is referenced by digest only
+func ImageVersion(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	tagStart := strings.LastIndex(image, ":")
+	if tagStart == -1 || strings.Contains(image[tagStart:], "/") {
+		return ""
+	}
+	return imageTagVersion.FindString(image[tagStart+1:])
+}
+
+// Lint returns a warning for each setting whose key is not in the schema, or is not supported by the given RabbitMQ version.
+// Versions are only checked if the version is a semantic version.
+func Lint(settings []Setting, version string) []string {
+	// nil for images without a semantic version tag
+	parsedVersion, _ := semver.NewVersion(version)
+	var warnings []string
+	for _, setting := range settings {
+		if warning := lint(setting, parsedVersion); warning != "" {
+			warnings = append(warnings, fmt.Sprintf("line %d: %s", setting.Line, warning))
+		}
+	}
+	return warnings
+}
+
+func lint(setting Setting, version *semver.Version) string {
+	var unsupported string
+	for _, key := range schema {
+		if !key.matches(setting.Key) {
+			continue
+		}
+		if version == nil {
+			return ""
+		}
+		switch {
+		case key.since != "" && version.LessThan(semver.MustParse(key.since)):
+			unsupported = fmt.Sprintf("key %s requires RabbitMQ %s or later, but the image is %s", setting.Key, key.since, version)
+		case key.until != "" && !version.LessThan(semver.MustParse(key.until)):
+			unsupported = fmt.Sprintf("key %s was removed in RabbitMQ %s, but the image is %s", setting.Key, key.until, version)
+		default:
+			return ""
+		}
+	}
+	if unsupported != "" {
+		return unsupported
+	}
+	return fmt.Sprintf("unknown key %s", setting.Key)
+}
@@ -0,0 +1,118 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+//
+
+package resource
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// AdditionalConfigFromHashAnnotation is set on the server ConfigMap to the hash of the content referenced in
+// spec.rabbitmq.additionalConfigFrom, so that changes to the content restart the nodes like changes to additionalConfig
+const AdditionalConfigFromHashAnnotation = "rabbitmq.com/additionalConfigFromSHA256"
+
+// additionalConfigFromFilename is the file of the nth fragment of spec.rabbitmq.additionalConfigFrom in the conf.d volume.
+// Indexes are padded, since conf.d files are read in alphabetical order.
+func additionalConfigFromFilename(index int) string {
+	return fmt.Sprintf("additionalConfigFrom-%02d.conf", index+1)
+}
+
+// ReadAdditionalConfigFrom returns the rabbitmq.conf fragments referenced in spec.rabbitmq.additionalConfigFrom, in order.
+// It returns an error if a referenced object or key does not exist.
+func ReadAdditionalConfigFrom(ctx context.Context, k8sClient client.Reader, instance *rabbitmqv1beta1.RabbitmqCluster) ([][]byte, error) {
+	fragments := make([][]byte, 0, len(instance.Spec.Rabbitmq.AdditionalConfigFrom))
+	for _, source := range instance.Spec.Rabbitmq.AdditionalConfigFrom {
+		var (
+			fragment  []byte
+			ok        bool
+			kind, key string
+		)
+		name := additionalConfigSourceName(source)
+		switch {
+		case source.ConfigMapKeyRef != nil:
+			kind, key = "ConfigMap", source.ConfigMapKeyRef.Key
+			configMap := &corev1.ConfigMap{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: instance.Namespace}, configMap); err != nil {
+				return nil, fmt.Errorf("failed to get additionalConfigFrom ConfigMap %s: %w", name, err)
+			}
+			var value string
+			value, ok = configMap.Data[key]
+			fragment = []byte(value)
+		case source.SecretKeyRef != nil:
+			kind, key = "Secret", source.SecretKeyRef.Key
+			secret := &corev1.Secret{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: instance.Namespace}, secret); err != nil {
+				return nil, fmt.Errorf("failed to get additionalConfigFrom Secret %s: %w", name, err)
+			}
+			fragment, ok = secret.Data[key]
+		default:
+			continue
+		}
+		if !ok {
+			return nil, fmt.Errorf("key %s of additionalConfigFrom %s %s not found", key, kind, name)
+		}
+		fragments = append(fragments, fragment)
+	}
+	return fragments, nil
+}
+
+// AdditionalConfigFromHash returns the SHA-256 hash of the rabbitmq.conf fragments, or an empty string if there are none
+func AdditionalConfigFromHash(fragments [][]byte) string {
+	return contentHash(fragments)
+}
+
+// contentHash returns the SHA-256 hash of the contents, or an empty string if there are none
+func contentHash(contents [][]byte) string {
+	if len(contents) == 0 {
+		return ""
+	}
+	hash := sha256.New()
+	for _, content := range contents {
+		hash.Write(content)
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func additionalConfigSourceName(source rabbitmqv1beta1.AdditionalConfigSource) string {
+	if source.ConfigMapKeyRef != nil {
+		return source.ConfigMapKeyRef.Name
+	}
+	return source.SecretKeyRef.Name
+}
+
+// appendAdditionalConfigFromProjections adds the fragments of spec.rabbitmq.additionalConfigFrom to the conf.d volume
+func appendAdditionalConfigFromProjections(volumes []corev1.Volume, instance *rabbitmqv1beta1.RabbitmqCluster) {
+	for _, value := range volumes {
+		if value.Name != "rabbitmq-confd" {
+			continue
+		}
+		for i, source := range instance.Spec.Rabbitmq.AdditionalConfigFrom {
+			switch {
+			case source.ConfigMapKeyRef != nil:
+				value.Projected.Sources = append(value.Projected.Sources, corev1.VolumeProjection{
+					ConfigMap: &corev1.ConfigMapProjection{
+						LocalObjectReference: source.ConfigMapKeyRef.LocalObjectReference,
+						Items:                []corev1.KeyToPath{{Key: source.ConfigMapKeyRef.Key, Path: additionalConfigFromFilename(i)}},
+					},
+				})
+			case source.SecretKeyRef != nil:
+				value.Projected.Sources = append(value.Projected.Sources, secretKeyProjection(*source.SecretKeyRef, additionalConfigFromFilename(i)))
+			}
+		}
+	}
+}
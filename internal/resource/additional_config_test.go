@@ -0,0 +1,104 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+//
+
+package resource_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("AdditionalConfigFrom", func() {
+	var (
+		instance  *rabbitmqv1beta1.RabbitmqCluster
+		configMap *corev1.ConfigMap
+		secret    *corev1.Secret
+		k8sClient client.Reader
+	)
+
+	BeforeEach(func() {
+		instance = &rabbitmqv1beta1.RabbitmqCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "rabbit", Namespace: "default"},
+			Spec: rabbitmqv1beta1.RabbitmqClusterSpec{
+				Rabbitmq: rabbitmqv1beta1.RabbitmqClusterConfigurationSpec{
+					AdditionalConfigFrom: []rabbitmqv1beta1.AdditionalConfigSource{
+						{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "tuning"},
+							Key:                  "rabbitmq.conf",
+						}},
+						{SecretKeyRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "shovels"},
+							Key:                  "shovels.conf",
+						}},
+					},
+				},
+			},
+		}
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "tuning", Namespace: "default"},
+			Data:       map[string]string{"rabbitmq.conf": "channel_max = 64"},
+		}
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "shovels", Namespace: "default"},
+			Data:       map[string][]byte{"shovels.conf": []byte("default_pass = secret")},
+		}
+	})
+
+	JustBeforeEach(func() {
+		k8sClient = fake.NewClientBuilder().WithObjects(configMap, secret).Build()
+	})
+
+	Context("ReadAdditionalConfigFrom", func() {
+		It("returns the fragments in order", func(ctx SpecContext) {
+			fragments, err := resource.ReadAdditionalConfigFrom(ctx, k8sClient, instance)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fragments).To(Equal([][]byte{[]byte("channel_max = 64"), []byte("default_pass = secret")}))
+		})
+
+		When("a referenced object does not exist", func() {
+			BeforeEach(func() {
+				secret.Name = "other"
+			})
+
+			It("returns a not found error", func(ctx SpecContext) {
+				_, err := resource.ReadAdditionalConfigFrom(ctx, k8sClient, instance)
+				Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+			})
+		})
+
+		When("a referenced key does not exist", func() {
+			BeforeEach(func() {
+				configMap.Data = map[string]string{"other.conf": ""}
+			})
+
+			It("returns an error", func(ctx SpecContext) {
+				_, err := resource.ReadAdditionalConfigFrom(ctx, k8sClient, instance)
+				Expect(err).To(MatchError("key rabbitmq.conf of additionalConfigFrom ConfigMap tuning not found"))
+			})
+		})
+	})
+
+	Context("AdditionalConfigFromHash", func() {
+		It("depends on the order of the fragments", func() {
+			Expect(resource.AdditionalConfigFromHash([][]byte{[]byte("a"), []byte("b")})).NotTo(
+				Equal(resource.AdditionalConfigFromHash([][]byte{[]byte("b"), []byte("a")})))
+		})
+
+		It("is empty without fragments", func() {
+			Expect(resource.AdditionalConfigFromHash(nil)).To(BeEmpty())
+		})
+	})
+})
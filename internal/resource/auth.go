@@ -0,0 +1,339 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+//
+
+package resource
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/metadata"
+	"gopkg.in/ini.v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AuthSecretsHashAnnotation is set on the server ConfigMap to the hash of the Secrets rendering the credentials of spec.auth,
+// so that the nodes are restarted when a credential changes
+const AuthSecretsHashAnnotation = "rabbitmq.com/authSecretsSHA256"
+
+const (
+	// OAuth2SecretName is the Secret holding the rabbitmq.conf settings of spec.auth.oauth2 which are secret
+	OAuth2SecretName   = "oauth2"
+	OAuth2ConfFilename = "oauth2.conf"
+	oauth2Dir          = "/etc/rabbitmq-oauth2/"
+	oauth2VolumeName   = "rabbitmq-oauth2"
+	// LDAPSecretName is the Secret holding the rabbitmq.conf settings of spec.auth.ldap which are secret
+	LDAPSecretName         = "ldap"
+	LDAPConfFilename       = "ldap.conf"
+	ldapDir                = "/etc/rabbitmq-ldap/"
+	ldapVolumeName         = "rabbitmq-ldap"
+	internalAuthBackend    = "rabbit_auth_backend_internal"
+	oauth2AuthBackend      = "rabbit_auth_backend_oauth2"
+	ldapAuthBackend        = "rabbit_auth_backend_ldap"
+	authBackendsKeyPrefix  = "auth_backends."
+	authMechanismKeyPrefix = "auth_mechanisms"
+)
+
+// authBackendAliases are the short names rabbitmq.conf accepts for the authentication backends
+var authBackendAliases = map[string]string{
+	"internal": internalAuthBackend,
+	"oauth2":   oauth2AuthBackend,
+	"ldap":     ldapAuthBackend,
+}
+
+// authVolumeMountPaths are the directories the key material of spec.auth is mounted in
+var authVolumeMountPaths = map[string]string{
+	oauth2VolumeName: oauth2Dir,
+	ldapVolumeName:   ldapDir,
+}
+
+// authBackends returns the authentication backends spec.auth requires, in order, or nil to keep the default
+// of RabbitMQ. Tokens are tried before passwords, and the internal database comes last, since it holds
+// the default user the operator authenticates as.
+func authBackends(instance *rabbitmqv1beta1.RabbitmqCluster) []string {
+	var backends []string
+	if instance.OAuth2Enabled() {
+		backends = append(backends, oauth2AuthBackend)
+	}
+	if instance.LDAPEnabled() {
+		backends = append(backends, ldapAuthBackend)
+	}
+	if backends == nil {
+		return nil
+	}
+	return append(backends, internalAuthBackend)
+}
+
+// authConf sets the authentication backends and the settings of the backends in spec.auth
+func (builder *RabbitmqResourceBuilder) authConf(section *ini.Section) error {
+	var keys [][2]string
+	for i, backend := range authBackends(builder.Instance) {
+		keys = append(keys, [2]string{fmt.Sprintf("%s%d", authBackendsKeyPrefix, i+1), backend})
+	}
+	if builder.Instance.OAuth2Enabled() {
+		keys = append(keys, oauth2Conf(builder.Instance.Spec.Auth.OAuth2)...)
+	}
+	if builder.Instance.LDAPEnabled() {
+		keys = append(keys, ldapConf(builder.Instance.Spec.Auth.LDAP)...)
+	}
+	for _, key := range keys {
+		if _, err := section.NewKey(key[0], key[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func oauth2Conf(oauth2 *rabbitmqv1beta1.OAuth2Spec) [][2]string {
+	keys := [][2]string{{"auth_oauth2.resource_server_id", oauth2.ResourceServerID}}
+	if oauth2.IssuerURL != "" {
+		keys = append(keys, [2]string{"auth_oauth2.issuer", oauth2.IssuerURL})
+	}
+	if oauth2.JWKSURL != "" {
+		keys = append(keys, [2]string{"auth_oauth2.jwks_url", oauth2.JWKSURL})
+	}
+	if oauth2.CACertificate != nil {
+		keys = append(keys,
+			[2]string{"auth_oauth2.https.cacertfile", oauth2Dir + caCertFilename},
+			[2]string{"auth_oauth2.https.peer_verification", "verify_peer"},
+		)
+	}
+	for _, key := range oauth2.SigningKeys {
+		keys = append(keys, [2]string{"auth_oauth2.signing_keys." + key.ID, oauth2Dir + oauth2SigningKeyPath(key)})
+	}
+	if oauth2.DefaultSigningKey != "" {
+		keys = append(keys, [2]string{"auth_oauth2.default_key", oauth2.DefaultSigningKey})
+	}
+	for i, claim := range oauth2.PreferredUsernameClaims {
+		keys = append(keys, [2]string{fmt.Sprintf("auth_oauth2.preferred_username_claims.%d", i+1), claim})
+	}
+	if oauth2.AdditionalScopesKey != "" {
+		keys = append(keys, [2]string{"auth_oauth2.additional_scopes_key", oauth2.AdditionalScopesKey})
+	}
+	// aliases are indexed, since scopes of providers commonly contain dots
+	for i, alias := range slices.Sorted(maps.Keys(oauth2.ScopeAliases)) {
+		keys = append(keys,
+			[2]string{fmt.Sprintf("auth_oauth2.scope_aliases.%d.alias", i+1), alias},
+			[2]string{fmt.Sprintf("auth_oauth2.scope_aliases.%d.scope", i+1), oauth2.ScopeAliases[alias]},
+		)
+	}
+
+	if management := oauth2.Management; management != nil {
+		keys = append(keys,
+			[2]string{"management.oauth_enabled", "true"},
+			[2]string{"management.oauth_client_id", management.ClientID},
+		)
+		if management.Scopes != "" {
+			keys = append(keys, [2]string{"management.oauth_scopes", management.Scopes})
+		}
+		if management.ProviderURL != "" {
+			keys = append(keys, [2]string{"management.oauth_provider_url", management.ProviderURL})
+		}
+	}
+	return keys
+}
+
+func ldapConf(ldap *rabbitmqv1beta1.LDAPSpec) [][2]string {
+	var keys [][2]string
+	for i, server := range ldap.Servers {
+		keys = append(keys, [2]string{fmt.Sprintf("auth_ldap.servers.%d", i+1), server})
+	}
+	port := ldap.Port
+	if port == nil && ldap.TLS != nil && !ldap.TLS.StartTLS {
+		port = new(int32(636))
+	}
+	if port != nil {
+		keys = append(keys, [2]string{"auth_ldap.port", strconv.Itoa(int(*port))})
+	}
+	if tls := ldap.TLS; tls != nil {
+		if tls.StartTLS {
+			keys = append(keys, [2]string{"auth_ldap.use_starttls", "true"})
+		} else {
+			keys = append(keys, [2]string{"auth_ldap.use_ssl", "true"})
+		}
+		if tls.CACertificate != nil {
+			keys = append(keys, [2]string{"auth_ldap.ssl_options.cacertfile", ldapDir + caCertFilename})
+		}
+		keys = append(keys, [2]string{"auth_ldap.ssl_options.verify", "verify_peer"})
+	}
+	if ldap.UserDNPattern != "" {
+		keys = append(keys, [2]string{"auth_ldap.user_dn_pattern", ldap.UserDNPattern})
+	}
+	if lookup := ldap.DNLookup; lookup != nil {
+		keys = append(keys,
+			[2]string{"auth_ldap.dn_lookup_attribute", lookup.Attribute},
+			[2]string{"auth_ldap.dn_lookup_base", lookup.Base},
+		)
+	}
+	// the passwords of the bind user are in the LDAP Secret
+	if ldap.BindDN != "" {
+		if ldap.DNLookup != nil {
+			keys = append(keys, [2]string{"auth_ldap.dn_lookup_bind.user_dn", ldap.BindDN})
+		}
+		keys = append(keys, [2]string{"auth_ldap.other_bind.user_dn", ldap.BindDN})
+	}
+	return keys
+}
+
+func oauth2SigningKeyPath(key rabbitmqv1beta1.OAuth2SigningKey) string {
+	return "signing-keys/" + key.ID + ".pem"
+}
+
+// oauth2Volume projects the CA certificate and the signing keys of spec.auth.oauth2, or returns nil if none is set
+func oauth2Volume(instance *rabbitmqv1beta1.RabbitmqCluster) *corev1.Volume {
+	oauth2 := instance.Spec.Auth.OAuth2
+	if oauth2 == nil || (oauth2.CACertificate == nil && len(oauth2.SigningKeys) == 0) {
+		return nil
+	}
+	var sources []corev1.VolumeProjection
+	if oauth2.CACertificate != nil {
+		sources = append(sources, secretKeyProjection(*oauth2.CACertificate, caCertFilename))
+	}
+	for _, key := range oauth2.SigningKeys {
+		sources = append(sources, secretKeyProjection(key.SecretKeyRef, oauth2SigningKeyPath(key)))
+	}
+	return &corev1.Volume{
+		Name:         oauth2VolumeName,
+		VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{Sources: sources}},
+	}
+}
+
+// ldapVolume projects the CA certificate of spec.auth.ldap.tls, or returns nil if none is set
+func ldapVolume(instance *rabbitmqv1beta1.RabbitmqCluster) *corev1.Volume {
+	ldap := instance.Spec.Auth.LDAP
+	if ldap == nil || ldap.TLS == nil || ldap.TLS.CACertificate == nil {
+		return nil
+	}
+	return &corev1.Volume{
+		Name: ldapVolumeName,
+		VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{
+			Sources: []corev1.VolumeProjection{secretKeyProjection(*ldap.TLS.CACertificate, caCertFilename)},
+		}},
+	}
+}
+
+func secretKeyProjection(selector corev1.SecretKeySelector, path string) corev1.VolumeProjection {
+	return corev1.VolumeProjection{Secret: &corev1.SecretProjection{
+		LocalObjectReference: selector.LocalObjectReference,
+		Items:                []corev1.KeyToPath{{Key: selector.Key, Path: path}},
+		Optional:             selector.Optional,
+	}}
+}
+
+// OAuth2Secret returns the Secret holding the client secret of the management UI in rabbitmq.conf format,
+// which is mounted in the conf.d directory of the nodes
+func OAuth2Secret(instance *rabbitmqv1beta1.RabbitmqCluster, clientSecret string) (*corev1.Secret, error) {
+	clientSecret, err := confValue(clientSecret)
+	if err != nil {
+		return nil, err
+	}
+	return authSecret(instance, OAuth2SecretName, OAuth2ConfFilename,
+		fmt.Sprintf("management.oauth_client_secret = %s\n", clientSecret)), nil
+}
+
+// LDAPSecret returns the Secret holding the password of the LDAP bind user in rabbitmq.conf format,
+// which is mounted in the conf.d directory of the nodes
+func LDAPSecret(instance *rabbitmqv1beta1.RabbitmqCluster, bindPassword string) (*corev1.Secret, error) {
+	bindPassword, err := confValue(bindPassword)
+	if err != nil {
+		return nil, err
+	}
+	conf := fmt.Sprintf("auth_ldap.other_bind.password = %s\n", bindPassword)
+	if instance.Spec.Auth.LDAP.DNLookup != nil {
+		conf = fmt.Sprintf("auth_ldap.dn_lookup_bind.password = %s\n", bindPassword) + conf
+	}
+	return authSecret(instance, LDAPSecretName, LDAPConfFilename, conf), nil
+}
+
+// AuthSecretsHash returns the SHA-256 hash of the rendered auth Secrets, or an empty string if there are none
+func AuthSecretsHash(confs [][]byte) string {
+	return contentHash(confs)
+}
+
+// confValue trims a secret value for a rabbitmq.conf setting, and rejects values spanning several lines,
+// which would add settings to rabbitmq.conf
+func confValue(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if strings.ContainsAny(value, "\r\n") {
+		return "", errors.New("the value must not contain line breaks")
+	}
+	return value, nil
+}
+
+func authSecret(instance *rabbitmqv1beta1.RabbitmqCluster, name, filename, conf string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      instance.ChildResourceName(name),
+			Namespace: instance.Namespace,
+			Labels:    metadata.GetLabels(instance.Name, instance.Labels),
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{filename: []byte(conf)},
+	}
+}
+
+// ValidateAuthConfig checks that spec.rabbitmq.additionalConfig keeps the backends spec.auth requires, since its
+// auth_backends keys override the ones set by the operator index by index, and that it keeps a mechanism clients
+// can present OAuth 2.0 tokens and LDAP passwords with if it sets auth_mechanisms.
+func ValidateAuthConfig(instance *rabbitmqv1beta1.RabbitmqCluster) error {
+	required := authBackends(instance)
+	if required == nil {
+		return nil
+	}
+	cfg, err := ini.Load([]byte(instance.Spec.Rabbitmq.AdditionalConfig))
+	if err != nil {
+		return fmt.Errorf("failed to load spec.rabbitmq.additionalConfig: %w", err)
+	}
+	section := cfg.Section("")
+
+	backends := map[string]string{}
+	for i, backend := range required {
+		backends[strconv.Itoa(i+1)] = backend
+	}
+	for _, key := range section.KeyStrings() {
+		if !strings.HasPrefix(key, authBackendsKeyPrefix) {
+			continue
+		}
+		backend := section.Key(key).String()
+		if alias, ok := authBackendAliases[backend]; ok {
+			backend = alias
+		}
+		// auth_backends.1, or auth_backends.1.authn and auth_backends.1.authz which replace auth_backends.1
+		suffix := strings.TrimPrefix(key, authBackendsKeyPrefix)
+		if index, _, split := strings.Cut(suffix, "."); split {
+			delete(backends, index)
+		}
+		backends[suffix] = backend
+	}
+	configured := slices.Collect(maps.Values(backends))
+	var missing []string
+	for _, backend := range required {
+		if !slices.Contains(configured, backend) {
+			missing = append(missing, backend)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("auth_backends must include %s, which spec.auth requires", strings.Join(missing, " and "))
+	}
+
+	mechanisms, err := authMechanisms(instance.Spec.Rabbitmq.AdditionalConfig)
+	if err != nil {
+		return err
+	}
+	if len(mechanisms) > 0 && !slices.Contains(mechanisms, "PLAIN") && !slices.Contains(mechanisms, "AMQPLAIN") {
+		return fmt.Errorf("auth_mechanisms must include PLAIN or AMQPLAIN, which clients present OAuth 2.0 tokens and LDAP passwords with")
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+//
+
+package resource_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Auth", func() {
+	var instance *rabbitmqv1beta1.RabbitmqCluster
+
+	BeforeEach(func() {
+		instance = &rabbitmqv1beta1.RabbitmqCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "rabbit", Namespace: "a-namespace"},
+			Spec: rabbitmqv1beta1.RabbitmqClusterSpec{
+				Auth: rabbitmqv1beta1.AuthSpec{
+					OAuth2: &rabbitmqv1beta1.OAuth2Spec{ResourceServerID: "rabbitmq", IssuerURL: "https://idp.example.com"},
+				},
+			},
+		}
+	})
+
+	Context("ValidateAuthConfig", func() {
+		It("accepts the backends set by the operator", func() {
+			Expect(resource.ValidateAuthConfig(instance)).To(Succeed())
+		})
+
+		It("accepts additionalConfig splitting a backend into authn and authz", func() {
+			instance.Spec.Rabbitmq.AdditionalConfig = "auth_backends.1.authn = oauth2\nauth_backends.1.authz = rabbit_auth_backend_cache"
+			Expect(resource.ValidateAuthConfig(instance)).To(Succeed())
+		})
+
+		It("rejects additionalConfig replacing the internal database", func() {
+			instance.Spec.Rabbitmq.AdditionalConfig = "auth_backends.2 = http"
+			Expect(resource.ValidateAuthConfig(instance)).To(MatchError(ContainSubstring("auth_backends must include rabbit_auth_backend_internal")))
+		})
+
+		It("accepts auth_mechanisms including PLAIN", func() {
+			instance.Spec.Rabbitmq.AdditionalConfig = "auth_mechanisms.1 = PLAIN\nauth_mechanisms.2 = EXTERNAL"
+			Expect(resource.ValidateAuthConfig(instance)).To(Succeed())
+		})
+
+		It("rejects additionalConfig dropping the LDAP backend", func() {
+			instance.Spec.Auth.LDAP = &rabbitmqv1beta1.LDAPSpec{Servers: []string{"ldap.example.com"}}
+			instance.Spec.Rabbitmq.AdditionalConfig = "auth_backends.2 = internal"
+			Expect(resource.ValidateAuthConfig(instance)).To(MatchError(ContainSubstring("auth_backends must include rabbit_auth_backend_ldap")))
+		})
+
+		It("ignores additionalConfig when spec.auth is not set", func() {
+			instance.Spec.Auth.OAuth2 = nil
+			instance.Spec.Rabbitmq.AdditionalConfig = "auth_backends.1 = ldap\nauth_mechanisms.1 = EXTERNAL"
+			Expect(resource.ValidateAuthConfig(instance)).To(Succeed())
+		})
+	})
+
+	Context("OAuth2Secret", func() {
+		It("renders the client secret of the management UI in rabbitmq.conf format", func() {
+			secret, err := resource.OAuth2Secret(instance, "s3cr3t\n")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(secret.Name).To(Equal("rabbit-oauth2"))
+			Expect(secret.Namespace).To(Equal("a-namespace"))
+			Expect(secret.Type).To(Equal(corev1.SecretTypeOpaque))
+			Expect(secret.Data).To(HaveKeyWithValue("oauth2.conf", []byte("management.oauth_client_secret = s3cr3t\n")))
+		})
+
+		It("rejects client secrets spanning several lines", func() {
+			_, err := resource.OAuth2Secret(instance, "s3cr3t\nauth_backends.1 = anonymous")
+			Expect(err).To(MatchError(ContainSubstring("line breaks")))
+		})
+	})
+
+	Context("LDAPSecret", func() {
+		BeforeEach(func() {
+			instance.Spec.Auth.LDAP = &rabbitmqv1beta1.LDAPSpec{
+				Servers: []string{"ldap.example.com"},
+				BindDN:  "cn=rabbitmq,dc=example,dc=com",
+			}
+		})
+
+		It("renders the bind password for authorisation queries", func() {
+			secret, err := resource.LDAPSecret(instance, "p4ss")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(secret.Name).To(Equal("rabbit-ldap"))
+			Expect(secret.Data).To(HaveKeyWithValue("ldap.conf", []byte("auth_ldap.other_bind.password = p4ss\n")))
+		})
+
+		It("renders the bind password for the lookup of users", func() {
+			instance.Spec.Auth.LDAP.DNLookup = &rabbitmqv1beta1.LDAPDNLookupSpec{Attribute: "uid", Base: "dc=example,dc=com"}
+			secret, err := resource.LDAPSecret(instance, "p4ss")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(secret.Data).To(HaveKeyWithValue("ldap.conf", []byte(
+				"auth_ldap.dn_lookup_bind.password = p4ss\nauth_ldap.other_bind.password = p4ss\n")))
+		})
+
+		It("rejects bind passwords spanning several lines", func() {
+			_, err := resource.LDAPSecret(instance, "p4ss\r\nauth_ldap.log = network")
+			Expect(err).To(MatchError(ContainSubstring("line breaks")))
+		})
+	})
+})
@@ -21,6 +21,7 @@ import (
 
 	"gopkg.in/ini.v1"
 
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
 	"github.com/rabbitmq/cluster-operator/v2/internal/metadata"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
@@ -58,15 +59,44 @@ prometheus.ssl.port      = 15691
 	tlsCertPath     = tlsCertDir + tlsCertFilename
 	tlsKeyFilename  = "tls.key"
 	tlsKeyPath      = tlsCertDir + tlsKeyFilename
+
+	InterNodeTLSConfigFilename = "inter_node_tls.config"
+	interNodeTLSConfigPath     = "/etc/rabbitmq/" + InterNodeTLSConfigFilename
+	// Nodes verify each other's certificate against the CA, and check that it is valid for the host name of the peer.
+	interNodeTLSConfig = `[
+  {server, [
+    {cacertfile, "` + caCertPath + `"},
+    {certfile, "` + tlsCertPath + `"},
+    {keyfile, "` + tlsKeyPath + `"},
+    {secure_renegotiate, true},
+    {fail_if_no_peer_cert, true},
+    {verify, verify_peer}
+  ]},
+  {client, [
+    {cacertfile, "` + caCertPath + `"},
+    {certfile, "` + tlsCertPath + `"},
+    {keyfile, "` + tlsKeyPath + `"},
+    {secure_renegotiate, true},
+    {verify, verify_peer},
+    {customize_hostname_check, [
+      {match_fun, public_key:pkix_verify_hostname_match_fun(https)}
+    ]}
+  ]}
+].
+`
 )
 
 type ServerConfigMapBuilder struct {
 	*RabbitmqResourceBuilder
 	UpdateRequiresStsRestart bool
+	// RuntimeConfigKeys are the changed keys which can be applied on the running nodes, if the update does not require a restart
+	RuntimeConfigKeys []string
+	// RestartConfigKeys are the changed keys and files which require a restart
+	RestartConfigKeys []string
 }
 
 func (builder *RabbitmqResourceBuilder) ServerConfigMap() *ServerConfigMapBuilder {
-	return &ServerConfigMapBuilder{builder, true}
+	return &ServerConfigMapBuilder{RabbitmqResourceBuilder: builder, UpdateRequiresStsRestart: true}
 }
 
 func (builder *ServerConfigMapBuilder) Build() (client.Object, error) {
@@ -103,6 +133,20 @@ func (builder *ServerConfigMapBuilder) Update(object client.Object) error {
 		return err
 	}
 
+	if pathPrefix := builder.Instance.Spec.Management.PathPrefix; pathPrefix != "" {
+		if _, err := defaultSection.NewKey("management.path_prefix", pathPrefix); err != nil {
+			return err
+		}
+	}
+
+	if err := builder.replicationConf(defaultSection); err != nil {
+		return err
+	}
+
+	if err := builder.authConf(defaultSection); err != nil {
+		return err
+	}
+
 	rmqProperties := builder.Instance.Spec.Rabbitmq
 	authMechsConfigured, err := areAuthMechanismsConfigued(rmqProperties.AdditionalConfig)
 	if err != nil {
@@ -214,7 +258,7 @@ func (builder *ServerConfigMapBuilder) Update(object client.Object) error {
 				return err
 			}
 		}
-		if builder.Instance.AdditionalPluginEnabled("rabbitmq_stream") {
+		if builder.streamNeeded() {
 			if _, err := userConfigurationSection.NewKey("stream.listeners.ssl.default", "5551"); err != nil {
 				return err
 			}
@@ -259,12 +303,37 @@ func (builder *ServerConfigMapBuilder) Update(object client.Object) error {
 		}
 	}
 
+	if builder.Instance.TLSEnabled() {
+		// Appended as sources rather than set with NewKey, so that the listener certificates and TLS options
+		// override the defaults above when the user configuration is appended and the file is reloaded
+		if err := userConfiguration.Append([]byte(listenerTLSConf(builder.Instance))); err != nil {
+			return err
+		}
+		if err := userConfiguration.Append([]byte(tlsOptionsConf(builder.Instance))); err != nil {
+			return err
+		}
+	}
+
 	if builder.Instance.MemoryLimited() {
 		if _, err := userConfigurationSection.NewKey("total_memory_available_override_value", fmt.Sprintf("%d", removeHeadroom(builder.Instance.Spec.Resources.Limits.Memory().Value()))); err != nil {
 			return err
 		}
 	}
 
+	if definitions := rmqProperties.Definitions; definitions != nil {
+		if _, err := userConfigurationSection.NewKey("definitions.import_backend", "local_filesystem"); err != nil {
+			return err
+		}
+		if _, err := userConfigurationSection.NewKey("definitions.local.path", DefinitionsPath); err != nil {
+			return err
+		}
+		if definitions.SkipIfUnchanged {
+			if _, err := userConfigurationSection.NewKey("definitions.skip_if_unchanged", "true"); err != nil {
+				return err
+			}
+		}
+	}
+
 	var rmqConfBuffer strings.Builder
 	if _, err := operatorConfiguration.WriteTo(&rmqConfBuffer); err != nil {
 		return err
@@ -293,9 +362,20 @@ func (builder *ServerConfigMapBuilder) Update(object client.Object) error {
 	}
 
 	updateProperty(configMap.Data, "advanced.config", rmqProperties.AdvancedConfig)
-	updateProperty(configMap.Data, "rabbitmq-env.conf", rmqProperties.EnvConfig)
+	updateProperty(configMap.Data, "rabbitmq-env.conf", envConfig(builder.Instance))
 	updateProperty(configMap.Data, "erl_inetrc", rmqProperties.ErlangInetConfig)
 
+	if builder.Instance.InterNodeTLSEnabled() {
+		configMap.Data[InterNodeTLSConfigFilename] = interNodeTLSConfig
+	} else {
+		delete(configMap.Data, InterNodeTLSConfigFilename)
+	}
+
+	// compared with the previous ConfigMap below, so that changes to the content of additionalConfigFrom
+	// and to the credentials of spec.auth restart the nodes
+	setHashAnnotation(configMap, AdditionalConfigFromHashAnnotation, builder.AdditionalConfigFromHash)
+	setHashAnnotation(configMap, AuthSecretsHashAnnotation, builder.AuthSecretsHash)
+
 	if err := controllerutil.SetControllerReference(builder.Instance, configMap, builder.Scheme); err != nil {
 		return fmt.Errorf("failed setting controller reference: %w", err)
 	}
@@ -307,8 +387,16 @@ func (builder *ServerConfigMapBuilder) Update(object client.Object) error {
 	if err := removeConfigNotRequiringNodeRestart(updatedConfigMap); err != nil {
 		return err
 	}
+	runtimeConfigKeys, err := removeRuntimeConfigChanges(previousConfigMap, updatedConfigMap, len(rmqProperties.AdditionalConfigFrom) > 0 || builder.RestartOnRuntimeConfigChange)
+	if err != nil {
+		return err
+	}
+	builder.RuntimeConfigKeys, builder.RestartConfigKeys = nil, nil
 	if equality.Semantic.DeepEqual(previousConfigMap, updatedConfigMap) {
 		builder.UpdateRequiresStsRestart = false
+		builder.RuntimeConfigKeys = runtimeConfigKeys
+	} else {
+		builder.RestartConfigKeys = changedConfig(previousConfigMap, updatedConfigMap)
 	}
 
 	return nil
@@ -340,6 +428,140 @@ func removeConfigNotRequiringNodeRestart(configMap *corev1.ConfigMap) error {
 	return nil
 }
 
+// tlsListener is a group of listeners using its own certificate, configured in spec.tls.listeners
+type tlsListener struct {
+	name string
+	spec *rabbitmqv1beta1.ListenerTLSSpec
+	// prefixes of the rabbitmq.conf keys setting the certificate files of the listeners in the group
+	configPrefixes []string
+}
+
+// certDir is the directory the listener's certificate is mounted at. It is not nested in tlsCertDir,
+// since that directory is a read-only projected volume.
+func (listener tlsListener) certDir() string {
+	return "/etc/rabbitmq-tls-" + listener.name + "/"
+}
+
+func (listener tlsListener) volumeName() string {
+	return "rabbitmq-tls-" + listener.name
+}
+
+func tlsListeners(instance *rabbitmqv1beta1.RabbitmqCluster) []tlsListener {
+	listenersSpec := instance.Spec.TLS.Listeners
+	if listenersSpec == nil {
+		return nil
+	}
+
+	var listeners []tlsListener
+	if listenersSpec.AMQP != nil {
+		listeners = append(listeners, tlsListener{name: "amqp", spec: listenersSpec.AMQP, configPrefixes: []string{"ssl_options"}})
+	}
+	if listenersSpec.Management != nil {
+		listeners = append(listeners, tlsListener{name: "management", spec: listenersSpec.Management, configPrefixes: []string{"management.ssl"}})
+	}
+	if listenersSpec.Prometheus != nil {
+		listeners = append(listeners, tlsListener{name: "prometheus", spec: listenersSpec.Prometheus, configPrefixes: []string{"prometheus.ssl"}})
+	}
+	if listenersSpec.Web != nil {
+		listeners = append(listeners, tlsListener{name: "web", spec: listenersSpec.Web, configPrefixes: webTLSConfigPrefixes(instance)})
+	}
+	return listeners
+}
+
+// ListenerCertificate is the certificate of a group of listeners configured in spec.tls.listeners
+type ListenerCertificate struct {
+	Spec *rabbitmqv1beta1.ListenerTLSSpec
+	// directory the certificate is mounted at in the rabbitmq container
+	MountPath string
+}
+
+// ListenerCertificates returns the certificates of the groups of listeners configured in spec.tls.listeners
+func ListenerCertificates(instance *rabbitmqv1beta1.RabbitmqCluster) []ListenerCertificate {
+	var certificates []ListenerCertificate
+	for _, listener := range tlsListeners(instance) {
+		certificates = append(certificates, ListenerCertificate{Spec: listener.spec, MountPath: listener.certDir()})
+	}
+	return certificates
+}
+
+// webTLSConfigPrefixes returns the prefixes of the rabbitmq.conf TLS settings of the enabled web plugins
+func webTLSConfigPrefixes(instance *rabbitmqv1beta1.RabbitmqCluster) []string {
+	var prefixes []string
+	if instance.AdditionalPluginEnabled("rabbitmq_web_mqtt") {
+		prefixes = append(prefixes, "web_mqtt.ssl")
+	}
+	if instance.AdditionalPluginEnabled("rabbitmq_web_stomp") {
+		prefixes = append(prefixes, "web_stomp.ssl")
+	}
+	if instance.AdditionalPluginEnabled("rabbitmq_web_amqp") {
+		prefixes = append(prefixes, "web_amqp.tls")
+	}
+	return prefixes
+}
+
+// tlsOptionsConf applies spec.tls.options to every TLS listener.
+// The stream, MQTT and STOMP listeners use ssl_options like the AMQP listener.
+// Client certificates are only verified by these messaging listeners, since the operator
+// does not present a client certificate when connecting to the management listener.
+func tlsOptionsConf(instance *rabbitmqv1beta1.RabbitmqCluster) string {
+	options := instance.Spec.TLS.Options
+	if options == nil {
+		return ""
+	}
+
+	var conf strings.Builder
+	prefixes := append([]string{"ssl_options", "management.ssl", "prometheus.ssl"}, webTLSConfigPrefixes(instance)...)
+	for _, prefix := range prefixes {
+		for i, version := range options.Versions {
+			fmt.Fprintf(&conf, "%s.versions.%d = %s\n", prefix, i+1, version)
+		}
+		for i, cipher := range options.Ciphers {
+			fmt.Fprintf(&conf, "%s.ciphers.%d = %s\n", prefix, i+1, cipher)
+		}
+		if options.HonorCipherOrder != nil {
+			fmt.Fprintf(&conf, "%s.honor_cipher_order = %t\n", prefix, *options.HonorCipherOrder)
+		}
+	}
+	if options.Verify != "" {
+		fmt.Fprintf(&conf, "ssl_options.verify = %s\n", options.Verify)
+	}
+	if options.FailIfNoPeerCert != nil {
+		fmt.Fprintf(&conf, "ssl_options.fail_if_no_peer_cert = %t\n", *options.FailIfNoPeerCert)
+	}
+	return conf.String()
+}
+
+// listenerTLSConf points the listeners in spec.tls.listeners at their own certificate files
+func listenerTLSConf(instance *rabbitmqv1beta1.RabbitmqCluster) string {
+	var conf strings.Builder
+	for _, listener := range tlsListeners(instance) {
+		for _, prefix := range listener.configPrefixes {
+			fmt.Fprintf(&conf, "%s.certfile = %s\n", prefix, listener.certDir()+tlsCertFilename)
+			fmt.Fprintf(&conf, "%s.keyfile = %s\n", prefix, listener.certDir()+tlsKeyFilename)
+			if listener.spec.CaSecretName == "" {
+				continue
+			}
+			fmt.Fprintf(&conf, "%s.cacertfile = %s\n", prefix, listener.certDir()+caCertFilename)
+			if prefix == "ssl_options" {
+				conf.WriteString("ssl_options.verify = verify_peer\n")
+			}
+		}
+	}
+	return conf.String()
+}
+
+// setHashAnnotation sets the annotation to the hash, or removes it if the hash is empty
+func setHashAnnotation(configMap *corev1.ConfigMap, annotation, hash string) {
+	if hash == "" {
+		delete(configMap.Annotations, annotation)
+		return
+	}
+	if configMap.Annotations == nil {
+		configMap.Annotations = map[string]string{}
+	}
+	configMap.Annotations[annotation] = hash
+}
+
 func updateProperty(configMapData map[string]string, key string, value string) {
 	if value == "" {
 		delete(configMapData, key)
@@ -359,18 +581,25 @@ func removeHeadroom(memLimit int64) int64 {
 }
 
 func areAuthMechanismsConfigued(additionalConfig string) (bool, error) {
+	mechanisms, err := authMechanisms(additionalConfig)
+	return len(mechanisms) > 0, err
+}
+
+// authMechanisms returns the SASL mechanisms set in additionalConfig
+func authMechanisms(additionalConfig string) ([]string, error) {
 	iniFile, err := ini.Load([]byte(additionalConfig))
 	if err != nil {
-		return false, fmt.Errorf("failed to load spec.rabbitmq.additionalConfig: %w", err)
+		return nil, fmt.Errorf("failed to load spec.rabbitmq.additionalConfig: %w", err)
 	}
 
 	section := iniFile.Section("")
+	var mechanisms []string
 	for _, key := range section.KeyStrings() {
-		if strings.HasPrefix(key, "auth_mechanisms") {
-			return true, nil
+		if strings.HasPrefix(key, authMechanismKeyPrefix) {
+			mechanisms = append(mechanisms, section.Key(key).String())
 		}
 	}
-	return false, nil
+	return mechanisms, nil
 }
 
 var ErrInvalidEnvConfig = errors.New("spec.rabbitmq.envConfig must not contain shell command substitution ('$(...)' or backticks)")
@@ -158,6 +158,15 @@ var _ = Describe("GenerateServerConfigMap", func() {
 			Expect(operatorDefaultConf.Section("").KeysHash()).To(HaveKeyWithValue("cluster_formation.target_cluster_size_hint", "100"))
 		})
 
+		It("sets the management path prefix", func() {
+			builder.Instance.Spec.Management.PathPrefix = "/rabbitmq"
+
+			Expect(configMapBuilder.Update(configMap)).To(Succeed())
+			operatorDefaultConf, err := ini.Load([]byte(configMap.Data["operatorDefaults.conf"]))
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(operatorDefaultConf.Section("").KeysHash()).To(HaveKeyWithValue("management.path_prefix", "/rabbitmq"))
+		})
+
 		When("valid userDefinedConfiguration is provided", func() {
 			It("adds configurations in a new rabbitmq configuration", func() {
 				userDefinedConfiguration := "cluster_formation.peer_discovery_backend = my-backend\n" +
@@ -241,6 +250,24 @@ CONSOLE_LOG=new`
 				Expect(configMap.Data).ToNot(HaveKey("rabbitmq-env.conf"))
 			})
 
+			It("appends the directory of pluginSources to the plugins directories", func() {
+				instance.Spec.Rabbitmq.EnvConfig = "RABBITMQ_PLUGINS_DIR=/opt/rabbitmq/plugins:/opt/rabbitmq/community-plugins"
+				instance.Spec.Rabbitmq.PluginSources = []rabbitmqv1beta1.PluginSource{{
+					Name:   "rabbitmq_delayed_message_exchange",
+					URL:    "https://example.com/rabbitmq_delayed_message_exchange-4.1.0.ez",
+					SHA256: "aa0c2ec2b1a1c0a17bb7ea6bc9f7b3e1d1b5e2f5a8c9d0e1f2a3b4c5d6e7f8a9",
+				}}
+
+				Expect(configMapBuilder.Update(configMap)).To(Succeed())
+				Expect(configMap.Data).To(HaveKeyWithValue("rabbitmq-env.conf", `RABBITMQ_PLUGINS_DIR=/opt/rabbitmq/plugins:/opt/rabbitmq/community-plugins
+# added by the operator for spec.rabbitmq.pluginSources
+case ":$RABBITMQ_PLUGINS_DIR:" in
+  *:/operator/plugins:*) ;;
+  *) RABBITMQ_PLUGINS_DIR="${RABBITMQ_PLUGINS_DIR:-${RABBITMQ_HOME:-/opt/rabbitmq}/plugins}:/operator/plugins" ;;
+esac
+`))
+			})
+
 			Context("rabbitmq-env.conf is set", func() {
 				When("new envConf is empty", func() {
 					It("removes rabbitmq-env.conf key from configMap", func() {
@@ -330,6 +357,18 @@ CONSOLE_LOG=new`
 				})
 			})
 
+			When("the RabbitmqCluster is a replication upstream", func() {
+				It("adds the TLS stream listener", func() {
+					instance.Spec.TLS.SecretName = "tls-secret"
+					builder.ReplicationUpstream = true
+
+					Expect(configMapBuilder.Update(configMap)).To(Succeed())
+					userConfiguration, err := ini.Load([]byte(configMap.Data["userDefinedConfiguration.conf"]))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(userConfiguration.Section("").KeysHash()).To(HaveKeyWithValue("stream.listeners.ssl.default", "5551"))
+				})
+			})
+
 			When("Web AMQP, Web MQTT and Web STOMP are enabled", func() {
 				It("adds TLS config for the additional plugins", func() {
 					additionalPlugins := []rabbitmqv1beta1.Plugin{"rabbitmq_web_amqp", "rabbitmq_web_mqtt", "rabbitmq_web_stomp"}
@@ -392,6 +431,337 @@ CONSOLE_LOG=new`
 			})
 		})
 
+		Context("Listener certificates", func() {
+			It("points each listener group at its own certificate", func() {
+				instance.Name = "rabbit-tls"
+				instance.Spec.TLS.SecretName = "tls-secret"
+				instance.Spec.TLS.CaSecretName = "tls-mutual-secret"
+				instance.Spec.Rabbitmq.AdditionalPlugins = []rabbitmqv1beta1.Plugin{"rabbitmq_web_mqtt"}
+				instance.Spec.TLS.Listeners = &rabbitmqv1beta1.TLSListenersSpec{
+					Management: &rabbitmqv1beta1.ListenerTLSSpec{SecretName: "management-tls", CaSecretName: "public-ca"},
+					Web:        &rabbitmqv1beta1.ListenerTLSSpec{SecretName: "web-tls"},
+				}
+
+				expectedConfiguration := iniString(`ssl_options.certfile   = /etc/rabbitmq-tls/tls.crt
+					ssl_options.keyfile    = /etc/rabbitmq-tls/tls.key
+					listeners.ssl.default  = 5671
+					management.ssl.certfile   = /etc/rabbitmq-tls-management/tls.crt
+					management.ssl.keyfile    = /etc/rabbitmq-tls-management/tls.key
+					management.ssl.port       = 15671
+					prometheus.ssl.certfile = /etc/rabbitmq-tls/tls.crt
+					prometheus.ssl.keyfile   = /etc/rabbitmq-tls/tls.key
+					prometheus.ssl.port       = 15691
+					management.tcp.port     = 15672
+					prometheus.tcp.port       = 15692
+					web_mqtt.ssl.port       = 15676
+					web_mqtt.ssl.certfile   = /etc/rabbitmq-tls-web/tls.crt
+					web_mqtt.ssl.keyfile    = /etc/rabbitmq-tls-web/tls.key
+					ssl_options.cacertfile = /etc/rabbitmq-tls/ca.crt
+					ssl_options.verify     = verify_peer
+					management.ssl.cacertfile = /etc/rabbitmq-tls-management/ca.crt
+					prometheus.ssl.cacertfile = /etc/rabbitmq-tls/ca.crt
+					web_mqtt.ssl.cacertfile   = /etc/rabbitmq-tls/ca.crt`)
+
+				Expect(configMapBuilder.Update(configMap)).To(Succeed())
+				Expect(configMap.Data).To(HaveKeyWithValue("userDefinedConfiguration.conf", expectedConfiguration))
+			})
+
+			It("enables peer verification for the AMQP listener with its own CA", func() {
+				instance.Spec.TLS.SecretName = "tls-secret"
+				instance.Spec.TLS.Listeners = &rabbitmqv1beta1.TLSListenersSpec{
+					AMQP: &rabbitmqv1beta1.ListenerTLSSpec{SecretName: "amqp-tls", CaSecretName: "amqp-ca"},
+				}
+
+				expectedConfiguration := iniString(`ssl_options.certfile   = /etc/rabbitmq-tls-amqp/tls.crt
+					ssl_options.keyfile    = /etc/rabbitmq-tls-amqp/tls.key
+					listeners.ssl.default  = 5671
+					management.ssl.certfile   = /etc/rabbitmq-tls/tls.crt
+					management.ssl.keyfile    = /etc/rabbitmq-tls/tls.key
+					management.ssl.port       = 15671
+					prometheus.ssl.certfile = /etc/rabbitmq-tls/tls.crt
+					prometheus.ssl.keyfile   = /etc/rabbitmq-tls/tls.key
+					prometheus.ssl.port       = 15691
+					management.tcp.port     = 15672
+					prometheus.tcp.port       = 15692
+					ssl_options.cacertfile = /etc/rabbitmq-tls-amqp/ca.crt
+					ssl_options.verify     = verify_peer`)
+
+				Expect(configMapBuilder.Update(configMap)).To(Succeed())
+				Expect(configMap.Data).To(HaveKeyWithValue("userDefinedConfiguration.conf", expectedConfiguration))
+			})
+		})
+
+		Context("TLS options", func() {
+			It("applies the options to every TLS listener", func() {
+				instance.Name = "rabbit-tls"
+				instance.Spec.TLS.SecretName = "tls-secret"
+				instance.Spec.TLS.CaSecretName = "tls-mutual-secret"
+				instance.Spec.Rabbitmq.AdditionalPlugins = []rabbitmqv1beta1.Plugin{"rabbitmq_mqtt", "rabbitmq_web_stomp"}
+				instance.Spec.TLS.Options = &rabbitmqv1beta1.TLSOptionsSpec{
+					Versions:         []string{"tlsv1.3"},
+					Ciphers:          []string{"TLS_AES_256_GCM_SHA384", "TLS_CHACHA20_POLY1305_SHA256"},
+					HonorCipherOrder: new(true),
+					FailIfNoPeerCert: new(true),
+				}
+
+				Expect(configMapBuilder.Update(configMap)).To(Succeed())
+				userConfiguration, err := ini.Load([]byte(configMap.Data["userDefinedConfiguration.conf"]))
+				Expect(err).NotTo(HaveOccurred())
+				keys := userConfiguration.Section("").KeysHash()
+				for _, prefix := range []string{"ssl_options", "management.ssl", "prometheus.ssl", "web_stomp.ssl"} {
+					Expect(keys).To(HaveKeyWithValue(prefix+".versions.1", "tlsv1.3"))
+					Expect(keys).NotTo(HaveKey(prefix + ".versions.2"))
+					Expect(keys).To(HaveKeyWithValue(prefix+".ciphers.1", "TLS_AES_256_GCM_SHA384"))
+					Expect(keys).To(HaveKeyWithValue(prefix+".ciphers.2", "TLS_CHACHA20_POLY1305_SHA256"))
+					Expect(keys).To(HaveKeyWithValue(prefix+".honor_cipher_order", "true"))
+				}
+				Expect(keys).To(HaveKeyWithValue("ssl_options.verify", "verify_peer"))
+				Expect(keys).To(HaveKeyWithValue("ssl_options.fail_if_no_peer_cert", "true"))
+				for _, prefix := range []string{"management.ssl", "prometheus.ssl", "web_stomp.ssl"} {
+					Expect(keys).NotTo(HaveKey(prefix + ".verify"))
+					Expect(keys).NotTo(HaveKey(prefix + ".fail_if_no_peer_cert"))
+				}
+			})
+
+			It("overrides the default peer verification", func() {
+				instance.Spec.TLS.SecretName = "tls-secret"
+				instance.Spec.TLS.CaSecretName = "tls-mutual-secret"
+				instance.Spec.TLS.Options = &rabbitmqv1beta1.TLSOptionsSpec{Verify: "verify_none"}
+
+				Expect(configMapBuilder.Update(configMap)).To(Succeed())
+				userConfiguration, err := ini.Load([]byte(configMap.Data["userDefinedConfiguration.conf"]))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(userConfiguration.Section("").KeysHash()).To(HaveKeyWithValue("ssl_options.verify", "verify_none"))
+			})
+
+			It("gives precedence to additionalConfig", func() {
+				instance.Spec.TLS.SecretName = "tls-secret"
+				instance.Spec.TLS.Options = &rabbitmqv1beta1.TLSOptionsSpec{Versions: []string{"tlsv1.3"}}
+				instance.Spec.Rabbitmq.AdditionalConfig = "management.ssl.versions.1 = tlsv1.2"
+
+				Expect(configMapBuilder.Update(configMap)).To(Succeed())
+				userConfiguration, err := ini.Load([]byte(configMap.Data["userDefinedConfiguration.conf"]))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(userConfiguration.Section("").KeysHash()).To(HaveKeyWithValue("management.ssl.versions.1", "tlsv1.2"))
+			})
+		})
+
+		Context("Definitions", func() {
+			It("imports the mounted definitions at boot", func() {
+				instance.Spec.Rabbitmq.Definitions = &rabbitmqv1beta1.DefinitionsSpec{
+					Sources:         []rabbitmqv1beta1.DefinitionsSource{{ConfigMap: &corev1.LocalObjectReference{Name: "definitions"}}},
+					SkipIfUnchanged: true,
+				}
+
+				Expect(configMapBuilder.Update(configMap)).To(Succeed())
+				userConfiguration, err := ini.Load([]byte(configMap.Data["userDefinedConfiguration.conf"]))
+				Expect(err).NotTo(HaveOccurred())
+				keys := userConfiguration.Section("").KeysHash()
+				Expect(keys).To(HaveKeyWithValue("definitions.import_backend", "local_filesystem"))
+				Expect(keys).To(HaveKeyWithValue("definitions.local.path", "/etc/rabbitmq-definitions/"))
+				Expect(keys).To(HaveKeyWithValue("definitions.skip_if_unchanged", "true"))
+			})
+
+			It("does not configure definitions by default", func() {
+				Expect(configMapBuilder.Update(configMap)).To(Succeed())
+				Expect(configMap.Data["userDefinedConfiguration.conf"]).NotTo(ContainSubstring("definitions."))
+			})
+		})
+
+		Context("Replication", func() {
+			operatorDefaults := func() map[string]string {
+				operatorDefaultConf, err := ini.Load([]byte(configMap.Data["operatorDefaults.conf"]))
+				Expect(err).NotTo(HaveOccurred())
+				return operatorDefaultConf.Section("").KeysHash()
+			}
+
+			It("configures upstreams", func() {
+				builder.ReplicationUpstream = true
+				Expect(configMapBuilder.Update(configMap)).To(Succeed())
+				keys := operatorDefaults()
+				Expect(keys).To(HaveKeyWithValue("schema_definition_sync.operating_mode", "upstream"))
+				Expect(keys).To(HaveKeyWithValue("standby.replication.operating_mode", "upstream"))
+				Expect(keys).NotTo(HaveKey("schema_definition_sync.downstream.locals.users"))
+			})
+
+			It("configures downstreams to keep their default users", func() {
+				instance.Spec.Replication = &rabbitmqv1beta1.ReplicationSpec{
+					Upstream:     rabbitmqv1beta1.RabbitmqClusterReference{Name: "upstream"},
+					VirtualHosts: []string{"/"},
+				}
+				Expect(configMapBuilder.Update(configMap)).To(Succeed())
+				keys := operatorDefaults()
+				Expect(keys).To(HaveKeyWithValue("schema_definition_sync.operating_mode", "downstream"))
+				Expect(keys).To(HaveKeyWithValue("standby.replication.operating_mode", "downstream"))
+				Expect(keys).To(HaveKeyWithValue("schema_definition_sync.downstream.locals.users", "^default_user_"))
+				Expect(keys).To(HaveKeyWithValue("schema_definition_sync.downstream.locals.global_parameters", "^standby"))
+			})
+
+			It("does not configure replication by default", func() {
+				Expect(configMapBuilder.Update(configMap)).To(Succeed())
+				Expect(configMap.Data["operatorDefaults.conf"]).NotTo(ContainSubstring("operating_mode"))
+			})
+		})
+
+		Context("OAuth 2.0", func() {
+			operatorDefaults := func() map[string]string {
+				operatorDefaultConf, err := ini.Load([]byte(configMap.Data["operatorDefaults.conf"]))
+				Expect(err).NotTo(HaveOccurred())
+				return operatorDefaultConf.Section("").KeysHash()
+			}
+
+			BeforeEach(func() {
+				instance.Spec.Auth.OAuth2 = &rabbitmqv1beta1.OAuth2Spec{
+					ResourceServerID: "rabbitmq",
+					IssuerURL:        "https://idp.example.com/realms/rabbitmq",
+				}
+			})
+
+			It("authenticates with OAuth 2.0 before the internal database", func() {
+				Expect(configMapBuilder.Update(configMap)).To(Succeed())
+				keys := operatorDefaults()
+				Expect(keys).To(HaveKeyWithValue("auth_backends.1", "rabbit_auth_backend_oauth2"))
+				Expect(keys).To(HaveKeyWithValue("auth_backends.2", "rabbit_auth_backend_internal"))
+				Expect(keys).To(HaveKeyWithValue("auth_oauth2.resource_server_id", "rabbitmq"))
+				Expect(keys).To(HaveKeyWithValue("auth_oauth2.issuer", "https://idp.example.com/realms/rabbitmq"))
+				Expect(keys).NotTo(HaveKey("auth_oauth2.https.cacertfile"))
+				Expect(keys).NotTo(HaveKey("management.oauth_enabled"))
+			})
+
+			It("references the mounted key material", func() {
+				instance.Spec.Auth.OAuth2.CACertificate = &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "idp-ca"},
+					Key:                  "ca.crt",
+				}
+				instance.Spec.Auth.OAuth2.SigningKeys = []rabbitmqv1beta1.OAuth2SigningKey{{
+					ID: "key-1",
+					SecretKeyRef: corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "idp-keys"},
+						Key:                  "key-1.pem",
+					},
+				}}
+				instance.Spec.Auth.OAuth2.DefaultSigningKey = "key-1"
+				Expect(configMapBuilder.Update(configMap)).To(Succeed())
+				keys := operatorDefaults()
+				Expect(keys).To(HaveKeyWithValue("auth_oauth2.https.cacertfile", "/etc/rabbitmq-oauth2/ca.crt"))
+				Expect(keys).To(HaveKeyWithValue("auth_oauth2.https.peer_verification", "verify_peer"))
+				Expect(keys).To(HaveKeyWithValue("auth_oauth2.signing_keys.key-1", "/etc/rabbitmq-oauth2/signing-keys/key-1.pem"))
+				Expect(keys).To(HaveKeyWithValue("auth_oauth2.default_key", "key-1"))
+			})
+
+			It("configures claims, scope aliases and the management UI", func() {
+				instance.Spec.Auth.OAuth2.PreferredUsernameClaims = []string{"preferred_username", "sub"}
+				instance.Spec.Auth.OAuth2.AdditionalScopesKey = "roles"
+				instance.Spec.Auth.OAuth2.ScopeAliases = map[string]string{
+					"rabbitmq.write": "rabbitmq.write:*/*",
+					"admin":          "rabbitmq.tag:administrator",
+				}
+				instance.Spec.Auth.OAuth2.Management = &rabbitmqv1beta1.OAuth2ManagementSpec{
+					ClientID: "rabbitmq-management",
+					Scopes:   "openid profile rabbitmq.tag:administrator",
+				}
+				Expect(configMapBuilder.Update(configMap)).To(Succeed())
+				keys := operatorDefaults()
+				Expect(keys).To(HaveKeyWithValue("auth_oauth2.preferred_username_claims.1", "preferred_username"))
+				Expect(keys).To(HaveKeyWithValue("auth_oauth2.preferred_username_claims.2", "sub"))
+				Expect(keys).To(HaveKeyWithValue("auth_oauth2.additional_scopes_key", "roles"))
+				Expect(keys).To(HaveKeyWithValue("auth_oauth2.scope_aliases.1.alias", "admin"))
+				Expect(keys).To(HaveKeyWithValue("auth_oauth2.scope_aliases.1.scope", "rabbitmq.tag:administrator"))
+				Expect(keys).To(HaveKeyWithValue("auth_oauth2.scope_aliases.2.alias", "rabbitmq.write"))
+				Expect(keys).To(HaveKeyWithValue("auth_oauth2.scope_aliases.2.scope", "rabbitmq.write:*/*"))
+				Expect(keys).To(HaveKeyWithValue("management.oauth_enabled", "true"))
+				Expect(keys).To(HaveKeyWithValue("management.oauth_client_id", "rabbitmq-management"))
+				Expect(keys).To(HaveKeyWithValue("management.oauth_scopes", "openid profile rabbitmq.tag:administrator"))
+				Expect(configMap.Data["operatorDefaults.conf"]).NotTo(ContainSubstring("oauth_client_secret"))
+			})
+
+			It("does not configure authentication backends by default", func() {
+				instance.Spec.Auth.OAuth2 = nil
+				Expect(configMapBuilder.Update(configMap)).To(Succeed())
+				Expect(configMap.Data["operatorDefaults.conf"]).NotTo(ContainSubstring("auth_backends"))
+				Expect(configMap.Data["operatorDefaults.conf"]).NotTo(ContainSubstring("auth_oauth2"))
+			})
+		})
+
+		Context("LDAP", func() {
+			operatorDefaults := func() map[string]string {
+				operatorDefaultConf, err := ini.Load([]byte(configMap.Data["operatorDefaults.conf"]))
+				Expect(err).NotTo(HaveOccurred())
+				return operatorDefaultConf.Section("").KeysHash()
+			}
+
+			BeforeEach(func() {
+				instance.Spec.Auth.LDAP = &rabbitmqv1beta1.LDAPSpec{
+					Servers:       []string{"ldap-0.example.com", "ldap-1.example.com"},
+					UserDNPattern: "cn=${username},ou=People,dc=example,dc=com",
+				}
+			})
+
+			It("authenticates with LDAP before the internal database", func() {
+				Expect(configMapBuilder.Update(configMap)).To(Succeed())
+				keys := operatorDefaults()
+				Expect(keys).To(HaveKeyWithValue("auth_backends.1", "rabbit_auth_backend_ldap"))
+				Expect(keys).To(HaveKeyWithValue("auth_backends.2", "rabbit_auth_backend_internal"))
+				Expect(keys).To(HaveKeyWithValue("auth_ldap.servers.1", "ldap-0.example.com"))
+				Expect(keys).To(HaveKeyWithValue("auth_ldap.servers.2", "ldap-1.example.com"))
+				Expect(keys).To(HaveKeyWithValue("auth_ldap.user_dn_pattern", "cn=${username},ou=People,dc=example,dc=com"))
+				Expect(keys).NotTo(HaveKey("auth_ldap.port"))
+				Expect(keys).NotTo(HaveKey("auth_ldap.use_ssl"))
+			})
+
+			It("tries OAuth 2.0 tokens before LDAP passwords", func() {
+				instance.Spec.Auth.OAuth2 = &rabbitmqv1beta1.OAuth2Spec{ResourceServerID: "rabbitmq", IssuerURL: "https://idp.example.com"}
+				Expect(configMapBuilder.Update(configMap)).To(Succeed())
+				keys := operatorDefaults()
+				Expect(keys).To(HaveKeyWithValue("auth_backends.1", "rabbit_auth_backend_oauth2"))
+				Expect(keys).To(HaveKeyWithValue("auth_backends.2", "rabbit_auth_backend_ldap"))
+				Expect(keys).To(HaveKeyWithValue("auth_backends.3", "rabbit_auth_backend_internal"))
+			})
+
+			It("connects with LDAPS on port 636", func() {
+				instance.Spec.Auth.LDAP.TLS = &rabbitmqv1beta1.LDAPTLSSpec{
+					CACertificate: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "ldap-ca"},
+						Key:                  "ca.crt",
+					},
+				}
+				Expect(configMapBuilder.Update(configMap)).To(Succeed())
+				keys := operatorDefaults()
+				Expect(keys).To(HaveKeyWithValue("auth_ldap.port", "636"))
+				Expect(keys).To(HaveKeyWithValue("auth_ldap.use_ssl", "true"))
+				Expect(keys).To(HaveKeyWithValue("auth_ldap.ssl_options.cacertfile", "/etc/rabbitmq-ldap/ca.crt"))
+				Expect(keys).To(HaveKeyWithValue("auth_ldap.ssl_options.verify", "verify_peer"))
+			})
+
+			It("upgrades connections with StartTLS on the configured port", func() {
+				instance.Spec.Auth.LDAP.Port = new(int32(10389))
+				instance.Spec.Auth.LDAP.TLS = &rabbitmqv1beta1.LDAPTLSSpec{StartTLS: true}
+				Expect(configMapBuilder.Update(configMap)).To(Succeed())
+				keys := operatorDefaults()
+				Expect(keys).To(HaveKeyWithValue("auth_ldap.port", "10389"))
+				Expect(keys).To(HaveKeyWithValue("auth_ldap.use_starttls", "true"))
+				Expect(keys).NotTo(HaveKey("auth_ldap.use_ssl"))
+				Expect(keys).NotTo(HaveKey("auth_ldap.ssl_options.cacertfile"))
+			})
+
+			It("looks up users with the bind user, whose password is not in the ConfigMap", func() {
+				instance.Spec.Auth.LDAP.UserDNPattern = ""
+				instance.Spec.Auth.LDAP.DNLookup = &rabbitmqv1beta1.LDAPDNLookupSpec{Attribute: "uid", Base: "ou=People,dc=example,dc=com"}
+				instance.Spec.Auth.LDAP.BindDN = "cn=rabbitmq,dc=example,dc=com"
+				instance.Spec.Auth.LDAP.BindPassword = &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "ldap-bind"},
+					Key:                  "password",
+				}
+				Expect(configMapBuilder.Update(configMap)).To(Succeed())
+				keys := operatorDefaults()
+				Expect(keys).To(HaveKeyWithValue("auth_ldap.dn_lookup_attribute", "uid"))
+				Expect(keys).To(HaveKeyWithValue("auth_ldap.dn_lookup_base", "ou=People,dc=example,dc=com"))
+				Expect(keys).To(HaveKeyWithValue("auth_ldap.dn_lookup_bind.user_dn", "cn=rabbitmq,dc=example,dc=com"))
+				Expect(keys).To(HaveKeyWithValue("auth_ldap.other_bind.user_dn", "cn=rabbitmq,dc=example,dc=com"))
+				Expect(configMap.Data["operatorDefaults.conf"]).NotTo(ContainSubstring("password"))
+			})
+		})
+
 		Context("Mutual TLS", func() {
 			It("adds TLS config when TLS is enabled", func() {
 				instance.Name = "rabbit-tls"
@@ -678,6 +1048,38 @@ CONSOLE_LOG=new`
 			})
 		})
 
+		Context("Inter-node TLS", func() {
+			BeforeEach(func() {
+				instance.Spec.TLS = rabbitmqv1beta1.TLSSpec{
+					SecretName:   "tls-secret",
+					CaSecretName: "tls-mutual-secret",
+					InterNode:    true,
+				}
+			})
+
+			It("adds the Erlang distribution TLS options", func() {
+				Expect(configMapBuilder.Update(configMap)).To(Succeed())
+				Expect(configMap.Data).To(HaveKey("inter_node_tls.config"))
+				interNodeConfig := configMap.Data["inter_node_tls.config"]
+				Expect(interNodeConfig).To(ContainSubstring(`{cacertfile, "/etc/rabbitmq-tls/ca.crt"}`))
+				Expect(interNodeConfig).To(ContainSubstring(`{certfile, "/etc/rabbitmq-tls/tls.crt"}`))
+				Expect(interNodeConfig).To(ContainSubstring(`{keyfile, "/etc/rabbitmq-tls/tls.key"}`))
+				Expect(interNodeConfig).To(ContainSubstring("{fail_if_no_peer_cert, true}"))
+				Expect(interNodeConfig).To(ContainSubstring("{verify, verify_peer}"))
+			})
+
+			When("inter-node TLS is disabled", func() {
+				It("deletes the key", func() {
+					Expect(configMapBuilder.Update(configMap)).To(Succeed())
+					Expect(configMap.Data).To(HaveKey("inter_node_tls.config"))
+
+					instance.Spec.TLS.InterNode = false
+					Expect(configMapBuilder.Update(configMap)).To(Succeed())
+					Expect(configMap.Data).ToNot(HaveKey("inter_node_tls.config"))
+				})
+			})
+		})
+
 		Describe("UpdateRequiresStsRestart", func() {
 			BeforeEach(func() {
 				Expect(configMapBuilder.Update(configMap)).To(Succeed())
@@ -696,12 +1098,117 @@ CONSOLE_LOG=new`
 					Expect(configMapBuilder.UpdateRequiresStsRestart).To(BeFalse())
 				})
 			})
+			When("the content referenced in additionalConfigFrom changes", func() {
+				It("requires the StatefulSet to be restarted", func() {
+					builder.AdditionalConfigFromHash = "abc123"
+					Expect(configMapBuilder.Update(configMap)).To(Succeed())
+					Expect(configMapBuilder.UpdateRequiresStsRestart).To(BeTrue())
+					Expect(configMap.Annotations).To(HaveKeyWithValue("rabbitmq.com/additionalConfigFromSHA256", "abc123"))
+				})
+			})
+			When("additionalConfigFrom is removed", func() {
+				It("removes the hash of its content", func() {
+					configMap.Annotations = map[string]string{"rabbitmq.com/additionalConfigFromSHA256": "abc123"}
+					Expect(configMapBuilder.Update(configMap)).To(Succeed())
+					Expect(configMap.Annotations).NotTo(HaveKey("rabbitmq.com/additionalConfigFromSHA256"))
+				})
+			})
+			When("a credential of spec.auth changes", func() {
+				It("requires the StatefulSet to be restarted", func() {
+					builder.AuthSecretsHash = "def456"
+					Expect(configMapBuilder.Update(configMap)).To(Succeed())
+					Expect(configMapBuilder.UpdateRequiresStsRestart).To(BeTrue())
+					Expect(configMap.Annotations).To(HaveKeyWithValue("rabbitmq.com/authSecretsSHA256", "def456"))
+				})
+			})
 			When("config change includes more than cluster formation nodes", func() {
 				It("requires the StatefulSet to be restarted", func() {
 					instance.Spec.Replicas = new(int32(3))
 					instance.Spec.Rabbitmq.AdditionalConfig = "foo = bar"
 					Expect(configMapBuilder.Update(configMap)).To(Succeed())
 					Expect(configMapBuilder.UpdateRequiresStsRestart).To(BeTrue())
+					Expect(configMapBuilder.RestartConfigKeys).To(ConsistOf("foo"))
+				})
+			})
+			When("only keys which can be changed on running nodes change", func() {
+				BeforeEach(func() {
+					instance.Spec.Rabbitmq.AdditionalConfig = "log.default.level = info\ndisk_free_limit.absolute = 2GB\nchannel_max = 2047"
+					Expect(configMapBuilder.Update(configMap)).To(Succeed())
+				})
+
+				It("does not require the StatefulSet to be restarted", func() {
+					instance.Spec.Rabbitmq.AdditionalConfig = "log.default.level = debug\ndisk_free_limit.absolute = 4GB\nchannel_max = 2047"
+					Expect(configMapBuilder.Update(configMap)).To(Succeed())
+					Expect(configMapBuilder.UpdateRequiresStsRestart).To(BeFalse())
+					Expect(configMapBuilder.RuntimeConfigKeys).To(Equal([]string{"disk_free_limit.absolute", "log.default.level"}))
+					Expect(configMapBuilder.RestartConfigKeys).To(BeEmpty())
+					Expect(configMap.Data["userDefinedConfiguration.conf"]).To(ContainSubstring("log.default.level        = debug"))
+				})
+
+				It("requires the StatefulSet to be restarted if other keys change as well", func() {
+					instance.Spec.Rabbitmq.AdditionalConfig = "log.default.level = debug\ndisk_free_limit.absolute = 2GB\nchannel_max = 2047\nheartbeat = 30"
+					Expect(configMapBuilder.Update(configMap)).To(Succeed())
+					Expect(configMapBuilder.UpdateRequiresStsRestart).To(BeTrue())
+					Expect(configMapBuilder.RestartConfigKeys).To(ConsistOf("heartbeat"))
+					Expect(configMapBuilder.RuntimeConfigKeys).To(BeEmpty())
+				})
+
+				It("requires the StatefulSet to be restarted if a key is removed", func() {
+					instance.Spec.Rabbitmq.AdditionalConfig = "log.default.level = debug\nchannel_max = 2047"
+					Expect(configMapBuilder.Update(configMap)).To(Succeed())
+					Expect(configMapBuilder.UpdateRequiresStsRestart).To(BeTrue())
+					Expect(configMapBuilder.RestartConfigKeys).To(ConsistOf("disk_free_limit.absolute"))
+				})
+
+				It("requires the StatefulSet to be restarted if a log output sets its own level", func() {
+					// rabbitmqctl set_log_level would override the level of the console
+					instance.Spec.Rabbitmq.AdditionalConfig = "log.default.level = debug\nlog.console.level = info\ndisk_free_limit.absolute = 2GB\nchannel_max = 2047"
+					Expect(configMapBuilder.Update(configMap)).To(Succeed())
+					Expect(configMapBuilder.UpdateRequiresStsRestart).To(BeTrue())
+					Expect(configMapBuilder.RestartConfigKeys).To(ConsistOf("log.default.level", "log.console.level"))
+				})
+
+				It("requires the StatefulSet to be restarted if the level of the console changes", func() {
+					instance.Spec.Rabbitmq.AdditionalConfig = "log.default.level = info\nlog.console.level = info\ndisk_free_limit.absolute = 2GB\nchannel_max = 2047"
+					Expect(configMapBuilder.Update(configMap)).To(Succeed())
+
+					configMapBuilder = builder.ServerConfigMap()
+					instance.Spec.Rabbitmq.AdditionalConfig = "log.default.level = info\nlog.console.level = debug\ndisk_free_limit.absolute = 2GB\nchannel_max = 2047"
+					Expect(configMapBuilder.Update(configMap)).To(Succeed())
+					Expect(configMapBuilder.UpdateRequiresStsRestart).To(BeTrue())
+					Expect(configMapBuilder.RestartConfigKeys).To(ConsistOf("log.console.level"))
+				})
+
+				It("requires the StatefulSet to be restarted if rabbitmqctl does not accept the value", func() {
+					instance.Spec.Rabbitmq.AdditionalConfig = "log.default.level = debug; reboot\ndisk_free_limit.absolute = 2GB\nchannel_max = 2047"
+					Expect(configMapBuilder.Update(configMap)).To(Succeed())
+					Expect(configMapBuilder.UpdateRequiresStsRestart).To(BeTrue())
+				})
+
+				It("requires the StatefulSet to be restarted if additionalConfigFrom is set", func() {
+					instance.Spec.Rabbitmq.AdditionalConfigFrom = []rabbitmqv1beta1.AdditionalConfigSource{{
+						ConfigMapKeyRef: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "extra"}, Key: "extra.conf"},
+					}}
+					instance.Spec.Rabbitmq.AdditionalConfig = "log.default.level = debug\ndisk_free_limit.absolute = 2GB\nchannel_max = 2047"
+					Expect(configMapBuilder.Update(configMap)).To(Succeed())
+					Expect(configMapBuilder.UpdateRequiresStsRestart).To(BeTrue())
+				})
+
+				It("requires the StatefulSet to be restarted if runtime keys cannot be applied to running nodes", func() {
+					builder.RestartOnRuntimeConfigChange = true
+					instance.Spec.Rabbitmq.AdditionalConfig = "log.default.level = debug\ndisk_free_limit.absolute = 2GB\nchannel_max = 2047"
+					Expect(configMapBuilder.Update(configMap)).To(Succeed())
+					Expect(configMapBuilder.UpdateRequiresStsRestart).To(BeTrue())
+					Expect(configMapBuilder.RestartConfigKeys).To(ConsistOf("log.default.level"))
+					Expect(configMapBuilder.RuntimeConfigKeys).To(BeEmpty())
+				})
+			})
+			When("advanced.config changes", func() {
+				It("reports the file as requiring a restart", func() {
+					instance.Spec.Rabbitmq.AdvancedConfig = "[]."
+					Expect(configMapBuilder.Update(configMap)).To(Succeed())
+					Expect(configMapBuilder.UpdateRequiresStsRestart).To(BeTrue())
+					Expect(configMapBuilder.RestartConfigKeys).To(ConsistOf("advanced.config"))
 				})
 			})
 		})
@@ -729,3 +1236,24 @@ func iniString(input string) string {
 	ExpectWithOffset(1, err).NotTo(HaveOccurred())
 	return output.String()
 }
+
+var _ = Describe("RuntimeConfigCommands", func() {
+	It("returns the rabbitmqctl commands of the runtime keys", func() {
+		commands, err := resource.RuntimeConfigCommands(&corev1.ConfigMap{Data: map[string]string{
+			"userDefinedConfiguration.conf": "cluster_name = rabbit\nvm_memory_high_watermark.relative = 0.6\nconsumer_timeout = 3600000\nheartbeat = not-a-number\n",
+		}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(commands).To(Equal([]string{
+			"rabbitmqctl set_vm_memory_high_watermark 0.6",
+			"rabbitmqctl eval 'application:set_env(rabbit, consumer_timeout, 3600000).'",
+		}))
+	})
+
+	It("does not set the log level when a log output sets its own level", func() {
+		commands, err := resource.RuntimeConfigCommands(&corev1.ConfigMap{Data: map[string]string{
+			"userDefinedConfiguration.conf": "log.default.level = debug\nlog.console.level = warning\ndisk_free_limit.absolute = 2GB\n",
+		}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(commands).To(Equal([]string{"rabbitmqctl set_disk_free_limit 2GB"}))
+	})
+})
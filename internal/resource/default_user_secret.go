@@ -12,6 +12,7 @@ package resource
 import (
 	"bytes"
 	"fmt"
+	"net/url"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -62,20 +63,17 @@ func (builder *DefaultUserSecretBuilder) Build() (client.Object, error) {
 		}
 	}
 
-	if username == "" {
-		generatedUsername, err := generateUsername(24)
+	if username == "" || password == "" {
+		generatedUsername, generatedPassword, err := GenerateDefaultUserCredentials()
 		if err != nil {
 			return nil, err
 		}
-		username = generatedUsername
-	}
-
-	if password == "" {
-		generatedPassword, err := randomEncodedString(24)
-		if err != nil {
-			return nil, err
+		if username == "" {
+			username = generatedUsername
+		}
+		if password == "" {
+			password = generatedPassword
 		}
-		password = generatedPassword
 	}
 
 	defaultUserConf, err := generateDefaultUserConf(username, password)
@@ -101,7 +99,8 @@ func (builder *DefaultUserSecretBuilder) Build() (client.Object, error) {
 		},
 	}
 	builder.updatePorts(secret)
-	builder.updateConnectionString(secret)
+	builder.updateURIs(secret)
+	builder.updateCACertificate(secret)
 
 	return secret, nil
 }
@@ -114,8 +113,16 @@ func (builder *DefaultUserSecretBuilder) Update(object client.Object) error {
 	secret := object.(*corev1.Secret)
 	secret.Labels = metadata.GetLabels(builder.Instance.Name, builder.Instance.Labels)
 	secret.Annotations = metadata.ReconcileAndFilterAnnotations(secret.GetAnnotations(), builder.Instance.Annotations)
+	if secret.Data == nil {
+		secret.Data = make(map[string][]byte)
+	}
+	// Secrets created by older versions of the operator may lack the keys required by the service binding spec
+	secret.Data["provider"] = []byte(bindingProvider)
+	secret.Data["type"] = []byte(bindingType)
+	secret.Data["host"] = []byte(builder.Instance.ServiceSubDomain())
 	builder.updatePorts(secret)
-	builder.updateConnectionString(secret)
+	builder.updateURIs(secret)
+	builder.updateCACertificate(secret)
 
 	if err := controllerutil.SetControllerReference(builder.Instance, secret, builder.Scheme); err != nil {
 		return fmt.Errorf("failed setting controller reference: %w", err)
@@ -124,6 +131,23 @@ func (builder *DefaultUserSecretBuilder) Update(object client.Object) error {
 	return nil
 }
 
+// SetCredentials replaces the default user credentials in the Secret,
+// including the ones embedded in default_user.conf, connection_string and the URIs.
+func (builder *DefaultUserSecretBuilder) SetCredentials(secret *corev1.Secret, username, password string) error {
+	defaultUserConf, err := generateDefaultUserConf(username, password)
+	if err != nil {
+		return err
+	}
+	if secret.Data == nil {
+		secret.Data = make(map[string][]byte)
+	}
+	secret.Data["username"] = []byte(username)
+	secret.Data["password"] = []byte(password)
+	secret.Data["default_user.conf"] = defaultUserConf
+	builder.updateURIs(secret)
+	return nil
+}
+
 func (builder *DefaultUserSecretBuilder) updatePorts(secret *corev1.Secret) {
 	const (
 		AMQPPort  = "5672"
@@ -154,7 +178,7 @@ func (builder *DefaultUserSecretBuilder) updatePorts(secret *corev1.Secret) {
 		"web-amqp-port":  "15678",
 	}
 
-	if builder.Instance.Spec.TLS.SecretName != "" {
+	if builder.Instance.SecretTLSEnabled() {
 		secret.Data["port"] = []byte(AMQPSPort)
 
 		for plugin, portName := range portNames {
@@ -178,13 +202,86 @@ func (builder *DefaultUserSecretBuilder) updatePorts(secret *corev1.Secret) {
 }
 
 func (builder *DefaultUserSecretBuilder) updateConnectionString(secret *corev1.Secret) {
-	if builder.Instance.Spec.TLS.SecretName != "" {
+	if builder.Instance.SecretTLSEnabled() {
 		secret.Data["connection_string"] = fmt.Appendf(nil, "amqps://%s:%s@%s:%s/", secret.Data["username"], secret.Data["password"], secret.Data["host"], secret.Data["port"])
 	} else {
 		secret.Data["connection_string"] = fmt.Appendf(nil, "amqp://%s:%s@%s:%s/", secret.Data["username"], secret.Data["password"], secret.Data["host"], secret.Data["port"])
 	}
 }
 
+// updateURIs sets connection_string, the well-known uri entry of the service binding spec, and a URI per protocol
+// the cluster listens on. Protocols served by plugins get the TLS URI if TLS is enabled, like their ports.
+// Unlike connection_string, the URIs escape the credentials, and the AMQP and stream URIs select the default
+// virtual host "/" explicitly, since an empty path selects the virtual host "".
+func (builder *DefaultUserSecretBuilder) updateURIs(secret *corev1.Secret) {
+	builder.updateConnectionString(secret)
+
+	userinfo := url.UserPassword(string(secret.Data["username"]), string(secret.Data["password"]))
+	host := builder.Instance.ServiceSubDomain()
+	const defaultVhostPath = "/%2F"
+	uri := func(scheme string, port int, path string) []byte {
+		return fmt.Appendf(nil, "%s://%s@%s:%d%s", scheme, userinfo, host, port, path)
+	}
+	tls := builder.Instance.SecretTLSEnabled()
+
+	if builder.Instance.DisableNonTLSListeners() {
+		delete(secret.Data, "amqp-uri")
+	} else {
+		secret.Data["amqp-uri"] = uri("amqp", 5672, defaultVhostPath)
+	}
+	if tls {
+		secret.Data["amqps-uri"] = uri("amqps", 5671, defaultVhostPath)
+		secret.Data["uri"] = secret.Data["amqps-uri"]
+		secret.Data["management-uri"] = uri("https", 15671, "")
+	} else {
+		delete(secret.Data, "amqps-uri")
+		secret.Data["uri"] = secret.Data["amqp-uri"]
+		secret.Data["management-uri"] = uri("http", 15672, "")
+	}
+
+	pluginURIs := []struct {
+		key, scheme, tlsScheme string
+		plugin                 v1beta1.Plugin
+		port, tlsPort          int
+		path                   string
+	}{
+		{"mqtt-uri", "mqtt", "mqtts", "rabbitmq_mqtt", 1883, 8883, ""},
+		{"stomp-uri", "stomp", "stomp+ssl", "rabbitmq_stomp", 61613, 61614, ""},
+		{"stream-uri", "rabbitmq-stream", "rabbitmq-stream+tls", "rabbitmq_stream", 5552, 5551, defaultVhostPath},
+	}
+	for _, p := range pluginURIs {
+		switch {
+		case !builder.pluginEnabled(p.plugin):
+			delete(secret.Data, p.key)
+		case tls:
+			secret.Data[p.key] = uri(p.tlsScheme, p.tlsPort, p.path)
+		default:
+			secret.Data[p.key] = uri(p.scheme, p.port, p.path)
+		}
+	}
+}
+
+// updateCACertificate sets ca.crt, the CA certificate clients verify the server certificate against, if TLS is enabled
+// and the CA certificate is known
+func (builder *DefaultUserSecretBuilder) updateCACertificate(secret *corev1.Secret) {
+	if builder.Instance.SecretTLSEnabled() && len(builder.TLSCACertificate) > 0 {
+		secret.Data["ca.crt"] = builder.TLSCACertificate
+	} else {
+		delete(secret.Data, "ca.crt")
+	}
+}
+
+// GenerateDefaultUserCredentials returns a random username with the "default_user_" prefix and a random password
+func GenerateDefaultUserCredentials() (username, password string, err error) {
+	if username, err = generateUsername(24); err != nil {
+		return "", "", err
+	}
+	if password, err = randomEncodedString(24); err != nil {
+		return "", "", err
+	}
+	return username, password, nil
+}
+
 // generateUsername returns a base64 string that has "default_user_" as prefix
 // returned string has length 'l' when base64 decoded
 func generateUsername(l int) (string, error) {
@@ -197,8 +294,13 @@ func generateUsername(l int) (string, error) {
 	return string(append([]byte(usernamePrefix), encodedSlice[0:len(encodedSlice)-len(usernamePrefix)]...)), nil
 }
 
+// pluginEnabled returns true if the plugin is enabled, including plugins the operator enables on top of
+// spec.rabbitmq.additionalPlugins, such as the stream plugin for warm standby replication
 func (builder *DefaultUserSecretBuilder) pluginEnabled(plugin v1beta1.Plugin) bool {
-	return slices.Contains(builder.Instance.Spec.Rabbitmq.AdditionalPlugins, plugin)
+	if plugin == "rabbitmq_stream" && builder.streamNeeded() {
+		return true
+	}
+	return slices.Contains(builder.plugins(), plugin)
 }
 
 func generateDefaultUserConf(username, password string) ([]byte, error) {
@@ -136,6 +136,16 @@ var _ = Describe("DefaultUserSecret", func() {
 				Expect(ok).To(BeTrue(), "Failed to find key 'type' ")
 				Expect(string(t)).To(Equal("rabbitmq"))
 			})
+
+			By("setting the AMQP and management URIs", func() {
+				amqpURI := fmt.Appendf(nil, "amqp://%s:%s@a name.a namespace.svc:5672/%%2F", username, password)
+				Expect(secret.Data).To(HaveKeyWithValue("amqp-uri", amqpURI))
+				Expect(secret.Data).To(HaveKeyWithValue("uri", amqpURI))
+				Expect(secret.Data).To(HaveKeyWithValue("management-uri", fmt.Appendf(nil, "http://%s:%s@a name.a namespace.svc:15672", username, password)))
+				Expect(secret.Data).NotTo(HaveKey("amqps-uri"))
+				Expect(secret.Data).NotTo(HaveKey("mqtt-uri"))
+				Expect(secret.Data).NotTo(HaveKey("ca.crt"))
+			})
 		})
 	})
 
@@ -157,6 +167,33 @@ var _ = Describe("DefaultUserSecret", func() {
 		})
 	})
 
+	Context("SetCredentials", func() {
+		It("replaces the credentials everywhere in the secret", func() {
+			obj, err := defaultUserSecretBuilder.Build()
+			Expect(err).NotTo(HaveOccurred())
+			secret = obj.(*corev1.Secret)
+
+			Expect(defaultUserSecretBuilder.SetCredentials(secret, "new-user", "new-password")).To(Succeed())
+			Expect(string(secret.Data["username"])).To(Equal("new-user"))
+			Expect(string(secret.Data["password"])).To(Equal("new-password"))
+			Expect(string(secret.Data["connection_string"])).To(Equal("amqp://new-user:new-password@a name.a namespace.svc:5672/"))
+
+			cfg, err := ini.Load(secret.Data["default_user.conf"])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Section("").Key("default_user").Value()).To(Equal("new-user"))
+			Expect(cfg.Section("").Key("default_pass").Value()).To(Equal("new-password"))
+		})
+
+		It("escapes the credentials in the URIs", func() {
+			obj, err := defaultUserSecretBuilder.Build()
+			Expect(err).NotTo(HaveOccurred())
+			secret = obj.(*corev1.Secret)
+
+			Expect(defaultUserSecretBuilder.SetCredentials(secret, "new-user", "p@ss/word")).To(Succeed())
+			Expect(string(secret.Data["uri"])).To(Equal("amqp://new-user:p%40ss%2Fword@a name.a namespace.svc:5672/%2F"))
+		})
+	})
+
 	Context("when MQTT, STOMP, streams, WebAMQP, WebMQTT, and WebSTOMP are enabled", func() {
 		It("adds the MQTT, STOMP, stream, WebAMQP, WebMQTT, and WebSTOMP ports to the user secret", func() {
 			var port []byte
@@ -197,9 +234,26 @@ var _ = Describe("DefaultUserSecret", func() {
 			port, ok = secret.Data["web-amqp-port"]
 			Expect(ok).To(BeTrue(), "Failed to find key \"web-amqp-port\" in the generated Secret")
 			Expect(port).To(BeEquivalentTo("15678"))
+
+			Expect(secret.Data).To(HaveKeyWithValue("mqtt-uri", MatchRegexp("^mqtt://.*@a name.a namespace.svc:1883$")))
+			Expect(secret.Data).To(HaveKeyWithValue("stomp-uri", MatchRegexp("^stomp://.*@a name.a namespace.svc:61613$")))
+			Expect(secret.Data).To(HaveKeyWithValue("stream-uri", MatchRegexp("^rabbitmq-stream://.*@a name.a namespace.svc:5552/%2F$")))
 		})
 	})
 
+	It("exposes the stream protocol when replication enables it", func() {
+		instance.Spec.Replication = &rabbitmqv1beta1.ReplicationSpec{
+			Upstream:     rabbitmqv1beta1.RabbitmqClusterReference{Name: "upstream"},
+			VirtualHosts: []string{"/"},
+		}
+		obj, err := defaultUserSecretBuilder.Build()
+		Expect(err).NotTo(HaveOccurred())
+		secret = obj.(*corev1.Secret)
+		Expect(defaultUserSecretBuilder.Update(secret)).To(Succeed())
+		Expect(secret.Data).To(HaveKeyWithValue("stream-port", []byte("5552")))
+		Expect(secret.Data).To(HaveKeyWithValue("stream-uri", MatchRegexp("^rabbitmq-stream://.*:5552/%2F$")))
+	})
+
 	Context("when TLS is enabled", func() {
 		It("Uses the AMQPS protocol in the user secret", func() {
 			instance.Spec.TLS.SecretName = "tls-secret"
@@ -213,6 +267,26 @@ var _ = Describe("DefaultUserSecret", func() {
 
 			By("setting the connection string to use the AMQPS protocol")
 			Expect(secret.Data).To(HaveKeyWithValue("connection_string", MatchRegexp("amqps:.*:5671/")))
+
+			By("setting the AMQPS and HTTPS management URIs, and keeping the AMQP URI of the non-TLS listener")
+			Expect(secret.Data).To(HaveKeyWithValue("amqps-uri", MatchRegexp("^amqps://.*@a name.a namespace.svc:5671/%2F$")))
+			Expect(secret.Data).To(HaveKeyWithValue("uri", secret.Data["amqps-uri"]))
+			Expect(secret.Data).To(HaveKeyWithValue("amqp-uri", MatchRegexp("^amqp://.*:5672/%2F$")))
+			Expect(secret.Data).To(HaveKeyWithValue("management-uri", MatchRegexp("^https://.*:15671$")))
+		})
+
+		It("adds the CA certificate and drops the AMQP URI when non-TLS listeners are disabled", func() {
+			instance.Spec.TLS.SecretName = "tls-secret"
+			instance.Spec.TLS.DisableNonTLSListeners = true
+			builder.TLSCACertificate = []byte("ca certificate")
+
+			obj, err := defaultUserSecretBuilder.Build()
+			Expect(err).NotTo(HaveOccurred())
+			secret = obj.(*corev1.Secret)
+
+			Expect(secret.Data).To(HaveKeyWithValue("ca.crt", []byte("ca certificate")))
+			Expect(secret.Data).NotTo(HaveKey("amqp-uri"))
+			Expect(secret.Data).To(HaveKey("amqps-uri"))
 		})
 
 		Context("when MQTT, STOMP, streams, WebMQTT, and WebSTOMP are enabled", func() {
@@ -256,10 +330,41 @@ var _ = Describe("DefaultUserSecret", func() {
 				port, ok = secret.Data["web-amqp-port"]
 				Expect(ok).To(BeTrue(), "Failed to find key \"web-stomp-port\" in the generated Secret")
 				Expect(port).To(BeEquivalentTo("15677"))
+
+				Expect(secret.Data).To(HaveKeyWithValue("mqtt-uri", MatchRegexp("^mqtts://.*:8883$")))
+				Expect(secret.Data).To(HaveKeyWithValue("stomp-uri", MatchRegexp("^stomp\\+ssl://.*:61614$")))
+				Expect(secret.Data).To(HaveKeyWithValue("stream-uri", MatchRegexp("^rabbitmq-stream\\+tls://.*:5551/%2F$")))
 			})
 		})
 	})
 
+	Context("Update of a Secret created by an older version", func() {
+		It("adds the service binding keys", func() {
+			secret = &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "a namespace"},
+				Data: map[string][]byte{
+					"username": []byte("user"),
+					"password": []byte("pass"),
+					"port":     []byte("5672"),
+				},
+			}
+			Expect(defaultUserSecretBuilder.Update(secret)).To(Succeed())
+			Expect(secret.Data).To(HaveKeyWithValue("provider", []byte("rabbitmq")))
+			Expect(secret.Data).To(HaveKeyWithValue("type", []byte("rabbitmq")))
+			Expect(secret.Data).To(HaveKeyWithValue("host", []byte("a name.a namespace.svc")))
+			Expect(secret.Data).To(HaveKeyWithValue("uri", []byte("amqp://user:pass@a name.a namespace.svc:5672/%2F")))
+		})
+
+		It("removes the CA certificate when TLS is disabled", func() {
+			secret = &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "a namespace"},
+				Data:       map[string][]byte{"ca.crt": []byte("ca certificate")},
+			}
+			Expect(defaultUserSecretBuilder.Update(secret)).To(Succeed())
+			Expect(secret.Data).NotTo(HaveKey("ca.crt"))
+		})
+	})
+
 	Context("Update with instance labels", func() {
 		It("Updates the secret", func() {
 			instance = rabbitmqv1beta1.RabbitmqCluster{
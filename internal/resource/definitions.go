@@ -0,0 +1,91 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+//
+
+package resource
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ReadDefinitions returns the definitions files referenced in spec.rabbitmq.definitions, keyed by file name.
+// It returns an error if a referenced object does not exist, if a key is not a .json file or is used
+// by more than one source, or if a file does not contain a JSON object.
+func ReadDefinitions(ctx context.Context, k8sClient client.Reader, instance *rabbitmqv1beta1.RabbitmqCluster) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	if instance.Spec.Rabbitmq.Definitions == nil {
+		return files, nil
+	}
+
+	for _, source := range instance.Spec.Rabbitmq.Definitions.Sources {
+		var data map[string][]byte
+		var kind, name string
+		switch {
+		case source.ConfigMap != nil:
+			kind, name = "ConfigMap", source.ConfigMap.Name
+			configMap := &corev1.ConfigMap{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: instance.Namespace}, configMap); err != nil {
+				return nil, fmt.Errorf("failed to get definitions ConfigMap %s: %w", name, err)
+			}
+			data = make(map[string][]byte, len(configMap.Data))
+			for key, value := range configMap.Data {
+				data[key] = []byte(value)
+			}
+			maps.Copy(data, configMap.BinaryData)
+		case source.Secret != nil:
+			kind, name = "Secret", source.Secret.Name
+			secret := &corev1.Secret{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: instance.Namespace}, secret); err != nil {
+				return nil, fmt.Errorf("failed to get definitions Secret %s: %w", name, err)
+			}
+			data = secret.Data
+		default:
+			continue
+		}
+
+		for key, value := range data {
+			if !strings.HasSuffix(key, ".json") {
+				return nil, fmt.Errorf("key %s of definitions %s %s is not a .json file", key, kind, name)
+			}
+			if _, ok := files[key]; ok {
+				return nil, fmt.Errorf("key %s of definitions %s %s is used by another definitions source", key, kind, name)
+			}
+			var definitions map[string]json.RawMessage
+			if err := json.Unmarshal(value, &definitions); err != nil {
+				return nil, fmt.Errorf("key %s of definitions %s %s does not contain a JSON object: %w", key, kind, name, err)
+			}
+			files[key] = value
+		}
+	}
+
+	return files, nil
+}
+
+// DefinitionsHash returns the SHA-256 hash of the definitions files, independent of the order of the sources
+func DefinitionsHash(files map[string][]byte) string {
+	hash := sha256.New()
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		hash.Write([]byte(name))
+		hash.Write([]byte{0})
+		hash.Write(files[name])
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,160 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+//
+
+package resource
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8sresource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefinitionsBackupKey is the key of definitions exports in Secrets and ConfigMaps
+	DefinitionsBackupKey = "definitions.json"
+	// Names of exports end with the time they were taken, so that they sort in the order they were taken
+	definitionsBackupTimeFormat = "20060102-150405"
+	definitionsBackupComponent  = "definitions-backup"
+	definitionsBackupMountPath  = "/backup"
+	definitionsExportMountPath  = "/export"
+)
+
+// DefinitionsBackupLabels returns the labels of the Secrets, ConfigMaps and Jobs holding definitions exports.
+// They are not owned by the RabbitmqCluster, so that they are kept when the RabbitmqCluster is deleted.
+func DefinitionsBackupLabels(instanceName string) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":      instanceName,
+		"app.kubernetes.io/component": definitionsBackupComponent,
+		"app.kubernetes.io/part-of":   "rabbitmq",
+	}
+}
+
+// DefinitionsBackupName returns the name of the export taken at the given time
+func DefinitionsBackupName(instance *rabbitmqv1beta1.RabbitmqCluster, t time.Time) string {
+	return instance.ChildResourceName("definitions-" + t.UTC().Format(definitionsBackupTimeFormat))
+}
+
+// DefinitionsBackupSecret returns a Secret holding a definitions export
+func DefinitionsBackupSecret(instance *rabbitmqv1beta1.RabbitmqCluster, name string, definitions []byte) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: instance.Namespace,
+			Labels:    DefinitionsBackupLabels(instance.Name),
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{DefinitionsBackupKey: definitions},
+	}
+}
+
+// DefinitionsBackupConfigMap returns a ConfigMap holding a definitions export
+func DefinitionsBackupConfigMap(instance *rabbitmqv1beta1.RabbitmqCluster, name string, definitions []byte) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: instance.Namespace,
+			Labels:    DefinitionsBackupLabels(instance.Name),
+		},
+		Data: map[string]string{DefinitionsBackupKey: string(definitions)},
+	}
+}
+
+// DefinitionsBackupJob returns a Job copying the export held by the Secret with the same name to the PersistentVolumeClaim
+// referenced in spec.backup.definitions, and deleting the oldest exports of the RabbitmqCluster beyond the retention.
+// The RabbitMQ image is used, since it is available wherever the cluster runs and provides a shell.
+func DefinitionsBackupJob(instance *rabbitmqv1beta1.RabbitmqCluster, name string) *batchv1.Job {
+	// matches the exports of this RabbitmqCluster only, even if the name of another RabbitmqCluster starts with the same prefix
+	exportsPattern := instance.ChildResourceName("definitions") + "-" + strings.Repeat("[0-9]", 8) + "-" + strings.Repeat("[0-9]", 6) + ".json"
+	script := `set -eu
+cp ` + definitionsExportMountPath + `/` + DefinitionsBackupKey + ` "` + definitionsBackupMountPath + `/${EXPORT_NAME}.json"
+ls -1 ` + definitionsBackupMountPath + `/` + exportsPattern + ` | sort -r | tail -n +$((RETENTION + 1)) | while read -r file; do rm -f "$file"; done`
+
+	rabbitmqUID := int64(999)
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: instance.Namespace,
+			Labels:    DefinitionsBackupLabels(instance.Name),
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            new(int32(3)),
+			TTLSecondsAfterFinished: new(int32(24 * 60 * 60)),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					// app.kubernetes.io/name is omitted, so that the Services of the RabbitmqCluster do not select the Pod
+					Labels: map[string]string{
+						"app.kubernetes.io/component": definitionsBackupComponent,
+						"app.kubernetes.io/part-of":   "rabbitmq",
+					},
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy:    corev1.RestartPolicyNever,
+					ImagePullSecrets: instance.Spec.ImagePullSecrets,
+					SecurityContext: &corev1.PodSecurityContext{
+						FSGroup:      new(int64(0)),
+						RunAsUser:    &rabbitmqUID,
+						RunAsNonRoot: new(true),
+					},
+					Containers: []corev1.Container{
+						{
+							Name:    "export",
+							Image:   instance.Spec.Image,
+							Command: []string{"sh", "-c", script},
+							Env: []corev1.EnvVar{
+								{Name: "EXPORT_NAME", Value: name},
+								{Name: "RETENTION", Value: strconv.Itoa(instance.DefinitionsBackupRetention())},
+							},
+							Resources: corev1.ResourceRequirements{
+								Limits: corev1.ResourceList{
+									corev1.ResourceCPU:    k8sresource.MustParse("100m"),
+									corev1.ResourceMemory: k8sresource.MustParse("64Mi"),
+								},
+								Requests: corev1.ResourceList{
+									corev1.ResourceCPU:    k8sresource.MustParse("100m"),
+									corev1.ResourceMemory: k8sresource.MustParse("64Mi"),
+								},
+							},
+							SecurityContext: &corev1.SecurityContext{
+								AllowPrivilegeEscalation: new(false),
+								ReadOnlyRootFilesystem:   new(true),
+								Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "export", MountPath: definitionsExportMountPath, ReadOnly: true},
+								{Name: "backup", MountPath: definitionsBackupMountPath},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "export",
+							VolumeSource: corev1.VolumeSource{
+								Secret: &corev1.SecretVolumeSource{SecretName: name},
+							},
+						},
+						{
+							Name: "backup",
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: instance.Spec.Backup.Definitions.PersistentVolumeClaimName,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
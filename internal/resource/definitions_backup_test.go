@@ -0,0 +1,86 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+//
+
+package resource_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("DefinitionsBackup", func() {
+	var instance *rabbitmqv1beta1.RabbitmqCluster
+
+	BeforeEach(func() {
+		instance = &rabbitmqv1beta1.RabbitmqCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "rabbit", Namespace: "default"},
+			Spec: rabbitmqv1beta1.RabbitmqClusterSpec{
+				Image:            "rabbitmq:4.2",
+				ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+				Backup: rabbitmqv1beta1.BackupSpec{
+					Definitions: &rabbitmqv1beta1.DefinitionsBackupSpec{
+						Schedule:                  "@daily",
+						StorageType:               rabbitmqv1beta1.DefinitionsBackupStoragePersistentVolumeClaim,
+						PersistentVolumeClaimName: "backups",
+						Retention:                 3,
+					},
+				},
+			},
+		}
+	})
+
+	It("names exports after the time they were taken, in UTC", func() {
+		location := time.FixedZone("UTC+2", 2*60*60)
+		Expect(resource.DefinitionsBackupName(instance, time.Date(2026, time.March, 4, 5, 6, 7, 0, location))).To(Equal("rabbit-definitions-20260304-030607"))
+	})
+
+	It("stores exports in Secrets and ConfigMaps under definitions.json", func() {
+		secret := resource.DefinitionsBackupSecret(instance, "rabbit-definitions-20260304-030607", []byte(`{}`))
+		Expect(secret.Namespace).To(Equal("default"))
+		Expect(secret.Labels).To(HaveKeyWithValue("app.kubernetes.io/component", "definitions-backup"))
+		Expect(secret.OwnerReferences).To(BeEmpty())
+		Expect(secret.Data).To(HaveKeyWithValue("definitions.json", []byte(`{}`)))
+
+		configMap := resource.DefinitionsBackupConfigMap(instance, "rabbit-definitions-20260304-030607", []byte(`{}`))
+		Expect(configMap.Data).To(HaveKeyWithValue("definitions.json", `{}`))
+	})
+
+	Context("DefinitionsBackupJob", func() {
+		It("copies the export to the PersistentVolumeClaim", func() {
+			job := resource.DefinitionsBackupJob(instance, "rabbit-definitions-20260304-030607")
+			podSpec := job.Spec.Template.Spec
+			Expect(podSpec.ImagePullSecrets).To(ConsistOf(corev1.LocalObjectReference{Name: "registry"}))
+			Expect(podSpec.Volumes).To(ConsistOf(
+				HaveField("Secret.SecretName", "rabbit-definitions-20260304-030607"),
+				HaveField("PersistentVolumeClaim.ClaimName", "backups"),
+			))
+
+			container := podSpec.Containers[0]
+			Expect(container.Image).To(Equal("rabbitmq:4.2"))
+			Expect(container.Env).To(ConsistOf(
+				corev1.EnvVar{Name: "EXPORT_NAME", Value: "rabbit-definitions-20260304-030607"},
+				corev1.EnvVar{Name: "RETENTION", Value: "3"},
+			))
+			Expect(container.Command[2]).To(ContainSubstring(`cp /export/definitions.json "/backup/${EXPORT_NAME}.json"`))
+			Expect(container.Command[2]).To(ContainSubstring("/backup/rabbit-definitions-[0-9][0-9][0-9][0-9][0-9][0-9][0-9][0-9]-[0-9][0-9][0-9][0-9][0-9][0-9].json"))
+		})
+
+		It("does not label the Pod with the RabbitmqCluster name", func() {
+			job := resource.DefinitionsBackupJob(instance, "rabbit-definitions-20260304-030607")
+			Expect(job.Labels).To(HaveKeyWithValue("app.kubernetes.io/name", "rabbit"))
+			Expect(job.Spec.Template.Labels).NotTo(HaveKey("app.kubernetes.io/name"))
+		})
+	})
+})
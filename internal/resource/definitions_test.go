@@ -0,0 +1,124 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+//
+
+package resource_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Definitions", func() {
+	var (
+		instance  *rabbitmqv1beta1.RabbitmqCluster
+		configMap *corev1.ConfigMap
+		secret    *corev1.Secret
+		k8sClient client.Reader
+	)
+
+	BeforeEach(func() {
+		instance = &rabbitmqv1beta1.RabbitmqCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "rabbit", Namespace: "default"},
+			Spec: rabbitmqv1beta1.RabbitmqClusterSpec{
+				Rabbitmq: rabbitmqv1beta1.RabbitmqClusterConfigurationSpec{
+					Definitions: &rabbitmqv1beta1.DefinitionsSpec{
+						Sources: []rabbitmqv1beta1.DefinitionsSource{
+							{ConfigMap: &corev1.LocalObjectReference{Name: "topology"}},
+							{Secret: &corev1.LocalObjectReference{Name: "users"}},
+						},
+					},
+				},
+			},
+		}
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "topology", Namespace: "default"},
+			Data:       map[string]string{"vhosts.json": `{"vhosts": [{"name": "orders"}]}`},
+		}
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "users", Namespace: "default"},
+			Data:       map[string][]byte{"users.json": []byte(`{"users": []}`)},
+		}
+	})
+
+	JustBeforeEach(func() {
+		k8sClient = fake.NewClientBuilder().WithObjects(configMap, secret).Build()
+	})
+
+	Context("ReadDefinitions", func() {
+		It("returns the files of all sources", func(ctx SpecContext) {
+			files, err := resource.ReadDefinitions(ctx, k8sClient, instance)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(HaveLen(2))
+			Expect(string(files["vhosts.json"])).To(Equal(`{"vhosts": [{"name": "orders"}]}`))
+			Expect(string(files["users.json"])).To(Equal(`{"users": []}`))
+		})
+
+		When("a file is not a JSON object", func() {
+			BeforeEach(func() {
+				configMap.Data["vhosts.json"] = `{"vhosts": [`
+			})
+
+			It("returns an error", func(ctx SpecContext) {
+				_, err := resource.ReadDefinitions(ctx, k8sClient, instance)
+				Expect(err).To(MatchError(ContainSubstring("key vhosts.json of definitions ConfigMap topology does not contain a JSON object")))
+			})
+		})
+
+		When("a key is not a .json file", func() {
+			BeforeEach(func() {
+				secret.Data["users.yaml"] = []byte("users: []")
+			})
+
+			It("returns an error", func(ctx SpecContext) {
+				_, err := resource.ReadDefinitions(ctx, k8sClient, instance)
+				Expect(err).To(MatchError(ContainSubstring("key users.yaml of definitions Secret users is not a .json file")))
+			})
+		})
+
+		When("two sources contain the same key", func() {
+			BeforeEach(func() {
+				secret.Data["vhosts.json"] = []byte(`{}`)
+			})
+
+			It("returns an error", func(ctx SpecContext) {
+				_, err := resource.ReadDefinitions(ctx, k8sClient, instance)
+				Expect(err).To(MatchError(ContainSubstring("key vhosts.json of definitions Secret users is used by another definitions source")))
+			})
+		})
+
+		When("a source does not exist", func() {
+			BeforeEach(func() {
+				secret.Name = "other"
+			})
+
+			It("returns a NotFound error", func(ctx SpecContext) {
+				_, err := resource.ReadDefinitions(ctx, k8sClient, instance)
+				Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+			})
+		})
+	})
+
+	Context("DefinitionsHash", func() {
+		It("changes with the content of the files", func() {
+			files := map[string][]byte{"a.json": []byte(`{}`), "b.json": []byte(`{"users": []}`)}
+			hash := resource.DefinitionsHash(files)
+			Expect(resource.DefinitionsHash(map[string][]byte{"b.json": []byte(`{"users": []}`), "a.json": []byte(`{}`)})).To(Equal(hash))
+
+			files["b.json"] = []byte(`{"users": [{"name": "orders"}]}`)
+			Expect(resource.DefinitionsHash(files)).NotTo(Equal(hash))
+		})
+	})
+})
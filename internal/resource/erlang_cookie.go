@@ -11,7 +11,9 @@ package resource
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -24,6 +26,11 @@ import (
 
 const (
 	erlangCookieName = "erlang-cookie"
+	ErlangCookieKey  = ".erlang.cookie"
+	// ErlangCookieHashAnnotation is set on the Pod template when the Erlang cookie is rotated.
+	// The setup container waits until the mounted cookie matches the hash, since a new Pod may
+	// briefly see the previous content of the Secret.
+	ErlangCookieHashAnnotation = "rabbitmq.com/erlangCookieSHA256"
 )
 
 type ErlangCookieBuilder struct {
@@ -35,7 +42,7 @@ func (builder *RabbitmqResourceBuilder) ErlangCookie() *ErlangCookieBuilder {
 }
 
 func (builder *ErlangCookieBuilder) Build() (client.Object, error) {
-	cookie, err := randomEncodedString(24)
+	cookie, err := GenerateErlangCookie()
 	if err != nil {
 		return nil, err
 	}
@@ -47,7 +54,7 @@ func (builder *ErlangCookieBuilder) Build() (client.Object, error) {
 		},
 		Type: corev1.SecretTypeOpaque,
 		Data: map[string][]byte{
-			".erlang.cookie": []byte(cookie),
+			ErlangCookieKey: []byte(cookie),
 		},
 	}, nil
 }
@@ -68,6 +75,16 @@ func (builder *ErlangCookieBuilder) Update(object client.Object) error {
 	return nil
 }
 
+func GenerateErlangCookie() (string, error) {
+	return randomEncodedString(24)
+}
+
+// ErlangCookieHash returns the hex encoded SHA-256 hash of the cookie, as printed by sha256sum
+func ErlangCookieHash(cookie []byte) string {
+	hash := sha256.Sum256(cookie)
+	return hex.EncodeToString(hash[:])
+}
+
 func randomEncodedString(dataLen int) (string, error) {
 	randomBytes := make([]byte, dataLen)
 	if _, err := rand.Read(randomBytes); err != nil {
@@ -179,4 +179,10 @@ var _ = Describe("ErlangCookie", func() {
 			Expect(erlangCookieBuilder.UpdateMayRequireStsRecreate()).To(BeFalse())
 		})
 	})
+
+	Context("ErlangCookieHash", func() {
+		It("matches the output of sha256sum", func() {
+			Expect(resource.ErlangCookieHash([]byte("cookie"))).To(Equal("d7e83e28a04b537e64424546b14caf9b67bad2f28dabce68116e0d372319fa00"))
+		})
+	})
 })
@@ -0,0 +1,185 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+//
+
+package resource
+
+import (
+	"fmt"
+	"net/url"
+
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/metadata"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	LinksCASecretName  = "links-ca"
+	LinkUserSecretName = "link-user"
+	// LinksCAPath is the directory in which the CA certificates of the RabbitmqClusters in spec.links are mounted
+	LinksCAPath         = "/etc/rabbitmq-links-ca/"
+	linksCAVolumeName   = "links-ca"
+	federationComponent = "federation-upstream"
+	shovelComponent     = "shovel"
+)
+
+// LinkVirtualHost returns the virtual host of this RabbitmqCluster the link is declared in, "/" by default
+func LinkVirtualHost(link rabbitmqv1beta1.LinkSpec) string {
+	if link.VirtualHost == "" {
+		return "/"
+	}
+	return link.VirtualHost
+}
+
+// LinkRemoteVirtualHost returns the virtual host of the other RabbitmqCluster, the virtual host of the link by default
+func LinkRemoteVirtualHost(link rabbitmqv1beta1.LinkSpec) string {
+	if link.RemoteVirtualHost == "" {
+		return LinkVirtualHost(link)
+	}
+	return link.RemoteVirtualHost
+}
+
+// LinkUsername returns the name of the user a RabbitmqCluster authenticates as on the other RabbitmqClusters of its links.
+// It is unique per RabbitmqCluster, since several RabbitmqClusters can link to the same one.
+func LinkUsername(rmq *rabbitmqv1beta1.RabbitmqCluster) string {
+	return fmt.Sprintf("link_%s_%s", rmq.Namespace, rmq.Name)
+}
+
+// LinkUserSecret returns the Secret of a RabbitmqCluster holding the credentials of its link user
+func LinkUserSecret(rmq *rabbitmqv1beta1.RabbitmqCluster, password string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rmq.ChildResourceName(LinkUserSecretName),
+			Namespace: rmq.Namespace,
+			Labels:    metadata.GetLabels(rmq.Name, rmq.Labels),
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			"username": []byte(LinkUsername(rmq)),
+			"password": []byte(password),
+		},
+	}
+}
+
+// LinkCAFilename returns the name of the file holding the CA certificate of the other RabbitmqCluster of a link,
+// in the links CA Secret and in LinksCAPath
+func LinkCAFilename(link rabbitmqv1beta1.LinkSpec) string {
+	return link.Name + ".crt"
+}
+
+// LinksCASecret returns the Secret holding the CA certificates of the other RabbitmqClusters of the links, which may
+// be in other namespaces, keyed by LinkCAFilename
+func LinksCASecret(rmq *rabbitmqv1beta1.RabbitmqCluster, data map[string][]byte) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rmq.ChildResourceName(LinksCASecretName),
+			Namespace: rmq.Namespace,
+			Labels:    metadata.GetLabels(rmq.Name, rmq.Labels),
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: data,
+	}
+}
+
+// linksCAVolume mounts the links CA Secret. It is optional since the Secret is created after the StatefulSet.
+func linksCAVolume(rmq *rabbitmqv1beta1.RabbitmqCluster) corev1.Volume {
+	return corev1.Volume{
+		Name: linksCAVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: rmq.ChildResourceName(LinksCASecretName),
+				Optional:   new(true),
+			},
+		},
+	}
+}
+
+// LinkURI returns the AMQP URI of the other RabbitmqCluster of a link, through the given port of its client Service.
+// TLS is used if caFile is set, in which case the certificate of the other RabbitmqCluster is verified against it.
+func LinkURI(remote *rabbitmqv1beta1.RabbitmqCluster, port int32, username, password, vhost, caFile string) string {
+	host := remote.ServiceSubDomain()
+	if caFile == "" {
+		return fmt.Sprintf("amqp://%s@%s:%d/%s", url.UserPassword(username, password), host, port, url.PathEscape(vhost))
+	}
+	query := url.Values{
+		"cacertfile":             []string{caFile},
+		"verify":                 []string{"verify_peer"},
+		"server_name_indication": []string{host},
+	}
+	return fmt.Sprintf("amqps://%s@%s:%d/%s?%s", url.UserPassword(username, password), host, port, url.PathEscape(vhost), query.Encode())
+}
+
+// LinkParameter returns the runtime parameter component and value declaring the federation upstream or the
+// dynamic shovel of a link, given the URI of the other RabbitmqCluster
+func LinkParameter(link rabbitmqv1beta1.LinkSpec, remoteURI string) (component string, value map[string]any) {
+	if link.Type == rabbitmqv1beta1.LinkTypeShovel {
+		return shovelComponent, shovelParameter(link, remoteURI)
+	}
+
+	value = map[string]any{"uri": remoteURI}
+	if federation := link.Federation; federation != nil {
+		if federation.Exchange != "" {
+			value["exchange"] = federation.Exchange
+		}
+		if federation.Queue != "" {
+			value["queue"] = federation.Queue
+		}
+		if federation.MaxHops != nil {
+			value["max-hops"] = *federation.MaxHops
+		}
+	}
+	return federationComponent, value
+}
+
+// LinkComponent returns the runtime parameter component of a link
+func LinkComponent(linkType rabbitmqv1beta1.LinkType) string {
+	if linkType == rabbitmqv1beta1.LinkTypeShovel {
+		return shovelComponent
+	}
+	return federationComponent
+}
+
+func shovelParameter(link rabbitmqv1beta1.LinkSpec, remoteURI string) map[string]any {
+	shovel := link.Shovel
+	if shovel == nil {
+		shovel = &rabbitmqv1beta1.ShovelLinkSpec{}
+	}
+	// a URI without host connects to the node running the shovel
+	localURI := "amqp:///" + url.PathEscape(LinkVirtualHost(link))
+	srcURI, destURI := remoteURI, localURI
+	if shovel.Direction == rabbitmqv1beta1.ShovelPush {
+		srcURI, destURI = localURI, remoteURI
+	}
+
+	value := map[string]any{
+		"src-protocol":  "amqp091",
+		"src-uri":       srcURI,
+		"dest-protocol": "amqp091",
+		"dest-uri":      destURI,
+	}
+	if shovel.SourceQueue != "" {
+		value["src-queue"] = shovel.SourceQueue
+	} else {
+		value["src-exchange"] = shovel.SourceExchange
+		value["src-exchange-key"] = "#"
+		if shovel.SourceExchangeKey != "" {
+			value["src-exchange-key"] = shovel.SourceExchangeKey
+		}
+	}
+	if shovel.DestinationQueue != "" {
+		value["dest-queue"] = shovel.DestinationQueue
+	}
+	if shovel.DestinationExchange != "" {
+		value["dest-exchange"] = shovel.DestinationExchange
+		if shovel.DestinationExchangeKey != "" {
+			value["dest-exchange-key"] = shovel.DestinationExchangeKey
+		}
+	}
+	return value
+}
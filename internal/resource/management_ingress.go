@@ -0,0 +1,275 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+//
+
+package resource
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/metadata"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	ManagementIngressSuffix = "management"
+	// Ingress-nginx does not look at the appProtocol of Service ports, so it is told explicitly
+	// to use HTTPS when talking to the management-tls port.
+	ingressNginxBackendProtocolAnnotation = "nginx.ingress.kubernetes.io/backend-protocol"
+)
+
+var (
+	HTTPRouteGVK         = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1", Kind: "HTTPRoute"}
+	BackendTLSPolicyGVK  = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1", Kind: "BackendTLSPolicy"}
+	gatewayAPIGroup      = HTTPRouteGVK.Group
+	managementPortNumber = map[string]int64{"management": 15672, "management-tls": 15671}
+)
+
+type ManagementIngressBuilder struct {
+	*RabbitmqResourceBuilder
+}
+
+type ManagementHTTPRouteBuilder struct {
+	*RabbitmqResourceBuilder
+}
+
+type ManagementBackendTLSPolicyBuilder struct {
+	*RabbitmqResourceBuilder
+}
+
+func (builder *RabbitmqResourceBuilder) ManagementIngress() *ManagementIngressBuilder {
+	return &ManagementIngressBuilder{builder}
+}
+
+func (builder *RabbitmqResourceBuilder) ManagementHTTPRoute() *ManagementHTTPRouteBuilder {
+	return &ManagementHTTPRouteBuilder{builder}
+}
+
+func (builder *RabbitmqResourceBuilder) ManagementBackendTLSPolicy() *ManagementBackendTLSPolicyBuilder {
+	return &ManagementBackendTLSPolicyBuilder{builder}
+}
+
+// managementBackendTLS returns true when the management UI is only reachable through its TLS listener.
+func managementBackendTLS(instance *rabbitmqv1beta1.RabbitmqCluster) bool {
+	return instance.TLSEnabled() && instance.DisableNonTLSListeners()
+}
+
+func managementServicePortName(instance *rabbitmqv1beta1.RabbitmqCluster) string {
+	if managementBackendTLS(instance) {
+		return "management-tls"
+	}
+	return "management"
+}
+
+func managementIngressAnnotations(instance *rabbitmqv1beta1.RabbitmqCluster, existing map[string]string, defaults map[string]string) map[string]string {
+	annotations := metadata.ReconcileAndFilterAnnotations(existing, instance.Annotations)
+	return metadata.ReconcileAnnotations(annotations, defaults, instance.Spec.Management.Ingress.Annotations)
+}
+
+func (builder *ManagementIngressBuilder) Build() (client.Object, error) {
+	return &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      builder.Instance.ChildResourceName(ManagementIngressSuffix),
+			Namespace: builder.Instance.Namespace,
+		},
+	}, nil
+}
+
+func (builder *ManagementIngressBuilder) UpdateMayRequireStsRecreate() bool {
+	return false
+}
+
+func (builder *ManagementIngressBuilder) Update(object client.Object) error {
+	ingress := object.(*networkingv1.Ingress)
+	ingressSpec := builder.Instance.Spec.Management.Ingress
+
+	var defaultAnnotations map[string]string
+	if managementBackendTLS(builder.Instance) {
+		defaultAnnotations = map[string]string{ingressNginxBackendProtocolAnnotation: "HTTPS"}
+	}
+	ingress.Annotations = managementIngressAnnotations(builder.Instance, ingress.Annotations, defaultAnnotations)
+	ingress.Labels = metadata.GetLabels(builder.Instance.Name, builder.Instance.Labels)
+
+	pathType := networkingv1.PathTypePrefix
+	ingress.Spec = networkingv1.IngressSpec{
+		IngressClassName: ingressSpec.IngressClassName,
+		Rules: []networkingv1.IngressRule{
+			{
+				Host: ingressSpec.Host,
+				IngressRuleValue: networkingv1.IngressRuleValue{
+					HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{
+							{
+								Path:     builder.Instance.ManagementPathPrefix(),
+								PathType: &pathType,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: builder.Instance.ChildResourceName(ServiceSuffix),
+										Port: networkingv1.ServiceBackendPort{
+											Name: managementServicePortName(builder.Instance),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if ingressSpec.TLSSecretName != "" {
+		ingressTLS := networkingv1.IngressTLS{SecretName: ingressSpec.TLSSecretName}
+		if ingressSpec.Host != "" {
+			ingressTLS.Hosts = []string{ingressSpec.Host}
+		}
+		ingress.Spec.TLS = []networkingv1.IngressTLS{ingressTLS}
+	}
+
+	if err := controllerutil.SetControllerReference(builder.Instance, ingress, builder.Scheme); err != nil {
+		return fmt.Errorf("failed setting controller reference: %w", err)
+	}
+	return nil
+}
+
+func (builder *ManagementHTTPRouteBuilder) Build() (client.Object, error) {
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(HTTPRouteGVK)
+	route.SetName(builder.Instance.ChildResourceName(ManagementIngressSuffix))
+	route.SetNamespace(builder.Instance.Namespace)
+	return route, nil
+}
+
+func (builder *ManagementHTTPRouteBuilder) UpdateMayRequireStsRecreate() bool {
+	return false
+}
+
+func (builder *ManagementHTTPRouteBuilder) Update(object client.Object) error {
+	route := object.(*unstructured.Unstructured)
+	ingressSpec := builder.Instance.Spec.Management.Ingress
+
+	route.SetAnnotations(managementIngressAnnotations(builder.Instance, route.GetAnnotations(), nil))
+	route.SetLabels(metadata.GetLabels(builder.Instance.Name, builder.Instance.Labels))
+
+	parentRefs := make([]any, 0, len(ingressSpec.ParentRefs))
+	for _, ref := range ingressSpec.ParentRefs {
+		parentRef := map[string]any{
+			"group": gatewayAPIGroup,
+			"kind":  "Gateway",
+			"name":  ref.Name,
+		}
+		if ref.Namespace != "" {
+			parentRef["namespace"] = ref.Namespace
+		}
+		if ref.SectionName != "" {
+			parentRef["sectionName"] = ref.SectionName
+		}
+		parentRefs = append(parentRefs, parentRef)
+	}
+
+	portName := managementServicePortName(builder.Instance)
+	spec := map[string]any{
+		"parentRefs": parentRefs,
+		"rules": []any{
+			map[string]any{
+				"matches": []any{
+					map[string]any{
+						"path": map[string]any{
+							"type":  "PathPrefix",
+							"value": builder.Instance.ManagementPathPrefix(),
+						},
+					},
+				},
+				"backendRefs": []any{
+					map[string]any{
+						"group": "",
+						"kind":  "Service",
+						"name":  builder.Instance.ChildResourceName(ServiceSuffix),
+						"port":  managementPortNumber[portName],
+					},
+				},
+			},
+		},
+	}
+	if ingressSpec.Host != "" {
+		spec["hostnames"] = []any{ingressSpec.Host}
+	}
+
+	if err := unstructured.SetNestedField(route.Object, spec, "spec"); err != nil {
+		return fmt.Errorf("failed setting HTTPRoute spec: %w", err)
+	}
+
+	if err := controllerutil.SetControllerReference(builder.Instance, route, builder.Scheme); err != nil {
+		return fmt.Errorf("failed setting controller reference: %w", err)
+	}
+	return nil
+}
+
+func (builder *ManagementBackendTLSPolicyBuilder) Build() (client.Object, error) {
+	policy := &unstructured.Unstructured{}
+	policy.SetGroupVersionKind(BackendTLSPolicyGVK)
+	policy.SetName(builder.Instance.ChildResourceName(ManagementIngressSuffix))
+	policy.SetNamespace(builder.Instance.Namespace)
+	return policy, nil
+}
+
+func (builder *ManagementBackendTLSPolicyBuilder) UpdateMayRequireStsRecreate() bool {
+	return false
+}
+
+func (builder *ManagementBackendTLSPolicyBuilder) Update(object client.Object) error {
+	policy := object.(*unstructured.Unstructured)
+
+	policy.SetAnnotations(metadata.ReconcileAndFilterAnnotations(policy.GetAnnotations(), builder.Instance.Annotations))
+	policy.SetLabels(metadata.GetLabels(builder.Instance.Name, builder.Instance.Labels))
+
+	// The gateway validates the certificate of the management listener against the name of the client Service.
+	// The RabbitMQ certificate SAN must therefore include <cluster-name>.<namespace>.svc
+	validation := map[string]any{
+		"hostname": builder.Instance.ServiceSubDomain(),
+	}
+	if builder.Instance.Spec.TLS.CaSecretName != "" {
+		validation["caCertificateRefs"] = []any{
+			map[string]any{
+				"group": "",
+				"kind":  "Secret",
+				"name":  builder.Instance.Spec.TLS.CaSecretName,
+			},
+		}
+	} else {
+		validation["wellKnownCACertificates"] = "System"
+	}
+
+	spec := map[string]any{
+		"targetRefs": []any{
+			map[string]any{
+				"group":       "",
+				"kind":        "Service",
+				"name":        builder.Instance.ChildResourceName(ServiceSuffix),
+				"sectionName": "management-tls",
+			},
+		},
+		"validation": validation,
+	}
+
+	if err := unstructured.SetNestedField(policy.Object, spec, "spec"); err != nil {
+		return fmt.Errorf("failed setting BackendTLSPolicy spec: %w", err)
+	}
+
+	if err := controllerutil.SetControllerReference(builder.Instance, policy, builder.Scheme); err != nil {
+		return fmt.Errorf("failed setting controller reference: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+//
+
+package resource_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	defaultscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+var _ = Describe("Management Ingress", func() {
+	var (
+		instance rabbitmqv1beta1.RabbitmqCluster
+		builder  *resource.RabbitmqResourceBuilder
+		scheme   *runtime.Scheme
+	)
+
+	BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		Expect(rabbitmqv1beta1.AddToScheme(scheme)).To(Succeed())
+		Expect(defaultscheme.AddToScheme(scheme)).To(Succeed())
+		instance = rabbitmqv1beta1.RabbitmqCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "rabbit",
+				Namespace: "a-namespace",
+			},
+			Spec: rabbitmqv1beta1.RabbitmqClusterSpec{
+				Management: rabbitmqv1beta1.ManagementSpec{
+					Ingress: &rabbitmqv1beta1.ManagementIngressSpec{
+						Type: rabbitmqv1beta1.ManagementIngressTypeIngress,
+						Host: "rabbit.example.com",
+					},
+				},
+			},
+		}
+		builder = &resource.RabbitmqResourceBuilder{
+			Instance: &instance,
+			Scheme:   scheme,
+		}
+	})
+
+	Context("Ingress", func() {
+		var ingress *networkingv1.Ingress
+
+		BeforeEach(func() {
+			obj, err := builder.ManagementIngress().Build()
+			Expect(err).NotTo(HaveOccurred())
+			ingress = obj.(*networkingv1.Ingress)
+		})
+
+		It("generates correct metadata", func() {
+			Expect(ingress.Name).To(Equal("rabbit-management"))
+			Expect(ingress.Namespace).To(Equal("a-namespace"))
+		})
+
+		It("routes the host to the management port of the client Service", func() {
+			Expect(builder.ManagementIngress().Update(ingress)).To(Succeed())
+
+			Expect(ingress.Spec.Rules).To(HaveLen(1))
+			Expect(ingress.Spec.Rules[0].Host).To(Equal("rabbit.example.com"))
+			paths := ingress.Spec.Rules[0].HTTP.Paths
+			Expect(paths).To(HaveLen(1))
+			Expect(paths[0].Path).To(Equal("/"))
+			Expect(*paths[0].PathType).To(Equal(networkingv1.PathTypePrefix))
+			Expect(paths[0].Backend.Service.Name).To(Equal("rabbit"))
+			Expect(paths[0].Backend.Service.Port.Name).To(Equal("management"))
+			Expect(ingress.Spec.TLS).To(BeEmpty())
+			Expect(ingress.Annotations).NotTo(HaveKey("nginx.ingress.kubernetes.io/backend-protocol"))
+		})
+
+		It("uses the configured path prefix", func() {
+			instance.Spec.Management.PathPrefix = "/rabbitmq"
+			Expect(builder.ManagementIngress().Update(ingress)).To(Succeed())
+			Expect(ingress.Spec.Rules[0].HTTP.Paths[0].Path).To(Equal("/rabbitmq"))
+		})
+
+		It("sets the ingress class, TLS secret and annotations", func() {
+			instance.Spec.Management.Ingress.IngressClassName = new("nginx")
+			instance.Spec.Management.Ingress.TLSSecretName = "ingress-tls"
+			instance.Spec.Management.Ingress.Annotations = map[string]string{"cert-manager.io/cluster-issuer": "letsencrypt"}
+			Expect(builder.ManagementIngress().Update(ingress)).To(Succeed())
+
+			Expect(*ingress.Spec.IngressClassName).To(Equal("nginx"))
+			Expect(ingress.Spec.TLS).To(ConsistOf(networkingv1.IngressTLS{
+				Hosts:      []string{"rabbit.example.com"},
+				SecretName: "ingress-tls",
+			}))
+			Expect(ingress.Annotations).To(HaveKeyWithValue("cert-manager.io/cluster-issuer", "letsencrypt"))
+		})
+
+		It("sets labels and the controller reference", func() {
+			Expect(builder.ManagementIngress().Update(ingress)).To(Succeed())
+			Expect(ingress.Labels).To(HaveKeyWithValue("app.kubernetes.io/name", "rabbit"))
+			Expect(ingress.Labels).To(HaveKeyWithValue("app.kubernetes.io/part-of", "rabbitmq"))
+			Expect(ingress.OwnerReferences).To(HaveLen(1))
+			Expect(ingress.OwnerReferences[0].Name).To(Equal("rabbit"))
+		})
+
+		When("non-TLS listeners are disabled", func() {
+			BeforeEach(func() {
+				instance.Spec.TLS = rabbitmqv1beta1.TLSSpec{
+					SecretName:             "tls-secret",
+					DisableNonTLSListeners: true,
+				}
+			})
+
+			It("routes to the management-tls port over HTTPS", func() {
+				Expect(builder.ManagementIngress().Update(ingress)).To(Succeed())
+				Expect(ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Port.Name).To(Equal("management-tls"))
+				Expect(ingress.Annotations).To(HaveKeyWithValue("nginx.ingress.kubernetes.io/backend-protocol", "HTTPS"))
+			})
+
+			It("lets users override the backend protocol annotation", func() {
+				instance.Spec.Management.Ingress.Annotations = map[string]string{"nginx.ingress.kubernetes.io/backend-protocol": "GRPCS"}
+				Expect(builder.ManagementIngress().Update(ingress)).To(Succeed())
+				Expect(ingress.Annotations).To(HaveKeyWithValue("nginx.ingress.kubernetes.io/backend-protocol", "GRPCS"))
+			})
+		})
+	})
+
+	Context("HTTPRoute", func() {
+		var route *unstructured.Unstructured
+
+		BeforeEach(func() {
+			instance.Spec.Management.Ingress.Type = rabbitmqv1beta1.ManagementIngressTypeHTTPRoute
+			instance.Spec.Management.Ingress.ParentRefs = []rabbitmqv1beta1.ManagementGatewayParentRef{
+				{Name: "gateway", Namespace: "gateway-namespace", SectionName: "https"},
+			}
+			obj, err := builder.ManagementHTTPRoute().Build()
+			Expect(err).NotTo(HaveOccurred())
+			route = obj.(*unstructured.Unstructured)
+		})
+
+		It("generates correct metadata", func() {
+			Expect(route.GroupVersionKind()).To(Equal(resource.HTTPRouteGVK))
+			Expect(route.GetName()).To(Equal("rabbit-management"))
+			Expect(route.GetNamespace()).To(Equal("a-namespace"))
+		})
+
+		It("attaches to the parent Gateways and routes to the management port", func() {
+			instance.Spec.Management.PathPrefix = "/rabbitmq"
+			Expect(builder.ManagementHTTPRoute().Update(route)).To(Succeed())
+
+			hostnames, _, _ := unstructured.NestedStringSlice(route.Object, "spec", "hostnames")
+			Expect(hostnames).To(ConsistOf("rabbit.example.com"))
+
+			parentRefs, _, _ := unstructured.NestedSlice(route.Object, "spec", "parentRefs")
+			Expect(parentRefs).To(ConsistOf(map[string]any{
+				"group":       "gateway.networking.k8s.io",
+				"kind":        "Gateway",
+				"name":        "gateway",
+				"namespace":   "gateway-namespace",
+				"sectionName": "https",
+			}))
+
+			rules, _, _ := unstructured.NestedSlice(route.Object, "spec", "rules")
+			Expect(rules).To(HaveLen(1))
+			rule := rules[0].(map[string]any)
+			Expect(rule["matches"]).To(ConsistOf(map[string]any{
+				"path": map[string]any{"type": "PathPrefix", "value": "/rabbitmq"},
+			}))
+			Expect(rule["backendRefs"]).To(ConsistOf(map[string]any{
+				"group": "",
+				"kind":  "Service",
+				"name":  "rabbit",
+				"port":  int64(15672),
+			}))
+			Expect(route.GetOwnerReferences()).To(HaveLen(1))
+		})
+
+		It("routes to the management-tls port when non-TLS listeners are disabled", func() {
+			instance.Spec.TLS = rabbitmqv1beta1.TLSSpec{
+				SecretName:             "tls-secret",
+				DisableNonTLSListeners: true,
+			}
+			Expect(builder.ManagementHTTPRoute().Update(route)).To(Succeed())
+			rules, _, _ := unstructured.NestedSlice(route.Object, "spec", "rules")
+			backendRef := rules[0].(map[string]any)["backendRefs"].([]any)[0].(map[string]any)
+			Expect(backendRef["port"]).To(Equal(int64(15671)))
+		})
+	})
+
+	Context("BackendTLSPolicy", func() {
+		var policy *unstructured.Unstructured
+
+		BeforeEach(func() {
+			instance.Spec.TLS = rabbitmqv1beta1.TLSSpec{
+				SecretName:             "tls-secret",
+				CaSecretName:           "ca-secret",
+				DisableNonTLSListeners: true,
+			}
+			obj, err := builder.ManagementBackendTLSPolicy().Build()
+			Expect(err).NotTo(HaveOccurred())
+			policy = obj.(*unstructured.Unstructured)
+		})
+
+		It("validates the management-tls backend against the CA secret", func() {
+			Expect(builder.ManagementBackendTLSPolicy().Update(policy)).To(Succeed())
+			Expect(policy.GroupVersionKind()).To(Equal(resource.BackendTLSPolicyGVK))
+
+			targetRefs, _, _ := unstructured.NestedSlice(policy.Object, "spec", "targetRefs")
+			Expect(targetRefs).To(ConsistOf(map[string]any{
+				"group":       "",
+				"kind":        "Service",
+				"name":        "rabbit",
+				"sectionName": "management-tls",
+			}))
+			hostname, _, _ := unstructured.NestedString(policy.Object, "spec", "validation", "hostname")
+			Expect(hostname).To(Equal("rabbit.a-namespace.svc"))
+			caRefs, _, _ := unstructured.NestedSlice(policy.Object, "spec", "validation", "caCertificateRefs")
+			Expect(caRefs).To(ConsistOf(map[string]any{"group": "", "kind": "Secret", "name": "ca-secret"}))
+		})
+
+		It("falls back to the system CAs when no CA secret is configured", func() {
+			instance.Spec.TLS.CaSecretName = ""
+			Expect(builder.ManagementBackendTLSPolicy().Update(policy)).To(Succeed())
+			wellKnown, _, _ := unstructured.NestedString(policy.Object, "spec", "validation", "wellKnownCACertificates")
+			Expect(wellKnown).To(Equal("System"))
+		})
+	})
+})
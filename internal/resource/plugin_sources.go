@@ -0,0 +1,176 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+//
+
+package resource
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	k8sresource "k8s.io/apimachinery/pkg/api/resource"
+)
+
+// DefaultPluginFetcherImage runs the fetch-plugins init container unless spec.rabbitmq.pluginFetcherImage is set
+const DefaultPluginFetcherImage = "curlimages/curl:8.16.0"
+
+const (
+	fetchPluginsContainerName = "fetch-plugins"
+	// directory of the rabbitmq-plugins volume the plugins of spec.rabbitmq.pluginSources are fetched into
+	pluginSourcesDir = "/operator/plugins"
+	// directory image volumes of spec.rabbitmq.pluginSources are mounted in
+	pluginImagesDir = "/plugin-images"
+)
+
+// pluginSourcesEnvConfig appends pluginSourcesDir to the plugins directories in rabbitmq-env.conf, which is sourced by the
+// server and the CLI tools. It keeps the directories set by the image or earlier in spec.rabbitmq.envConfig, and otherwise
+// the default plugins directory of the image. The directory is only appended once if the file is sourced again.
+const pluginSourcesEnvConfig = `# added by the operator for spec.rabbitmq.pluginSources
+case ":$RABBITMQ_PLUGINS_DIR:" in
+  *:` + pluginSourcesDir + `:*) ;;
+  *) RABBITMQ_PLUGINS_DIR="${RABBITMQ_PLUGINS_DIR:-${RABBITMQ_HOME:-/opt/rabbitmq}/plugins}:` + pluginSourcesDir + `" ;;
+esac
+`
+
+// envConfig returns the content of rabbitmq-env.conf
+func envConfig(instance *rabbitmqv1beta1.RabbitmqCluster) string {
+	conf := instance.Spec.Rabbitmq.EnvConfig
+	if len(instance.Spec.Rabbitmq.PluginSources) == 0 {
+		return conf
+	}
+	if conf != "" && !strings.HasSuffix(conf, "\n") {
+		conf += "\n"
+	}
+	return conf + pluginSourcesEnvConfig
+}
+
+// envConfigIsSet returns true if rabbitmq-env.conf is mounted in the nodes
+func envConfigIsSet(instance *rabbitmqv1beta1.RabbitmqCluster) bool {
+	return envConfig(instance) != ""
+}
+
+// PluginSourceFilename returns the name of the .ez file of a plugin source, and an error if it does not match the plugin name.
+// RabbitMQ expects the files of plugins to be named after the plugin, optionally followed by its version.
+func PluginSourceFilename(source rabbitmqv1beta1.PluginSource) (string, error) {
+	var filename string
+	switch {
+	case source.URL != "":
+		u, err := url.Parse(source.URL)
+		if err != nil {
+			return "", fmt.Errorf("invalid URL: %w", err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return "", fmt.Errorf("URL scheme must be http or https, not %q", u.Scheme)
+		}
+		filename = path.Base(u.Path)
+	case source.Image != nil:
+		filename = path.Base(source.Image.Path)
+	default:
+		return "", fmt.Errorf("plugin source %s has neither url nor image", source.Name)
+	}
+
+	name := string(source.Name)
+	if !strings.HasSuffix(filename, ".ez") {
+		return "", fmt.Errorf("plugin file %s must have the extension .ez", filename)
+	}
+	if base := strings.TrimSuffix(filename, ".ez"); base != name && !strings.HasPrefix(base, name+"-") {
+		return "", fmt.Errorf("plugin file %s does not match the plugin name %s", filename, name)
+	}
+	return filename, nil
+}
+
+func pluginImageVolumeName(index int) string {
+	return fmt.Sprintf("plugin-image-%d", index)
+}
+
+// pluginImageVolumes returns the image volumes of the plugin sources given by OCI images or artifacts
+func pluginImageVolumes(instance *rabbitmqv1beta1.RabbitmqCluster) []corev1.Volume {
+	var volumes []corev1.Volume
+	for i, source := range instance.Spec.Rabbitmq.PluginSources {
+		if source.Image == nil {
+			continue
+		}
+		volumes = append(volumes, corev1.Volume{
+			Name: pluginImageVolumeName(i),
+			VolumeSource: corev1.VolumeSource{
+				Image: &corev1.ImageVolumeSource{
+					Reference:  source.Image.Reference,
+					PullPolicy: source.Image.PullPolicy,
+				},
+			},
+		})
+	}
+	return volumes
+}
+
+// fetchPluginsContainer returns the init container copying the plugins of spec.rabbitmq.pluginSources into the rabbitmq-plugins
+// volume, or nil if there are none. It fails if a file does not have the expected checksum, so that the node does not start.
+func fetchPluginsContainer(instance *rabbitmqv1beta1.RabbitmqCluster) (*corev1.Container, error) {
+	sources := instance.Spec.Rabbitmq.PluginSources
+	if len(sources) == 0 {
+		return nil, nil
+	}
+
+	image := instance.Spec.Rabbitmq.PluginFetcherImage
+	if image == "" {
+		image = DefaultPluginFetcherImage
+	}
+	volumeMounts := []corev1.VolumeMount{{Name: "rabbitmq-plugins", MountPath: "/operator"}}
+	script := []string{"set -e", "rm -rf " + pluginSourcesDir, "mkdir -p " + pluginSourcesDir}
+	for i, source := range sources {
+		// invalid sources are rejected by the webhook, unless it is disabled
+		filename, err := PluginSourceFilename(source)
+		if err != nil {
+			return nil, fmt.Errorf("invalid spec.rabbitmq.pluginSources[%d]: %w", i, err)
+		}
+		file := shellQuote(pluginSourcesDir + "/" + filename)
+		if source.Image != nil {
+			mountPath := fmt.Sprintf("%s/%d", pluginImagesDir, i)
+			volumeMounts = append(volumeMounts, corev1.VolumeMount{Name: pluginImageVolumeName(i), MountPath: mountPath, ReadOnly: true})
+			script = append(script, fmt.Sprintf("cp %s %s", shellQuote(path.Join(mountPath, source.Image.Path)), file))
+		} else {
+			script = append(script, fmt.Sprintf("curl --fail --silent --show-error --location --retry 3 --output %s %s", file, shellQuote(source.URL)))
+		}
+		script = append(script, fmt.Sprintf("echo %s | sha256sum -c -", shellQuote(source.SHA256+"  "+pluginSourcesDir+"/"+filename)))
+	}
+
+	cpu := k8sresource.MustParse("100m")
+	memory := k8sresource.MustParse("128Mi")
+	return &corev1.Container{
+		Name:    fetchPluginsContainerName,
+		Image:   image,
+		Command: []string{"sh", "-c", strings.Join(script, "\n")},
+		Resources: corev1.ResourceRequirements{
+			Limits:   corev1.ResourceList{corev1.ResourceCPU: cpu, corev1.ResourceMemory: memory},
+			Requests: corev1.ResourceList{corev1.ResourceCPU: cpu, corev1.ResourceMemory: memory},
+		},
+		VolumeMounts:             volumeMounts,
+		TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+		SecurityContext: &corev1.SecurityContext{
+			AllowPrivilegeEscalation: new(bool(false)),
+			Capabilities: &corev1.Capabilities{
+				Drop: []corev1.Capability{"ALL"},
+			},
+			Privileged:             new(bool(false)),
+			ReadOnlyRootFilesystem: new(bool(true)),
+			RunAsNonRoot:           new(bool(true)),
+			SeccompProfile: &corev1.SeccompProfile{
+				Type: corev1.SeccompProfileTypeRuntimeDefault,
+			},
+		},
+	}, nil
+}
+
+// shellQuote quotes a string for sh
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,46 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+//
+
+package resource_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+)
+
+var _ = Describe("PluginSourceFilename", func() {
+	DescribeTable("file names matching the plugin",
+		func(source rabbitmqv1beta1.PluginSource, expected string) {
+			source.Name = "rabbitmq_delayed_message_exchange"
+			Expect(resource.PluginSourceFilename(source)).To(Equal(expected))
+		},
+		Entry("URL with version", rabbitmqv1beta1.PluginSource{URL: "https://example.com/v4.1.0/rabbitmq_delayed_message_exchange-4.1.0.ez?download=1"},
+			"rabbitmq_delayed_message_exchange-4.1.0.ez"),
+		Entry("image path without version", rabbitmqv1beta1.PluginSource{Image: &rabbitmqv1beta1.PluginImageSource{Reference: "plugins:1", Path: "/rabbitmq_delayed_message_exchange.ez"}},
+			"rabbitmq_delayed_message_exchange.ez"),
+	)
+
+	DescribeTable("invalid sources",
+		func(source rabbitmqv1beta1.PluginSource, message string) {
+			source.Name = "rabbitmq_delayed_message_exchange"
+			_, err := resource.PluginSourceFilename(source)
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("other plugin", rabbitmqv1beta1.PluginSource{URL: "https://example.com/rabbitmq_message_deduplication-0.6.4.ez"},
+			"does not match the plugin name rabbitmq_delayed_message_exchange"),
+		Entry("plugin name prefix", rabbitmqv1beta1.PluginSource{URL: "https://example.com/rabbitmq_delayed_message_exchange_v2-1.0.ez"},
+			"does not match the plugin name"),
+		Entry("not an archive", rabbitmqv1beta1.PluginSource{Image: &rabbitmqv1beta1.PluginImageSource{Reference: "plugins:1", Path: "rabbitmq_delayed_message_exchange-4.1.0.zip"}},
+			"must have the extension .ez"),
+		Entry("unsupported scheme", rabbitmqv1beta1.PluginSource{URL: "ftp://example.com/rabbitmq_delayed_message_exchange-4.1.0.ez"},
+			`URL scheme must be http or https, not "ftp"`),
+	)
+})
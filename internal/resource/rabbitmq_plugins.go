@@ -2,6 +2,7 @@ package resource
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -22,6 +23,14 @@ var requiredPlugins = []string{
 
 const PluginsConfigName = "plugins-conf"
 
+const (
+	replicationPlugin = "rabbitmq_multi_dc_replication"
+	federationPlugin  = "rabbitmq_federation"
+	shovelPlugin      = "rabbitmq_shovel"
+	oauth2Plugin      = "rabbitmq_auth_backend_oauth2"
+	ldapPlugin        = "rabbitmq_auth_backend_ldap"
+)
+
 type RabbitmqPluginsConfigMapBuilder struct {
 	*RabbitmqResourceBuilder
 }
@@ -54,7 +63,7 @@ func (builder *RabbitmqPluginsConfigMapBuilder) Update(object client.Object) err
 	if configMap.Data == nil {
 		configMap.Data = make(map[string]string)
 	}
-	configMap.Data["enabled_plugins"] = desiredPluginsAsString(builder.Instance.Spec.Rabbitmq.AdditionalPlugins)
+	configMap.Data["enabled_plugins"] = desiredPluginsAsString(builder.plugins())
 
 	if err := controllerutil.SetControllerReference(builder.Instance, configMap, builder.Scheme); err != nil {
 		return fmt.Errorf("failed setting controller reference: %w", err)
@@ -62,6 +71,28 @@ func (builder *RabbitmqPluginsConfigMapBuilder) Update(object client.Object) err
 	return nil
 }
 
+// plugins returns the additional plugins of the instance, and the plugins needed by the warm standby replication
+// and the links the instance takes part in
+func (builder *RabbitmqResourceBuilder) plugins() []rabbitmqv1beta1.Plugin {
+	plugins := slices.Clone(builder.Instance.Spec.Rabbitmq.AdditionalPlugins)
+	if builder.ReplicationUpstream || builder.Instance.ReplicationEnabled() {
+		plugins = append(plugins, replicationPlugin)
+	}
+	if builder.Instance.LinkEnabled(rabbitmqv1beta1.LinkTypeFederation) {
+		plugins = append(plugins, federationPlugin)
+	}
+	if builder.Instance.LinkEnabled(rabbitmqv1beta1.LinkTypeShovel) {
+		plugins = append(plugins, shovelPlugin)
+	}
+	if builder.Instance.OAuth2Enabled() {
+		plugins = append(plugins, oauth2Plugin)
+	}
+	if builder.Instance.LDAPEnabled() {
+		plugins = append(plugins, ldapPlugin)
+	}
+	return plugins
+}
+
 type RabbitmqPlugins struct {
 	requiredPlugins   []string
 	additionalPlugins []string
@@ -79,6 +110,23 @@ func NewRabbitmqPlugins(plugins []rabbitmqv1beta1.Plugin) RabbitmqPlugins {
 	}
 }
 
+// RabbitmqPluginsFromConfigMap returns the plugins listed in the plugins ConfigMap, which include
+// the plugins enabled by the operator on top of spec.rabbitmq.additionalPlugins.
+func RabbitmqPluginsFromConfigMap(configMap *corev1.ConfigMap) RabbitmqPlugins {
+	list := strings.TrimSuffix(strings.TrimSpace(configMap.Data["enabled_plugins"]), ".")
+	list = strings.TrimSuffix(strings.TrimPrefix(list, "["), "]")
+	var plugins []string
+	for p := range strings.SplitSeq(list, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			plugins = append(plugins, p)
+		}
+	}
+	return RabbitmqPlugins{
+		requiredPlugins:   requiredPlugins,
+		additionalPlugins: plugins,
+	}
+}
+
 func (r *RabbitmqPlugins) DesiredPlugins() []string {
 	allPlugins := append(r.requiredPlugins, r.additionalPlugins...)
 
@@ -59,6 +59,16 @@ var _ = Describe("RabbitMQPlugins", func() {
 		})
 	})
 
+	Context("RabbitmqPluginsFromConfigMap", func() {
+		It("returns the required plugins and the plugins listed in the ConfigMap", func() {
+			configMap := &corev1.ConfigMap{Data: map[string]string{
+				"enabled_plugins": "[rabbitmq_peer_discovery_k8s,rabbitmq_prometheus,rabbitmq_management,rabbitmq_multi_dc_replication].",
+			}}
+			plugins := rmqresource.RabbitmqPluginsFromConfigMap(configMap)
+			Expect(plugins.AsString(" ")).To(Equal("rabbitmq_peer_discovery_k8s rabbitmq_prometheus rabbitmq_management rabbitmq_multi_dc_replication"))
+		})
+	})
+
 	Context("PluginsConfigMap", func() {
 		var (
 			instance         rabbitmqv1beta1.RabbitmqCluster
@@ -218,6 +228,56 @@ var _ = Describe("RabbitMQPlugins", func() {
 				})
 			})
 
+			When("the instance is a replication downstream", func() {
+				It("enables the replication plugin", func() {
+					builder.Instance.Spec.Replication = &rabbitmqv1beta1.ReplicationSpec{
+						Upstream: rabbitmqv1beta1.RabbitmqClusterReference{Name: "upstream"},
+					}
+					Expect(configMapBuilder.Update(configMap)).To(Succeed())
+					Expect(configMap.Data).To(HaveKeyWithValue("enabled_plugins",
+						"[rabbitmq_peer_discovery_k8s,rabbitmq_prometheus,rabbitmq_management,rabbitmq_multi_dc_replication]."))
+				})
+			})
+
+			When("the instance is a replication upstream", func() {
+				It("enables the replication plugin once", func() {
+					builder.ReplicationUpstream = true
+					builder.Instance.Spec.Rabbitmq.AdditionalPlugins = []rabbitmqv1beta1.Plugin{"rabbitmq_multi_dc_replication"}
+					Expect(configMapBuilder.Update(configMap)).To(Succeed())
+					Expect(configMap.Data).To(HaveKeyWithValue("enabled_plugins",
+						"[rabbitmq_peer_discovery_k8s,rabbitmq_prometheus,rabbitmq_management,rabbitmq_multi_dc_replication]."))
+					Expect(builder.Instance.Spec.Rabbitmq.AdditionalPlugins).To(HaveLen(1))
+				})
+			})
+
+			When("the instance has links", func() {
+				It("enables the federation and shovel plugins", func() {
+					builder.Instance.Spec.Links = []rabbitmqv1beta1.LinkSpec{
+						{Name: "upstream", Type: rabbitmqv1beta1.LinkTypeFederation},
+						{Name: "orders", Type: rabbitmqv1beta1.LinkTypeShovel},
+					}
+					Expect(configMapBuilder.Update(configMap)).To(Succeed())
+					Expect(configMap.Data).To(HaveKeyWithValue("enabled_plugins",
+						"[rabbitmq_peer_discovery_k8s,rabbitmq_prometheus,rabbitmq_management,rabbitmq_federation,rabbitmq_shovel]."))
+				})
+			})
+
+			When("the instance authenticates with spec.auth", func() {
+				It("enables the OAuth 2.0 backend plugin", func() {
+					builder.Instance.Spec.Auth.OAuth2 = &rabbitmqv1beta1.OAuth2Spec{ResourceServerID: "rabbitmq"}
+					Expect(configMapBuilder.Update(configMap)).To(Succeed())
+					Expect(configMap.Data).To(HaveKeyWithValue("enabled_plugins",
+						"[rabbitmq_peer_discovery_k8s,rabbitmq_prometheus,rabbitmq_management,rabbitmq_auth_backend_oauth2]."))
+				})
+
+				It("enables the LDAP backend plugin", func() {
+					builder.Instance.Spec.Auth.LDAP = &rabbitmqv1beta1.LDAPSpec{Servers: []string{"ldap.example.com"}}
+					Expect(configMapBuilder.Update(configMap)).To(Succeed())
+					Expect(configMap.Data).To(HaveKeyWithValue("enabled_plugins",
+						"[rabbitmq_peer_discovery_k8s,rabbitmq_prometheus,rabbitmq_management,rabbitmq_auth_backend_ldap]."))
+				})
+			})
+
 			// ensures that we are not unnecessarily running `rabbitmq-plugins set` when CR labels are updated
 			It("does not update labels on the config map", func() {
 				configMap.Labels = map[string]string{
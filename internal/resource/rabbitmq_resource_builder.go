@@ -15,12 +15,24 @@ import (
 	"github.com/Masterminds/semver/v3"
 	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 type RabbitmqResourceBuilder struct {
 	Instance *rabbitmqv1beta1.RabbitmqCluster
 	Scheme   *runtime.Scheme
+	// ReplicationUpstream is true when other RabbitmqClusters replicate from Instance with spec.replication.
+	ReplicationUpstream bool
+	// TLSCACertificate is the CA certificate clients of Instance verify its server certificate against, if known.
+	TLSCACertificate []byte
+	// AdditionalConfigFromHash is the hash of the content referenced in spec.rabbitmq.additionalConfigFrom, see AdditionalConfigFromHash.
+	AdditionalConfigFromHash string
+	// AuthSecretsHash is the hash of the Secrets rendering the credentials of spec.auth, see AuthSecretsHash.
+	AuthSecretsHash string
+	// RestartOnRuntimeConfigChange is true when changes to runtime keys of spec.rabbitmq.additionalConfig
+	// cannot be applied to the running nodes, so that they restart the nodes like other keys.
+	RestartOnRuntimeConfigChange bool
 }
 
 type ResourceBuilder interface {
@@ -81,6 +93,16 @@ func (builder *RabbitmqResourceBuilder) ResourceBuilders() []ResourceBuilder {
 		)
 	}
 
+	if builder.Instance.StreamPerPodServicesEnabled() {
+		for i := int32(0); i < ptr.Deref(builder.Instance.Spec.Replicas, 1); i++ {
+			builders = append(builders, builder.StreamPodService(i))
+		}
+	}
+
+	if builder.Instance.ManagementIngressEnabled() {
+		builders = append(builders, builder.managementIngressBuilders()...)
+	}
+
 	// Appending StatefulSet builder separately because the order of the builders is important
 	// The SA, ConfigMap, and Secret need to be created before the StatefulSet. Otherwise, Pods
 	// created by the StatefulSet will block on the creation of dependent resources.
@@ -92,3 +114,13 @@ func (builder *RabbitmqResourceBuilder) ResourceBuilders() []ResourceBuilder {
 	}
 	return builders
 }
+
+func (builder *RabbitmqResourceBuilder) managementIngressBuilders() []ResourceBuilder {
+	if builder.Instance.Spec.Management.Ingress.Type != rabbitmqv1beta1.ManagementIngressTypeHTTPRoute {
+		return []ResourceBuilder{builder.ManagementIngress()}
+	}
+	if managementBackendTLS(builder.Instance) {
+		return []ResourceBuilder{builder.ManagementHTTPRoute(), builder.ManagementBackendTLSPolicy()}
+	}
+	return []ResourceBuilder{builder.ManagementHTTPRoute()}
+}
@@ -125,6 +125,61 @@ var _ = Describe("RabbitmqResourceBuilder", func() {
 			})
 		})
 
+		When("the management UI is exposed through an Ingress", func() {
+			BeforeEach(func() {
+				instance.Spec.Management.Ingress = &rabbitmqv1beta1.ManagementIngressSpec{
+					Type: rabbitmqv1beta1.ManagementIngressTypeIngress,
+				}
+			})
+			It("returns the Ingress builder before the StatefulSet builder", func() {
+				resourceBuilders := builder.ResourceBuilders()
+				Expect(resourceBuilders).To(HaveLen(11))
+				Expect(resourceBuilders[9]).To(BeAssignableToTypeOf(&resource.ManagementIngressBuilder{}))
+				Expect(resourceBuilders[10]).To(BeAssignableToTypeOf(&resource.StatefulSetBuilder{}))
+			})
+		})
+
+		When("the management UI is exposed through an HTTPRoute", func() {
+			BeforeEach(func() {
+				instance.Spec.Management.Ingress = &rabbitmqv1beta1.ManagementIngressSpec{
+					Type:       rabbitmqv1beta1.ManagementIngressTypeHTTPRoute,
+					ParentRefs: []rabbitmqv1beta1.ManagementGatewayParentRef{{Name: "gateway"}},
+				}
+			})
+			It("returns the HTTPRoute builder", func() {
+				resourceBuilders := builder.ResourceBuilders()
+				Expect(resourceBuilders).To(HaveLen(11))
+				Expect(resourceBuilders).To(ContainElement(BeAssignableToTypeOf(&resource.ManagementHTTPRouteBuilder{})))
+				Expect(resourceBuilders).NotTo(ContainElement(BeAssignableToTypeOf(&resource.ManagementBackendTLSPolicyBuilder{})))
+			})
+
+			It("returns the BackendTLSPolicy builder when non-TLS listeners are disabled", func() {
+				instance.Spec.TLS = rabbitmqv1beta1.TLSSpec{SecretName: "tls-secret", DisableNonTLSListeners: true}
+				resourceBuilders := builder.ResourceBuilders()
+				Expect(resourceBuilders).To(HaveLen(12))
+				Expect(resourceBuilders).To(ContainElement(BeAssignableToTypeOf(&resource.ManagementBackendTLSPolicyBuilder{})))
+			})
+		})
+
+		When("per-Pod stream Services are requested", func() {
+			BeforeEach(func() {
+				instance.Spec.Replicas = new(int32(3))
+				instance.Spec.Stream.PerPodServices = &rabbitmqv1beta1.StreamPerPodServicesSpec{Type: "LoadBalancer"}
+			})
+			It("returns a stream Service builder per replica when stream is enabled", func() {
+				instance.Spec.Rabbitmq.AdditionalPlugins = []rabbitmqv1beta1.Plugin{"rabbitmq_stream"}
+				resourceBuilders := builder.ResourceBuilders()
+				Expect(resourceBuilders).To(HaveLen(13))
+				for i := 9; i < 12; i++ {
+					Expect(resourceBuilders[i]).To(BeAssignableToTypeOf(&resource.StreamPodServiceBuilder{}))
+				}
+				Expect(resourceBuilders[12]).To(BeAssignableToTypeOf(&resource.StatefulSetBuilder{}))
+			})
+			It("does not return stream Service builders when stream is not enabled", func() {
+				Expect(builder.ResourceBuilders()).NotTo(ContainElement(BeAssignableToTypeOf(&resource.StreamPodServiceBuilder{})))
+			})
+		})
+
 		When("RabbitMQ version is 4.1.0 or greater", func() {
 			BeforeEach(func() {
 				instance.Annotations = map[string]string{
@@ -0,0 +1,132 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+//
+
+package resource
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/metadata"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var VolumeSnapshotGVK = schema.GroupVersionKind{Group: "snapshot.storage.k8s.io", Version: "v1", Kind: "VolumeSnapshot"}
+
+const (
+	// Keys of the Secret of a RabbitmqClusterBackup. The definitions are stored under DefinitionsBackupKey.
+	BackupRabbitmqClusterKey = "rabbitmqcluster.json"
+	BackupErlangCookieKey    = "erlang-cookie"
+	BackupDefaultUserKey     = "default-user.json"
+	backupComponent          = "backup"
+)
+
+// BackupLabels returns the labels of the Secrets and VolumeSnapshots of a RabbitmqClusterBackup
+func BackupLabels(backup *rabbitmqv1beta1.RabbitmqClusterBackup) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":      backup.Spec.RabbitmqClusterReference.Name,
+		"app.kubernetes.io/component": backupComponent,
+		"app.kubernetes.io/part-of":   "rabbitmq",
+	}
+}
+
+// BackupSecret returns the Secret holding everything but the volumes needed to restore a RabbitmqCluster
+func BackupSecret(backup *rabbitmqv1beta1.RabbitmqClusterBackup, data map[string][]byte) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backup.Name,
+			Namespace: backup.Namespace,
+			Labels:    BackupLabels(backup),
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: data,
+	}
+}
+
+// BackupVolumeSnapshot returns the VolumeSnapshot of the persistent volume of the i-th node of the backed up RabbitmqCluster
+func BackupVolumeSnapshot(backup *rabbitmqv1beta1.RabbitmqClusterBackup, pvcName string, i int) (*unstructured.Unstructured, error) {
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetGroupVersionKind(VolumeSnapshotGVK)
+	snapshot.SetName(backup.Name + "-server-" + strconv.Itoa(i))
+	snapshot.SetNamespace(backup.Namespace)
+	snapshot.SetLabels(BackupLabels(backup))
+
+	spec := map[string]any{
+		"source": map[string]any{
+			"persistentVolumeClaimName": pvcName,
+		},
+	}
+	if backup.Spec.VolumeSnapshotClassName != "" {
+		spec["volumeSnapshotClassName"] = backup.Spec.VolumeSnapshotClassName
+	}
+	if err := unstructured.SetNestedField(snapshot.Object, spec, "spec"); err != nil {
+		return nil, fmt.Errorf("failed setting VolumeSnapshot spec: %w", err)
+	}
+	return snapshot, nil
+}
+
+// BackedUpRabbitmqCluster returns the manifest of a RabbitmqCluster as stored in a backup: its name, labels, annotations and spec.
+// Annotations set by kubectl and by the operator itself are left out.
+func BackedUpRabbitmqCluster(instance *rabbitmqv1beta1.RabbitmqCluster) ([]byte, error) {
+	backedUp := rabbitmqv1beta1.RabbitmqCluster{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: rabbitmqv1beta1.GroupVersion.String(),
+			Kind:       "RabbitmqCluster",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        instance.Name,
+			Namespace:   instance.Namespace,
+			Labels:      instance.Labels,
+			Annotations: metadata.ReconcileAndFilterAnnotations(map[string]string{}, instance.Annotations),
+		},
+		Spec: instance.Spec,
+	}
+	delete(backedUp.Annotations, rabbitmqv1beta1.RabbitmqVersionAnnotation)
+	delete(backedUp.Annotations, rabbitmqv1beta1.ErlangVersionAnnotation)
+	delete(backedUp.Annotations, rabbitmqv1beta1.RestoredFromAnnotation)
+	return json.Marshal(backedUp)
+}
+
+// RestoredPersistentVolumeClaim returns the PersistentVolumeClaim of the i-th node of a restored RabbitmqCluster,
+// provisioned from the given VolumeSnapshot. It has the name and labels the StatefulSet expects,
+// so that the StatefulSet uses it instead of provisioning an empty volume.
+func RestoredPersistentVolumeClaim(instance *rabbitmqv1beta1.RabbitmqCluster, i int, snapshot rabbitmqv1beta1.BackupVolumeSnapshot) *corev1.PersistentVolumeClaim {
+	storage := instance.Spec.Persistence.Storage.DeepCopy()
+	if snapshot.RestoreSize != nil && snapshot.RestoreSize.Cmp(storage) > 0 {
+		storage = snapshot.RestoreSize.DeepCopy()
+	}
+
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        instance.PVCName(i),
+			Namespace:   instance.Namespace,
+			Labels:      metadata.Label(instance.Name),
+			Annotations: metadata.ReconcileAndFilterAnnotations(map[string]string{}, instance.Annotations),
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: storage,
+				},
+			},
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			StorageClassName: instance.Spec.Persistence.StorageClassName,
+			DataSource: &corev1.TypedLocalObjectReference{
+				APIGroup: &VolumeSnapshotGVK.Group,
+				Kind:     VolumeSnapshotGVK.Kind,
+				Name:     snapshot.VolumeSnapshotName,
+			},
+		},
+	}
+}
@@ -0,0 +1,135 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+//
+
+package resource_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	corev1 "k8s.io/api/core/v1"
+	k8sresource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("RabbitmqClusterBackup", func() {
+	var (
+		instance *rabbitmqv1beta1.RabbitmqCluster
+		backup   *rabbitmqv1beta1.RabbitmqClusterBackup
+	)
+
+	BeforeEach(func() {
+		storage := k8sresource.MustParse("10Gi")
+		instance = &rabbitmqv1beta1.RabbitmqCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "rabbit",
+				Namespace: "default",
+				Labels:    map[string]string{"team": "messaging"},
+				Annotations: map[string]string{
+					"kubectl.kubernetes.io/last-applied-configuration": "{}",
+					rabbitmqv1beta1.RabbitmqVersionAnnotation:          "4.2.0",
+					"owner": "messaging",
+				},
+			},
+			Spec: rabbitmqv1beta1.RabbitmqClusterSpec{
+				Replicas: new(int32(3)),
+				Persistence: rabbitmqv1beta1.RabbitmqClusterPersistenceSpec{
+					Storage:          &storage,
+					StorageClassName: new("fast"),
+				},
+			},
+		}
+		backup = &rabbitmqv1beta1.RabbitmqClusterBackup{
+			ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "default"},
+			Spec: rabbitmqv1beta1.RabbitmqClusterBackupSpec{
+				RabbitmqClusterReference: corev1.LocalObjectReference{Name: "rabbit"},
+			},
+		}
+	})
+
+	Context("BackupVolumeSnapshot", func() {
+		It("snapshots the PersistentVolumeClaim of the node", func() {
+			snapshot, err := resource.BackupVolumeSnapshot(backup, instance.PVCName(1), 1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(snapshot.GroupVersionKind()).To(Equal(resource.VolumeSnapshotGVK))
+			Expect(snapshot.GetName()).To(Equal("nightly-server-1"))
+			Expect(snapshot.GetNamespace()).To(Equal("default"))
+			Expect(snapshot.GetLabels()).To(HaveKeyWithValue("app.kubernetes.io/component", "backup"))
+			pvcName, _, _ := unstructured.NestedString(snapshot.Object, "spec", "source", "persistentVolumeClaimName")
+			Expect(pvcName).To(Equal("persistence-rabbit-server-1"))
+			_, found, _ := unstructured.NestedString(snapshot.Object, "spec", "volumeSnapshotClassName")
+			Expect(found).To(BeFalse())
+		})
+
+		It("uses the VolumeSnapshotClass of the backup", func() {
+			backup.Spec.VolumeSnapshotClassName = "csi-snapclass"
+			snapshot, err := resource.BackupVolumeSnapshot(backup, instance.PVCName(0), 0)
+			Expect(err).NotTo(HaveOccurred())
+			className, _, _ := unstructured.NestedString(snapshot.Object, "spec", "volumeSnapshotClassName")
+			Expect(className).To(Equal("csi-snapclass"))
+		})
+	})
+
+	Context("BackedUpRabbitmqCluster", func() {
+		It("keeps the name, labels, user annotations and spec only", func() {
+			instance.Status.SetCondition("ReconcileSuccess", corev1.ConditionTrue, "Success")
+			data, err := resource.BackedUpRabbitmqCluster(instance)
+			Expect(err).NotTo(HaveOccurred())
+
+			backedUp := &rabbitmqv1beta1.RabbitmqCluster{}
+			Expect(json.Unmarshal(data, backedUp)).To(Succeed())
+			Expect(backedUp.Kind).To(Equal("RabbitmqCluster"))
+			Expect(backedUp.Name).To(Equal("rabbit"))
+			Expect(backedUp.Labels).To(Equal(map[string]string{"team": "messaging"}))
+			Expect(backedUp.Annotations).To(Equal(map[string]string{"owner": "messaging"}))
+			Expect(backedUp.Spec).To(Equal(instance.Spec))
+			Expect(backedUp.Status.Conditions).To(BeEmpty())
+		})
+	})
+
+	Context("RestoredPersistentVolumeClaim", func() {
+		var snapshot rabbitmqv1beta1.BackupVolumeSnapshot
+
+		BeforeEach(func() {
+			snapshot = rabbitmqv1beta1.BackupVolumeSnapshot{
+				PersistentVolumeClaimName: "persistence-rabbit-server-2",
+				VolumeSnapshotName:        "nightly-server-2",
+			}
+		})
+
+		It("provisions the volume the StatefulSet expects from the VolumeSnapshot", func() {
+			pvc := resource.RestoredPersistentVolumeClaim(instance, 2, snapshot)
+			Expect(pvc.Name).To(Equal("persistence-rabbit-server-2"))
+			Expect(pvc.Namespace).To(Equal("default"))
+			Expect(pvc.Labels).To(Equal(map[string]string{
+				"app.kubernetes.io/name":      "rabbit",
+				"app.kubernetes.io/component": "rabbitmq",
+				"app.kubernetes.io/part-of":   "rabbitmq",
+			}))
+			Expect(pvc.Spec.StorageClassName).To(Equal(new("fast")))
+			Expect(pvc.Spec.AccessModes).To(ConsistOf(corev1.ReadWriteOnce))
+			Expect(pvc.Spec.Resources.Requests[corev1.ResourceStorage]).To(Equal(k8sresource.MustParse("10Gi")))
+			Expect(pvc.Spec.DataSource).To(Equal(&corev1.TypedLocalObjectReference{
+				APIGroup: new("snapshot.storage.k8s.io"),
+				Kind:     "VolumeSnapshot",
+				Name:     "nightly-server-2",
+			}))
+		})
+
+		It("requests at least the restore size of the VolumeSnapshot", func() {
+			snapshot.RestoreSize = new(k8sresource.MustParse("20Gi"))
+			pvc := resource.RestoredPersistentVolumeClaim(instance, 2, snapshot)
+			Expect(pvc.Spec.Resources.Requests[corev1.ResourceStorage]).To(Equal(k8sresource.MustParse("20Gi")))
+		})
+	})
+})
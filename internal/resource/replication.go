@@ -0,0 +1,163 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+//
+
+package resource
+
+import (
+	"fmt"
+
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/metadata"
+	"gopkg.in/ini.v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ReplicationUserSecretName = "replication-user"
+	ReplicationCASecretName   = "replication-ca"
+	// ReplicationCAPath is the directory in which the CA certificate of the upstream is mounted on a downstream
+	ReplicationCAPath       = "/etc/rabbitmq-replication-ca/"
+	replicationCAVolumeName = "replication-ca"
+	// Global runtime parameters holding the upstream endpoints and credentials on the downstream
+	SchemaSyncUpstreamParameter         = "schema_definition_sync_upstream"
+	StandbyReplicationUpstreamParameter = "standby_replication_upstream"
+	// Tag of the virtual hosts whose messages are replicated
+	StandbyReplicationVhostTag = "standby_replication"
+)
+
+// streamNeeded returns true when the instance needs the stream listener, including
+// when it is the upstream of warm standby replication, which replicates messages over the stream protocol
+func (builder *RabbitmqResourceBuilder) streamNeeded() bool {
+	return builder.Instance.StreamNeeded() || builder.ReplicationUpstream
+}
+
+// replicationConf sets the operating mode of schema definition sync and standby replication.
+// A cluster which is both a downstream and an upstream is configured as a downstream, since replication cannot be chained.
+// Downstream clusters keep their own default users and replication parameters when definitions are synchronised.
+func (builder *RabbitmqResourceBuilder) replicationConf(section *ini.Section) error {
+	mode := ""
+	switch {
+	case builder.Instance.ReplicationEnabled():
+		mode = "downstream"
+	case builder.ReplicationUpstream:
+		mode = "upstream"
+	default:
+		return nil
+	}
+	keys := [][2]string{
+		{"schema_definition_sync.operating_mode", mode},
+		{"standby.replication.operating_mode", mode},
+	}
+	if mode == "downstream" {
+		keys = append(keys,
+			[2]string{"schema_definition_sync.downstream.locals.users", "^" + usernamePrefix},
+			[2]string{"schema_definition_sync.downstream.locals.global_parameters", "^standby"},
+		)
+	}
+	for _, key := range keys {
+		if _, err := section.NewKey(key[0], key[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReplicationUsername returns the name of the user a downstream RabbitmqCluster authenticates as on its upstream.
+// It is unique per downstream, since several downstreams can replicate from the same upstream.
+func ReplicationUsername(downstream *rabbitmqv1beta1.RabbitmqCluster) string {
+	return fmt.Sprintf("standby_replicator_%s_%s", downstream.Namespace, downstream.Name)
+}
+
+// ReplicationUserSecret returns the Secret of a downstream RabbitmqCluster holding the credentials of its replication user
+func ReplicationUserSecret(downstream *rabbitmqv1beta1.RabbitmqCluster, password string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      downstream.ChildResourceName(ReplicationUserSecretName),
+			Namespace: downstream.Namespace,
+			Labels:    metadata.GetLabels(downstream.Name, downstream.Labels),
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			"username": []byte(ReplicationUsername(downstream)),
+			"password": []byte(password),
+		},
+	}
+}
+
+// ReplicationCASecret returns the Secret of a downstream RabbitmqCluster holding the CA certificate of its upstream,
+// which may be in another namespace
+func ReplicationCASecret(downstream *rabbitmqv1beta1.RabbitmqCluster, ca []byte) *corev1.Secret {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      downstream.ChildResourceName(ReplicationCASecretName),
+			Namespace: downstream.Namespace,
+			Labels:    metadata.GetLabels(downstream.Name, downstream.Labels),
+		},
+		Type: corev1.SecretTypeOpaque,
+	}
+	if len(ca) > 0 {
+		secret.Data = map[string][]byte{caCertFilename: ca}
+	}
+	return secret
+}
+
+// replicationCAVolume mounts the replication CA Secret. It is optional since the Secret is created after the StatefulSet,
+// and only when the upstream serves TLS.
+func replicationCAVolume(downstream *rabbitmqv1beta1.RabbitmqCluster) corev1.Volume {
+	return corev1.Volume{
+		Name: replicationCAVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: downstream.ChildResourceName(ReplicationCASecretName),
+				Optional:   new(true),
+			},
+		},
+	}
+}
+
+// GenerateUserPassword returns a random password for a user the operator creates on another RabbitmqCluster,
+// such as a replication or link user
+func GenerateUserPassword() (string, error) {
+	return randomEncodedString(24)
+}
+
+// ReplicationUpstreamParameters returns the values of the schema definition sync and standby replication
+// global parameters of a downstream, keyed by parameter name.
+// Definitions are synchronised over AMQP 0-9-1 and messages are replicated over the stream protocol,
+// through the client Service of the upstream. With tls, the TLS listeners are used, and the certificate of the
+// upstream is verified against the CA certificate in ReplicationCAPath.
+func ReplicationUpstreamParameters(upstream *rabbitmqv1beta1.RabbitmqCluster, username, password string, tls bool) map[string]map[string]any {
+	host := upstream.ServiceSubDomain()
+	parameter := func(port int) map[string]any {
+		value := map[string]any{
+			"endpoints": []any{fmt.Sprintf("%s:%d", host, port)},
+			"username":  username,
+			"password":  password,
+		}
+		if tls {
+			value["ssl_options"] = map[string]any{
+				"cacertfile":             ReplicationCAPath + caCertFilename,
+				"verify":                 "verify_peer",
+				"server_name_indication": host,
+			}
+		}
+		return value
+	}
+	if tls {
+		return map[string]map[string]any{
+			SchemaSyncUpstreamParameter:         parameter(5671),
+			StandbyReplicationUpstreamParameter: parameter(5551),
+		}
+	}
+	return map[string]map[string]any{
+		SchemaSyncUpstreamParameter:         parameter(5672),
+		StandbyReplicationUpstreamParameter: parameter(5552),
+	}
+}
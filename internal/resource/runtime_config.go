@@ -0,0 +1,203 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+//
+
+package resource
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/ini.v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const userDefinedConfigurationFilename = "userDefinedConfiguration.conf"
+
+// runtimeConfigKey is a rabbitmq.conf key which can be changed on running nodes
+type runtimeConfigKey struct {
+	// values the command accepts. Other values require a restart, and are never passed to the shell.
+	valuePattern *regexp.Regexp
+	command      func(value string) string
+	// keys which the command would override on the running nodes. The nodes are restarted if one of them is set.
+	overrides *regexp.Regexp
+}
+
+var (
+	logLevelPattern = regexp.MustCompile(`^(debug|info|notice|warning|error|critical|alert|emergency|none)$`)
+	// levels of single log outputs and categories, such as log.console.level and log.connection.level
+	outputLogLevelPattern = regexp.MustCompile(`^log\..+\.level$`)
+	integerPattern        = regexp.MustCompile(`^[0-9]+$`)
+	ratioPattern          = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+	// information units as accepted by rabbitmq.conf and rabbitmqctl, such as 2GB or 500MiB
+	memoryPattern = regexp.MustCompile(`^[0-9]+([kKmMgGtT]i?[bB]?)?$`)
+)
+
+// runtimeConfigKeys are the keys of spec.rabbitmq.additionalConfig the operator applies with rabbitmqctl on every node
+// instead of restarting the nodes. Changes to other keys restart the nodes.
+var runtimeConfigKeys = map[string]runtimeConfigKey{
+	// set_log_level changes the level of every log output, so the outputs must not set their own level
+	"log.default.level": {valuePattern: logLevelPattern, overrides: outputLogLevelPattern, command: func(value string) string {
+		return "rabbitmqctl set_log_level " + value
+	}},
+	"vm_memory_high_watermark.relative": {valuePattern: ratioPattern, command: func(value string) string {
+		return "rabbitmqctl set_vm_memory_high_watermark " + value
+	}},
+	"vm_memory_high_watermark.absolute": {valuePattern: memoryPattern, command: func(value string) string {
+		return "rabbitmqctl set_vm_memory_high_watermark absolute " + value
+	}},
+	"disk_free_limit.relative": {valuePattern: ratioPattern, command: func(value string) string {
+		return "rabbitmqctl set_disk_free_limit mem_relative " + value
+	}},
+	"disk_free_limit.absolute": {valuePattern: memoryPattern, command: func(value string) string {
+		return "rabbitmqctl set_disk_free_limit " + value
+	}},
+	// the following settings apply to connections and channels opened after the change
+	"channel_max":      {valuePattern: integerPattern, command: setRabbitEnv("channel_max")},
+	"heartbeat":        {valuePattern: integerPattern, command: setRabbitEnv("heartbeat")},
+	"consumer_timeout": {valuePattern: integerPattern, command: setRabbitEnv("consumer_timeout")},
+}
+
+func setRabbitEnv(parameter string) func(string) string {
+	return func(value string) string {
+		return fmt.Sprintf("rabbitmqctl eval 'application:set_env(rabbit, %s, %s).'", parameter, value)
+	}
+}
+
+func (key runtimeConfigKey) accepts(value string) bool {
+	return key.valuePattern.MatchString(value)
+}
+
+// overridesAny returns true if the section sets another key the command of the named key would override
+func (key runtimeConfigKey) overridesAny(name string, section *ini.Section) bool {
+	if key.overrides == nil {
+		return false
+	}
+	return slices.ContainsFunc(section.KeyStrings(), func(other string) bool {
+		return other != name && key.overrides.MatchString(other)
+	})
+}
+
+// removeRuntimeConfigChanges resets the changed runtime keys of the updated ConfigMap to their previous values,
+// so that comparing the ConfigMaps afterwards only detects changes requiring a restart. It returns the keys it reset.
+// Keys which are added or removed are not reset, since their previous value may be a default the operator does not know.
+// Nothing is reset if restartOnChange is set, e.g. because the configuration is extended with spec.rabbitmq.additionalConfigFrom,
+// whose fragments may set the same keys.
+func removeRuntimeConfigChanges(previous, updated *corev1.ConfigMap, restartOnChange bool) ([]string, error) {
+	if restartOnChange || previous.Data[userDefinedConfigurationFilename] == "" {
+		return nil, nil
+	}
+	previousConf, err := ini.Load([]byte(previous.Data[userDefinedConfigurationFilename]))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load previous %s when deciding whether to restart STS: %w", userDefinedConfigurationFilename, err)
+	}
+	updatedConf, err := ini.Load([]byte(updated.Data[userDefinedConfigurationFilename]))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s when deciding whether to restart STS: %w", userDefinedConfigurationFilename, err)
+	}
+	previousSection, updatedSection := previousConf.Section(""), updatedConf.Section("")
+
+	var changedKeys []string
+	for name, key := range runtimeConfigKeys {
+		if !previousSection.HasKey(name) || !updatedSection.HasKey(name) {
+			continue
+		}
+		previousValue, updatedValue := previousSection.Key(name).String(), updatedSection.Key(name).String()
+		if previousValue == updatedValue || !key.accepts(updatedValue) || key.overridesAny(name, updatedSection) {
+			continue
+		}
+		updatedSection.Key(name).SetValue(previousValue)
+		changedKeys = append(changedKeys, name)
+	}
+	if len(changedKeys) == 0 {
+		return nil, nil
+	}
+	slices.Sort(changedKeys)
+
+	for _, file := range []struct {
+		configMap *corev1.ConfigMap
+		conf      *ini.File
+	}{{previous, previousConf}, {updated, updatedConf}} {
+		var b strings.Builder
+		if _, err := file.conf.WriteTo(&b); err != nil {
+			return nil, fmt.Errorf("failed to write %s when deciding whether to restart STS: %w", userDefinedConfigurationFilename, err)
+		}
+		file.configMap.Data[userDefinedConfigurationFilename] = b.String()
+	}
+	return changedKeys, nil
+}
+
+// changedConfig returns the rabbitmq.conf keys which differ between the ConfigMaps, and the names of other changed files
+func changedConfig(previous, updated *corev1.ConfigMap) []string {
+	var changed []string
+	for _, filename := range unionOfKeys(previous.Data, updated.Data) {
+		previousData, updatedData := previous.Data[filename], updated.Data[filename]
+		if previousData == updatedData {
+			continue
+		}
+		if !strings.HasSuffix(filename, ".conf") || filename == "rabbitmq-env.conf" {
+			changed = append(changed, filename)
+			continue
+		}
+		previousConf, previousErr := ini.Load([]byte(previousData))
+		updatedConf, updatedErr := ini.Load([]byte(updatedData))
+		if previousErr != nil || updatedErr != nil {
+			changed = append(changed, filename)
+			continue
+		}
+		previousSection, updatedSection := previousConf.Section(""), updatedConf.Section("")
+		for _, name := range unionOfKeys(previousSection.KeysHash(), updatedSection.KeysHash()) {
+			if !previousSection.HasKey(name) || !updatedSection.HasKey(name) || previousSection.Key(name).String() != updatedSection.Key(name).String() {
+				changed = append(changed, name)
+			}
+		}
+	}
+	if previous.Annotations[AdditionalConfigFromHashAnnotation] != updated.Annotations[AdditionalConfigFromHashAnnotation] {
+		changed = append(changed, "additionalConfigFrom")
+	}
+	if previous.Annotations[AuthSecretsHashAnnotation] != updated.Annotations[AuthSecretsHashAnnotation] {
+		changed = append(changed, "spec.auth credentials")
+	}
+	slices.Sort(changed)
+	return slices.Compact(changed)
+}
+
+func unionOfKeys(maps ...map[string]string) []string {
+	var keys []string
+	for _, m := range maps {
+		for key := range m {
+			if !slices.Contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// RuntimeConfigCommands returns the rabbitmqctl commands applying the runtime keys set in spec.rabbitmq.additionalConfig
+// of the server ConfigMap. The commands are idempotent, so all runtime keys are applied, not only the changed ones.
+// Keys whose command would override other keys set in the ConfigMap are skipped.
+func RuntimeConfigCommands(configMap *corev1.ConfigMap) ([]string, error) {
+	conf, err := ini.Load([]byte(configMap.Data[userDefinedConfigurationFilename]))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", userDefinedConfigurationFilename, err)
+	}
+	section := conf.Section("")
+	var commands []string
+	for _, name := range section.KeyStrings() {
+		key, ok := runtimeConfigKeys[name]
+		if !ok || !key.accepts(section.Key(name).String()) || key.overridesAny(name, section) {
+			continue
+		}
+		commands = append(commands, key.command(section.Key(name).String()))
+	}
+	return commands, nil
+}
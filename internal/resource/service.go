@@ -186,7 +186,7 @@ func (builder *ServiceBuilder) generateServicePortsMap() map[string]corev1.Servi
 			}
 		}
 
-		if builder.Instance.StreamNeeded() {
+		if builder.streamNeeded() {
 			servicePortsMap["stream"] = corev1.ServicePort{
 				Protocol:    corev1.ProtocolTCP,
 				Port:        5552,
@@ -257,7 +257,7 @@ func (builder *ServiceBuilder) generateServicePortsMap() map[string]corev1.Servi
 				AppProtocol: new("https"),
 			}
 		}
-		if builder.Instance.StreamNeeded() {
+		if builder.streamNeeded() {
 			servicePortsMap["streams"] = corev1.ServicePort{
 				Protocol:    corev1.ProtocolTCP,
 				Port:        5551,
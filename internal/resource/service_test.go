@@ -596,6 +596,18 @@ var _ = Context("Services", func() {
 				Entry(nil, "rabbitmq_stream_management", "stream", 5552, new("rabbitmq.com/stream")),
 			)
 
+			It("exposes the stream port of replication upstreams", func() {
+				serviceBuilder.ReplicationUpstream = true
+				Expect(serviceBuilder.Update(svc)).To(Succeed())
+				Expect(svc.Spec.Ports).To(ContainElement(corev1.ServicePort{
+					Name:        "stream",
+					Port:        5552,
+					TargetPort:  intstr.FromInt(5552),
+					Protocol:    corev1.ProtocolTCP,
+					AppProtocol: new("rabbitmq.com/stream"),
+				}))
+			})
+
 			It("updates the service type from ClusterIP to NodePort", func() {
 				svc.Spec.Type = corev1.ServiceTypeClusterIP
 				serviceBuilder.Instance.Spec.Service.Type = "NodePort"
@@ -15,6 +15,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -41,6 +42,12 @@ const (
 	initContainerMemory string = "64Mi"
 	defaultPVCName      string = "persistence"
 	DeletionMarker      string = "skipPreStopChecks"
+	// FullRestartAnnotation is set on the StatefulSet when all Pods must be restarted at the same time,
+	// rather than one by one, because old and new Pods would not be able to form a cluster.
+	FullRestartAnnotation string = "rabbitmq.com/fullRestartRequiredAt"
+	interNodeTLSErlArgs   string = "-proto_dist inet_tls -ssl_dist_optfile " + interNodeTLSConfigPath
+	// DefinitionsPath is the directory in which the definitions referenced in spec.rabbitmq.definitions are mounted
+	DefinitionsPath string = "/etc/rabbitmq-definitions/"
 )
 
 type StatefulSetBuilder struct {
@@ -124,7 +131,20 @@ func (builder *StatefulSetBuilder) Update(object client.Object) error {
 	updatePersistenceStorageCapacity(&sts.Spec.VolumeClaimTemplates, builder.Instance.Spec.Persistence.Storage)
 
 	// pod template
-	sts.Spec.Template = builder.podTemplateSpec(sts.Spec.Template.Annotations)
+	previousTemplate := sts.Spec.Template
+	template, err := builder.podTemplateSpec(sts.Spec.Template.Annotations)
+	if err != nil {
+		return err
+	}
+	sts.Spec.Template = template
+
+	// Nodes using TLS for Erlang distribution cannot communicate with nodes that do not
+	if sts.ResourceVersion != "" && interNodeTLSEnabledInTemplate(previousTemplate) != builder.Instance.InterNodeTLSEnabled() {
+		if sts.Annotations == nil {
+			sts.Annotations = map[string]string{}
+		}
+		sts.Annotations[FullRestartAnnotation] = time.Now().Format(time.RFC3339)
+	}
 
 	if !sts.Spec.Template.Spec.Containers[0].Resources.Limits.Memory().Equal(*sts.Spec.Template.Spec.Containers[0].Resources.Requests.Memory()) {
 		logger := ctrl.Log.WithName("statefulset").WithName("RabbitmqCluster")
@@ -137,6 +157,12 @@ func (builder *StatefulSetBuilder) Update(object client.Object) error {
 		}
 	}
 
+	// The operator deletes all Pods at once during a full restart, see FullRestartAnnotation.
+	// Pods are recreated from the updated template only if the StatefulSet controller does not roll them out itself.
+	if _, ok := sts.Annotations[FullRestartAnnotation]; ok {
+		sts.Spec.UpdateStrategy = appsv1.StatefulSetUpdateStrategy{Type: appsv1.OnDeleteStatefulSetStrategyType}
+	}
+
 	if err := controllerutil.SetControllerReference(builder.Instance, sts, builder.Scheme); err != nil {
 		return fmt.Errorf("failed setting controller reference: %w", err)
 	}
@@ -429,7 +455,7 @@ func sortVolumeMounts(mounts []corev1.VolumeMount) {
 	}
 }
 
-func (builder *StatefulSetBuilder) podTemplateSpec(previousPodAnnotations map[string]string) corev1.PodTemplateSpec {
+func (builder *StatefulSetBuilder) podTemplateSpec(previousPodAnnotations map[string]string) (corev1.PodTemplateSpec, error) {
 	// default pod annotations
 	defaultPodAnnotations := make(map[string]string)
 
@@ -583,7 +609,7 @@ func (builder *StatefulSetBuilder) podTemplateSpec(previousPodAnnotations map[st
 		})
 	}
 
-	if builder.Instance.Spec.Rabbitmq.EnvConfig != "" {
+	if envConfigIsSet(builder.Instance) {
 		rabbitmqContainerVolumeMounts = append(rabbitmqContainerVolumeMounts, corev1.VolumeMount{
 			Name: "server-conf", MountPath: "/etc/rabbitmq/rabbitmq-env.conf", SubPath: "rabbitmq-env.conf",
 		})
@@ -601,6 +627,26 @@ func (builder *StatefulSetBuilder) podTemplateSpec(previousPodAnnotations map[st
 		})
 	}
 
+	if builder.Instance.InterNodeTLSEnabled() {
+		rabbitmqContainerVolumeMounts = append(rabbitmqContainerVolumeMounts, corev1.VolumeMount{
+			Name: "server-conf", MountPath: interNodeTLSConfigPath, SubPath: InterNodeTLSConfigFilename,
+		})
+	}
+
+	if builder.Instance.StreamPerPodServicesEnabled() {
+		// Each node reads the address it advertises to stream clients from the key named after its Pod
+		volumes = append(volumes, corev1.Volume{
+			Name: "stream-advertised",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: builder.Instance.ChildResourceName(StreamAdvertisedConfigMapName),
+					}}}})
+		rabbitmqContainerVolumeMounts = append(rabbitmqContainerVolumeMounts, corev1.VolumeMount{
+			Name: "stream-advertised", MountPath: "/etc/rabbitmq/conf.d/12-stream_advertised.conf", SubPathExpr: "$(MY_POD_NAME).conf",
+		})
+	}
+
 	tlsSpec := builder.Instance.Spec.TLS
 	if builder.Instance.SecretTLSEnabled() {
 		rabbitmqContainerVolumeMounts = append(rabbitmqContainerVolumeMounts, corev1.VolumeMount{
@@ -619,7 +665,7 @@ func (builder *StatefulSetBuilder) podTemplateSpec(previousPodAnnotations map[st
 						{
 							Secret: &corev1.SecretProjection{
 								LocalObjectReference: corev1.LocalObjectReference{
-									Name: tlsSpec.SecretName,
+									Name: builder.Instance.TLSSecretName(),
 								},
 								Optional: &secretEnforced,
 								Items: []corev1.KeyToPath{
@@ -650,6 +696,79 @@ func (builder *StatefulSetBuilder) podTemplateSpec(previousPodAnnotations map[st
 		volumes = append(volumes, tlsProjectedVolume)
 	}
 
+	for _, listener := range tlsListeners(builder.Instance) {
+		rabbitmqContainerVolumeMounts = append(rabbitmqContainerVolumeMounts, corev1.VolumeMount{
+			Name:      listener.volumeName(),
+			MountPath: listener.certDir(),
+			ReadOnly:  true,
+		})
+		volumes = append(volumes, listenerTLSVolume(listener))
+	}
+
+	if definitions := builder.Instance.Spec.Rabbitmq.Definitions; definitions != nil {
+		rabbitmqContainerVolumeMounts = append(rabbitmqContainerVolumeMounts, corev1.VolumeMount{
+			Name:      "definitions",
+			MountPath: DefinitionsPath,
+			ReadOnly:  true,
+		})
+		volumes = append(volumes, definitionsVolume(definitions))
+	}
+
+	if len(builder.Instance.Spec.Links) > 0 {
+		rabbitmqContainerVolumeMounts = append(rabbitmqContainerVolumeMounts, corev1.VolumeMount{
+			Name:      linksCAVolumeName,
+			MountPath: LinksCAPath,
+			ReadOnly:  true,
+		})
+		volumes = append(volumes, linksCAVolume(builder.Instance))
+	}
+
+	if builder.Instance.ReplicationEnabled() {
+		rabbitmqContainerVolumeMounts = append(rabbitmqContainerVolumeMounts, corev1.VolumeMount{
+			Name:      replicationCAVolumeName,
+			MountPath: ReplicationCAPath,
+			ReadOnly:  true,
+		})
+		volumes = append(volumes, replicationCAVolume(builder.Instance))
+	}
+
+	for _, volume := range []*corev1.Volume{oauth2Volume(builder.Instance), ldapVolume(builder.Instance)} {
+		if volume == nil {
+			continue
+		}
+		rabbitmqContainerVolumeMounts = append(rabbitmqContainerVolumeMounts, corev1.VolumeMount{
+			Name:      volume.Name,
+			MountPath: authVolumeMountPaths[volume.Name],
+			ReadOnly:  true,
+		})
+		volumes = append(volumes, *volume)
+	}
+
+	if builder.Instance.OAuth2ClientSecretEnabled() {
+		appendAuthSecretVolumeProjection(volumes, builder.Instance.ChildResourceName(OAuth2SecretName), OAuth2ConfFilename)
+		rabbitmqContainerVolumeMounts = append(rabbitmqContainerVolumeMounts, corev1.VolumeMount{
+			Name: "rabbitmq-confd", MountPath: "/etc/rabbitmq/conf.d/13-" + OAuth2ConfFilename, SubPath: OAuth2ConfFilename,
+		})
+	}
+
+	if builder.Instance.LDAPBindPasswordEnabled() {
+		appendAuthSecretVolumeProjection(volumes, builder.Instance.ChildResourceName(LDAPSecretName), LDAPConfFilename)
+		rabbitmqContainerVolumeMounts = append(rabbitmqContainerVolumeMounts, corev1.VolumeMount{
+			Name: "rabbitmq-confd", MountPath: "/etc/rabbitmq/conf.d/14-" + LDAPConfFilename, SubPath: LDAPConfFilename,
+		})
+	}
+
+	if len(builder.Instance.Spec.Rabbitmq.AdditionalConfigFrom) > 0 {
+		appendAdditionalConfigFromProjections(volumes, builder.Instance)
+		// after additionalConfig, which is mounted as 90-userDefinedConfiguration.conf
+		for i := range builder.Instance.Spec.Rabbitmq.AdditionalConfigFrom {
+			filename := additionalConfigFromFilename(i)
+			rabbitmqContainerVolumeMounts = append(rabbitmqContainerVolumeMounts, corev1.VolumeMount{
+				Name: "rabbitmq-confd", MountPath: "/etc/rabbitmq/conf.d/91-" + filename, SubPath: filename,
+			})
+		}
+	}
+
 	rabbitmqUID := int64(999)
 	podTemplateSpec := corev1.PodTemplateSpec{
 		ObjectMeta: metav1.ObjectMeta{
@@ -670,7 +789,7 @@ func (builder *StatefulSetBuilder) podTemplateSpec(previousPodAnnotations map[st
 			TerminationGracePeriodSeconds: builder.Instance.Spec.TerminationGracePeriodSeconds,
 			Affinity:                      builder.Instance.Spec.Affinity,
 			Tolerations:                   builder.Instance.Spec.Tolerations,
-			InitContainers:                []corev1.Container{setupContainer(builder.Instance)},
+			InitContainers:                []corev1.Container{setupContainer(builder.Instance, previousPodAnnotations[ErlangCookieHashAnnotation])},
 			Volumes:                       volumes,
 			Containers: []corev1.Container{
 				{
@@ -755,16 +874,118 @@ func (builder *StatefulSetBuilder) podTemplateSpec(previousPodAnnotations map[st
 			defaultUserCredentialUpdater(builder.Instance))
 	}
 
+	if builder.Instance.InterNodeTLSEnabled() {
+		// CLI tools run by the operator, the preStop hook and users inherit RABBITMQ_CTL_ERL_ARGS from the container
+		// environment, so they connect to the node using TLS as well.
+		podTemplateSpec.Spec.Containers[0].Env = append(podTemplateSpec.Spec.Containers[0].Env,
+			corev1.EnvVar{
+				Name:  "RABBITMQ_SERVER_ADDITIONAL_ERL_ARGS",
+				Value: interNodeTLSErlArgs,
+			},
+			corev1.EnvVar{
+				Name:  "RABBITMQ_CTL_ERL_ARGS",
+				Value: interNodeTLSErlArgs,
+			},
+		)
+	}
+
+	fetchPlugins, err := fetchPluginsContainer(builder.Instance)
+	if err != nil {
+		return corev1.PodTemplateSpec{}, err
+	}
+	if fetchPlugins != nil {
+		// plugins are fetched before the setup container, so that invalid plugin files fail quickly.
+		// The plugins directory is added to RABBITMQ_PLUGINS_DIR in rabbitmq-env.conf, see pluginSourcesEnvConfig.
+		podTemplateSpec.Spec.InitContainers = append([]corev1.Container{*fetchPlugins}, podTemplateSpec.Spec.InitContainers...)
+		podTemplateSpec.Spec.Volumes = append(podTemplateSpec.Spec.Volumes, pluginImageVolumes(builder.Instance)...)
+	}
+
 	podTemplateSpec.Spec.ServiceAccountName = builder.Instance.ChildResourceName(serviceAccountName)
 	podTemplateSpec.Spec.AutomountServiceAccountToken = new(true)
 
-	return podTemplateSpec
+	return podTemplateSpec, nil
+}
+
+// definitionsVolume projects all keys of the definitions ConfigMaps and Secrets into a single directory,
+// from which RabbitMQ imports every .json file at boot
+func definitionsVolume(definitions *rabbitmqv1beta1.DefinitionsSpec) corev1.Volume {
+	volume := corev1.Volume{
+		Name: "definitions",
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{},
+		},
+	}
+	for _, source := range definitions.Sources {
+		if source.ConfigMap != nil {
+			volume.Projected.Sources = append(volume.Projected.Sources, corev1.VolumeProjection{
+				ConfigMap: &corev1.ConfigMapProjection{LocalObjectReference: *source.ConfigMap},
+			})
+		}
+		if source.Secret != nil {
+			volume.Projected.Sources = append(volume.Projected.Sources, corev1.VolumeProjection{
+				Secret: &corev1.SecretProjection{LocalObjectReference: *source.Secret},
+			})
+		}
+	}
+	return volume
+}
+
+func listenerTLSVolume(listener tlsListener) corev1.Volume {
+	secretEnforced := true
+	volume := corev1.Volume{
+		Name: listener.volumeName(),
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources: []corev1.VolumeProjection{
+					{
+						Secret: &corev1.SecretProjection{
+							LocalObjectReference: corev1.LocalObjectReference{Name: listener.spec.SecretName},
+							Optional:             &secretEnforced,
+							Items: []corev1.KeyToPath{
+								{Key: "tls.crt", Path: "tls.crt"},
+								{Key: "tls.key", Path: "tls.key"},
+							},
+						},
+					},
+				},
+				DefaultMode: new(int32(400)),
+			},
+		},
+	}
+	if listener.spec.CaSecretName != "" {
+		volume.Projected.Sources = append(volume.Projected.Sources, corev1.VolumeProjection{
+			Secret: &corev1.SecretProjection{
+				LocalObjectReference: corev1.LocalObjectReference{Name: listener.spec.CaSecretName},
+				Optional:             &secretEnforced,
+				Items: []corev1.KeyToPath{
+					{Key: "ca.crt", Path: "ca.crt"},
+				},
+			},
+		})
+	}
+	return volume
+}
+
+// interNodeTLSEnabledInTemplate returns true if the Pods created from the template use TLS for Erlang distribution
+func interNodeTLSEnabledInTemplate(template corev1.PodTemplateSpec) bool {
+	for _, container := range template.Spec.Containers {
+		if container.Name != "rabbitmq" {
+			continue
+		}
+		for _, env := range container.Env {
+			if env.Name == "RABBITMQ_SERVER_ADDITIONAL_ERL_ARGS" && strings.Contains(env.Value, "inet_tls") {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 func (builder *StatefulSetBuilder) rabbitmqConfigurationIsSet() bool {
 	return builder.Instance.Spec.Rabbitmq.AdvancedConfig != "" ||
-		builder.Instance.Spec.Rabbitmq.EnvConfig != "" ||
-		builder.Instance.Spec.Rabbitmq.ErlangInetConfig != ""
+		envConfigIsSet(builder.Instance) ||
+		builder.Instance.Spec.Rabbitmq.ErlangInetConfig != "" ||
+		builder.Instance.InterNodeTLSEnabled()
 }
 
 func (builder *StatefulSetBuilder) startupProbe() *corev1.Probe {
@@ -813,6 +1034,7 @@ func defaultUserCredentialUpdater(instance *rabbitmqv1beta1.RabbitmqCluster) cor
 		// However, that approach seems to be less common.)
 		managementURI = "https://$(HOSTNAME_DOMAIN):15671"
 	}
+	managementURI += instance.Spec.Management.PathPrefix
 	container := corev1.Container{
 		Name: "default-user-credential-updater",
 		Resources: corev1.ResourceRequirements{
@@ -882,17 +1104,27 @@ func envVarsK8sObjects(instance *rabbitmqv1beta1.RabbitmqCluster) []corev1.EnvVa
 	}
 }
 
-func setupContainer(instance *rabbitmqv1beta1.RabbitmqCluster) corev1.Container {
+func setupContainer(instance *rabbitmqv1beta1.RabbitmqCluster, erlangCookieHash string) corev1.Container {
 	//Init Container resources
 	cpuRequest := k8sresource.MustParse(initContainerCPU)
 	memoryRequest := k8sresource.MustParse(initContainerMemory)
+	rabbitmqadminConf := "echo '[default]' > /var/lib/rabbitmq/.rabbitmqadmin.conf " +
+		"&& sed -e 's/default_user/username/' -e 's/default_pass/password/' %s >> /var/lib/rabbitmq/.rabbitmqadmin.conf "
+	if pathPrefix := instance.Spec.Management.PathPrefix; pathPrefix != "" {
+		rabbitmqadminConf += "&& echo 'path_prefix = " + pathPrefix + "' >> /var/lib/rabbitmq/.rabbitmqadmin.conf "
+	}
+	copyErlangCookie := "cp /tmp/erlang-cookie-secret/.erlang.cookie /var/lib/rabbitmq/.erlang.cookie " +
+		"&& chmod 600 /var/lib/rabbitmq/.erlang.cookie ; "
+	if erlangCookieHash != "" {
+		// after a rotation, nodes must not start with the previous cookie, since they could not join the other nodes
+		copyErlangCookie = "until [ \"$(sha256sum < /tmp/erlang-cookie-secret/.erlang.cookie | cut -d ' ' -f 1)\" = \"" + erlangCookieHash + "\" ] ; " +
+			"do echo 'waiting for the rotated Erlang cookie' ; sleep 2 ; done ; " + copyErlangCookie
+	}
 	command := []string{
 		"sh", "-c",
-		"cp /tmp/erlang-cookie-secret/.erlang.cookie /var/lib/rabbitmq/.erlang.cookie " +
-			"&& chmod 600 /var/lib/rabbitmq/.erlang.cookie ; " +
+		copyErlangCookie +
 			"cp /tmp/rabbitmq-plugins/enabled_plugins /operator/enabled_plugins ; " +
-			"echo '[default]' > /var/lib/rabbitmq/.rabbitmqadmin.conf " +
-			"&& sed -e 's/default_user/username/' -e 's/default_pass/password/' %s >> /var/lib/rabbitmq/.rabbitmqadmin.conf " +
+			rabbitmqadminConf +
 			"&& chmod 600 /var/lib/rabbitmq/.rabbitmqadmin.conf ; " +
 			"sleep " + strconv.Itoa(int(ptr.Deref(instance.Spec.DelayStartSeconds, 30))),
 	}
@@ -987,6 +1219,29 @@ func appendDefaultUserSecretVolumeProjection(volumes []corev1.Volume, instance *
 	}
 }
 
+// appendAuthSecretVolumeProjection adds a Secret holding secret settings of spec.auth to the conf.d volume
+func appendAuthSecretVolumeProjection(volumes []corev1.Volume, secretName, filename string) {
+	for _, value := range volumes {
+		if value.Name == "rabbitmq-confd" {
+			value.Projected.Sources = append(value.Projected.Sources,
+				corev1.VolumeProjection{
+					Secret: &corev1.SecretProjection{
+						LocalObjectReference: corev1.LocalObjectReference{
+							Name: secretName,
+						},
+						Items: []corev1.KeyToPath{
+							{
+								Key:  filename,
+								Path: filename,
+							},
+						},
+					},
+				},
+			)
+		}
+	}
+}
+
 func appendVaultAnnotations(currentAnnotations map[string]string, instance *rabbitmqv1beta1.RabbitmqCluster) map[string]string {
 	vault := instance.Spec.SecretBackend.Vault
 
@@ -1134,7 +1389,7 @@ func (builder *StatefulSetBuilder) updateContainerPorts() []corev1.ContainerPort
 		})
 	}
 
-	if builder.Instance.StreamNeeded() {
+	if builder.streamNeeded() {
 		ports = append(ports, corev1.ContainerPort{
 			Name:          "stream",
 			ContainerPort: 5552,
@@ -1171,7 +1426,7 @@ func (builder *StatefulSetBuilder) updateContainerPorts() []corev1.ContainerPort
 			})
 		}
 
-		if builder.Instance.StreamNeeded() {
+		if builder.streamNeeded() {
 			ports = append(ports, corev1.ContainerPort{
 				Name:          "streams",
 				ContainerPort: 5551,
@@ -1237,7 +1492,7 @@ func (builder *StatefulSetBuilder) updateContainerPortsOnlyTLSListeners() []core
 		})
 	}
 
-	if builder.Instance.StreamNeeded() {
+	if builder.streamNeeded() {
 		ports = append(ports, corev1.ContainerPort{
 			Name:          "streams",
 			ContainerPort: 5551,
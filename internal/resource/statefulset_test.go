@@ -10,6 +10,8 @@
 package resource_test
 
 import (
+	"strings"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gstruct"
@@ -559,6 +561,50 @@ var _ = Describe("StatefulSet", func() {
 				}))
 			})
 
+			It("mounts the certificate of each listener group in its own volume", func() {
+				instance.Spec.TLS.SecretName = "tls-secret"
+				instance.Spec.TLS.Listeners = &rabbitmqv1beta1.TLSListenersSpec{
+					Management: &rabbitmqv1beta1.ListenerTLSSpec{SecretName: "management-tls", CaSecretName: "public-ca"},
+				}
+				Expect(stsBuilder.Update(statefulSet)).To(Succeed())
+
+				Expect(statefulSet.Spec.Template.Spec.Volumes).To(ContainElement(corev1.Volume{
+					Name: "rabbitmq-tls-management",
+					VolumeSource: corev1.VolumeSource{
+						Projected: &corev1.ProjectedVolumeSource{
+							Sources: []corev1.VolumeProjection{
+								{
+									Secret: &corev1.SecretProjection{
+										LocalObjectReference: corev1.LocalObjectReference{Name: "management-tls"},
+										Optional:             new(true),
+										Items: []corev1.KeyToPath{
+											{Key: "tls.crt", Path: "tls.crt"},
+											{Key: "tls.key", Path: "tls.key"},
+										},
+									},
+								},
+								{
+									Secret: &corev1.SecretProjection{
+										LocalObjectReference: corev1.LocalObjectReference{Name: "public-ca"},
+										Optional:             new(true),
+										Items: []corev1.KeyToPath{
+											{Key: "ca.crt", Path: "ca.crt"},
+										},
+									},
+								},
+							},
+							DefaultMode: new(int32(400)),
+						},
+					},
+				}))
+				rabbitmqContainerSpec := extractContainer(statefulSet.Spec.Template.Spec.Containers, "rabbitmq")
+				Expect(rabbitmqContainerSpec.VolumeMounts).To(ContainElement(corev1.VolumeMount{
+					Name:      "rabbitmq-tls-management",
+					MountPath: "/etc/rabbitmq-tls-management/",
+					ReadOnly:  true,
+				}))
+			})
+
 			It("opens tls ports for amqps and management-tls on the rabbitmq container", func() {
 				instance.Spec.TLS.SecretName = "tls-secret"
 				Expect(stsBuilder.Update(statefulSet)).To(Succeed())
@@ -927,6 +973,62 @@ var _ = Describe("StatefulSet", func() {
 			Expect(container.StartupProbe.FailureThreshold).To(BeEquivalentTo(30))
 		})
 
+		Context("Inter-node TLS", func() {
+			BeforeEach(func() {
+				instance.Spec.TLS = rabbitmqv1beta1.TLSSpec{
+					SecretName:   "tls-secret",
+					CaSecretName: "tls-mutual-secret",
+					InterNode:    true,
+				}
+			})
+
+			It("configures the node and the CLI tools to use TLS for Erlang distribution", func() {
+				Expect(stsBuilder.Update(statefulSet)).To(Succeed())
+
+				container := extractContainer(statefulSet.Spec.Template.Spec.Containers, "rabbitmq")
+				Expect(container.Env).To(ContainElements(
+					corev1.EnvVar{
+						Name:  "RABBITMQ_SERVER_ADDITIONAL_ERL_ARGS",
+						Value: "-proto_dist inet_tls -ssl_dist_optfile /etc/rabbitmq/inter_node_tls.config",
+					},
+					corev1.EnvVar{
+						Name:  "RABBITMQ_CTL_ERL_ARGS",
+						Value: "-proto_dist inet_tls -ssl_dist_optfile /etc/rabbitmq/inter_node_tls.config",
+					},
+				))
+				Expect(container.VolumeMounts).To(ContainElement(corev1.VolumeMount{
+					Name:      "server-conf",
+					MountPath: "/etc/rabbitmq/inter_node_tls.config",
+					SubPath:   "inter_node_tls.config",
+				}))
+			})
+
+			It("does not require a full restart of a new StatefulSet", func() {
+				Expect(stsBuilder.Update(statefulSet)).To(Succeed())
+				Expect(statefulSet.Annotations).NotTo(HaveKey("rabbitmq.com/fullRestartRequiredAt"))
+				Expect(statefulSet.Spec.UpdateStrategy.Type).To(Equal(appsv1.RollingUpdateStatefulSetStrategyType))
+			})
+
+			When("inter-node TLS is enabled on an existing StatefulSet", func() {
+				It("restarts all Pods at once", func() {
+					instance.Spec.TLS.InterNode = false
+					Expect(stsBuilder.Update(statefulSet)).To(Succeed())
+					statefulSet.ResourceVersion = "1"
+
+					instance.Spec.TLS.InterNode = true
+					Expect(stsBuilder.Update(statefulSet)).To(Succeed())
+					Expect(statefulSet.Annotations).To(HaveKey("rabbitmq.com/fullRestartRequiredAt"))
+					Expect(statefulSet.Spec.UpdateStrategy).To(Equal(appsv1.StatefulSetUpdateStrategy{
+						Type: appsv1.OnDeleteStatefulSetStrategyType,
+					}))
+
+					By("keeping the OnDelete strategy until the restart completed")
+					Expect(stsBuilder.Update(statefulSet)).To(Succeed())
+					Expect(statefulSet.Spec.UpdateStrategy.Type).To(Equal(appsv1.OnDeleteStatefulSetStrategyType))
+				})
+			})
+		})
+
 		When("annotation rabbitmq.com/legacy-startup-probe is present", func() {
 			BeforeEach(func() {
 				instance.Annotations = map[string]string{
@@ -1562,6 +1664,341 @@ default_pass = {{ .Data.data.password }}
 			}))
 		})
 
+		It("mounts the stream advertised address of the Pod when per-Pod stream Services are requested", func() {
+			instance.Spec.Rabbitmq.AdditionalPlugins = []rabbitmqv1beta1.Plugin{"rabbitmq_stream"}
+			instance.Spec.Stream.PerPodServices = &rabbitmqv1beta1.StreamPerPodServicesSpec{Type: "LoadBalancer"}
+			stsBuilder := builder.StatefulSet()
+			Expect(stsBuilder.Update(statefulSet)).To(Succeed())
+
+			Expect(statefulSet.Spec.Template.Spec.Volumes).To(ContainElement(corev1.Volume{
+				Name: "stream-advertised",
+				VolumeSource: corev1.VolumeSource{
+					ConfigMap: &corev1.ConfigMapVolumeSource{
+						LocalObjectReference: corev1.LocalObjectReference{Name: instance.ChildResourceName("stream-advertised")},
+					},
+				},
+			}))
+			container := extractContainer(statefulSet.Spec.Template.Spec.Containers, "rabbitmq")
+			Expect(container.VolumeMounts).To(ContainElement(corev1.VolumeMount{
+				Name:        "stream-advertised",
+				MountPath:   "/etc/rabbitmq/conf.d/12-stream_advertised.conf",
+				SubPathExpr: "$(MY_POD_NAME).conf",
+			}))
+		})
+
+		It("mounts the definitions ConfigMaps and Secrets in a single directory", func() {
+			instance.Spec.Rabbitmq.Definitions = &rabbitmqv1beta1.DefinitionsSpec{
+				Sources: []rabbitmqv1beta1.DefinitionsSource{
+					{ConfigMap: &corev1.LocalObjectReference{Name: "topology"}},
+					{Secret: &corev1.LocalObjectReference{Name: "users"}},
+				},
+			}
+			stsBuilder := builder.StatefulSet()
+			Expect(stsBuilder.Update(statefulSet)).To(Succeed())
+
+			Expect(statefulSet.Spec.Template.Spec.Volumes).To(ContainElement(corev1.Volume{
+				Name: "definitions",
+				VolumeSource: corev1.VolumeSource{
+					Projected: &corev1.ProjectedVolumeSource{
+						Sources: []corev1.VolumeProjection{
+							{ConfigMap: &corev1.ConfigMapProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "topology"}}},
+							{Secret: &corev1.SecretProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "users"}}},
+						},
+					},
+				},
+			}))
+			container := extractContainer(statefulSet.Spec.Template.Spec.Containers, "rabbitmq")
+			Expect(container.VolumeMounts).To(ContainElement(corev1.VolumeMount{
+				Name:      "definitions",
+				MountPath: "/etc/rabbitmq-definitions/",
+				ReadOnly:  true,
+			}))
+		})
+
+		It("mounts the CA certificates of the linked RabbitmqClusters", func() {
+			instance.Spec.Links = []rabbitmqv1beta1.LinkSpec{{
+				Name:            "orders",
+				Type:            rabbitmqv1beta1.LinkTypeFederation,
+				RabbitmqCluster: rabbitmqv1beta1.RabbitmqClusterReference{Name: "remote"},
+			}}
+			stsBuilder := builder.StatefulSet()
+			Expect(stsBuilder.Update(statefulSet)).To(Succeed())
+
+			Expect(statefulSet.Spec.Template.Spec.Volumes).To(ContainElement(corev1.Volume{
+				Name: "links-ca",
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{SecretName: instance.ChildResourceName("links-ca"), Optional: new(true)},
+				},
+			}))
+			container := extractContainer(statefulSet.Spec.Template.Spec.Containers, "rabbitmq")
+			Expect(container.VolumeMounts).To(ContainElement(corev1.VolumeMount{
+				Name:      "links-ca",
+				MountPath: "/etc/rabbitmq-links-ca/",
+				ReadOnly:  true,
+			}))
+		})
+
+		It("mounts the CA certificate of the replication upstream", func() {
+			instance.Spec.Replication = &rabbitmqv1beta1.ReplicationSpec{
+				Upstream:     rabbitmqv1beta1.RabbitmqClusterReference{Name: "upstream"},
+				VirtualHosts: []string{"/"},
+			}
+			stsBuilder := builder.StatefulSet()
+			Expect(stsBuilder.Update(statefulSet)).To(Succeed())
+
+			Expect(statefulSet.Spec.Template.Spec.Volumes).To(ContainElement(corev1.Volume{
+				Name: "replication-ca",
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{SecretName: instance.ChildResourceName("replication-ca"), Optional: new(true)},
+				},
+			}))
+			container := extractContainer(statefulSet.Spec.Template.Spec.Containers, "rabbitmq")
+			Expect(container.VolumeMounts).To(ContainElement(corev1.VolumeMount{
+				Name:      "replication-ca",
+				MountPath: "/etc/rabbitmq-replication-ca/",
+				ReadOnly:  true,
+			}))
+		})
+
+		It("mounts the key material of OAuth 2.0", func() {
+			instance.Spec.Auth.OAuth2 = &rabbitmqv1beta1.OAuth2Spec{
+				ResourceServerID: "rabbitmq",
+				CACertificate: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "idp-ca"},
+					Key:                  "tls.crt",
+				},
+				SigningKeys: []rabbitmqv1beta1.OAuth2SigningKey{{
+					ID: "key-1",
+					SecretKeyRef: corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "idp-keys"},
+						Key:                  "public.pem",
+					},
+				}},
+			}
+			stsBuilder := builder.StatefulSet()
+			Expect(stsBuilder.Update(statefulSet)).To(Succeed())
+
+			Expect(statefulSet.Spec.Template.Spec.Volumes).To(ContainElement(corev1.Volume{
+				Name: "rabbitmq-oauth2",
+				VolumeSource: corev1.VolumeSource{
+					Projected: &corev1.ProjectedVolumeSource{
+						Sources: []corev1.VolumeProjection{
+							{Secret: &corev1.SecretProjection{
+								LocalObjectReference: corev1.LocalObjectReference{Name: "idp-ca"},
+								Items:                []corev1.KeyToPath{{Key: "tls.crt", Path: "ca.crt"}},
+							}},
+							{Secret: &corev1.SecretProjection{
+								LocalObjectReference: corev1.LocalObjectReference{Name: "idp-keys"},
+								Items:                []corev1.KeyToPath{{Key: "public.pem", Path: "signing-keys/key-1.pem"}},
+							}},
+						},
+					},
+				},
+			}))
+			container := extractContainer(statefulSet.Spec.Template.Spec.Containers, "rabbitmq")
+			Expect(container.VolumeMounts).To(ContainElement(corev1.VolumeMount{
+				Name:      "rabbitmq-oauth2",
+				MountPath: "/etc/rabbitmq-oauth2/",
+				ReadOnly:  true,
+			}))
+			Expect(container.VolumeMounts).NotTo(ContainElement(HaveField("MountPath", "/etc/rabbitmq/conf.d/13-oauth2.conf")))
+		})
+
+		It("mounts the OAuth 2.0 client secret of the management UI in conf.d", func() {
+			instance.Spec.Auth.OAuth2 = &rabbitmqv1beta1.OAuth2Spec{
+				ResourceServerID: "rabbitmq",
+				Management: &rabbitmqv1beta1.OAuth2ManagementSpec{
+					ClientID: "rabbitmq-management",
+					ClientSecret: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "idp-client"},
+						Key:                  "secret",
+					},
+				},
+			}
+			stsBuilder := builder.StatefulSet()
+			Expect(stsBuilder.Update(statefulSet)).To(Succeed())
+
+			confd := extractVolume(statefulSet.Spec.Template.Spec.Volumes, "rabbitmq-confd")
+			Expect(confd.Projected.Sources).To(ContainElement(corev1.VolumeProjection{
+				Secret: &corev1.SecretProjection{
+					LocalObjectReference: corev1.LocalObjectReference{Name: instance.ChildResourceName("oauth2")},
+					Items:                []corev1.KeyToPath{{Key: "oauth2.conf", Path: "oauth2.conf"}},
+				},
+			}))
+			container := extractContainer(statefulSet.Spec.Template.Spec.Containers, "rabbitmq")
+			Expect(container.VolumeMounts).To(ContainElement(corev1.VolumeMount{
+				Name:      "rabbitmq-confd",
+				MountPath: "/etc/rabbitmq/conf.d/13-oauth2.conf",
+				SubPath:   "oauth2.conf",
+			}))
+		})
+
+		It("mounts the LDAP CA certificate and bind password", func() {
+			instance.Spec.Auth.LDAP = &rabbitmqv1beta1.LDAPSpec{
+				Servers:       []string{"ldap.example.com"},
+				UserDNPattern: "cn=${username},dc=example,dc=com",
+				TLS: &rabbitmqv1beta1.LDAPTLSSpec{CACertificate: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "ldap-ca"},
+					Key:                  "ca.crt",
+				}},
+				BindDN: "cn=rabbitmq,dc=example,dc=com",
+				BindPassword: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "ldap-bind"},
+					Key:                  "password",
+				},
+			}
+			stsBuilder := builder.StatefulSet()
+			Expect(stsBuilder.Update(statefulSet)).To(Succeed())
+
+			Expect(statefulSet.Spec.Template.Spec.Volumes).To(ContainElement(corev1.Volume{
+				Name: "rabbitmq-ldap",
+				VolumeSource: corev1.VolumeSource{
+					Projected: &corev1.ProjectedVolumeSource{
+						Sources: []corev1.VolumeProjection{{Secret: &corev1.SecretProjection{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "ldap-ca"},
+							Items:                []corev1.KeyToPath{{Key: "ca.crt", Path: "ca.crt"}},
+						}}},
+					},
+				},
+			}))
+			confd := extractVolume(statefulSet.Spec.Template.Spec.Volumes, "rabbitmq-confd")
+			Expect(confd.Projected.Sources).To(ContainElement(corev1.VolumeProjection{
+				Secret: &corev1.SecretProjection{
+					LocalObjectReference: corev1.LocalObjectReference{Name: instance.ChildResourceName("ldap")},
+					Items:                []corev1.KeyToPath{{Key: "ldap.conf", Path: "ldap.conf"}},
+				},
+			}))
+			container := extractContainer(statefulSet.Spec.Template.Spec.Containers, "rabbitmq")
+			Expect(container.VolumeMounts).To(ContainElements(
+				corev1.VolumeMount{Name: "rabbitmq-ldap", MountPath: "/etc/rabbitmq-ldap/", ReadOnly: true},
+				corev1.VolumeMount{Name: "rabbitmq-confd", MountPath: "/etc/rabbitmq/conf.d/14-ldap.conf", SubPath: "ldap.conf"},
+			))
+		})
+
+		It("mounts the fragments of additionalConfigFrom in order after additionalConfig", func() {
+			instance.Spec.Rabbitmq.AdditionalConfigFrom = []rabbitmqv1beta1.AdditionalConfigSource{
+				{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "tuning"},
+					Key:                  "rabbitmq.conf",
+				}},
+				{SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "shovels"},
+					Key:                  "shovels.conf",
+				}},
+			}
+			stsBuilder := builder.StatefulSet()
+			Expect(stsBuilder.Update(statefulSet)).To(Succeed())
+
+			confd := extractVolume(statefulSet.Spec.Template.Spec.Volumes, "rabbitmq-confd")
+			Expect(confd.Projected.Sources).To(ContainElements(
+				corev1.VolumeProjection{ConfigMap: &corev1.ConfigMapProjection{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "tuning"},
+					Items:                []corev1.KeyToPath{{Key: "rabbitmq.conf", Path: "additionalConfigFrom-01.conf"}},
+				}},
+				corev1.VolumeProjection{Secret: &corev1.SecretProjection{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "shovels"},
+					Items:                []corev1.KeyToPath{{Key: "shovels.conf", Path: "additionalConfigFrom-02.conf"}},
+				}},
+			))
+			container := extractContainer(statefulSet.Spec.Template.Spec.Containers, "rabbitmq")
+			Expect(container.VolumeMounts).To(ContainElements(
+				corev1.VolumeMount{Name: "rabbitmq-confd", MountPath: "/etc/rabbitmq/conf.d/91-additionalConfigFrom-01.conf", SubPath: "additionalConfigFrom-01.conf"},
+				corev1.VolumeMount{Name: "rabbitmq-confd", MountPath: "/etc/rabbitmq/conf.d/91-additionalConfigFrom-02.conf", SubPath: "additionalConfigFrom-02.conf"},
+			))
+		})
+
+		It("fetches the plugins of pluginSources before the setup container", func() {
+			instance.Spec.Rabbitmq.PluginSources = []rabbitmqv1beta1.PluginSource{
+				{
+					Name:   "rabbitmq_delayed_message_exchange",
+					URL:    "https://github.com/rabbitmq/rabbitmq-delayed-message-exchange/releases/download/v4.1.0/rabbitmq_delayed_message_exchange-4.1.0.ez",
+					SHA256: strings.Repeat("a", 64),
+				},
+				{
+					Name:   "rabbitmq_message_deduplication",
+					Image:  &rabbitmqv1beta1.PluginImageSource{Reference: "registry.example.com/plugins/deduplication:0.6.4", Path: "plugins/rabbitmq_message_deduplication-0.6.4.ez"},
+					SHA256: strings.Repeat("b", 64),
+				},
+			}
+			stsBuilder := builder.StatefulSet()
+			Expect(stsBuilder.Update(statefulSet)).To(Succeed())
+
+			initContainers := statefulSet.Spec.Template.Spec.InitContainers
+			Expect(initContainers).To(HaveLen(2))
+			Expect(initContainers[0].Name).To(Equal("fetch-plugins"))
+			Expect(initContainers[0].Image).To(Equal(resource.DefaultPluginFetcherImage))
+			Expect(initContainers[0].Command[2]).To(Equal(strings.Join([]string{
+				"set -e",
+				"rm -rf /operator/plugins",
+				"mkdir -p /operator/plugins",
+				"curl --fail --silent --show-error --location --retry 3 --output '/operator/plugins/rabbitmq_delayed_message_exchange-4.1.0.ez' " +
+					"'https://github.com/rabbitmq/rabbitmq-delayed-message-exchange/releases/download/v4.1.0/rabbitmq_delayed_message_exchange-4.1.0.ez'",
+				"echo '" + strings.Repeat("a", 64) + "  /operator/plugins/rabbitmq_delayed_message_exchange-4.1.0.ez' | sha256sum -c -",
+				"cp '/plugin-images/1/plugins/rabbitmq_message_deduplication-0.6.4.ez' '/operator/plugins/rabbitmq_message_deduplication-0.6.4.ez'",
+				"echo '" + strings.Repeat("b", 64) + "  /operator/plugins/rabbitmq_message_deduplication-0.6.4.ez' | sha256sum -c -",
+			}, "\n")))
+			Expect(initContainers[0].VolumeMounts).To(ConsistOf(
+				corev1.VolumeMount{Name: "rabbitmq-plugins", MountPath: "/operator"},
+				corev1.VolumeMount{Name: "plugin-image-1", MountPath: "/plugin-images/1", ReadOnly: true},
+			))
+			Expect(initContainers[1].Name).To(Equal("setup-container"))
+
+			Expect(extractVolume(statefulSet.Spec.Template.Spec.Volumes, "plugin-image-1").Image).To(Equal(&corev1.ImageVolumeSource{
+				Reference: "registry.example.com/plugins/deduplication:0.6.4",
+			}))
+			// the plugins directory is added in rabbitmq-env.conf, keeping the directories of the image
+			container := extractContainer(statefulSet.Spec.Template.Spec.Containers, "rabbitmq")
+			Expect(container.Env).NotTo(ContainElement(HaveField("Name", "RABBITMQ_PLUGINS_DIR")))
+			Expect(container.VolumeMounts).To(ContainElement(corev1.VolumeMount{
+				Name: "server-conf", MountPath: "/etc/rabbitmq/rabbitmq-env.conf", SubPath: "rabbitmq-env.conf",
+			}))
+			Expect(extractVolume(statefulSet.Spec.Template.Spec.Volumes, "server-conf").ConfigMap).NotTo(BeNil())
+		})
+
+		It("fails if a plugin source is invalid", func() {
+			instance.Spec.Rabbitmq.PluginSources = []rabbitmqv1beta1.PluginSource{{
+				Name:   "rabbitmq_delayed_message_exchange",
+				URL:    "https://example.com/plugin.zip",
+				SHA256: strings.Repeat("a", 64),
+			}}
+			stsBuilder := builder.StatefulSet()
+			Expect(stsBuilder.Update(statefulSet)).To(MatchError(ContainSubstring("invalid spec.rabbitmq.pluginSources[0]: plugin file plugin.zip must have the extension .ez")))
+		})
+
+		It("does not fetch plugins without pluginSources", func() {
+			stsBuilder := builder.StatefulSet()
+			Expect(stsBuilder.Update(statefulSet)).To(Succeed())
+
+			Expect(statefulSet.Spec.Template.Spec.InitContainers).To(ConsistOf(HaveField("Name", "setup-container")))
+			container := extractContainer(statefulSet.Spec.Template.Spec.Containers, "rabbitmq")
+			Expect(container.VolumeMounts).NotTo(ContainElement(HaveField("MountPath", "/etc/rabbitmq/rabbitmq-env.conf")))
+		})
+
+		It("adds the management path prefix to rabbitmqadmin.conf", func() {
+			instance.Spec.Management.PathPrefix = "/rabbitmq"
+			stsBuilder := builder.StatefulSet()
+			Expect(stsBuilder.Update(statefulSet)).To(Succeed())
+
+			initContainer := extractContainer(statefulSet.Spec.Template.Spec.InitContainers, "setup-container")
+			Expect(initContainer.Command[2]).To(ContainSubstring(
+				"/tmp/default_user.conf >> /var/lib/rabbitmq/.rabbitmqadmin.conf " +
+					"&& echo 'path_prefix = /rabbitmq' >> /var/lib/rabbitmq/.rabbitmqadmin.conf " +
+					"&& chmod 600 /var/lib/rabbitmq/.rabbitmqadmin.conf ; "))
+		})
+
+		It("waits for the rotated Erlang cookie before copying it", func() {
+			statefulSet.Spec.Template.Annotations = map[string]string{resource.ErlangCookieHashAnnotation: "abc123"}
+			stsBuilder := builder.StatefulSet()
+			Expect(stsBuilder.Update(statefulSet)).To(Succeed())
+
+			initContainer := extractContainer(statefulSet.Spec.Template.Spec.InitContainers, "setup-container")
+			Expect(initContainer.Command[2]).To(HavePrefix(
+				"until [ \"$(sha256sum < /tmp/erlang-cookie-secret/.erlang.cookie | cut -d ' ' -f 1)\" = \"abc123\" ] ; " +
+					"do echo 'waiting for the rotated Erlang cookie' ; sleep 2 ; done ; " +
+					"cp /tmp/erlang-cookie-secret/.erlang.cookie /var/lib/rabbitmq/.erlang.cookie "))
+			Expect(statefulSet.Spec.Template.Annotations).To(HaveKeyWithValue(resource.ErlangCookieHashAnnotation, "abc123"))
+		})
+
 		It("sets TerminationGracePeriodSeconds in podTemplate as provided in instance spec", func() {
 			instance.Spec.TerminationGracePeriodSeconds = new(int64(10))
 			builder = &resource.RabbitmqResourceBuilder{
@@ -0,0 +1,182 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+//
+
+package resource
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/metadata"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// StreamPodServiceLabel is set on per-Pod stream Services. Its value is the name of the Pod the Service selects.
+	StreamPodServiceLabel         = "rabbitmq.com/stream-pod-service"
+	StreamAdvertisedConfigMapName = "stream-advertised"
+	streamPodServiceSuffix        = "stream"
+	streamPodNamePlaceholder      = "{pod}"
+	statefulSetPodNameLabel       = "statefulset.kubernetes.io/pod-name"
+	streamPort                    = 5552
+	streamTLSPort                 = 5551
+)
+
+type StreamPodServiceBuilder struct {
+	*RabbitmqResourceBuilder
+	Index int32
+}
+
+func (builder *RabbitmqResourceBuilder) StreamPodService(index int32) *StreamPodServiceBuilder {
+	return &StreamPodServiceBuilder{builder, index}
+}
+
+// StreamPodServiceName returns the name of the stream Service selecting the Pod with the given ordinal.
+func StreamPodServiceName(instance *rabbitmqv1beta1.RabbitmqCluster, index int32) string {
+	return fmt.Sprintf("%s-%s", podName(instance, index), streamPodServiceSuffix)
+}
+
+func podName(instance *rabbitmqv1beta1.RabbitmqCluster, index int32) string {
+	return fmt.Sprintf("%s-%d", instance.ChildResourceName(stsSuffix), index)
+}
+
+func (builder *StreamPodServiceBuilder) Build() (client.Object, error) {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      StreamPodServiceName(builder.Instance, builder.Index),
+			Namespace: builder.Instance.Namespace,
+		},
+	}, nil
+}
+
+func (builder *StreamPodServiceBuilder) UpdateMayRequireStsRecreate() bool {
+	return false
+}
+
+func (builder *StreamPodServiceBuilder) Update(object client.Object) error {
+	service := object.(*corev1.Service)
+	pod := podName(builder.Instance, builder.Index)
+	perPodSpec := builder.Instance.Spec.Stream.PerPodServices
+
+	annotations := make(map[string]string, len(perPodSpec.Annotations))
+	for k, v := range perPodSpec.Annotations {
+		annotations[k] = strings.ReplaceAll(v, streamPodNamePlaceholder, pod)
+	}
+	service.Annotations = metadata.ReconcileAnnotations(metadata.ReconcileAndFilterAnnotations(service.Annotations, builder.Instance.Annotations), annotations)
+	service.Labels = metadata.GetLabels(builder.Instance.Name, builder.Instance.Labels)
+	service.Labels[StreamPodServiceLabel] = pod
+
+	selector := metadata.LabelSelector(builder.Instance.Name)
+	selector[statefulSetPodNameLabel] = pod
+	service.Spec.Selector = selector
+	service.Spec.Type = perPodSpec.Type
+	if service.Spec.Type == "" {
+		service.Spec.Type = corev1.ServiceTypeLoadBalancer
+	}
+	service.Spec.IPFamilyPolicy = builder.Instance.Spec.Service.IPFamilyPolicy
+	// Stream clients must reach the node they were redirected to, so traffic must not be
+	// forwarded to a different node by kube-proxy.
+	service.Spec.ExternalTrafficPolicy = corev1.ServiceExternalTrafficPolicyLocal
+	service.Spec.Ports = builder.updatePorts(service.Spec.Ports)
+
+	if err := controllerutil.SetControllerReference(builder.Instance, service, builder.Scheme); err != nil {
+		return fmt.Errorf("failed setting controller reference: %w", err)
+	}
+	return nil
+}
+
+// updatePorts returns the desired stream ports, keeping NodePorts that were already allocated
+func (builder *StreamPodServiceBuilder) updatePorts(existing []corev1.ServicePort) []corev1.ServicePort {
+	var desired []corev1.ServicePort
+	if !builder.Instance.DisableNonTLSListeners() {
+		desired = append(desired, corev1.ServicePort{
+			Protocol:    corev1.ProtocolTCP,
+			Port:        streamPort,
+			TargetPort:  intstr.FromInt32(streamPort),
+			Name:        "stream",
+			AppProtocol: new("rabbitmq.com/stream"),
+		})
+	}
+	if builder.Instance.TLSEnabled() {
+		desired = append(desired, corev1.ServicePort{
+			Protocol:    corev1.ProtocolTCP,
+			Port:        streamTLSPort,
+			TargetPort:  intstr.FromInt32(streamTLSPort),
+			Name:        "streams",
+			AppProtocol: new("rabbitmq.com/stream-tls"),
+		})
+	}
+	for i := range desired {
+		for _, port := range existing {
+			if port.Name == desired[i].Name {
+				desired[i].NodePort = port.NodePort
+			}
+		}
+	}
+	return desired
+}
+
+// StreamAdvertisedAddressForPod returns the address the node running in the given Pod should advertise to stream clients.
+// service is the per-Pod stream Service and may be nil if it has not been created yet.
+func StreamAdvertisedAddressForPod(instance *rabbitmqv1beta1.RabbitmqCluster, index int32, service *corev1.Service) rabbitmqv1beta1.StreamAdvertisedAddress {
+	pod := podName(instance, index)
+	address := rabbitmqv1beta1.StreamAdvertisedAddress{Pod: pod}
+	if service == nil {
+		return address
+	}
+
+	perPodSpec := instance.Spec.Stream.PerPodServices
+	if perPodSpec.AdvertisedHost != "" {
+		address.Host = strings.ReplaceAll(perPodSpec.AdvertisedHost, streamPodNamePlaceholder, pod)
+	} else if ingress := service.Status.LoadBalancer.Ingress; len(ingress) > 0 {
+		address.Host = ingress[0].Hostname
+		if address.Host == "" {
+			address.Host = ingress[0].IP
+		}
+	}
+	if address.Host == "" {
+		return address
+	}
+
+	for _, port := range service.Spec.Ports {
+		advertisedPort := port.Port
+		if service.Spec.Type == corev1.ServiceTypeNodePort {
+			advertisedPort = port.NodePort
+		}
+		switch port.Name {
+		case "stream":
+			address.Port = advertisedPort
+		case "streams":
+			address.TLSPort = advertisedPort
+		}
+	}
+	return address
+}
+
+// StreamAdvertisedConf renders the rabbitmq.conf settings making a node advertise the given address
+func StreamAdvertisedConf(address rabbitmqv1beta1.StreamAdvertisedAddress) string {
+	if address.Host == "" {
+		return ""
+	}
+	var conf strings.Builder
+	fmt.Fprintf(&conf, "stream.advertised_host = %s\n", address.Host)
+	if address.Port != 0 {
+		fmt.Fprintf(&conf, "stream.advertised_port = %d\n", address.Port)
+	}
+	if address.TLSPort != 0 {
+		fmt.Fprintf(&conf, "stream.advertised_tls_port = %d\n", address.TLSPort)
+	}
+	return conf.String()
+}
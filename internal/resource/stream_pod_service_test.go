@@ -0,0 +1,153 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+//
+
+package resource_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	defaultscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+var _ = Describe("StreamPodService", func() {
+	var (
+		instance rabbitmqv1beta1.RabbitmqCluster
+		builder  *resource.RabbitmqResourceBuilder
+		service  *corev1.Service
+	)
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(rabbitmqv1beta1.AddToScheme(scheme)).To(Succeed())
+		Expect(defaultscheme.AddToScheme(scheme)).To(Succeed())
+		instance = rabbitmqv1beta1.RabbitmqCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "rabbit",
+				Namespace: "a-namespace",
+			},
+			Spec: rabbitmqv1beta1.RabbitmqClusterSpec{
+				Replicas: new(int32(3)),
+				Rabbitmq: rabbitmqv1beta1.RabbitmqClusterConfigurationSpec{
+					AdditionalPlugins: []rabbitmqv1beta1.Plugin{"rabbitmq_stream"},
+				},
+				Stream: rabbitmqv1beta1.StreamSpec{
+					PerPodServices: &rabbitmqv1beta1.StreamPerPodServicesSpec{
+						Type: corev1.ServiceTypeLoadBalancer,
+					},
+				},
+			},
+		}
+		builder = &resource.RabbitmqResourceBuilder{
+			Instance: &instance,
+			Scheme:   scheme,
+		}
+		obj, err := builder.StreamPodService(1).Build()
+		Expect(err).NotTo(HaveOccurred())
+		service = obj.(*corev1.Service)
+	})
+
+	It("names the Service after the Pod", func() {
+		Expect(service.Name).To(Equal("rabbit-server-1-stream"))
+		Expect(service.Namespace).To(Equal("a-namespace"))
+	})
+
+	It("selects a single Pod and exposes the stream port", func() {
+		Expect(builder.StreamPodService(1).Update(service)).To(Succeed())
+
+		Expect(service.Spec.Type).To(Equal(corev1.ServiceTypeLoadBalancer))
+		Expect(service.Spec.Selector).To(Equal(map[string]string{
+			"app.kubernetes.io/name":             "rabbit",
+			"statefulset.kubernetes.io/pod-name": "rabbit-server-1",
+		}))
+		Expect(service.Spec.ExternalTrafficPolicy).To(Equal(corev1.ServiceExternalTrafficPolicyLocal))
+		Expect(service.Spec.Ports).To(ConsistOf(corev1.ServicePort{
+			Name:        "stream",
+			Protocol:    corev1.ProtocolTCP,
+			Port:        5552,
+			TargetPort:  intstr.FromInt32(5552),
+			AppProtocol: new("rabbitmq.com/stream"),
+		}))
+		Expect(service.Labels).To(HaveKeyWithValue(resource.StreamPodServiceLabel, "rabbit-server-1"))
+		Expect(service.OwnerReferences).To(HaveLen(1))
+	})
+
+	It("replaces the Pod name placeholder in annotations", func() {
+		instance.Spec.Stream.PerPodServices.Annotations = map[string]string{
+			"external-dns.alpha.kubernetes.io/hostname": "{pod}.streams.example.com",
+		}
+		Expect(builder.StreamPodService(1).Update(service)).To(Succeed())
+		Expect(service.Annotations).To(HaveKeyWithValue("external-dns.alpha.kubernetes.io/hostname", "rabbit-server-1.streams.example.com"))
+	})
+
+	It("exposes only the TLS stream port when non-TLS listeners are disabled", func() {
+		instance.Spec.TLS = rabbitmqv1beta1.TLSSpec{SecretName: "tls-secret", DisableNonTLSListeners: true}
+		Expect(builder.StreamPodService(1).Update(service)).To(Succeed())
+		Expect(service.Spec.Ports).To(HaveLen(1))
+		Expect(service.Spec.Ports[0].Name).To(Equal("streams"))
+		Expect(service.Spec.Ports[0].Port).To(Equal(int32(5551)))
+	})
+
+	It("keeps allocated NodePorts", func() {
+		instance.Spec.Stream.PerPodServices.Type = corev1.ServiceTypeNodePort
+		service.Spec.Ports = []corev1.ServicePort{{Name: "stream", Port: 5552, NodePort: 30552}}
+		Expect(builder.StreamPodService(1).Update(service)).To(Succeed())
+		Expect(service.Spec.Type).To(Equal(corev1.ServiceTypeNodePort))
+		Expect(service.Spec.Ports[0].NodePort).To(Equal(int32(30552)))
+	})
+
+	Describe("StreamAdvertisedAddressForPod", func() {
+		It("returns no host while the Service does not exist", func() {
+			address := resource.StreamAdvertisedAddressForPod(&instance, 1, nil)
+			Expect(address).To(Equal(rabbitmqv1beta1.StreamAdvertisedAddress{Pod: "rabbit-server-1"}))
+			Expect(resource.StreamAdvertisedConf(address)).To(BeEmpty())
+		})
+
+		It("advertises the LoadBalancer address and Service port", func() {
+			Expect(builder.StreamPodService(1).Update(service)).To(Succeed())
+			service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "203.0.113.7"}}
+
+			address := resource.StreamAdvertisedAddressForPod(&instance, 1, service)
+			Expect(address).To(Equal(rabbitmqv1beta1.StreamAdvertisedAddress{
+				Pod:  "rabbit-server-1",
+				Host: "203.0.113.7",
+				Port: 5552,
+			}))
+			Expect(resource.StreamAdvertisedConf(address)).To(Equal(
+				"stream.advertised_host = 203.0.113.7\n" +
+					"stream.advertised_port = 5552\n"))
+		})
+
+		It("advertises the configured host and NodePorts for NodePort Services", func() {
+			instance.Spec.TLS = rabbitmqv1beta1.TLSSpec{SecretName: "tls-secret"}
+			instance.Spec.Stream.PerPodServices.Type = corev1.ServiceTypeNodePort
+			instance.Spec.Stream.PerPodServices.AdvertisedHost = "{pod}.nodes.example.com"
+			Expect(builder.StreamPodService(1).Update(service)).To(Succeed())
+			service.Spec.Ports[0].NodePort = 30552
+			service.Spec.Ports[1].NodePort = 30551
+
+			address := resource.StreamAdvertisedAddressForPod(&instance, 1, service)
+			Expect(address).To(Equal(rabbitmqv1beta1.StreamAdvertisedAddress{
+				Pod:     "rabbit-server-1",
+				Host:    "rabbit-server-1.nodes.example.com",
+				Port:    30552,
+				TLSPort: 30551,
+			}))
+			Expect(resource.StreamAdvertisedConf(address)).To(Equal(
+				"stream.advertised_host = rabbit-server-1.nodes.example.com\n" +
+					"stream.advertised_port = 30552\n" +
+					"stream.advertised_tls_port = 30551\n"))
+		})
+	})
+})
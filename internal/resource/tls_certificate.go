@@ -0,0 +1,136 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+//
+
+package resource
+
+import (
+	"fmt"
+	"slices"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/metadata"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	TLSCertificateSuffix = "tls"
+	// X.509 limits the Common Name to 64 characters
+	maxCommonNameLength = 64
+)
+
+var CertificateGVK = schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}
+
+type TLSCertificateBuilder struct {
+	*RabbitmqResourceBuilder
+}
+
+func (builder *RabbitmqResourceBuilder) TLSCertificate() *TLSCertificateBuilder {
+	return &TLSCertificateBuilder{builder}
+}
+
+func (builder *TLSCertificateBuilder) Build() (client.Object, error) {
+	certificate := &unstructured.Unstructured{}
+	certificate.SetGroupVersionKind(CertificateGVK)
+	certificate.SetName(builder.Instance.ChildResourceName(TLSCertificateSuffix))
+	certificate.SetNamespace(builder.Instance.Namespace)
+	return certificate, nil
+}
+
+func (builder *TLSCertificateBuilder) UpdateMayRequireStsRecreate() bool {
+	return false
+}
+
+func (builder *TLSCertificateBuilder) Update(object client.Object) error {
+	certificate := object.(*unstructured.Unstructured)
+	certManagerSpec := builder.Instance.Spec.TLS.CertManager
+
+	certificate.SetAnnotations(metadata.ReconcileAndFilterAnnotations(certificate.GetAnnotations(), builder.Instance.Annotations))
+	labels := metadata.GetLabels(builder.Instance.Name, builder.Instance.Labels)
+	certificate.SetLabels(labels)
+
+	issuerRef := map[string]any{
+		"name":  certManagerSpec.IssuerRef.Name,
+		"kind":  certManagerSpec.IssuerRef.Kind,
+		"group": certManagerSpec.IssuerRef.Group,
+	}
+	if issuerRef["kind"] == "" {
+		issuerRef["kind"] = "Issuer"
+	}
+	if issuerRef["group"] == "" {
+		issuerRef["group"] = CertificateGVK.Group
+	}
+
+	// The Secret is labelled like all other child resources so that it is visible to the operator's cache
+	secretLabels := make(map[string]any, len(labels))
+	for k, v := range labels {
+		secretLabels[k] = v
+	}
+
+	spec := map[string]any{
+		"secretName": builder.Instance.TLSSecretName(),
+		"secretTemplate": map[string]any{
+			"labels": secretLabels,
+		},
+		"issuerRef": issuerRef,
+		"dnsNames":  certificateDNSNames(builder.Instance),
+		"usages":    []any{"server auth", "client auth", "digital signature", "key encipherment"},
+	}
+	if commonName := builder.Instance.ServiceSubDomain(); len(commonName) <= maxCommonNameLength {
+		spec["commonName"] = commonName
+	}
+	if certManagerSpec.Duration != nil {
+		spec["duration"] = certManagerSpec.Duration.Duration.String()
+	}
+	if certManagerSpec.RenewBefore != nil {
+		spec["renewBefore"] = certManagerSpec.RenewBefore.Duration.String()
+	}
+
+	if err := unstructured.SetNestedField(certificate.Object, spec, "spec"); err != nil {
+		return fmt.Errorf("failed setting Certificate spec: %w", err)
+	}
+
+	if err := controllerutil.SetControllerReference(builder.Instance, certificate, builder.Scheme); err != nil {
+		return fmt.Errorf("failed setting controller reference: %w", err)
+	}
+	return nil
+}
+
+// certificateDNSNames returns the names under which clients and peers reach the cluster:
+// the client Service, the headless Service, and every Pod through the headless Service.
+// Since the list grows with the number of replicas, cert-manager re-issues the certificate on scale out.
+func certificateDNSNames(instance *rabbitmqv1beta1.RabbitmqCluster) []any {
+	service := instance.ChildResourceName(ServiceSuffix)
+	headlessService := instance.ChildResourceName(headlessServiceSuffix)
+	names := []string{
+		service,
+		fmt.Sprintf("%s.%s", service, instance.Namespace),
+		instance.ServiceSubDomain(),
+		fmt.Sprintf("%s.%s.svc", headlessService, instance.Namespace),
+	}
+	for i := range ptr.Deref(instance.Spec.Replicas, 1) {
+		pod := podName(instance, i)
+		names = append(names,
+			fmt.Sprintf("%s.%s.%s", pod, headlessService, instance.Namespace),
+			fmt.Sprintf("%s.%s.%s.svc", pod, headlessService, instance.Namespace),
+		)
+	}
+
+	dnsNames := make([]any, 0, len(names)+len(instance.Spec.TLS.CertManager.AdditionalDNSNames))
+	for _, name := range append(names, instance.Spec.TLS.CertManager.AdditionalDNSNames...) {
+		if !slices.Contains(dnsNames, any(name)) {
+			dnsNames = append(dnsNames, name)
+		}
+	}
+	return dnsNames
+}
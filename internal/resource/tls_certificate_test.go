@@ -0,0 +1,134 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+//
+
+package resource_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rabbitmqv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	defaultscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+var _ = Describe("TLSCertificate", func() {
+	var (
+		instance    rabbitmqv1beta1.RabbitmqCluster
+		builder     *resource.RabbitmqResourceBuilder
+		certificate *unstructured.Unstructured
+	)
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(rabbitmqv1beta1.AddToScheme(scheme)).To(Succeed())
+		Expect(defaultscheme.AddToScheme(scheme)).To(Succeed())
+		instance = rabbitmqv1beta1.RabbitmqCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "rabbit",
+				Namespace: "a-namespace",
+			},
+			Spec: rabbitmqv1beta1.RabbitmqClusterSpec{
+				Replicas: new(int32(2)),
+				TLS: rabbitmqv1beta1.TLSSpec{
+					CertManager: &rabbitmqv1beta1.CertManagerTLSSpec{
+						IssuerRef: rabbitmqv1beta1.CertManagerIssuerReference{Name: "ca-issuer"},
+					},
+				},
+			},
+		}
+		builder = &resource.RabbitmqResourceBuilder{
+			Instance: &instance,
+			Scheme:   scheme,
+		}
+		obj, err := builder.TLSCertificate().Build()
+		Expect(err).NotTo(HaveOccurred())
+		certificate = obj.(*unstructured.Unstructured)
+	})
+
+	It("generates correct metadata", func() {
+		Expect(certificate.GroupVersionKind()).To(Equal(resource.CertificateGVK))
+		Expect(certificate.GetName()).To(Equal("rabbit-tls"))
+		Expect(certificate.GetNamespace()).To(Equal("a-namespace"))
+	})
+
+	It("issues the TLS secret used by the cluster", func() {
+		Expect(builder.TLSCertificate().Update(certificate)).To(Succeed())
+
+		secretName, _, _ := unstructured.NestedString(certificate.Object, "spec", "secretName")
+		Expect(secretName).To(Equal(instance.TLSSecretName()))
+		Expect(instance.SecretTLSEnabled()).To(BeTrue())
+
+		secretLabels, _, _ := unstructured.NestedStringMap(certificate.Object, "spec", "secretTemplate", "labels")
+		Expect(secretLabels).To(HaveKeyWithValue("app.kubernetes.io/part-of", "rabbitmq"))
+
+		issuerRef, _, _ := unstructured.NestedStringMap(certificate.Object, "spec", "issuerRef")
+		Expect(issuerRef).To(Equal(map[string]string{
+			"name":  "ca-issuer",
+			"kind":  "Issuer",
+			"group": "cert-manager.io",
+		}))
+		Expect(certificate.GetOwnerReferences()).To(HaveLen(1))
+	})
+
+	It("includes the names of the Services and every Pod", func() {
+		Expect(builder.TLSCertificate().Update(certificate)).To(Succeed())
+
+		commonName, _, _ := unstructured.NestedString(certificate.Object, "spec", "commonName")
+		Expect(commonName).To(Equal("rabbit.a-namespace.svc"))
+		dnsNames, _, _ := unstructured.NestedStringSlice(certificate.Object, "spec", "dnsNames")
+		Expect(dnsNames).To(ConsistOf(
+			"rabbit",
+			"rabbit.a-namespace",
+			"rabbit.a-namespace.svc",
+			"rabbit-nodes.a-namespace.svc",
+			"rabbit-server-0.rabbit-nodes.a-namespace",
+			"rabbit-server-0.rabbit-nodes.a-namespace.svc",
+			"rabbit-server-1.rabbit-nodes.a-namespace",
+			"rabbit-server-1.rabbit-nodes.a-namespace.svc",
+		))
+	})
+
+	It("adds the names of new Pods on scale out", func() {
+		instance.Spec.Replicas = new(int32(3))
+		Expect(builder.TLSCertificate().Update(certificate)).To(Succeed())
+		dnsNames, _, _ := unstructured.NestedStringSlice(certificate.Object, "spec", "dnsNames")
+		Expect(dnsNames).To(ContainElement("rabbit-server-2.rabbit-nodes.a-namespace"))
+	})
+
+	It("adds additional DNS names, the issuer kind and the certificate lifetime", func() {
+		instance.Spec.TLS.CertManager.AdditionalDNSNames = []string{"rabbit.example.com", "rabbit"}
+		instance.Spec.TLS.CertManager.IssuerRef.Kind = "ClusterIssuer"
+		instance.Spec.TLS.CertManager.Duration = &metav1.Duration{Duration: 720 * time.Hour}
+		instance.Spec.TLS.CertManager.RenewBefore = &metav1.Duration{Duration: 240 * time.Hour}
+		Expect(builder.TLSCertificate().Update(certificate)).To(Succeed())
+
+		dnsNames, _, _ := unstructured.NestedStringSlice(certificate.Object, "spec", "dnsNames")
+		Expect(dnsNames).To(ContainElement("rabbit.example.com"))
+		Expect(dnsNames).To(HaveLen(9))
+		kind, _, _ := unstructured.NestedString(certificate.Object, "spec", "issuerRef", "kind")
+		Expect(kind).To(Equal("ClusterIssuer"))
+		duration, _, _ := unstructured.NestedString(certificate.Object, "spec", "duration")
+		Expect(duration).To(Equal("720h0m0s"))
+		renewBefore, _, _ := unstructured.NestedString(certificate.Object, "spec", "renewBefore")
+		Expect(renewBefore).To(Equal("240h0m0s"))
+	})
+
+	It("omits the common name when it exceeds 64 characters", func() {
+		instance.Namespace = "a-namespace-with-a-name-long-enough-to-exceed-the-limit"
+		certificate.SetNamespace(instance.Namespace)
+		Expect(builder.TLSCertificate().Update(certificate)).To(Succeed())
+		_, found, _ := unstructured.NestedString(certificate.Object, "spec", "commonName")
+		Expect(found).To(BeFalse())
+	})
+})
@@ -0,0 +1,49 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+//
+
+package status
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RestartPendingCondition is true while a restart caused by a configuration change awaits approval.
+// changedKeys are the keys and files whose change requires the restart.
+func RestartPendingCondition(pending bool, changedKeys []string, approveAnnotation string, oldCondition *RabbitmqClusterCondition) RabbitmqClusterCondition {
+	condition := newRabbitmqClusterCondition(RestartPending)
+	if oldCondition != nil {
+		condition.LastTransitionTime = oldCondition.LastTransitionTime
+	}
+
+	if pending {
+		condition.Status = corev1.ConditionTrue
+		condition.Reason = "AwaitingApproval"
+		changes := "the configuration"
+		if len(changedKeys) > 0 {
+			changes = strings.Join(changedKeys, ", ")
+		}
+		condition.Message = fmt.Sprintf("Changes to %s require restarting the nodes. Annotate the RabbitmqCluster with %s to restart them", changes, approveAnnotation)
+	} else {
+		condition.Status = corev1.ConditionFalse
+		condition.Reason = "NoRestartPending"
+	}
+
+	if oldCondition == nil || oldCondition.Status != condition.Status {
+		condition.LastTransitionTime = metav1.Time{
+			Time: time.Now(),
+		}
+	}
+
+	return condition
+}
@@ -0,0 +1,52 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+//
+
+package status_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rabbitmqstatus "github.com/rabbitmq/cluster-operator/v2/internal/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("RestartPending", func() {
+	It("is true with the changed keys while a restart awaits approval", func() {
+		condition := rabbitmqstatus.RestartPendingCondition(true, []string{"advanced.config", "listeners.tcp.default"}, "rabbitmq.com/approve-restart", nil)
+		Expect(condition.Type).To(Equal(rabbitmqstatus.RestartPending))
+		Expect(condition.Status).To(Equal(corev1.ConditionTrue))
+		Expect(condition.Reason).To(Equal("AwaitingApproval"))
+		Expect(condition.Message).To(Equal("Changes to advanced.config, listeners.tcp.default require restarting the nodes. " +
+			"Annotate the RabbitmqCluster with rabbitmq.com/approve-restart to restart them"))
+	})
+
+	It("is false when no restart is pending", func() {
+		condition := rabbitmqstatus.RestartPendingCondition(false, nil, "rabbitmq.com/approve-restart", nil)
+		Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+		Expect(condition.Reason).To(Equal("NoRestartPending"))
+		Expect(condition.Message).To(BeEmpty())
+	})
+
+	It("keeps the transition time when the status does not change", func() {
+		previousTransitionTime := metav1.Time{Time: time.Date(2020, 2, 2, 8, 0, 0, 0, time.UTC)}
+		oldCondition := &rabbitmqstatus.RabbitmqClusterCondition{
+			Type:               rabbitmqstatus.RestartPending,
+			Status:             corev1.ConditionTrue,
+			LastTransitionTime: previousTransitionTime,
+		}
+		condition := rabbitmqstatus.RestartPendingCondition(true, []string{"heartbeat"}, "rabbitmq.com/approve-restart", oldCondition)
+		Expect(condition.LastTransitionTime).To(Equal(previousTransitionTime))
+
+		condition = rabbitmqstatus.RestartPendingCondition(false, nil, "rabbitmq.com/approve-restart", oldCondition)
+		Expect(condition.LastTransitionTime).NotTo(Equal(previousTransitionTime))
+	})
+})
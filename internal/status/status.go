@@ -23,6 +23,10 @@ const (
 	ClusterAvailable RabbitmqClusterConditionType = "ClusterAvailable"
 	NoWarnings       RabbitmqClusterConditionType = "NoWarnings"
 	ReconcileSuccess RabbitmqClusterConditionType = "ReconcileSuccess"
+	// TLSCertificateExpiring is only present when TLS is configured with a Secret
+	TLSCertificateExpiring RabbitmqClusterConditionType = "TLSCertificateExpiring"
+	// RestartPending is only present when spec.restartPolicy is Manual
+	RestartPending RabbitmqClusterConditionType = "RestartPending"
 )
 
 type RabbitmqClusterConditionType string
@@ -0,0 +1,54 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+//
+
+package status
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TLSCertificateExpiryWarningPeriod is how long before its expiry the server certificate is reported as expiring.
+// It is shorter than the period in which cert-manager renews certificates by default, so that the condition
+// only becomes true when a renewal did not happen.
+const TLSCertificateExpiryWarningPeriod = 14 * 24 * time.Hour
+
+func TLSCertificateExpiringCondition(notAfter time.Time, oldCondition *RabbitmqClusterCondition) RabbitmqClusterCondition {
+	condition := newRabbitmqClusterCondition(TLSCertificateExpiring)
+	if oldCondition != nil {
+		condition.LastTransitionTime = oldCondition.LastTransitionTime
+	}
+
+	remaining := time.Until(notAfter)
+	switch {
+	case remaining <= 0:
+		condition.Status = corev1.ConditionTrue
+		condition.Reason = "CertificateExpired"
+		condition.Message = fmt.Sprintf("The server certificate expired at %s", notAfter.UTC().Format(time.RFC3339))
+	case remaining <= TLSCertificateExpiryWarningPeriod:
+		condition.Status = corev1.ConditionTrue
+		condition.Reason = "CertificateExpiring"
+		condition.Message = fmt.Sprintf("The server certificate expires at %s", notAfter.UTC().Format(time.RFC3339))
+	default:
+		condition.Status = corev1.ConditionFalse
+		condition.Reason = "CertificateValid"
+		condition.Message = fmt.Sprintf("The server certificate is valid until %s", notAfter.UTC().Format(time.RFC3339))
+	}
+
+	if oldCondition == nil || oldCondition.Status != condition.Status {
+		condition.LastTransitionTime = metav1.Time{
+			Time: time.Now(),
+		}
+	}
+
+	return condition
+}
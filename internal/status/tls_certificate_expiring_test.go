@@ -0,0 +1,69 @@
+// RabbitMQ Cluster Operator
+//
+// Copyright 2020 VMware, Inc. All Rights Reserved.
+//
+// This product is licensed to you under the Mozilla Public license, Version 2.0 (the "License").  You may not use this product except in compliance with the Mozilla Public License.
+//
+// This product may include a number of subcomponents with separate copyright notices and license terms. Your use of these subcomponents is subject to the terms and conditions of the subcomponent's license, as noted in the LICENSE file.
+//
+
+package status_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rabbitmqstatus "github.com/rabbitmq/cluster-operator/v2/internal/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("TLSCertificateExpiring", func() {
+	It("is false while the certificate is valid for longer than the warning period", func() {
+		condition := rabbitmqstatus.TLSCertificateExpiringCondition(time.Now().Add(90*24*time.Hour), nil)
+		Expect(condition.Type).To(Equal(rabbitmqstatus.TLSCertificateExpiring))
+		Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+		Expect(condition.Reason).To(Equal("CertificateValid"))
+	})
+
+	It("is true when the certificate expires within the warning period", func() {
+		condition := rabbitmqstatus.TLSCertificateExpiringCondition(time.Now().Add(24*time.Hour), nil)
+		Expect(condition.Status).To(Equal(corev1.ConditionTrue))
+		Expect(condition.Reason).To(Equal("CertificateExpiring"))
+	})
+
+	It("is true when the certificate has expired", func() {
+		condition := rabbitmqstatus.TLSCertificateExpiringCondition(time.Now().Add(-time.Hour), nil)
+		Expect(condition.Status).To(Equal(corev1.ConditionTrue))
+		Expect(condition.Reason).To(Equal("CertificateExpired"))
+	})
+
+	Context("condition transitions", func() {
+		var previousTransitionTime metav1.Time
+
+		BeforeEach(func() {
+			previousTransitionTime = metav1.Time{Time: time.Date(2020, 2, 2, 8, 0, 0, 0, time.UTC)}
+		})
+
+		It("keeps the transition time when the status does not change", func() {
+			oldCondition := &rabbitmqstatus.RabbitmqClusterCondition{
+				Type:               rabbitmqstatus.TLSCertificateExpiring,
+				Status:             corev1.ConditionFalse,
+				LastTransitionTime: previousTransitionTime,
+			}
+			condition := rabbitmqstatus.TLSCertificateExpiringCondition(time.Now().Add(90*24*time.Hour), oldCondition)
+			Expect(condition.LastTransitionTime).To(Equal(previousTransitionTime))
+		})
+
+		It("updates the transition time when the certificate starts expiring", func() {
+			oldCondition := &rabbitmqstatus.RabbitmqClusterCondition{
+				Type:               rabbitmqstatus.TLSCertificateExpiring,
+				Status:             corev1.ConditionFalse,
+				LastTransitionTime: previousTransitionTime,
+			}
+			condition := rabbitmqstatus.TLSCertificateExpiringCondition(time.Now().Add(time.Hour), oldCondition)
+			Expect(condition.LastTransitionTime.Time).To(BeTemporally(">", previousTransitionTime.Time))
+		})
+	})
+})
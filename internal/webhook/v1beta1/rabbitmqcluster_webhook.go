@@ -18,17 +18,25 @@ package v1beta1
 
 import (
 	"context"
+	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	rabbitmqcomv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
+	"github.com/rabbitmq/cluster-operator/v2/internal/cron"
+	"github.com/rabbitmq/cluster-operator/v2/internal/erlang"
+	"github.com/rabbitmq/cluster-operator/v2/internal/rabbitmqconf"
+	"github.com/rabbitmq/cluster-operator/v2/internal/resource"
 )
 
 var rabbitmqclusterlog = logf.Log.WithName("rabbitmqcluster-webhook")
@@ -37,7 +45,7 @@ var rabbitmqclusterlog = logf.Log.WithName("rabbitmqcluster-webhook")
 func SetupRabbitmqClusterWebhookWithManager(mgr ctrl.Manager, defaulter RabbitmqClusterCustomDefaulter) error {
 	return ctrl.NewWebhookManagedBy(mgr, &rabbitmqcomv1beta1.RabbitmqCluster{}).
 		WithDefaulter(&defaulter).
-		WithValidator(&RabbitmqClusterCustomValidator{}).
+		WithValidator(&RabbitmqClusterCustomValidator{Reader: mgr.GetAPIReader()}).
 		Complete()
 }
 
@@ -51,24 +59,31 @@ type RabbitmqClusterCustomDefaulter struct {
 	DefaultRabbitmqImage    string
 	DefaultImagePullSecrets string
 	DefaultUserUpdaterImage string
+	// DefaultPluginFetcherImage is set as spec.rabbitmq.pluginFetcherImage of RabbitmqClusters with plugin sources
+	DefaultPluginFetcherImage string
 }
 
 // +kubebuilder:webhook:path=/validate-rabbitmq-com-v1beta1-rabbitmqcluster,mutating=false,failurePolicy=fail,sideEffects=None,groups=rabbitmq.com,resources=rabbitmqclusters,verbs=create;update,versions=v1beta1,name=vrabbitmqcluster-v1beta1.kb.io,admissionReviewVersions=v1
 
 // RabbitmqClusterCustomValidator rejects RabbitmqCluster resources that request
-// host-level or privileged access via spec.override.statefulSet.spec.template.spec.
+// host-level or privileged access via spec.override.statefulSet.spec.template.spec,
+// or that contain invalid combinations of settings the CRD schema cannot express.
 //
 // +kubebuilder:object:generate=false
-type RabbitmqClusterCustomValidator struct{}
+type RabbitmqClusterCustomValidator struct {
+	// Reader is used to validate the ConfigMaps and Secrets referenced in spec.rabbitmq.definitions.
+	// The definitions are not validated if it is nil.
+	Reader client.Reader
+}
 
 // ValidateCreate implements admission.Validator.
-func (v *RabbitmqClusterCustomValidator) ValidateCreate(_ context.Context, obj *rabbitmqcomv1beta1.RabbitmqCluster) (admission.Warnings, error) {
-	return nil, validatePodSpecOverride(obj)
+func (v *RabbitmqClusterCustomValidator) ValidateCreate(ctx context.Context, obj *rabbitmqcomv1beta1.RabbitmqCluster) (admission.Warnings, error) {
+	return v.validateRabbitmqCluster(ctx, obj)
 }
 
 // ValidateUpdate implements admission.Validator.
-func (v *RabbitmqClusterCustomValidator) ValidateUpdate(_ context.Context, _, newObj *rabbitmqcomv1beta1.RabbitmqCluster) (admission.Warnings, error) {
-	return nil, validatePodSpecOverride(newObj)
+func (v *RabbitmqClusterCustomValidator) ValidateUpdate(ctx context.Context, _, newObj *rabbitmqcomv1beta1.RabbitmqCluster) (admission.Warnings, error) {
+	return v.validateRabbitmqCluster(ctx, newObj)
 }
 
 // ValidateDelete implements admission.Validator.
@@ -76,7 +91,37 @@ func (v *RabbitmqClusterCustomValidator) ValidateDelete(_ context.Context, _ *ra
 	return nil, nil
 }
 
-func validatePodSpecOverride(cluster *rabbitmqcomv1beta1.RabbitmqCluster) error {
+func (v *RabbitmqClusterCustomValidator) validateRabbitmqCluster(ctx context.Context, cluster *rabbitmqcomv1beta1.RabbitmqCluster) (admission.Warnings, error) {
+	var allErrs field.ErrorList
+	allErrs = append(allErrs, validatePodSpecOverride(cluster)...)
+	allErrs = append(allErrs, validateTLS(cluster)...)
+	allErrs = append(allErrs, validateDefaultUser(cluster)...)
+	allErrs = append(allErrs, validateBackup(cluster)...)
+	allErrs = append(allErrs, validateMaintenanceWindows(cluster)...)
+	allErrs = append(allErrs, validateReplication(cluster)...)
+	allErrs = append(allErrs, validateLinks(cluster)...)
+	allErrs = append(allErrs, validateAuth(cluster)...)
+	warnings, configErrs := validateConfig(cluster)
+	allErrs = append(allErrs, configErrs...)
+	pluginSourcesWarnings, pluginSourcesErrs := validatePluginSources(cluster)
+	warnings = append(warnings, pluginSourcesWarnings...)
+	allErrs = append(allErrs, pluginSourcesErrs...)
+	definitionsWarnings, definitionsErrs := v.validateDefinitions(ctx, cluster)
+	warnings = append(warnings, definitionsWarnings...)
+	allErrs = append(allErrs, definitionsErrs...)
+	warnings = append(warnings, v.validateAdditionalConfigFrom(ctx, cluster)...)
+
+	if len(allErrs) > 0 {
+		return warnings, apierrors.NewInvalid(
+			schema.GroupKind{Group: "rabbitmq.com", Kind: "RabbitmqCluster"},
+			cluster.Name,
+			allErrs,
+		)
+	}
+	return warnings, nil
+}
+
+func validatePodSpecOverride(cluster *rabbitmqcomv1beta1.RabbitmqCluster) field.ErrorList {
 	override := cluster.Spec.Override.StatefulSet
 	if override == nil || override.Spec == nil || override.Spec.Template == nil || override.Spec.Template.Spec == nil {
 		return nil
@@ -147,12 +192,227 @@ func validatePodSpecOverride(cluster *rabbitmqcomv1beta1.RabbitmqCluster) error
 		}
 	}
 
-	if len(allErrs) > 0 {
-		return apierrors.NewInvalid(
-			schema.GroupKind{Group: "rabbitmq.com", Kind: "RabbitmqCluster"},
-			cluster.Name,
-			allErrs,
-		)
+	return allErrs
+}
+
+func validateTLS(cluster *rabbitmqcomv1beta1.RabbitmqCluster) field.ErrorList {
+	tlsPath := field.NewPath("spec", "tls")
+	var allErrs field.ErrorList
+
+	if cluster.Spec.TLS.CertManager != nil && cluster.VaultTLSEnabled() {
+		allErrs = append(allErrs, field.Forbidden(tlsPath.Child("certManager"),
+			"certManager cannot be used together with spec.secretBackend.vault.tls"))
+	}
+
+	if cluster.Spec.TLS.InterNode && !cluster.MutualTLSEnabled() {
+		allErrs = append(allErrs, field.Invalid(tlsPath.Child("interNode"), cluster.Spec.TLS.InterNode,
+			"inter-node TLS requires mutual TLS; set spec.tls.caSecretName or use spec.secretBackend.vault.tls"))
+	}
+
+	if cluster.Spec.TLS.Listeners != nil && !cluster.TLSEnabled() {
+		allErrs = append(allErrs, field.Forbidden(tlsPath.Child("listeners"),
+			"listener certificates require TLS; set spec.tls.secretName, spec.tls.certManager or spec.secretBackend.vault.tls"))
+	}
+
+	if cluster.DisableNonTLSListeners() && managementVerifiesPeer(cluster.Spec.Rabbitmq.AdditionalConfig) {
+		allErrs = append(allErrs, field.Forbidden(tlsPath.Child("disableNonTLSListeners"),
+			"the operator cannot connect to a management listener which requires client certificates; "+
+				"remove management.ssl.verify and management.ssl.fail_if_no_peer_cert from spec.rabbitmq.additionalConfig"))
+	}
+
+	if options := cluster.Spec.TLS.Options; options != nil {
+		optionsPath := tlsPath.Child("options")
+		if !cluster.TLSEnabled() {
+			allErrs = append(allErrs, field.Forbidden(optionsPath,
+				"TLS options require TLS; set spec.tls.secretName, spec.tls.certManager or spec.secretBackend.vault.tls"))
+		}
+		if options.FailIfNoPeerCert != nil && *options.FailIfNoPeerCert {
+			if !cluster.MutualTLSEnabled() {
+				allErrs = append(allErrs, field.Invalid(optionsPath.Child("failIfNoPeerCert"), true,
+					"client certificates cannot be verified without a CA; set spec.tls.caSecretName"))
+			}
+			if options.Verify == "verify_none" {
+				allErrs = append(allErrs, field.Invalid(optionsPath.Child("failIfNoPeerCert"), true,
+					"failIfNoPeerCert requires verify to be verify_peer"))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+// managementVerifiesPeer returns true if additionalConfig makes the management listener request client certificates.
+// Syntax errors are reported by validateConfig.
+func managementVerifiesPeer(additionalConfig string) bool {
+	settings, _ := rabbitmqconf.Parse(additionalConfig)
+	for _, setting := range settings {
+		switch setting.Key {
+		case "management.ssl.verify":
+			if setting.Value == "verify_peer" {
+				return true
+			}
+		case "management.ssl.fail_if_no_peer_cert":
+			if setting.Value == "true" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func validateDefaultUser(cluster *rabbitmqcomv1beta1.RabbitmqCluster) field.ErrorList {
+	rotation := cluster.Spec.DefaultUser.Rotation
+	if rotation == nil {
+		return nil
+	}
+	rotationPath := field.NewPath("spec", "defaultUser", "rotation")
+	var allErrs field.ErrorList
+
+	if !cluster.DefaultUserRotationSupported() {
+		allErrs = append(allErrs, field.Forbidden(rotationPath,
+			"rotation requires credentials generated by the operator; remove spec.secretBackend and default_user/default_pass from spec.rabbitmq.additionalConfig"))
+	}
+	if rotation.Interval != nil && rotation.Interval.Duration <= cluster.DefaultUserRotationGracePeriod() {
+		allErrs = append(allErrs, field.Invalid(rotationPath.Child("interval"), rotation.Interval.Duration.String(),
+			"interval must be longer than gracePeriod"))
+	}
+
+	return allErrs
+}
+
+func validateBackup(cluster *rabbitmqcomv1beta1.RabbitmqCluster) field.ErrorList {
+	if cluster.Spec.Backup.Definitions == nil {
+		return nil
+	}
+	schedule := cluster.Spec.Backup.Definitions.Schedule
+	if _, err := cron.Parse(schedule); err != nil {
+		return field.ErrorList{field.Invalid(field.NewPath("spec", "backup", "definitions", "schedule"), schedule, err.Error())}
+	}
+	return nil
+}
+
+func validateMaintenanceWindows(cluster *rabbitmqcomv1beta1.RabbitmqCluster) field.ErrorList {
+	var allErrs field.ErrorList
+	for i, window := range cluster.Spec.MaintenanceWindows {
+		path := field.NewPath("spec", "maintenanceWindows").Index(i)
+		if _, err := cron.Parse(window.Schedule); err != nil {
+			allErrs = append(allErrs, field.Invalid(path.Child("schedule"), window.Schedule, err.Error()))
+		}
+		if window.Duration.Duration < time.Minute {
+			allErrs = append(allErrs, field.Invalid(path.Child("duration"), window.Duration.String(), "must be at least 1m"))
+		}
+	}
+	return allErrs
+}
+
+func validateReplication(cluster *rabbitmqcomv1beta1.RabbitmqCluster) field.ErrorList {
+	if !cluster.ReplicationEnabled() {
+		return nil
+	}
+	if upstream := cluster.ReplicationUpstream(); upstream.Name == cluster.Name && upstream.Namespace == cluster.Namespace {
+		return field.ErrorList{field.Invalid(field.NewPath("spec", "replication", "upstream"), upstream.String(),
+			"a RabbitmqCluster cannot replicate from itself")}
+	}
+	return nil
+}
+
+func validateLinks(cluster *rabbitmqcomv1beta1.RabbitmqCluster) field.ErrorList {
+	var allErrs field.ErrorList
+	for i, link := range cluster.Spec.Links {
+		if remote := cluster.ReferencedRabbitmqCluster(link.RabbitmqCluster); remote.Name == cluster.Name && remote.Namespace == cluster.Namespace {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "links").Index(i).Child("rabbitmqCluster"), remote.String(),
+				"a RabbitmqCluster cannot link to itself"))
+		}
+	}
+	return allErrs
+}
+
+// validateAuth rejects spec.rabbitmq.additionalConfig which drops an authentication backend spec.auth requires
+func validateAuth(cluster *rabbitmqcomv1beta1.RabbitmqCluster) field.ErrorList {
+	if err := resource.ValidateAuthConfig(cluster); err != nil {
+		return field.ErrorList{field.Invalid(field.NewPath("spec", "rabbitmq", "additionalConfig"), cluster.Spec.Rabbitmq.AdditionalConfig, err.Error())}
+	}
+	return nil
+}
+
+// validateConfig rejects additionalConfig and advancedConfig with syntax errors, which would keep RabbitMQ from starting.
+// Keys of additionalConfig not known to the RabbitMQ version of spec.image only cause a warning, since the schema
+// does not list the keys of community plugins. The version of spec.image is the one the nodes run once the update
+// is applied, unlike the version annotation, which is the one the nodes run now.
+func validateConfig(cluster *rabbitmqcomv1beta1.RabbitmqCluster) (admission.Warnings, field.ErrorList) {
+	var (
+		warnings admission.Warnings
+		allErrs  field.ErrorList
+	)
+	if additionalConfig := cluster.Spec.Rabbitmq.AdditionalConfig; additionalConfig != "" {
+		settings, err := rabbitmqconf.Parse(additionalConfig)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "rabbitmq", "additionalConfig"), additionalConfig, err.Error()))
+		}
+		for _, warning := range rabbitmqconf.Lint(settings, rabbitmqconf.ImageVersion(cluster.Spec.Image)) {
+			warnings = append(warnings, "spec.rabbitmq.additionalConfig: "+warning)
+		}
+	}
+	if advancedConfig := cluster.Spec.Rabbitmq.AdvancedConfig; advancedConfig != "" {
+		if _, err := erlang.ParseConfig(advancedConfig); err != nil {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "rabbitmq", "advancedConfig"), advancedConfig, err.Error()))
+		}
+	}
+	return warnings, allErrs
+}
+
+// validatePluginSources rejects plugin sources whose .ez file is not named after the plugin, since RabbitMQ would not find the plugin.
+// Plugins which are fetched but not enabled only cause a warning.
+func validatePluginSources(cluster *rabbitmqcomv1beta1.RabbitmqCluster) (admission.Warnings, field.ErrorList) {
+	var (
+		warnings admission.Warnings
+		allErrs  field.ErrorList
+	)
+	names := map[rabbitmqcomv1beta1.Plugin]bool{}
+	for i, source := range cluster.Spec.Rabbitmq.PluginSources {
+		path := field.NewPath("spec", "rabbitmq", "pluginSources").Index(i)
+		if names[source.Name] {
+			allErrs = append(allErrs, field.Duplicate(path.Child("name"), source.Name))
+		}
+		names[source.Name] = true
+
+		if _, err := resource.PluginSourceFilename(source); err != nil {
+			if source.Image != nil {
+				allErrs = append(allErrs, field.Invalid(path.Child("image", "path"), source.Image.Path, err.Error()))
+			} else {
+				allErrs = append(allErrs, field.Invalid(path.Child("url"), source.URL, err.Error()))
+			}
+		}
+		if !slices.Contains(cluster.Spec.Rabbitmq.AdditionalPlugins, source.Name) {
+			warnings = append(warnings, fmt.Sprintf("%s: plugin %s is not enabled, add it to spec.rabbitmq.additionalPlugins", path, source.Name))
+		}
+	}
+	return warnings, allErrs
+}
+
+// validateDefinitions rejects definitions which are not valid JSON. Missing ConfigMaps and Secrets only cause a warning,
+// since they may be created after the RabbitmqCluster. The Pods do not start until they exist.
+func (v *RabbitmqClusterCustomValidator) validateDefinitions(ctx context.Context, cluster *rabbitmqcomv1beta1.RabbitmqCluster) (admission.Warnings, field.ErrorList) {
+	if v.Reader == nil || cluster.Spec.Rabbitmq.Definitions == nil {
+		return nil, nil
+	}
+	if _, err := resource.ReadDefinitions(ctx, v.Reader, cluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return admission.Warnings{err.Error()}, nil
+		}
+		return nil, field.ErrorList{field.Invalid(field.NewPath("spec", "rabbitmq", "definitions"), "", err.Error())}
+	}
+	return nil, nil
+}
+
+// validateAdditionalConfigFrom warns about missing ConfigMaps, Secrets and keys, since they may be created after the RabbitmqCluster.
+// The Pods do not start until they exist.
+func (v *RabbitmqClusterCustomValidator) validateAdditionalConfigFrom(ctx context.Context, cluster *rabbitmqcomv1beta1.RabbitmqCluster) admission.Warnings {
+	if v.Reader == nil || len(cluster.Spec.Rabbitmq.AdditionalConfigFrom) == 0 {
+		return nil
+	}
+	if _, err := resource.ReadAdditionalConfigFrom(ctx, v.Reader, cluster); err != nil {
+		return admission.Warnings{err.Error()}
 	}
 	return nil
 }
@@ -178,5 +438,9 @@ func (d *RabbitmqClusterCustomDefaulter) Default(_ context.Context, obj *rabbitm
 		obj.Spec.SecretBackend.Vault.DefaultUserUpdaterImage = &image
 	}
 
+	if len(obj.Spec.Rabbitmq.PluginSources) > 0 && obj.Spec.Rabbitmq.PluginFetcherImage == "" {
+		obj.Spec.Rabbitmq.PluginFetcherImage = d.DefaultPluginFetcherImage
+	}
+
 	return nil
 }
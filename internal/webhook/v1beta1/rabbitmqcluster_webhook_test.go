@@ -18,12 +18,15 @@ package v1beta1
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gstruct"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	rabbitmqcomv1beta1 "github.com/rabbitmq/cluster-operator/v2/api/v1beta1"
 )
@@ -39,9 +42,10 @@ var _ = Describe("RabbitmqCluster Webhook", func() {
 			ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
 		}
 		defaulter = RabbitmqClusterCustomDefaulter{
-			DefaultRabbitmqImage:    "rabbitmq:default",
-			DefaultImagePullSecrets: "secret-1,secret-2",
-			DefaultUserUpdaterImage: "credential-updater:default",
+			DefaultRabbitmqImage:      "rabbitmq:default",
+			DefaultImagePullSecrets:   "secret-1,secret-2",
+			DefaultUserUpdaterImage:   "credential-updater:default",
+			DefaultPluginFetcherImage: "plugin-fetcher:default",
 		}
 	})
 
@@ -112,6 +116,33 @@ var _ = Describe("RabbitmqCluster Webhook", func() {
 		})
 	})
 
+	Context("plugin fetcher image defaulting", func() {
+		BeforeEach(func() {
+			obj.Spec.Rabbitmq.PluginSources = []rabbitmqcomv1beta1.PluginSource{{
+				Name:   "rabbitmq_delayed_message_exchange",
+				URL:    "https://example.com/rabbitmq_delayed_message_exchange-4.1.0.ez",
+				SHA256: strings.Repeat("a", 64),
+			}}
+		})
+
+		It("sets the default plugin fetcher image when plugin sources are set", func() {
+			Expect(defaulter.Default(context.Background(), obj)).To(Succeed())
+			Expect(obj.Spec.Rabbitmq.PluginFetcherImage).To(Equal("plugin-fetcher:default"))
+		})
+
+		It("does not override a plugin fetcher image the user has already set", func() {
+			obj.Spec.Rabbitmq.PluginFetcherImage = "plugin-fetcher:custom"
+			Expect(defaulter.Default(context.Background(), obj)).To(Succeed())
+			Expect(obj.Spec.Rabbitmq.PluginFetcherImage).To(Equal("plugin-fetcher:custom"))
+		})
+
+		It("does not set the plugin fetcher image without plugin sources", func() {
+			obj.Spec.Rabbitmq.PluginSources = nil
+			Expect(defaulter.Default(context.Background(), obj)).To(Succeed())
+			Expect(obj.Spec.Rabbitmq.PluginFetcherImage).To(BeEmpty())
+		})
+	})
+
 	Context("override validation", func() {
 		var validator RabbitmqClusterCustomValidator
 
@@ -234,4 +265,460 @@ var _ = Describe("RabbitmqCluster Webhook", func() {
 			}, "readOnlyRootFilesystem"),
 		)
 	})
+
+	Context("TLS validation", func() {
+		var validator RabbitmqClusterCustomValidator
+
+		BeforeEach(func() {
+			validator = RabbitmqClusterCustomValidator{}
+			obj.Spec.TLS.CertManager = &rabbitmqcomv1beta1.CertManagerTLSSpec{
+				IssuerRef: rabbitmqcomv1beta1.CertManagerIssuerReference{Name: "ca-issuer"},
+			}
+		})
+
+		It("allows certificates issued by cert-manager", func() {
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects cert-manager together with Vault TLS", func() {
+			obj.Spec.SecretBackend.Vault = &rabbitmqcomv1beta1.VaultSpec{
+				Role: "rabbit",
+				TLS:  rabbitmqcomv1beta1.VaultTLSSpec{PKIIssuerPath: "pki/issue/rabbit"},
+			}
+			_, err := validator.ValidateUpdate(context.Background(), obj, obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.tls.certManager"))
+		})
+
+		It("rejects inter-node TLS without a CA certificate", func() {
+			obj.Spec.TLS.InterNode = true
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.tls.interNode"))
+
+			obj.Spec.TLS.CaSecretName = "ca-secret"
+			_, err = validator.ValidateCreate(context.Background(), obj)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects listener certificates without TLS", func() {
+			obj.Spec.TLS = rabbitmqcomv1beta1.TLSSpec{
+				Listeners: &rabbitmqcomv1beta1.TLSListenersSpec{
+					Management: &rabbitmqcomv1beta1.ListenerTLSSpec{SecretName: "management-tls"},
+				},
+			}
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.tls.listeners"))
+		})
+
+		It("rejects failIfNoPeerCert without a CA certificate", func() {
+			obj.Spec.TLS.Options = &rabbitmqcomv1beta1.TLSOptionsSpec{
+				Versions:         []string{"tlsv1.3"},
+				FailIfNoPeerCert: new(true),
+			}
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.tls.options.failIfNoPeerCert"))
+
+			obj.Spec.TLS.CaSecretName = "ca-secret"
+			_, err = validator.ValidateCreate(context.Background(), obj)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects a management listener requiring client certificates when non-TLS listeners are disabled", func() {
+			obj.Spec.TLS.CaSecretName = "ca-secret"
+			obj.Spec.TLS.DisableNonTLSListeners = true
+			obj.Spec.Rabbitmq.AdditionalConfig = "management.ssl.verify = verify_peer"
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.tls.disableNonTLSListeners"))
+
+			obj.Spec.TLS.DisableNonTLSListeners = false
+			_, err = validator.ValidateCreate(context.Background(), obj)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects failIfNoPeerCert when client certificates are not verified", func() {
+			obj.Spec.TLS.CaSecretName = "ca-secret"
+			obj.Spec.TLS.Options = &rabbitmqcomv1beta1.TLSOptionsSpec{
+				Verify:           "verify_none",
+				FailIfNoPeerCert: new(true),
+			}
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("verify_peer"))
+		})
+	})
+
+	Context("default user validation", func() {
+		var validator RabbitmqClusterCustomValidator
+
+		BeforeEach(func() {
+			validator = RabbitmqClusterCustomValidator{}
+			obj.Spec.DefaultUser.Rotation = &rabbitmqcomv1beta1.DefaultUserRotationSpec{
+				Interval: &metav1.Duration{Duration: 720 * time.Hour},
+			}
+		})
+
+		It("allows rotating generated credentials", func() {
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects rotating credentials set in additionalConfig", func() {
+			obj.Spec.Rabbitmq.AdditionalConfig = "default_pass = my-password"
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.defaultUser.rotation"))
+		})
+
+		It("rejects an interval shorter than the grace period", func() {
+			obj.Spec.DefaultUser.Rotation.Interval.Duration = 30 * time.Minute
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.defaultUser.rotation.interval"))
+		})
+	})
+
+	Context("backup validation", func() {
+		var validator RabbitmqClusterCustomValidator
+
+		BeforeEach(func() {
+			validator = RabbitmqClusterCustomValidator{}
+			obj.Spec.Backup.Definitions = &rabbitmqcomv1beta1.DefinitionsBackupSpec{Schedule: "0 3 * * *"}
+		})
+
+		It("allows a valid schedule", func() {
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects an invalid schedule", func() {
+			obj.Spec.Backup.Definitions.Schedule = "every day"
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.backup.definitions.schedule"))
+		})
+	})
+
+	Context("maintenance windows validation", func() {
+		var validator RabbitmqClusterCustomValidator
+
+		BeforeEach(func() {
+			validator = RabbitmqClusterCustomValidator{}
+			obj.Spec.MaintenanceWindows = []rabbitmqcomv1beta1.MaintenanceWindow{
+				{Schedule: "0 2 * * sat", Duration: metav1.Duration{Duration: 4 * time.Hour}},
+			}
+		})
+
+		It("allows valid windows", func() {
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects an invalid schedule", func() {
+			obj.Spec.MaintenanceWindows[0].Schedule = "saturday night"
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).To(MatchError(ContainSubstring("spec.maintenanceWindows[0].schedule")))
+		})
+
+		It("rejects windows shorter than a minute", func() {
+			obj.Spec.MaintenanceWindows[0].Duration = metav1.Duration{Duration: 30 * time.Second}
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).To(MatchError(ContainSubstring("spec.maintenanceWindows[0].duration")))
+		})
+	})
+
+	Context("replication validation", func() {
+		var validator RabbitmqClusterCustomValidator
+
+		BeforeEach(func() {
+			validator = RabbitmqClusterCustomValidator{}
+			obj.Spec.Replication = &rabbitmqcomv1beta1.ReplicationSpec{
+				Upstream:     rabbitmqcomv1beta1.RabbitmqClusterReference{Name: "upstream", Namespace: "other"},
+				VirtualHosts: []string{"/"},
+			}
+		})
+
+		It("allows an upstream in another namespace", func() {
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects replicating from itself", func() {
+			obj.Spec.Replication.Upstream = rabbitmqcomv1beta1.RabbitmqClusterReference{Name: obj.Name}
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("cannot replicate from itself"))
+		})
+	})
+
+	Context("links validation", func() {
+		var validator RabbitmqClusterCustomValidator
+
+		BeforeEach(func() {
+			validator = RabbitmqClusterCustomValidator{}
+			obj.Spec.Links = []rabbitmqcomv1beta1.LinkSpec{{
+				Name:            "orders",
+				Type:            rabbitmqcomv1beta1.LinkTypeFederation,
+				RabbitmqCluster: rabbitmqcomv1beta1.RabbitmqClusterReference{Name: obj.Name, Namespace: "other"},
+			}}
+		})
+
+		It("allows a RabbitmqCluster with the same name in another namespace", func() {
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects linking to itself", func() {
+			obj.Spec.Links[0].RabbitmqCluster.Namespace = ""
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.links[0].rabbitmqCluster"))
+			Expect(err.Error()).To(ContainSubstring("cannot link to itself"))
+		})
+	})
+
+	Context("auth validation", func() {
+		var validator RabbitmqClusterCustomValidator
+
+		BeforeEach(func() {
+			validator = RabbitmqClusterCustomValidator{}
+			obj.Spec.Auth.OAuth2 = &rabbitmqcomv1beta1.OAuth2Spec{
+				ResourceServerID: "rabbitmq",
+				IssuerURL:        "https://idp.example.com/realms/rabbitmq",
+			}
+		})
+
+		It("allows additionalConfig adding an authentication backend", func() {
+			obj.Spec.Rabbitmq.AdditionalConfig = "auth_backends.3 = http"
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("allows additionalConfig reordering the backends with aliases", func() {
+			obj.Spec.Rabbitmq.AdditionalConfig = "auth_backends.1 = internal\nauth_backends.2 = oauth2"
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects additionalConfig dropping the OAuth 2.0 backend", func() {
+			obj.Spec.Rabbitmq.AdditionalConfig = "auth_backends.1 = ldap"
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.rabbitmq.additionalConfig"))
+			Expect(err.Error()).To(ContainSubstring("auth_backends must include rabbit_auth_backend_oauth2"))
+		})
+
+		It("rejects additionalConfig dropping the LDAP backend", func() {
+			obj.Spec.Auth.LDAP = &rabbitmqcomv1beta1.LDAPSpec{Servers: []string{"ldap.example.com"}}
+			obj.Spec.Rabbitmq.AdditionalConfig = "auth_backends.2 = internal"
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("auth_backends must include rabbit_auth_backend_ldap"))
+		})
+
+		It("rejects auth_mechanisms without PLAIN or AMQPLAIN", func() {
+			obj.Spec.Rabbitmq.AdditionalConfig = "auth_mechanisms.1 = EXTERNAL"
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("auth_mechanisms must include PLAIN or AMQPLAIN"))
+		})
+	})
+
+	Context("plugin sources validation", func() {
+		var validator RabbitmqClusterCustomValidator
+
+		BeforeEach(func() {
+			validator = RabbitmqClusterCustomValidator{}
+			obj.Spec.Rabbitmq.AdditionalPlugins = []rabbitmqcomv1beta1.Plugin{"rabbitmq_delayed_message_exchange"}
+			obj.Spec.Rabbitmq.PluginSources = []rabbitmqcomv1beta1.PluginSource{{
+				Name:   "rabbitmq_delayed_message_exchange",
+				URL:    "https://example.com/rabbitmq_delayed_message_exchange-4.1.0.ez",
+				SHA256: strings.Repeat("a", 64),
+			}}
+		})
+
+		It("allows plugin files named after the plugin", func() {
+			warnings, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("rejects plugin files named after another plugin", func() {
+			obj.Spec.Rabbitmq.PluginSources[0].URL = "https://example.com/rabbitmq_message_deduplication-0.6.4.ez"
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).To(MatchError(ContainSubstring("spec.rabbitmq.pluginSources[0].url")))
+			Expect(err).To(MatchError(ContainSubstring("does not match the plugin name rabbitmq_delayed_message_exchange")))
+		})
+
+		It("rejects image paths named after another plugin", func() {
+			obj.Spec.Rabbitmq.PluginSources[0].URL = ""
+			obj.Spec.Rabbitmq.PluginSources[0].Image = &rabbitmqcomv1beta1.PluginImageSource{Reference: "plugins:1", Path: "rabbitmq_shovel.ez"}
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).To(MatchError(ContainSubstring("spec.rabbitmq.pluginSources[0].image.path")))
+		})
+
+		It("rejects duplicate plugins", func() {
+			obj.Spec.Rabbitmq.PluginSources = append(obj.Spec.Rabbitmq.PluginSources, obj.Spec.Rabbitmq.PluginSources[0])
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).To(MatchError(ContainSubstring("spec.rabbitmq.pluginSources[1].name: Duplicate value")))
+		})
+
+		It("warns about plugins which are not enabled", func() {
+			obj.Spec.Rabbitmq.AdditionalPlugins = nil
+			warnings, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(ConsistOf("spec.rabbitmq.pluginSources[0]: plugin rabbitmq_delayed_message_exchange is not enabled, add it to spec.rabbitmq.additionalPlugins"))
+		})
+	})
+
+	Context("configuration validation", func() {
+		var validator RabbitmqClusterCustomValidator
+
+		BeforeEach(func() {
+			validator = RabbitmqClusterCustomValidator{}
+		})
+
+		It("allows known additionalConfig keys without warnings", func() {
+			obj.Spec.Rabbitmq.AdditionalConfig = "cluster_name = my-cluster\nlog.console.level = debug"
+			warnings, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("rejects additionalConfig with syntax errors with the line", func() {
+			obj.Spec.Rabbitmq.AdditionalConfig = "cluster_name = my-cluster\nlog.console.level debug"
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.rabbitmq.additionalConfig"))
+			Expect(err.Error()).To(ContainSubstring("line 2: expected key = value, got log.console.level debug"))
+		})
+
+		It("warns about unknown additionalConfig keys with the line", func() {
+			obj.Spec.Rabbitmq.AdditionalConfig = "cluster_name = my-cluster\nclustr_name = typo"
+			warnings, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(ConsistOf("spec.rabbitmq.additionalConfig: line 2: unknown key clustr_name"))
+		})
+
+		It("warns about additionalConfig keys the version of the image does not support", func() {
+			// the nodes still run 3.13 until the new image is rolled out
+			obj.Annotations = map[string]string{rabbitmqcomv1beta1.RabbitmqVersionAnnotation: "3.13.7"}
+			obj.Spec.Image = "rabbitmq:4.1.0-management"
+			obj.Spec.Rabbitmq.AdditionalConfig = "mirroring_sync_batch_size = 1024"
+			warnings, err := validator.ValidateUpdate(context.Background(), obj, obj)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(ConsistOf(ContainSubstring("key mirroring_sync_batch_size was removed in RabbitMQ 4.0.0, but the image is 4.1.0")))
+		})
+
+		It("allows valid advancedConfig", func() {
+			obj.Spec.Rabbitmq.AdvancedConfig = "[{rabbit, [{tcp_listeners, [5672]}]}]."
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects advancedConfig with syntax errors with the line", func() {
+			obj.Spec.Rabbitmq.AdvancedConfig = "[\n  {rabbit, [{tcp_listeners, [5672]}]}\n]"
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.rabbitmq.advancedConfig"))
+			Expect(err.Error()).To(ContainSubstring("line 3:"))
+		})
+	})
+
+	Context("definitions validation", func() {
+		var (
+			validator RabbitmqClusterCustomValidator
+			configMap *corev1.ConfigMap
+		)
+
+		BeforeEach(func() {
+			obj.Spec.Rabbitmq.Definitions = &rabbitmqcomv1beta1.DefinitionsSpec{
+				Sources: []rabbitmqcomv1beta1.DefinitionsSource{{ConfigMap: &corev1.LocalObjectReference{Name: "definitions"}}},
+			}
+			configMap = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "definitions", Namespace: "default"},
+				Data:       map[string]string{"definitions.json": `{"vhosts": [{"name": "orders"}]}`},
+			}
+		})
+
+		JustBeforeEach(func() {
+			validator = RabbitmqClusterCustomValidator{Reader: fake.NewClientBuilder().WithObjects(configMap).Build()}
+		})
+
+		It("allows valid definitions", func() {
+			warnings, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(BeEmpty())
+		})
+
+		When("the definitions are not valid JSON", func() {
+			BeforeEach(func() {
+				configMap.Data["definitions.json"] = `{"vhosts": [`
+			})
+
+			It("rejects them", func() {
+				_, err := validator.ValidateCreate(context.Background(), obj)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("spec.rabbitmq.definitions"))
+			})
+		})
+
+		When("the ConfigMap does not exist yet", func() {
+			BeforeEach(func() {
+				configMap.Name = "other"
+			})
+
+			It("warns about it", func() {
+				warnings, err := validator.ValidateCreate(context.Background(), obj)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(warnings).To(ConsistOf(ContainSubstring("definitions ConfigMap definitions")))
+			})
+		})
+	})
+
+	Context("additionalConfigFrom validation", func() {
+		var (
+			validator RabbitmqClusterCustomValidator
+			secret    *corev1.Secret
+		)
+
+		BeforeEach(func() {
+			obj.Spec.Rabbitmq.AdditionalConfigFrom = []rabbitmqcomv1beta1.AdditionalConfigSource{{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "shovels"},
+					Key:                  "shovels.conf",
+				},
+			}}
+			secret = &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "shovels", Namespace: "default"},
+				Data:       map[string][]byte{"shovels.conf": []byte("log.file.level = debug")},
+			}
+		})
+
+		JustBeforeEach(func() {
+			validator = RabbitmqClusterCustomValidator{Reader: fake.NewClientBuilder().WithObjects(secret).Build()}
+		})
+
+		It("allows existing keys", func() {
+			warnings, err := validator.ValidateCreate(context.Background(), obj)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(BeEmpty())
+		})
+
+		When("the key does not exist yet", func() {
+			BeforeEach(func() {
+				delete(secret.Data, "shovels.conf")
+			})
+
+			It("warns about it", func() {
+				warnings, err := validator.ValidateCreate(context.Background(), obj)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(warnings).To(ConsistOf(ContainSubstring("key shovels.conf of additionalConfigFrom Secret shovels not found")))
+			})
+		})
+	})
 })